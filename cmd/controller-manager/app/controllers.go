@@ -41,6 +41,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/controller/openpitrix/helmrelease"
 	"kubesphere.io/kubesphere/pkg/controller/openpitrix/helmrepo"
 	"kubesphere.io/kubesphere/pkg/controller/quota"
+	"kubesphere.io/kubesphere/pkg/controller/savedsearch"
 	"kubesphere.io/kubesphere/pkg/controller/serviceaccount"
 	"kubesphere.io/kubesphere/pkg/controller/user"
 	"kubesphere.io/kubesphere/pkg/controller/workspace"
@@ -48,9 +49,15 @@ import (
 	"kubesphere.io/kubesphere/pkg/controller/workspacerolebinding"
 	"kubesphere.io/kubesphere/pkg/controller/workspacetemplate"
 	"kubesphere.io/kubesphere/pkg/models/kubeconfig"
+	auditingclient "kubesphere.io/kubesphere/pkg/simple/client/auditing/elasticsearch"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
+	eventsclient "kubesphere.io/kubesphere/pkg/simple/client/events/elasticsearch"
 	ldapclient "kubesphere.io/kubesphere/pkg/simple/client/ldap"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	esclient "kubesphere.io/kubesphere/pkg/simple/client/logging/elasticsearch"
+	lokiclient "kubesphere.io/kubesphere/pkg/simple/client/logging/loki"
+	notificationclient "kubesphere.io/kubesphere/pkg/simple/client/notification"
 	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
@@ -115,6 +122,7 @@ var allControllers = []string{
 	"rulegroup",
 	"clusterrulegroup",
 	"globalrulegroup",
+	"savedsearch",
 }
 
 // setup all available controllers one by one
@@ -136,9 +144,14 @@ func addAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 	////////////////////////////////////
 	// begin init necessary clients
 	////////////////////////////////////
-	kubeconfigClient := kubeconfig.NewOperator(client.Kubernetes(),
-		informerFactory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps().Lister(),
-		client.Config())
+	// the kubeconfig authenticates with the tokens issued by ks-apiserver in the token mode,
+	// no client certificate is signed for the users then
+	var kubeconfigClient kubeconfig.Interface
+	if !cmOptions.AuthenticationOptions.KubeconfigTokenMode() {
+		kubeconfigClient = kubeconfig.NewOperator(client.Kubernetes(),
+			informerFactory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps().Lister(),
+			client.Config())
+	}
 
 	var devopsClient devops.Interface
 	if cmOptions.DevopsOptions != nil && len(cmOptions.DevopsOptions.Host) != 0 {
@@ -547,6 +560,45 @@ func addAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 		}
 	}
 
+	// "savedsearch" controller
+	if cmOptions.IsControllerEnabled("savedsearch") {
+		if cmOptions.NotificationOptions != nil && cmOptions.NotificationOptions.IsEnabled() {
+			savedSearchReconciler := &savedsearch.Reconciler{
+				NotificationClient: notificationclient.NewClient(cmOptions.NotificationOptions),
+			}
+			if cmOptions.LoggingOptions != nil && cmOptions.LoggingOptions.Host != "" {
+				if cmOptions.LoggingOptions.Backend == logging.BackendLoki {
+					loggingClient, err := lokiclient.NewClient(cmOptions.LoggingOptions)
+					if err != nil {
+						return fmt.Errorf("failed to create loki client, please check logging configuration, error: %v", err)
+					}
+					savedSearchReconciler.LoggingClient = loggingClient
+				} else {
+					loggingClient, err := esclient.NewClient(cmOptions.LoggingOptions)
+					if err != nil {
+						return fmt.Errorf("failed to connect to elasticsearch, please check elasticsearch status, error: %v", err)
+					}
+					savedSearchReconciler.LoggingClient = loggingClient
+				}
+			}
+			if cmOptions.EventsOptions != nil && cmOptions.EventsOptions.Host != "" {
+				eventsClient, err := eventsclient.NewClient(cmOptions.EventsOptions)
+				if err != nil {
+					return fmt.Errorf("failed to connect to elasticsearch, please check elasticsearch status, error: %v", err)
+				}
+				savedSearchReconciler.EventsClient = eventsClient
+			}
+			if cmOptions.AuditingOptions != nil && cmOptions.AuditingOptions.Host != "" {
+				auditingClient, err := auditingclient.NewClient(cmOptions.AuditingOptions)
+				if err != nil {
+					return fmt.Errorf("failed to connect to elasticsearch, please check elasticsearch status, error: %v", err)
+				}
+				savedSearchReconciler.AuditingClient = auditingClient
+			}
+			addControllerWithSetup(mgr, "savedsearch", savedSearchReconciler)
+		}
+	}
+
 	// log all controllers process result
 	for _, name := range allControllers {
 		if cmOptions.IsControllerEnabled(name) {
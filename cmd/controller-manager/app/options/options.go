@@ -23,7 +23,11 @@ import (
 	"time"
 
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
+	"kubesphere.io/kubesphere/pkg/simple/client/events"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus"
+	"kubesphere.io/kubesphere/pkg/simple/client/notification"
 
 	controllerconfig "kubesphere.io/kubesphere/pkg/apiserver/config"
 
@@ -62,6 +66,10 @@ type KubeSphereControllerManagerOptions struct {
 	GatewayOptions        *gateway.Options
 	MonitoringOptions     *prometheus.Options
 	AlertingOptions       *alerting.Options
+	LoggingOptions        *logging.Options
+	EventsOptions         *events.Options
+	AuditingOptions       *auditing.Options
+	NotificationOptions   *notification.Options
 	LeaderElect           bool
 	LeaderElection        *leaderelection.LeaderElectionConfig
 	WebhookCertDir        string
@@ -249,4 +257,8 @@ func (s *KubeSphereControllerManagerOptions) MergeConfig(cfg *controllerconfig.C
 	s.GatewayOptions = cfg.GatewayOptions
 	s.MonitoringOptions = cfg.MonitoringOptions
 	s.AlertingOptions = cfg.AlertingOptions
+	s.LoggingOptions = cfg.LoggingOptions
+	s.EventsOptions = cfg.EventsOptions
+	s.AuditingOptions = cfg.AuditingOptions
+	s.NotificationOptions = cfg.NotificationOptions
 }
@@ -49,7 +49,9 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
 	eventsclient "kubesphere.io/kubesphere/pkg/simple/client/events/elasticsearch"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
 	esclient "kubesphere.io/kubesphere/pkg/simple/client/logging/elasticsearch"
+	lokiclient "kubesphere.io/kubesphere/pkg/simple/client/logging/loki"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/metricsserver"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus"
 	"kubesphere.io/kubesphere/pkg/simple/client/s3"
@@ -142,11 +144,19 @@ func (s *ServerRunOptions) NewAPIServer(stopCh <-chan struct{}) (*apiserver.APIS
 	apiServer.MetricsClient = metricsserver.NewMetricsClient(kubernetesClient.Kubernetes(), s.KubernetesOptions)
 
 	if s.LoggingOptions.Host != "" {
-		loggingClient, err := esclient.NewClient(s.LoggingOptions)
-		if err != nil {
-			return nil, fmt.Errorf("failed to connect to elasticsearch, please check elasticsearch status, error: %v", err)
+		if s.LoggingOptions.Backend == logging.BackendLoki {
+			loggingClient, err := lokiclient.NewClient(s.LoggingOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create loki client, please check logging configuration, error: %v", err)
+			}
+			apiServer.LoggingClient = loggingClient
+		} else {
+			loggingClient, err := esclient.NewClient(s.LoggingOptions)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to elasticsearch, please check elasticsearch status, error: %v", err)
+			}
+			apiServer.LoggingClient = loggingClient
 		}
-		apiServer.LoggingClient = loggingClient
 	}
 
 	if s.S3Options.Endpoint != "" {
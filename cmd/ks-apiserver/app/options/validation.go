@@ -36,6 +36,7 @@ func (s *ServerRunOptions) Validate() []error {
 	errors = append(errors, s.EventsOptions.Validate()...)
 	errors = append(errors, s.AuditingOptions.Validate()...)
 	errors = append(errors, s.AlertingOptions.Validate()...)
+	errors = append(errors, s.TerminalOptions.Validate()...)
 
 	return errors
 }
@@ -17,12 +17,15 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/emicklei/go-restful"
 
 	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/utils/stringutils"
 )
 
 const (
@@ -30,17 +33,51 @@ const (
 	OperationHistogram  = "histogram"
 	OperationQuery      = "query"
 	OperationExport     = "export"
+	OperationFollow     = "follow"
+	OperationFields     = "fields"
 
 	DefaultInterval = "15m"
 	DefaultSize     = 10
 	OrderAscending  = "asc"
 	OrderDescending = "desc"
+
+	// DefaultContextSize and MaxContextSize are the default and max number of records
+	// before and after the record of the log context.
+	DefaultContextSize = 10
+	MaxContextSize     = 1000
+
+	// DefaultFieldValuesSize and MaxFieldValuesSize are the default and max number of the top values
+	// counted for each field.
+	DefaultFieldValuesSize = 10
+	MaxFieldValuesSize     = 100
+
+	// FieldFilterPrefix is the prefix of the terms in the log query filtering by the fields
+	// parsed from the JSON log lines, e.g. field:level=error.
+	FieldFilterPrefix = "field:"
 )
 
 type APIResponse struct {
 	Logs       *logging.Logs       `json:"query,omitempty" description:"query results"`
 	Statistics *logging.Statistics `json:"statistics,omitempty" description:"statistics results"`
 	Histogram  *logging.Histogram  `json:"histogram,omitempty" description:"histogram results"`
+	Context    *logging.LogContext `json:"context,omitempty" description:"log context results"`
+	Facets     *logging.Facets     `json:"fields,omitempty" description:"field value counts"`
+}
+
+// FollowEvent is a batch of the new records streamed in the follow mode, in the time order.
+type FollowEvent struct {
+	Records []FollowRecord `json:"records" description:"new log records"`
+}
+
+type FollowRecord struct {
+	logging.Record
+	Highlights []Highlight `json:"highlights,omitempty" description:"matches of the keywords in the log message"`
+}
+
+// Highlight is a match of the keywords, [Start, End) is the byte range of the match in the log message.
+type Highlight struct {
+	Start int `json:"start" description:"start offset of the match"`
+	End   int `json:"end" description:"end offset of the match"`
 }
 
 type Query struct {
@@ -54,6 +91,9 @@ type Query struct {
 	ContainerFilter string
 	ContainerSearch string
 	LogSearch       string
+	FieldFilter     map[string][]string
+	Fields          []string
+	ParseJSON       bool
 	StartTime       time.Time
 	EndTime         time.Time
 	Interval        string
@@ -64,6 +104,7 @@ type Query struct {
 
 func ParseQueryParameter(req *restful.Request) (*Query, error) {
 	var q Query
+	var err error
 	q.Operation = req.QueryParameter("operation")
 	q.NamespaceFilter = req.QueryParameter("namespaces")
 	q.NamespaceSearch = req.QueryParameter("namespace_query")
@@ -73,7 +114,16 @@ func ParseQueryParameter(req *restful.Request) (*Query, error) {
 	q.PodSearch = req.QueryParameter("pod_query")
 	q.ContainerFilter = req.QueryParameter("containers")
 	q.ContainerSearch = req.QueryParameter("container_query")
-	q.LogSearch = req.QueryParameter("log_query")
+	q.LogSearch, q.FieldFilter, err = parseLogQuery(req.QueryParameter("log_query"))
+	if err != nil {
+		return nil, err
+	}
+	if pstr := req.QueryParameter("parse_json"); pstr != "" {
+		q.ParseJSON, err = strconv.ParseBool(pstr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parse_json: %s", pstr)
+		}
+	}
 
 	if q.Operation == "" {
 		q.Operation = OperationQuery
@@ -111,7 +161,99 @@ func ParseQueryParameter(req *restful.Request) (*Query, error) {
 		if q.Sort != OrderAscending {
 			q.Sort = OrderDescending
 		}
+	case OperationFields:
+		q.Fields = stringutils.Split(req.QueryParameter("fields"), ",")
+		if len(q.Fields) == 0 {
+			return nil, fmt.Errorf("fields are required")
+		}
+		for _, field := range q.Fields {
+			if !logging.IsValidFieldName(field) {
+				return nil, fmt.Errorf("invalid field %s", field)
+			}
+		}
+		if q.Size, err = parseNonNegativeParameter(req, "size", DefaultFieldValuesSize); err != nil {
+			return nil, err
+		}
+		if q.Size == 0 || q.Size > MaxFieldValuesSize {
+			return nil, fmt.Errorf("size must be between 1 and %d", MaxFieldValuesSize)
+		}
+	}
+
+	return &q, nil
+}
+
+// parseLogQuery splits the field filters out of the comma separated log query, the values of
+// a field are OR'ed, and the fields are AND'ed with each other and the keywords.
+func parseLogQuery(logQuery string) (string, map[string][]string, error) {
+	var keywords []string
+	var filter map[string][]string
+	for _, term := range stringutils.Split(logQuery, ",") {
+		if !strings.HasPrefix(term, FieldFilterPrefix) {
+			keywords = append(keywords, term)
+			continue
+		}
+		field, value, ok := strings.Cut(strings.TrimPrefix(term, FieldFilterPrefix), "=")
+		if !ok || !logging.IsValidFieldName(field) || value == "" {
+			return "", nil, fmt.Errorf("invalid field filter %s", term)
+		}
+		if filter == nil {
+			filter = make(map[string][]string)
+		}
+		filter[field] = append(filter[field], value)
+	}
+	return strings.Join(keywords, ","), filter, nil
+}
+
+// ContextQuery is the query of the records around a record of a container.
+type ContextQuery struct {
+	Namespace string
+	Pod       string
+	Container string
+	Time      time.Time
+	Offset    int64
+	Before    int64
+	After     int64
+}
+
+func ParseContextQueryParameter(req *restful.Request) (*ContextQuery, error) {
+	var q ContextQuery
+	var err error
+
+	q.Namespace = req.QueryParameter("namespace")
+	q.Pod = req.QueryParameter("pod")
+	q.Container = req.QueryParameter("container")
+	if q.Namespace == "" || q.Pod == "" || q.Container == "" {
+		return nil, fmt.Errorf("namespace, pod and container are required")
 	}
 
+	q.Time, err = time.Parse(time.RFC3339Nano, req.QueryParameter("time"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid time: %v", err)
+	}
+
+	if q.Offset, err = parseNonNegativeParameter(req, "offset", 0); err != nil {
+		return nil, err
+	}
+	if q.Before, err = parseNonNegativeParameter(req, "before", DefaultContextSize); err != nil {
+		return nil, err
+	}
+	if q.After, err = parseNonNegativeParameter(req, "after", DefaultContextSize); err != nil {
+		return nil, err
+	}
+	if q.Before > MaxContextSize || q.After > MaxContextSize {
+		return nil, fmt.Errorf("before and after must be no more than %d", MaxContextSize)
+	}
 	return &q, nil
 }
+
+func parseNonNegativeParameter(req *restful.Request, name string, defaultValue int64) (int64, error) {
+	str := req.QueryParameter(name)
+	if str == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %s", name, str)
+	}
+	return n, nil
+}
@@ -75,3 +75,69 @@ func TestParseQueryParameter(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, &expected, actual)
 }
+
+func TestParseFieldQueryParameter(t *testing.T) {
+	tests := []struct {
+		param       string
+		expected    *Query
+		expectedErr bool
+	}{
+		{
+			param: "log_query=timeout,field:level=error,field:level=warn,field:http.status=500&parse_json=true",
+			expected: &Query{
+				Operation: OperationQuery,
+				LogSearch: "timeout",
+				FieldFilter: map[string][]string{
+					"level":       {"error", "warn"},
+					"http.status": {"500"},
+				},
+				ParseJSON: true,
+				Sort:      OrderDescending,
+				Size:      DefaultSize,
+			},
+		},
+		{
+			param: "operation=fields&fields=level,trace_id&log_query=field:service=api",
+			expected: &Query{
+				Operation:   OperationFields,
+				FieldFilter: map[string][]string{"service": {"api"}},
+				Fields:      []string{"level", "trace_id"},
+				Size:        DefaultFieldValuesSize,
+			},
+		},
+		{
+			param:       "log_query=field:level",
+			expectedErr: true,
+		},
+		{
+			param:       "log_query=field:le\"vel=error",
+			expectedErr: true,
+		},
+		{
+			param:       "parse_json=yes",
+			expectedErr: true,
+		},
+		{
+			param:       "operation=fields",
+			expectedErr: true,
+		},
+		{
+			param:       "operation=fields&fields=level&size=1000",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest("GET", "http://localhost/tenant.kubesphere.io/v2alpha1/logs?"+test.param, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := ParseQueryParameter(restful.NewRequest(req))
+		if test.expectedErr {
+			assert.Error(t, err, test.param)
+			continue
+		}
+		assert.NoError(t, err, test.param)
+		assert.Equal(t, test.expected, actual, test.param)
+	}
+}
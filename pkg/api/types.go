@@ -24,6 +24,8 @@ import (
 type ListResult struct {
 	Items      []interface{} `json:"items"`
 	TotalItems int           `json:"totalItems"`
+	// Continue is the opaque token to retrieve the next page, it is empty if there are no more items.
+	Continue string `json:"continue,omitempty"`
 }
 
 type ResourceQuota struct {
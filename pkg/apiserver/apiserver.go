@@ -27,13 +27,19 @@ import (
 	"time"
 
 	"github.com/emicklei/go-restful"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	urlruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
 	unionauth "k8s.io/apiserver/pkg/authentication/request/union"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	runtimecache "sigs.k8s.io/controller-runtime/pkg/cache"
@@ -49,6 +55,8 @@ import (
 	audit "kubesphere.io/kubesphere/pkg/apiserver/auditing"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/authenticators/basic"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/authenticators/jwt"
+	scimauth "kubesphere.io/kubesphere/pkg/apiserver/authentication/authenticators/scim"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/request/anonymous"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/request/basictoken"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/request/bearertoken"
@@ -58,10 +66,15 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizerfactory"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/path"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/rbac"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/scope"
 	unionauthorizer "kubesphere.io/kubesphere/pkg/apiserver/authorization/union"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/webhook"
 	apiserverconfig "kubesphere.io/kubesphere/pkg/apiserver/config"
 	"kubesphere.io/kubesphere/pkg/apiserver/filters"
 	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	ksscheme "kubesphere.io/kubesphere/pkg/client/clientset/versioned/scheme"
+	clusterlister "kubesphere.io/kubesphere/pkg/client/listers/cluster/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/informers"
 	alertingv1 "kubesphere.io/kubesphere/pkg/kapis/alerting/v1"
 	alertingv2alpha1 "kubesphere.io/kubesphere/pkg/kapis/alerting/v2alpha1"
@@ -85,6 +98,7 @@ import (
 	operationsv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/operations/v1alpha2"
 	resourcesv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/resources/v1alpha2"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/kapis/resources/v1alpha3"
+	scimv2 "kubesphere.io/kubesphere/pkg/kapis/scim/v2"
 	servicemeshv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/servicemesh/metrics/v1alpha2"
 	tenantv1alpha2 "kubesphere.io/kubesphere/pkg/kapis/tenant/v1alpha2"
 	tenantv1alpha3 "kubesphere.io/kubesphere/pkg/kapis/tenant/v1alpha3"
@@ -94,6 +108,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/models/iam/am"
 	"kubesphere.io/kubesphere/pkg/models/iam/group"
 	"kubesphere.io/kubesphere/pkg/models/iam/im"
+	"kubesphere.io/kubesphere/pkg/models/kubeconfig"
 	"kubesphere.io/kubesphere/pkg/models/openpitrix"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/loginrecord"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/user"
@@ -180,6 +195,8 @@ func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
 	})
 	s.installDynamicResourceAPI()
 	s.installKubeSphereAPIs(stopCh)
+	s.installIdentityProviderReloader(stopCh)
+	s.installSignKeyRotator(stopCh)
 	s.installMetricsAPI()
 	s.installHealthz()
 
@@ -188,9 +205,7 @@ func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
 	}
 
 	s.Server.Handler = s.container
-	s.buildHandlerChain(stopCh)
-
-	return nil
+	return s.buildHandlerChain(stopCh)
 }
 
 func monitorRequest(r *restful.Request, response *restful.Response, chain *restful.FilterChain) {
@@ -224,6 +239,9 @@ func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
 		s.InformerFactory,
 		s.DevopsClient)
 	rbacAuthorizer := rbac.NewRBACAuthorizer(amOperator)
+	// the handlers authorize the resources they list or read for the users themselves,
+	// so the restrictions of the personal access tokens are enforced along with RBAC
+	scopedAuthorizer := unionauthorizer.New(scope.NewAuthorizer(amOperator), rbacAuthorizer)
 
 	urlruntime.Must(configv1alpha2.AddToContainer(s.container, s.Config))
 	urlruntime.Must(resourcev1alpha3.AddToContainer(s.container, s.InformerFactory, s.RuntimeCache))
@@ -232,13 +250,14 @@ func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
 	urlruntime.Must(openpitrixv1.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.KubeSphere(), s.Config.OpenPitrixOptions, s.OpenpitrixClient))
 	urlruntime.Must(openpitrixv2alpha1.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.KubeSphere(), s.Config.OpenPitrixOptions))
 	urlruntime.Must(operationsv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes()))
+	tokenOperator := auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions)
 	urlruntime.Must(resourcesv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.InformerFactory,
-		s.KubernetesClient.Master()))
+		s.newKubeconfigOperator(tokenOperator)))
 	urlruntime.Must(tenantv1alpha2.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.Kubernetes(),
-		s.KubernetesClient.KubeSphere(), s.EventsClient, s.LoggingClient, s.AuditingClient, amOperator, imOperator, rbacAuthorizer, s.MonitoringClient, s.RuntimeCache, s.Config.MeteringOptions, s.OpenpitrixClient))
+		s.KubernetesClient.KubeSphere(), s.EventsClient, s.LoggingClient, s.AuditingClient, amOperator, imOperator, scopedAuthorizer, s.MonitoringClient, s.RuntimeCache, s.Config.MeteringOptions, s.OpenpitrixClient))
 	urlruntime.Must(tenantv1alpha3.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.Kubernetes(),
-		s.KubernetesClient.KubeSphere(), s.EventsClient, s.LoggingClient, s.AuditingClient, amOperator, imOperator, rbacAuthorizer, s.MonitoringClient, s.RuntimeCache, s.Config.MeteringOptions, s.OpenpitrixClient))
-	urlruntime.Must(terminalv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), rbacAuthorizer, s.KubernetesClient.Config(), s.Config.TerminalOptions))
+		s.KubernetesClient.KubeSphere(), s.EventsClient, s.LoggingClient, s.AuditingClient, amOperator, imOperator, scopedAuthorizer, s.MonitoringClient, s.RuntimeCache, s.Config.MeteringOptions, s.OpenpitrixClient))
+	urlruntime.Must(terminalv1alpha2.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), scopedAuthorizer, s.KubernetesClient.Config(), s.Config.TerminalOptions, s.S3Client))
 	urlruntime.Must(clusterkapisv1alpha1.AddToContainer(s.container,
 		s.KubernetesClient.KubeSphere(),
 		s.InformerFactory.KubernetesSharedInformerFactory(),
@@ -246,16 +265,25 @@ func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
 		s.Config.MultiClusterOptions.ProxyPublishService,
 		s.Config.MultiClusterOptions.ProxyPublishAddress,
 		s.Config.MultiClusterOptions.AgentImage))
-	urlruntime.Must(iamapi.AddToContainer(s.container, imOperator, amOperator,
-		group.New(s.InformerFactory, s.KubernetesClient.KubeSphere(), s.KubernetesClient.Kubernetes()),
-		rbacAuthorizer))
+	groupOperator := group.New(s.InformerFactory, s.KubernetesClient.KubeSphere(), s.KubernetesClient.Kubernetes())
+	urlruntime.Must(iamapi.AddToContainer(s.container, imOperator, amOperator, groupOperator,
+		rbacAuthorizer, tokenOperator, s.newMultiFactorAuthenticator(),
+		auth.NewAccountLockoutOperator(s.KubernetesClient.KubeSphere(), s.CacheClient,
+			s.newEventRecorder(ksscheme.Scheme, stopCh), s.Config.AuthenticationOptions),
+		s.newAccessTokenOperator()))
+	if s.scimEnabled() {
+		urlruntime.Must(scimv2.AddToContainer(s.container, imOperator, groupOperator, s.Config.AuthenticationOptions.SCIMOptions))
+	}
 
 	userLister := s.InformerFactory.KubeSphereSharedInformerFactory().Iam().V1alpha2().Users().Lister()
+	identityprovider.SetSharedCache(s.CacheClient)
 	urlruntime.Must(oauth.AddToContainer(s.container, imOperator,
-		auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
-		auth.NewPasswordAuthenticator(s.KubernetesClient.KubeSphere(), userLister, s.Config.AuthenticationOptions),
+		tokenOperator,
+		auth.NewPasswordAuthenticator(s.KubernetesClient.KubeSphere(), userLister, s.CacheClient, s.Config.AuthenticationOptions),
 		auth.NewOAuthAuthenticator(s.KubernetesClient.KubeSphere(), userLister, s.Config.AuthenticationOptions),
+		s.newMultiFactorAuthenticator(),
 		auth.NewLoginRecorder(s.KubernetesClient.KubeSphere(), userLister),
+		auth.NewDeviceAuthorizationOperator(s.CacheClient),
 		s.Config.AuthenticationOptions))
 	urlruntime.Must(servicemeshv1alpha2.AddToContainer(s.Config.ServiceMeshOptions, s.container, s.KubernetesClient.Kubernetes(), s.CacheClient))
 	urlruntime.Must(networkv1alpha2.AddToContainer(s.container, s.Config.NetworkOptions.WeaveScopeHost))
@@ -277,6 +305,84 @@ func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
 	urlruntime.Must(gatewayv1alpha1.AddToContainer(s.container, s.Config.GatewayOptions, s.RuntimeCache, s.RuntimeClient, s.InformerFactory, s.KubernetesClient.Kubernetes(), s.LoggingClient))
 }
 
+func (s *APIServer) scimEnabled() bool {
+	return s.Config.AuthenticationOptions.SCIMOptions != nil && s.Config.AuthenticationOptions.SCIMOptions.Enable
+}
+
+func (s *APIServer) newMultiFactorAuthenticator() auth.MultiFactorAuthenticator {
+	iamInformers := s.InformerFactory.KubeSphereSharedInformerFactory().Iam().V1alpha2()
+	return auth.NewMultiFactorAuthenticator(s.KubernetesClient.KubeSphere(),
+		iamInformers.Users().Lister(),
+		iamInformers.GlobalRoleBindings().Lister(),
+		s.CacheClient,
+		s.Config.AuthenticationOptions)
+}
+
+func (s *APIServer) newAccessTokenOperator() auth.AccessTokenOperator {
+	return auth.NewAccessTokenOperator(s.KubernetesClient.KubeSphere(),
+		s.InformerFactory.KubeSphereSharedInformerFactory().Iam().V1alpha2().AccessTokens().Lister(),
+		s.Issuer,
+		s.Config.AuthenticationOptions)
+}
+
+// newKubeconfigOperator returns the operator of the kubeconfig the users download, in the token mode the
+// kubeconfig has a context for each member cluster if multicluster is enabled.
+func (s *APIServer) newKubeconfigOperator(tokenOperator auth.TokenManagementInterface) kubeconfig.Interface {
+	if !s.Config.AuthenticationOptions.KubeconfigTokenMode() {
+		return kubeconfig.NewReadOnlyOperator(s.InformerFactory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps().Lister(),
+			s.KubernetesClient.Master())
+	}
+	var clusterLister clusterlister.ClusterLister
+	if s.Config.MultiClusterOptions.Enable {
+		clusterLister = s.InformerFactory.KubeSphereSharedInformerFactory().Cluster().V1alpha1().Clusters().Lister()
+	}
+	return kubeconfig.NewTokenOperator(tokenOperator, clusterLister, s.Config.AuthenticationOptions.KubeconfigOptions)
+}
+
+// installIdentityProviderReloader reloads the identity providers once the Secret which contains the
+// identity providers configuration changes, the informer is started with the other Kubernetes informers.
+func (s *APIServer) installIdentityProviderReloader(stopCh <-chan struct{}) {
+	secretName := s.Config.AuthenticationOptions.OAuthOptions.IdentityProvidersSecret
+	if secretName == "" {
+		return
+	}
+	recorder := s.newEventRecorder(scheme.Scheme, stopCh)
+	identityprovider.NewReloader(s.InformerFactory.KubernetesSharedInformerFactory().Core().V1().Secrets().Informer(),
+		recorder, constants.KubeSphereNamespace, secretName, s.Config.AuthenticationOptions.OAuthOptions.IdentityProviders)
+}
+
+// newEventRecorder records the events of the objects in the given scheme on behalf of ks-apiserver.
+func (s *APIServer) newEventRecorder(scheme *runtime.Scheme, stopCh <-chan struct{}) record.EventRecorder {
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: s.KubernetesClient.Kubernetes().CoreV1().Events("")})
+	go func() {
+		<-stopCh
+		eventBroadcaster.Shutdown()
+	}()
+	return eventBroadcaster.NewRecorder(scheme, corev1.EventSource{Component: "ks-apiserver"})
+}
+
+// installSignKeyRotator shares and rotates the keys to sign the id token through the Secret, the informer
+// is started with the other Kubernetes informers.
+func (s *APIServer) installSignKeyRotator(stopCh <-chan struct{}) {
+	oauthOptions := s.Config.AuthenticationOptions.OAuthOptions
+	if oauthOptions.SignKeysSecret == "" {
+		return
+	}
+	// the replaced key is kept until the id tokens signed with it expire,
+	// or for another rotation period if the id tokens never expire
+	retention := oauthOptions.AccessTokenMaxAge + oauthOptions.AccessTokenInactivityTimeout
+	if retention == 0 {
+		retention = oauthOptions.SignKeyRotationPeriod
+	}
+	retention += s.Config.AuthenticationOptions.MaximumClockSkew
+	rotator := token.NewKeyRotator(s.KubernetesClient.Kubernetes(),
+		s.InformerFactory.KubernetesSharedInformerFactory().Core().V1().Secrets(),
+		constants.KubeSphereNamespace, oauthOptions.SignKeysSecret, s.Issuer.Keyring(),
+		oauthOptions.SignKeyRotationPeriod, retention)
+	go rotator.Run(stopCh)
+}
+
 // installHealthz creates the healthz endpoint for this server
 func (s *APIServer) installHealthz() {
 	urlruntime.Must(healthz.InstallHandler(s.container, []healthz.HealthChecker{}...))
@@ -307,10 +413,11 @@ func (s *APIServer) Run(ctx context.Context) (err error) {
 	return err
 }
 
-func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
+func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) error {
 	requestInfoResolver := &request.RequestInfoFactory{
 		APIPrefixes:          sets.NewString("api", "apis", "kapis", "kapi"),
 		GrouplessAPIPrefixes: sets.NewString("api", "kapi"),
+		TrustedProxies:       s.Config.AuthenticationOptions.TrustedProxies(),
 		GlobalResources: []schema.GroupResource{
 			iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser),
 			iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralGlobalRole),
@@ -339,8 +446,11 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 	handler = filters.WithKubeAPIServer(handler, s.KubernetesClient.Config())
 
 	if s.Config.AuditingOptions.Enable {
-		handler = filters.WithAuditing(handler,
-			audit.NewAuditing(s.InformerFactory, s.Config.AuditingOptions, stopCh))
+		auditing, err := audit.NewAuditing(s.InformerFactory, s.Config.AuditingOptions, stopCh)
+		if err != nil {
+			return err
+		}
+		handler = filters.WithAuditing(handler, auditing)
 	}
 
 	var authorizers authorizer.Authorizer
@@ -354,9 +464,22 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 		fallthrough
 	case authorization.RBAC:
 		excludedPaths := []string{"/oauth/*", "/kapis/config.kubesphere.io/*", "/kapis/version", "/kapis/metrics", "/healthz"}
+		if s.scimEnabled() {
+			// the SCIM endpoint authorizes the provisioning tokens itself
+			excludedPaths = append(excludedPaths, "/scim/*")
+		}
 		pathAuthorizer, _ := path.NewAuthorizer(excludedPaths)
 		amOperator := am.NewReadOnlyOperator(s.InformerFactory, s.DevopsClient)
-		authorizers = unionauthorizer.New(pathAuthorizer, rbac.NewRBACAuthorizer(amOperator))
+		chain := []authorizer.Authorizer{pathAuthorizer, scope.NewAuthorizer(amOperator)}
+		if s.Config.AuthorizationOptions.Webhook != nil {
+			// the webhook grants or vetoes the requests before RBAC, RBAC decides if it has no opinion
+			webhookAuthorizer, err := webhook.NewAuthorizer(s.Config.AuthorizationOptions.Webhook)
+			if err != nil {
+				return err
+			}
+			chain = append(chain, webhookAuthorizer)
+		}
+		authorizers = unionauthorizer.New(append(chain, rbac.NewRBACAuthorizer(amOperator))...)
 	}
 
 	handler = filters.WithAuthorization(handler, authorizers)
@@ -368,18 +491,26 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 	loginRecorder := auth.NewLoginRecorder(s.KubernetesClient.KubeSphere(), userLister)
 
 	// authenticators are unordered
-	authn := unionauth.New(anonymous.NewAuthenticator(),
+	authenticators := []authenticator.Request{anonymous.NewAuthenticator(),
 		basictoken.New(basic.NewBasicAuthenticator(auth.NewPasswordAuthenticator(
 			s.KubernetesClient.KubeSphere(),
 			userLister,
+			s.CacheClient,
 			s.Config.AuthenticationOptions),
-			loginRecorder)),
-		bearertoken.New(jwt.NewTokenAuthenticator(
-			auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
-			userLister)))
-	handler = filters.WithAuthentication(handler, authn)
+			loginRecorder, s.newMultiFactorAuthenticator()))}
+	if s.scimEnabled() {
+		// the provisioning tokens are checked before the JWT, they are only accepted by the SCIM endpoint
+		authenticators = append(authenticators,
+			bearertoken.New(scimauth.NewTokenAuthenticator(s.Config.AuthenticationOptions.SCIMOptions.Tokens)))
+	}
+	authenticators = append(authenticators, bearertoken.New(jwt.NewTokenAuthenticator(
+		auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
+		s.newAccessTokenOperator(),
+		userLister)))
+	handler = filters.WithAuthentication(handler, unionauth.New(authenticators...))
 	handler = filters.WithRequestInfo(handler, requestInfoResolver)
 	s.Server.Handler = handler
+	return nil
 }
 
 func isResourceExists(apiResources []v1.APIResource, resource schema.GroupVersionResource) bool {
@@ -501,6 +632,7 @@ func (s *APIServer) waitForResourceSync(ctx context.Context) error {
 			"workspaceroles",
 			"workspacerolebindings",
 			"loginrecords",
+			"accesstokens",
 		},
 		{Group: "cluster.kubesphere.io", Version: "v1alpha1"}: {
 			"clusters",
@@ -17,13 +17,14 @@ limitations under the License.
 package auditing
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
-	"net/http"
+	"fmt"
+	"math"
+	"path/filepath"
 	"time"
 
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/klog/v2"
 
 	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
@@ -31,42 +32,57 @@ import (
 )
 
 const (
-	GetSenderTimeout     = time.Second
-	SendTimeout          = time.Second * 3
-	DefaultSendersNum    = 100
-	DefaultBatchSize     = 100
-	DefaultBatchInterval = time.Second * 3
-	WebhookURL           = "https://kube-auditing-webhook-svc.kubesphere-logging-system.svc:6443/audit/webhook/event"
+	GetSenderTimeout        = time.Second
+	SendTimeout             = time.Second * 3
+	DefaultSendersNum       = 100
+	DefaultBatchSize        = 100
+	DefaultBatchInterval    = time.Second * 3
+	DefaultSendRetries      = 3
+	DefaultRetryInterval    = time.Second
+	DefaultRetryMaxInterval = time.Minute
+	DefaultSpoolMaxBytes    = 100 << 20
+	WebhookURL              = "https://kube-auditing-webhook-svc.kubesphere-logging-system.svc:6443/audit/webhook/event"
 )
 
+// Backend batches the auditing events and delivers every batch to all the sinks.
 type Backend struct {
-	url                string
-	senderCh           chan interface{}
 	cache              chan *v1alpha1.Event
-	client             http.Client
-	sendTimeout        time.Duration
-	getSenderTimeout   time.Duration
 	eventBatchSize     int
 	eventBatchInterval time.Duration
+	deliveries         []*delivery
 	stopCh             <-chan struct{}
 }
 
-func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan struct{}) *Backend {
+// delivery delivers the batches to a sink, the failed batches are retried with backoff.
+type delivery struct {
+	sink         Sink
+	sendRetries  int
+	retryBackoff wait.Backoff
+	// spool persists the batches pending delivery, it is nil if the spool is disabled.
+	spool       *spool
+	spoolNotify chan struct{}
+	stopCh      <-chan struct{}
+}
+
+func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan struct{}) (*Backend, error) {
+
+	sinks, err := NewSinks(opts)
+	if err != nil {
+		return nil, fmt.Errorf("create auditing sinks error, %s", err)
+	}
+
+	return NewBackendWithSinks(opts, sinks, cache, stopCh), nil
+}
+
+func NewBackendWithSinks(opts *options.Options, sinks []Sink, cache chan *v1alpha1.Event, stopCh <-chan struct{}) *Backend {
 
 	b := Backend{
-		url:                opts.WebhookUrl,
-		getSenderTimeout:   GetSenderTimeout,
 		cache:              cache,
-		sendTimeout:        SendTimeout,
 		eventBatchSize:     opts.EventBatchSize,
 		eventBatchInterval: opts.EventBatchInterval,
 		stopCh:             stopCh,
 	}
 
-	if len(b.url) == 0 {
-		b.url = WebhookURL
-	}
-
 	if b.eventBatchInterval == 0 {
 		b.eventBatchInterval = DefaultBatchInterval
 	}
@@ -75,19 +91,52 @@ func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan
 		b.eventBatchSize = DefaultBatchSize
 	}
 
-	sendersNum := opts.EventSendersNum
-	if sendersNum == 0 {
-		sendersNum = DefaultSendersNum
+	sendRetries := opts.EventSendRetries
+	if sendRetries == 0 {
+		sendRetries = DefaultSendRetries
+	}
+
+	retryMaxInterval := opts.EventRetryMaxInterval
+	if retryMaxInterval == 0 {
+		retryMaxInterval = DefaultRetryMaxInterval
+	}
+	retryInterval := DefaultRetryInterval
+	if retryInterval > retryMaxInterval {
+		retryInterval = retryMaxInterval
+	}
+
+	spoolMaxBytes := opts.SpoolMaxBytes
+	if spoolMaxBytes == 0 {
+		spoolMaxBytes = DefaultSpoolMaxBytes
 	}
-	b.senderCh = make(chan interface{}, sendersNum)
 
-	b.client = http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
+	for _, sink := range sinks {
+		d := &delivery{
+			sink:        sink,
+			sendRetries: sendRetries,
+			retryBackoff: wait.Backoff{
+				Duration: retryInterval,
+				Factor:   2,
+				Jitter:   0.1,
+				Steps:    math.MaxInt32,
+				Cap:      retryMaxInterval,
 			},
-		},
-		Timeout: b.sendTimeout,
+			spoolNotify: make(chan struct{}, 1),
+			stopCh:      stopCh,
+		}
+
+		if len(opts.SpoolDir) > 0 {
+			// Every sink has its own spool, so that an unavailable sink does not block the others.
+			s, err := newSpool(filepath.Join(opts.SpoolDir, sink.Name()), spoolMaxBytes, sink.Name())
+			if err != nil {
+				klog.Errorf("create auditing spool for sink %s error, auditing events will not be persisted, %s", sink.Name(), err)
+			} else {
+				d.spool = s
+				go d.replay()
+			}
+		}
+
+		b.deliveries = append(b.deliveries, d)
 	}
 
 	go b.worker()
@@ -107,7 +156,20 @@ func (b *Backend) worker() {
 			continue
 		}
 
-		go b.sendEvents(events)
+		EventsQueuedCounter.Add(float64(len(events.Items)))
+
+		bs, err := eventListToBytes(events)
+		if err != nil {
+			klog.Errorf("json marshal error, %s", err)
+			for _, d := range b.deliveries {
+				EventsDroppedCounter.WithLabelValues(d.sink.Name(), DropReasonMarshalError).Add(float64(len(events.Items)))
+			}
+			continue
+		}
+
+		for _, d := range b.deliveries {
+			d.deliver(events, bs)
+		}
 	}
 }
 
@@ -135,78 +197,118 @@ func (b *Backend) getEvents() *v1alpha1.EventList {
 	}
 }
 
-func (b *Backend) sendEvents(events *v1alpha1.EventList) {
+// deliver writes the batch into the spool, or sends it directly if the spool is disabled or unwritable.
+func (d *delivery) deliver(events *v1alpha1.EventList, bs []byte) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), b.sendTimeout)
-	defer cancel()
+	if d.spool != nil {
+		err := d.spool.write(bs, len(events.Items))
+		if err == nil {
+			select {
+			case d.spoolNotify <- struct{}{}:
+			default:
+			}
+			return
+		}
+		klog.Errorf("write auditing events to spool of sink %s error, %s", d.sink.Name(), err)
+	}
 
-	stopCh := make(chan struct{})
-	skipReturnSender := false
+	go d.sendEvents(events)
+}
 
-	send := func() {
-		ctx, cancel := context.WithTimeout(context.Background(), b.getSenderTimeout)
-		defer cancel()
+// replay sends the spooled batches one by one in the order they were written,
+// a batch is removed from the spool only after it is delivered.
+func (d *delivery) replay() {
 
-		select {
-		case <-ctx.Done():
-			klog.Error("Get auditing event sender timeout")
-			skipReturnSender = true
-			return
-		case b.senderCh <- struct{}{}:
+	backoff := d.retryBackoff
+	for {
+		entry, bs, _ := d.spool.peek()
+		if entry == nil {
+			select {
+			case <-d.spoolNotify:
+				continue
+			case <-d.stopCh:
+				return
+			}
 		}
 
-		start := time.Now()
-		defer func() {
-			stopCh <- struct{}{}
-			klog.V(8).Infof("send %d auditing logs used %d", len(events.Items), time.Since(start).Milliseconds())
-		}()
+		events := &v1alpha1.EventList{}
+		if err := json.Unmarshal(bs, events); err != nil {
+			klog.Errorf("decode spooled audit events error, %s", err)
+			d.spool.remove(entry)
+			EventsDroppedCounter.WithLabelValues(d.sink.Name(), DropReasonMarshalError).Add(float64(entry.count))
+			continue
+		}
 
-		bs, err := b.eventToBytes(events)
-		if err != nil {
-			klog.Errorf("json marshal error, %s", err)
-			return
+		if err := d.sink.Write(events); err != nil {
+			if isRejected(err) {
+				klog.Errorf("spooled audit events are rejected by sink %s, drop %d auditing events, %s", d.sink.Name(), entry.count, err)
+				backoff = d.retryBackoff
+				d.spool.remove(entry)
+				EventsDroppedCounter.WithLabelValues(d.sink.Name(), DropReasonRejected).Add(float64(entry.count))
+				continue
+			}
+			klog.Errorf("send spooled audit events to sink %s error, %s", d.sink.Name(), err)
+			select {
+			case <-time.After(backoff.Step()):
+				continue
+			case <-d.stopCh:
+				return
+			}
 		}
 
-		klog.V(8).Infof("%s", string(bs))
+		backoff = d.retryBackoff
+		d.spool.remove(entry)
+		EventsSentCounter.WithLabelValues(d.sink.Name()).Add(float64(entry.count))
+	}
+}
 
-		response, err := b.client.Post(b.url, "application/json", bytes.NewBuffer(bs))
-		if err != nil {
-			klog.Errorf("send audit events error, %s", err)
+// sendEvents sends the batch which is not spooled, the batch is dropped after all retries failed.
+func (d *delivery) sendEvents(events *v1alpha1.EventList) {
+
+	count := len(events.Items)
+	backoff := d.retryBackoff
+	for i := 0; ; i++ {
+		err := d.sink.Write(events)
+		if err == nil {
+			EventsSentCounter.WithLabelValues(d.sink.Name()).Add(float64(count))
 			return
 		}
-		defer response.Body.Close()
 
-		if response.StatusCode != http.StatusOK {
-			klog.Errorf("send audit events error[%d]", response.StatusCode)
+		if isRejected(err) {
+			klog.Errorf("%d audit events are rejected by sink %s, %s", count, d.sink.Name(), err)
+			EventsDroppedCounter.WithLabelValues(d.sink.Name(), DropReasonRejected).Add(float64(count))
 			return
 		}
-	}
 
-	go send()
-
-	defer func() {
-		if !skipReturnSender {
-			<-b.senderCh
+		if i >= d.sendRetries {
+			klog.Errorf("send %d audit events to sink %s error after %d retries, %s", count, d.sink.Name(), i, err)
+			EventsDroppedCounter.WithLabelValues(d.sink.Name(), DropReasonRetryExhausted).Add(float64(count))
+			return
 		}
-	}()
 
-	select {
-	case <-ctx.Done():
-		klog.Error("send audit events timeout")
-	case <-stopCh:
+		klog.V(4).Infof("send audit events to sink %s error, retrying, %s", d.sink.Name(), err)
+		select {
+		case <-time.After(backoff.Step()):
+		case <-d.stopCh:
+			EventsDroppedCounter.WithLabelValues(d.sink.Name(), DropReasonRetryExhausted).Add(float64(count))
+			return
+		}
 	}
 }
 
-func (b *Backend) eventToBytes(event *v1alpha1.EventList) ([]byte, error) {
+func eventListToBytes(event *v1alpha1.EventList) ([]byte, error) {
 
 	bs, err := json.Marshal(event)
 	if err != nil {
 		// Normally, the serialization failure is caused by the failure of ResponseObject serialization.
 		// To ensure the integrity of the auditing event to the greatest extent,
 		// it is necessary to delete ResponseObject and and then try to serialize again.
+		// The list is shared by the sinks, so the ResponseObject is deleted from a copy.
 		if event.Items[0].ResponseObject != nil {
-			event.Items[0].ResponseObject = nil
-			return json.Marshal(event)
+			items := make([]v1alpha1.Event, len(event.Items))
+			copy(items, event.Items)
+			items[0].ResponseObject = nil
+			return json.Marshal(&v1alpha1.EventList{Items: items})
 		}
 
 		return nil, err
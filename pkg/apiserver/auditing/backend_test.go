@@ -0,0 +1,293 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/apis/audit"
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/testutil"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+type fakeWebhook struct {
+	mutex    sync.Mutex
+	failures int32
+	received []string
+}
+
+func (f *fakeWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if atomic.AddInt32(&f.failures, -1) >= 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	events := &v1alpha1.EventList{}
+	if err := json.NewDecoder(r.Body).Decode(events); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, e := range events.Items {
+		f.received = append(f.received, string(e.AuditID))
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (f *fakeWebhook) Received() []string {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]string{}, f.received...)
+}
+
+func newEvent(id string) *v1alpha1.Event {
+	return &v1alpha1.Event{Event: audit.Event{AuditID: types.UID(id)}}
+}
+
+func TestBackendRetry(t *testing.T) {
+	webhook := &fakeWebhook{failures: 2}
+	server := httptest.NewServer(webhook)
+	defer server.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	cache := make(chan *v1alpha1.Event, 10)
+	_, err := NewBackend(&options.Options{
+		WebhookUrl:            server.URL,
+		EventBatchInterval:    10 * time.Millisecond,
+		EventRetryMaxInterval: 10 * time.Millisecond,
+	}, cache, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache <- newEvent("1")
+
+	assert.Eventually(t, func() bool {
+		return len(webhook.Received()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNewBackendSinkError(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	_, err := NewBackend(&options.Options{Sinks: []string{options.SinkFile}}, make(chan *v1alpha1.Event), stopCh)
+	assert.Error(t, err)
+}
+
+func TestEventListToBytes(t *testing.T) {
+	events := &v1alpha1.EventList{Items: []v1alpha1.Event{*newEvent("1")}}
+	events.Items[0].ResponseObject = &runtime.Unknown{Raw: []byte("{"), ContentType: runtime.ContentTypeJSON}
+
+	bs, err := eventListToBytes(events)
+	assert.NoError(t, err)
+	assert.Contains(t, string(bs), `"AuditID":"1"`)
+	// the list shared by the sinks is not modified
+	assert.NotNil(t, events.Items[0].ResponseObject)
+}
+
+func TestBackendSpoolReplay(t *testing.T) {
+	dir, err := os.MkdirTemp("", "auditing-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Spool events while the webhook is unavailable.
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	stopCh := make(chan struct{})
+	cache := make(chan *v1alpha1.Event, 10)
+	opts := &options.Options{
+		WebhookUrl:            unavailable.URL,
+		EventBatchSize:        1,
+		EventRetryMaxInterval: 10 * time.Millisecond,
+		SpoolDir:              dir,
+	}
+	if _, err = NewBackend(opts, cache, stopCh); err != nil {
+		t.Fatal(err)
+	}
+
+	cache <- newEvent("1")
+	cache <- newEvent("2")
+
+	assert.Eventually(t, func() bool {
+		files, _ := os.ReadDir(filepath.Join(dir, options.SinkWebhook))
+		return len(files) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	close(stopCh)
+
+	// Replay the spooled events after restart.
+	webhook := &fakeWebhook{}
+	server := httptest.NewServer(webhook)
+	defer server.Close()
+
+	stopCh = make(chan struct{})
+	defer close(stopCh)
+	opts.WebhookUrl = server.URL
+	if _, err = NewBackend(opts, make(chan *v1alpha1.Event), stopCh); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(webhook.Received()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1", "2"}, webhook.Received())
+
+	assert.Eventually(t, func() bool {
+		files, _ := os.ReadDir(filepath.Join(dir, options.SinkWebhook))
+		return len(files) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSpoolMaxBytes(t *testing.T) {
+	dir, err := os.MkdirTemp("", "auditing-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newSpool(dir, 10, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, s.write([]byte("123456"), 1))
+	assert.NoError(t, s.write([]byte("abcdef"), 1))
+	assert.Error(t, s.write([]byte("0123456789abc"), 1))
+
+	entry, data, err := s.peek()
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef", string(data))
+	s.remove(entry)
+
+	entry, _, _ = s.peek()
+	assert.Nil(t, entry)
+}
+
+var registerMetrics sync.Once
+
+// droppedEvents returns the number of the events dropped by the sink for the reason.
+func droppedEvents(t *testing.T, sink, reason string) float64 {
+	registerMetrics.Do(func() {
+		compbasemetrics.NewKubeRegistry().MustRegister(Metrics...)
+	})
+	value, err := testutil.GetCounterMetricValue(EventsDroppedCounter.WithLabelValues(sink, reason))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestBackendSpoolRejected(t *testing.T) {
+	dir, err := os.MkdirTemp("", "auditing-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the batch rejected by the webhook is dropped rather than blocking the batches after it
+	webhook := &fakeWebhook{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events := &v1alpha1.EventList{}
+		bs, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(bs, events); err != nil || string(events.Items[0].AuditID) == "1" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(bs))
+		webhook.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	rejected := droppedEvents(t, options.SinkWebhook, DropReasonRejected)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	cache := make(chan *v1alpha1.Event, 10)
+	if _, err = NewBackend(&options.Options{
+		WebhookUrl:            server.URL,
+		EventBatchSize:        1,
+		EventRetryMaxInterval: 10 * time.Millisecond,
+		SpoolDir:              dir,
+	}, cache, stopCh); err != nil {
+		t.Fatal(err)
+	}
+
+	cache <- newEvent("1")
+	cache <- newEvent("2")
+
+	assert.Eventually(t, func() bool {
+		return len(webhook.Received()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"2"}, webhook.Received())
+	assert.Equal(t, rejected+1, droppedEvents(t, options.SinkWebhook, DropReasonRejected))
+}
+
+func TestSpoolEvictInflight(t *testing.T) {
+	dir, err := os.MkdirTemp("", "auditing-spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newSpool(dir, 12, "evict")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the batch evicted while it's delivered is counted by the result of the delivery only
+	dropped := droppedEvents(t, "evict", DropReasonSpoolFull)
+	assert.NoError(t, s.write([]byte("123456"), 1))
+	entry, _, _ := s.peek()
+	assert.NoError(t, s.write([]byte("abcdef"), 2))
+	assert.NoError(t, s.write([]byte("ghijkl"), 3))
+	s.remove(entry)
+	assert.Equal(t, dropped, droppedEvents(t, "evict", DropReasonSpoolFull))
+
+	// the batch evicted while it's delivered is counted as dropped once if the delivery fails
+	entry, data, _ := s.peek()
+	assert.Equal(t, "abcdef", string(data))
+	assert.NoError(t, s.write([]byte("mnopqr"), 4))
+	entry, data, _ = s.peek()
+	assert.Equal(t, "ghijkl", string(data))
+	assert.Equal(t, dropped+2, droppedEvents(t, "evict", DropReasonSpoolFull))
+	s.remove(entry)
+	assert.Equal(t, dropped+2, droppedEvents(t, "evict", DropReasonSpoolFull))
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	compbasemetrics "k8s.io/component-base/metrics"
+)
+
+const (
+	DropReasonCacheTimeout   = "cache_timeout"
+	DropReasonRetryExhausted = "retry_exhausted"
+	DropReasonSpoolFull      = "spool_full"
+	DropReasonMarshalError   = "marshal_error"
+	DropReasonReadError      = "read_error"
+	DropReasonRejected       = "rejected"
+)
+
+var (
+	EventsQueuedCounter = compbasemetrics.NewCounter(
+		&compbasemetrics.CounterOpts{
+			Name:           "ks_server_auditing_events_queued_total",
+			Help:           "Counter of auditing events accepted by the auditing backend for delivery.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+	)
+
+	EventsSentCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "ks_server_auditing_events_sent_total",
+			Help:           "Counter of auditing events delivered broken out for each sink.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"sink"},
+	)
+
+	EventsDroppedCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "ks_server_auditing_events_dropped_total",
+			Help:           "Counter of auditing events dropped by the auditing backend broken out for each sink and reason.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"sink", "reason"},
+	)
+
+	SpoolBytes = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Name:           "ks_server_auditing_spool_bytes",
+			Help:           "Size in bytes of the auditing events pending delivery in the local spool broken out for each sink.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"sink"},
+	)
+
+	// Metrics lists the auditing metrics, they are registered together with the ks-apiserver request metrics.
+	Metrics = []compbasemetrics.Registerable{
+		EventsQueuedCounter,
+		EventsSentCounter,
+		EventsDroppedCounter,
+		SpoolBytes,
+	}
+)
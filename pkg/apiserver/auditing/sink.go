@@ -0,0 +1,159 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+// Sink is the destination which the batches of auditing events are delivered to.
+type Sink interface {
+	// Name returns the name of the sink, it is unique among the sinks of a backend.
+	Name() string
+	// Write delivers a batch of auditing events, the batch is retried if an error returned,
+	// unless the error is returned by Rejected.
+	Write(events *v1alpha1.EventList) error
+}
+
+// rejectedError is the error of a batch which the sink rejects permanently.
+type rejectedError struct {
+	err error
+}
+
+func (e *rejectedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rejectedError) Unwrap() error {
+	return e.err
+}
+
+// Rejected wraps the error of a batch which would never be accepted by the sink, e.g. a client error of the webhook,
+// the batch is dropped instead of being retried, so that it does not block the batches after it.
+func Rejected(err error) error {
+	return &rejectedError{err: err}
+}
+
+func isRejected(err error) bool {
+	var rejected *rejectedError
+	return errors.As(err, &rejected)
+}
+
+// NewSinks creates the sinks selected in the auditing options, the webhook sink is used if no sink is selected.
+func NewSinks(opts *options.Options) ([]Sink, error) {
+	names := opts.Sinks
+	if len(names) == 0 {
+		names = []string{options.SinkWebhook}
+	}
+
+	var sinks []Sink
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case options.SinkWebhook:
+			sinks = append(sinks, NewWebhookSink(opts))
+		case options.SinkFile:
+			if opts.File == nil || len(opts.File.Path) == 0 {
+				return nil, fmt.Errorf("auditing file sink requires a file path")
+			}
+			sinks = append(sinks, NewFileSink(opts.File))
+		case options.SinkStdout:
+			sinks = append(sinks, NewWriterSink(options.SinkStdout, os.Stdout))
+		case options.SinkSyslog:
+			syslogOpts := opts.Syslog
+			if syslogOpts == nil {
+				syslogOpts = &options.SyslogSinkOptions{}
+			}
+			s, err := NewSyslogSink(syslogOpts)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, s)
+		default:
+			return nil, fmt.Errorf("unsupported auditing sink %s", name)
+		}
+	}
+
+	return sinks, nil
+}
+
+// writerSink writes auditing events into an io.Writer as JSON lines.
+type writerSink struct {
+	name   string
+	writer io.Writer
+}
+
+func NewWriterSink(name string, writer io.Writer) Sink {
+	return &writerSink{
+		name:   name,
+		writer: writer,
+	}
+}
+
+func (s *writerSink) Name() string {
+	return s.name
+}
+
+func (s *writerSink) Write(events *v1alpha1.EventList) error {
+	bs, err := eventsToJSONLines(events)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.writer.Write(bs)
+	return err
+}
+
+// eventsToJSONLines encodes every event as a single line of JSON.
+func eventsToJSONLines(events *v1alpha1.EventList) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for i := range events.Items {
+		bs, err := eventToBytes(&events.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(bs)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+func eventToBytes(event *v1alpha1.Event) ([]byte, error) {
+	bs, err := json.Marshal(event)
+	if err != nil && event.ResponseObject != nil {
+		// Keep the auditing event even if the ResponseObject can not be serialized.
+		e := *event
+		e.ResponseObject = nil
+		return json.Marshal(&e)
+	}
+
+	return bs, err
+}
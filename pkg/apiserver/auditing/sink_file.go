@@ -0,0 +1,179 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+const (
+	DefaultFileMaxSize = 100
+	backupTimeFormat   = "2006-01-02T15-04-05.000"
+)
+
+// fileSink writes auditing events into a local file as JSON lines, the file is rotated
+// when it grows larger than the maximum size or older than the maximum age. The rotated
+// files are renamed with the rotation time, e.g. audit-2006-01-02T15-04-05.000.log.
+type fileSink struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	mutex    sync.Mutex
+	file     *os.File
+	size     int64
+	openTime time.Time
+	// now is used to get the current time, it is replaced in tests.
+	now func() time.Time
+}
+
+func NewFileSink(opts *options.FileSinkOptions) Sink {
+	s := &fileSink{
+		path:       opts.Path,
+		maxSize:    int64(opts.MaxSize) * 1024 * 1024,
+		maxAge:     opts.MaxAge,
+		maxBackups: opts.MaxBackups,
+		now:        time.Now,
+	}
+
+	if s.maxSize == 0 {
+		s.maxSize = DefaultFileMaxSize * 1024 * 1024
+	}
+
+	return s
+}
+
+func (s *fileSink) Name() string {
+	return options.SinkFile
+}
+
+func (s *fileSink) Write(events *v1alpha1.EventList) error {
+	bs, err := eventsToJSONLines(events)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+
+	if s.needRotate(int64(len(bs))) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(bs)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) needRotate(size int64) bool {
+	if s.size > 0 && s.size+size > s.maxSize {
+		return true
+	}
+
+	return s.maxAge > 0 && s.size > 0 && s.now().Sub(s.openTime) >= s.maxAge
+}
+
+func (s *fileSink) open() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	s.file = f
+	s.size = info.Size()
+	s.openTime = s.now()
+	// The age of an existing file is counted from its last modification.
+	if s.size > 0 {
+		s.openTime = info.ModTime()
+	}
+
+	return nil
+}
+
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		klog.Errorf("close auditing file %s error, %s", s.path, err)
+	}
+	s.file = nil
+
+	if err := os.Rename(s.path, s.backupName(s.now())); err != nil {
+		return err
+	}
+
+	s.removeBackups()
+	return s.open()
+}
+
+func (s *fileSink) backupName(t time.Time) string {
+	ext := filepath.Ext(s.path)
+	return strings.TrimSuffix(s.path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// removeBackups removes the oldest rotated files which exceed the maximum backups.
+func (s *fileSink) removeBackups() {
+	if s.maxBackups <= 0 {
+		return
+	}
+
+	ext := filepath.Ext(s.path)
+	backups, err := filepath.Glob(strings.TrimSuffix(s.path, ext) + "-*" + ext)
+	if err != nil {
+		klog.Errorf("list auditing file backups error, %s", err)
+		return
+	}
+
+	if len(backups) <= s.maxBackups {
+		return
+	}
+
+	// The backup names are sorted by the rotation time.
+	sort.Strings(backups)
+	for _, backup := range backups[:len(backups)-s.maxBackups] {
+		if err := os.Remove(backup); err != nil {
+			klog.Errorf("remove auditing file backup %s error, %s", backup, err)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+const (
+	DefaultSyslogTag      = "ks-apiserver"
+	DefaultSyslogFacility = "local0"
+	syslogSeverityInfo    = 6
+	syslogDialTimeout     = 3 * time.Second
+	syslogWriteTimeout    = 3 * time.Second
+	// nilValue is the RFC 5424 NILVALUE.
+	nilValue = "-"
+)
+
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogSink sends every auditing event as a RFC 5424 syslog message. The messages sent over
+// tcp are framed with octet counting as described in RFC 6587, and the messages sent over
+// unix stream sockets are terminated with a newline.
+type syslogSink struct {
+	network  string
+	address  string
+	priority int
+	tag      string
+	hostname string
+
+	mutex       sync.Mutex
+	conn        net.Conn
+	connNetwork string
+}
+
+func NewSyslogSink(opts *options.SyslogSinkOptions) (Sink, error) {
+	facility := opts.Facility
+	if len(facility) == 0 {
+		facility = DefaultSyslogFacility
+	}
+
+	code, ok := options.SyslogFacilities[strings.ToLower(facility)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported syslog facility %s", facility)
+	}
+
+	s := &syslogSink{
+		network:  opts.Network,
+		address:  opts.Address,
+		priority: code*8 + syslogSeverityInfo,
+		tag:      opts.Tag,
+		hostname: nilValue,
+	}
+
+	if len(s.tag) == 0 {
+		s.tag = DefaultSyslogTag
+	}
+
+	if hostname, err := os.Hostname(); err == nil && len(hostname) > 0 {
+		s.hostname = hostname
+	}
+
+	return s, nil
+}
+
+func (s *syslogSink) Name() string {
+	return options.SinkSyslog
+}
+
+func (s *syslogSink) Write(events *v1alpha1.EventList) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	for i := range events.Items {
+		bs, err := eventToBytes(&events.Items[i])
+		if err != nil {
+			return err
+		}
+
+		if err := s.write(s.format(&events.Items[i], bs)); err != nil {
+			// Reconnect when the batch is retried.
+			_ = s.conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *syslogSink) connect() error {
+	if len(s.network) > 0 {
+		conn, err := net.DialTimeout(s.network, s.address, syslogDialTimeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+		s.connNetwork = s.network
+		return nil
+	}
+
+	for _, path := range localSyslogPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.DialTimeout(network, path, syslogDialTimeout)
+			if err == nil {
+				s.conn = conn
+				s.connNetwork = network
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("unable to connect to the local syslog server")
+}
+
+// format formats the event as a RFC 5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+func (s *syslogSink) format(event *v1alpha1.Event, msg []byte) []byte {
+	timestamp := event.StageTimestamp.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s %s ",
+		s.priority,
+		timestamp.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		s.tag,
+		os.Getpid(),
+		syslogMsgID(event),
+		nilValue)
+
+	return append([]byte(header), msg...)
+}
+
+func (s *syslogSink) write(msg []byte) error {
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		return err
+	}
+
+	var err error
+	switch s.connNetwork {
+	case "udp", "udp4", "udp6", "unixgram":
+		_, err = s.conn.Write(msg)
+	case "unix":
+		_, err = s.conn.Write(append(msg, '\n'))
+	default:
+		_, err = fmt.Fprintf(s.conn, "%d %s", len(msg), msg)
+	}
+
+	return err
+}
+
+// syslogMsgID returns the verb of the event as the MSGID, which is printable ASCII without spaces.
+func syslogMsgID(event *v1alpha1.Event) string {
+	if len(event.Verb) == 0 {
+		return nilValue
+	}
+
+	id := make([]byte, 0, len(event.Verb))
+	for i := 0; i < len(event.Verb) && len(id) < 32; i++ {
+		c := event.Verb[i]
+		if c > 32 && c < 127 {
+			id = append(id, c)
+		}
+	}
+
+	if len(id) == 0 {
+		return nilValue
+	}
+
+	return string(id)
+}
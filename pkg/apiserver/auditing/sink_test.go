@@ -0,0 +1,204 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+func TestNewSinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      *options.Options
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name:     "default webhook sink",
+			opts:     &options.Options{},
+			expected: []string{options.SinkWebhook},
+		},
+		{
+			name: "combined sinks",
+			opts: &options.Options{
+				Sinks: []string{options.SinkStdout, options.SinkFile, options.SinkStdout},
+				File:  &options.FileSinkOptions{Path: "/tmp/audit.log"},
+			},
+			expected: []string{options.SinkStdout, options.SinkFile},
+		},
+		{
+			name:      "file sink without path",
+			opts:      &options.Options{Sinks: []string{options.SinkFile}},
+			expectErr: true,
+		},
+		{
+			name:      "unknown sink",
+			opts:      &options.Options{Sinks: []string{"kafka"}},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sinks, err := NewSinks(test.opts)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			var names []string
+			for _, s := range sinks {
+				names = append(names, s.Name())
+			}
+			assert.Equal(t, test.expected, names)
+		})
+	}
+}
+
+func TestValidateSinks(t *testing.T) {
+	opts := &options.Options{
+		Sinks:  []string{options.SinkSyslog},
+		Syslog: &options.SyslogSinkOptions{Facility: "LOCAL1"},
+	}
+	assert.Empty(t, opts.Validate())
+
+	opts.Syslog.Facility = "unknown"
+	assert.Len(t, opts.Validate(), 1)
+}
+
+func TestBackendMultipleSinks(t *testing.T) {
+	stdout := &syncBuffer{}
+	other := &syncBuffer{}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	cache := make(chan *v1alpha1.Event, 10)
+	NewBackendWithSinks(&options.Options{EventBatchInterval: 10 * time.Millisecond},
+		[]Sink{NewWriterSink("a", stdout), NewWriterSink("b", other)}, cache, stopCh)
+
+	cache <- newEvent("1")
+	cache <- newEvent("2")
+
+	for _, buf := range []*syncBuffer{stdout, other} {
+		assert.Eventually(t, func() bool {
+			return strings.Count(buf.String(), "\n") == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		assert.Contains(t, buf.String(), `"AuditID":"1"`)
+	}
+}
+
+func TestFileSinkRotate(t *testing.T) {
+	dir, err := os.MkdirTemp("", "auditing-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewFileSink(&options.FileSinkOptions{
+		Path:       filepath.Join(dir, "audit.log"),
+		MaxAge:     time.Hour,
+		MaxBackups: 1,
+	}).(*fileSink)
+	s.now = func() time.Time { return now }
+
+	events := &v1alpha1.EventList{Items: []v1alpha1.Event{*newEvent("1")}}
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, s.Write(events))
+		now = now.Add(time.Hour)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "audit*.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "audit-2023-01-01T02-00-00.000.log"),
+		filepath.Join(dir, "audit.log"),
+	}, files)
+
+	s.maxSize = 1
+	assert.NoError(t, s.Write(events))
+	bs, err := os.ReadFile(filepath.Join(dir, "audit.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(bs), "\n"))
+}
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s, err := NewSyslogSink(&options.SyslogSinkOptions{
+		Network:  "udp",
+		Address:  conn.LocalAddr().String(),
+		Facility: "auth",
+		Tag:      "audit",
+	})
+	assert.NoError(t, err)
+
+	event := newEvent("1")
+	event.Verb = "create"
+	assert.NoError(t, s.Write(&v1alpha1.EventList{Items: []v1alpha1.Event{*event}}))
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+
+	parts := strings.SplitN(string(buf[:n]), " ", 8)
+	assert.Len(t, parts, 8)
+	assert.Equal(t, "<38>1", parts[0])
+	assert.Equal(t, "audit", parts[3])
+	assert.Equal(t, "create", parts[5])
+	assert.Equal(t, "-", parts[6])
+	assert.Contains(t, parts[7], `"AuditID":"1"`)
+
+	_, err = NewSyslogSink(&options.SyslogSinkOptions{Facility: "unknown"})
+	assert.Error(t, err)
+}
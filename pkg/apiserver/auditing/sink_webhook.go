@@ -0,0 +1,114 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+// webhookSink sends the batches of auditing events to the kube-auditing webhook.
+type webhookSink struct {
+	url              string
+	senderCh         chan interface{}
+	client           http.Client
+	getSenderTimeout time.Duration
+}
+
+func NewWebhookSink(opts *options.Options) Sink {
+	s := &webhookSink{
+		url:              opts.WebhookUrl,
+		getSenderTimeout: GetSenderTimeout,
+	}
+
+	if len(s.url) == 0 {
+		s.url = WebhookURL
+	}
+
+	sendersNum := opts.EventSendersNum
+	if sendersNum == 0 {
+		sendersNum = DefaultSendersNum
+	}
+	s.senderCh = make(chan interface{}, sendersNum)
+
+	s.client = http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+		Timeout: SendTimeout,
+	}
+
+	return s
+}
+
+func (s *webhookSink) Name() string {
+	return options.SinkWebhook
+}
+
+func (s *webhookSink) Write(events *v1alpha1.EventList) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.getSenderTimeout)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("get auditing event sender timeout")
+	case s.senderCh <- struct{}{}:
+	}
+
+	start := time.Now()
+	defer func() {
+		<-s.senderCh
+		klog.V(8).Infof("send %d auditing logs used %d", len(events.Items), time.Since(start).Milliseconds())
+	}()
+
+	bs, err := eventListToBytes(events)
+	if err != nil {
+		return Rejected(err)
+	}
+
+	klog.V(8).Infof("%s", string(bs))
+
+	response, err := s.client.Post(s.url, "application/json", bytes.NewBuffer(bs))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		err = fmt.Errorf("send audit events error[%d]", response.StatusCode)
+		// the client errors are permanent except for timeouts and throttling
+		if response.StatusCode >= 400 && response.StatusCode < 500 &&
+			response.StatusCode != http.StatusRequestTimeout && response.StatusCode != http.StatusTooManyRequests {
+			return Rejected(err)
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	spoolFileSuffix = ".json"
+	spoolTempSuffix = ".tmp"
+)
+
+// spoolEntry is a batch of auditing events persisted in the spool.
+type spoolEntry struct {
+	name  string
+	size  int64
+	count int
+}
+
+// spool is a bounded write-ahead queue of auditing event batches on the local disk.
+// Every batch is written into a single file named by its creation time, a sequence
+// number and the number of events it holds, so the entries can be replayed in order
+// after ks-apiserver restarts without reading them.
+type spool struct {
+	dir      string
+	maxBytes int64
+	// sink is the name of the sink which the spooled batches are delivered to.
+	sink string
+
+	mutex   sync.Mutex
+	seq     uint64
+	size    int64
+	entries []spoolEntry
+	// inflight is the batch being delivered, which is counted by the result of the delivery
+	// rather than as dropped if it's evicted from a full spool meanwhile.
+	inflight        *spoolEntry
+	inflightEvicted bool
+}
+
+func newSpool(dir string, maxBytes int64, sink string) (*spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spool{
+		dir:      dir,
+		maxBytes: maxBytes,
+		sink:     sink,
+	}
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		// Remove the batches which were not written completely.
+		if strings.HasSuffix(f.Name(), spoolTempSuffix) {
+			_ = os.Remove(filepath.Join(dir, f.Name()))
+			continue
+		}
+
+		count, ok := parseSpoolFileName(f.Name())
+		if !ok {
+			continue
+		}
+
+		info, err := f.Info()
+		if err != nil {
+			klog.Errorf("stat auditing spool file %s error, %s", f.Name(), err)
+			continue
+		}
+
+		s.entries = append(s.entries, spoolEntry{name: f.Name(), size: info.Size(), count: count})
+		s.size += info.Size()
+	}
+
+	sort.Slice(s.entries, func(i, j int) bool {
+		return s.entries[i].name < s.entries[j].name
+	})
+
+	SpoolBytes.WithLabelValues(s.sink).Set(float64(s.size))
+	return s, nil
+}
+
+// parseSpoolFileName returns the number of events in the spool file.
+func parseSpoolFileName(name string) (int, bool) {
+	if !strings.HasSuffix(name, spoolFileSuffix) {
+		return 0, false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(name, spoolFileSuffix), "-")
+	if len(parts) != 3 {
+		return 0, false
+	}
+
+	count, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return 0, false
+	}
+
+	return count, true
+}
+
+// write persists a batch of events, the oldest batches are dropped if the spool is full.
+func (s *spool) write(data []byte, count int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	size := int64(len(data))
+	if s.maxBytes > 0 && size > s.maxBytes {
+		return fmt.Errorf("auditing event batch size %d exceeds spool capacity %d", size, s.maxBytes)
+	}
+
+	s.seq++
+	name := fmt.Sprintf("%020d-%010d-%d%s", time.Now().UnixNano(), s.seq, count, spoolFileSuffix)
+	tmp := filepath.Join(s.dir, name+spoolTempSuffix)
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	for s.maxBytes > 0 && s.size+size > s.maxBytes && len(s.entries) > 0 {
+		oldest := s.entries[0]
+		klog.Errorf("auditing spool of sink %s is full, drop %d auditing events", s.sink, oldest.count)
+		s.removeLocked(oldest)
+		if s.inflight != nil && s.inflight.name == oldest.name {
+			s.inflightEvicted = true
+			continue
+		}
+		EventsDroppedCounter.WithLabelValues(s.sink, DropReasonSpoolFull).Add(float64(oldest.count))
+	}
+
+	s.entries = append(s.entries, spoolEntry{name: name, size: size, count: count})
+	s.size += size
+	SpoolBytes.WithLabelValues(s.sink).Set(float64(s.size))
+	return nil
+}
+
+// peek returns the oldest batch in the spool and marks it in flight until it is removed. The batch in flight
+// before is counted as dropped if it was evicted from the spool before it could be delivered.
+func (s *spool) peek() (*spoolEntry, []byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.inflight != nil && s.inflightEvicted {
+		EventsDroppedCounter.WithLabelValues(s.sink, DropReasonSpoolFull).Add(float64(s.inflight.count))
+	}
+	s.inflight, s.inflightEvicted = nil, false
+
+	for len(s.entries) > 0 {
+		entry := s.entries[0]
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.name))
+		if err == nil {
+			s.inflight = &entry
+			return &entry, data, nil
+		}
+
+		klog.Errorf("read auditing spool file %s error, %s", entry.name, err)
+		s.removeLocked(entry)
+		EventsDroppedCounter.WithLabelValues(s.sink, DropReasonReadError).Add(float64(entry.count))
+	}
+
+	return nil, nil, nil
+}
+
+// remove deletes the batch from the spool after it is delivered or dropped, it may have been evicted already,
+// the caller counts the events of the batch either way.
+func (s *spool) remove(entry *spoolEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.removeLocked(*entry)
+	if s.inflight != nil && s.inflight.name == entry.name {
+		s.inflight, s.inflightEvicted = nil, false
+	}
+}
+
+func (s *spool) removeLocked(entry spoolEntry) {
+	for i := range s.entries {
+		if s.entries[i].name != entry.name {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(s.dir, entry.name)); err != nil && !os.IsNotExist(err) {
+			klog.Errorf("remove auditing spool file %s error, %s", entry.name, err)
+		}
+
+		s.entries = append(s.entries[:i], s.entries[i+1:]...)
+		s.size -= entry.size
+		SpoolBytes.WithLabelValues(s.sink).Set(float64(s.size))
+		return
+	}
+}
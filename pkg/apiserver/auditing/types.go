@@ -67,7 +67,7 @@ type auditing struct {
 	backend       *Backend
 }
 
-func NewAuditing(informers informers.InformerFactory, opts *options.Options, stopCh <-chan struct{}) Auditing {
+func NewAuditing(informers informers.InformerFactory, opts *options.Options, stopCh <-chan struct{}) (Auditing, error) {
 
 	a := &auditing{
 		webhookLister: informers.KubeSphereSharedInformerFactory().Auditing().V1alpha1().Webhooks().Lister(),
@@ -75,8 +75,12 @@ func NewAuditing(informers informers.InformerFactory, opts *options.Options, sto
 		cache:         make(chan *auditv1alpha1.Event, DefaultCacheCapacity),
 	}
 
-	a.backend = NewBackend(opts, a.cache, stopCh)
-	return a
+	backend, err := NewBackend(opts, a.cache, stopCh)
+	if err != nil {
+		return nil, err
+	}
+	a.backend = backend
+	return a, nil
 }
 
 func (a *auditing) getAuditLevel() audit.Level {
@@ -265,6 +269,8 @@ func (a *auditing) cacheEvent(e auditv1alpha1.Event) {
 		return
 	case <-time.After(CacheTimeout):
 		klog.V(8).Infof("cache audit event %s timeout", e.AuditID)
+		// The event is dropped before it is batched, so it is not counted for any sink.
+		EventsDroppedCounter.WithLabelValues("", DropReasonCacheTimeout).Inc()
 		break
 	}
 }
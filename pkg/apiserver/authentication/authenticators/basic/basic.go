@@ -18,6 +18,7 @@ package basic
 
 import (
 	"context"
+	"fmt"
 
 	"k8s.io/klog/v2"
 
@@ -39,12 +40,17 @@ import (
 type basicAuthenticator struct {
 	authenticator auth.PasswordAuthenticator
 	loginRecorder auth.LoginRecorder
+	// multiFactorAuthenticator rejects the users who must login with the second factor,
+	// which can't be carried by the basic authentication.
+	multiFactorAuthenticator auth.MultiFactorAuthenticator
 }
 
-func NewBasicAuthenticator(authenticator auth.PasswordAuthenticator, loginRecorder auth.LoginRecorder) basictoken.Password {
+func NewBasicAuthenticator(authenticator auth.PasswordAuthenticator, loginRecorder auth.LoginRecorder,
+	multiFactorAuthenticator auth.MultiFactorAuthenticator) basictoken.Password {
 	return &basicAuthenticator{
-		authenticator: authenticator,
-		loginRecorder: loginRecorder,
+		authenticator:            authenticator,
+		loginRecorder:            loginRecorder,
+		multiFactorAuthenticator: multiFactorAuthenticator,
 	}
 }
 
@@ -63,6 +69,15 @@ func (t *basicAuthenticator) AuthenticatePassword(ctx context.Context, username,
 		}
 		return nil, false, err
 	}
+	if t.multiFactorAuthenticator != nil {
+		required, err := t.multiFactorAuthenticator.Required(authenticated, provider)
+		if err != nil {
+			return nil, false, err
+		}
+		if required {
+			return nil, false, fmt.Errorf("user %s must login with multi-factor authentication", username)
+		}
+	}
 	return &authenticator.Response{
 		User: &user.DefaultInfo{
 			Name:   authenticated.GetName(),
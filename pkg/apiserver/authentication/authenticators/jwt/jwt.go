@@ -25,6 +25,8 @@ import (
 
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
 
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
 	"kubesphere.io/kubesphere/pkg/models/auth"
 
 	iamv1alpha2listers "kubesphere.io/kubesphere/pkg/client/listers/iam/v1alpha2"
@@ -36,19 +38,22 @@ import (
 // and group from user.AllUnauthenticated. This helps requests be passed along the handler chain,
 // because some resources are public accessible.
 type tokenAuthenticator struct {
-	tokenOperator auth.TokenManagementInterface
-	userLister    iamv1alpha2listers.UserLister
+	tokenOperator       auth.TokenManagementInterface
+	accessTokenOperator auth.AccessTokenOperator
+	userLister          iamv1alpha2listers.UserLister
 }
 
-func NewTokenAuthenticator(tokenOperator auth.TokenManagementInterface, userLister iamv1alpha2listers.UserLister) authenticator.Token {
+func NewTokenAuthenticator(tokenOperator auth.TokenManagementInterface, accessTokenOperator auth.AccessTokenOperator,
+	userLister iamv1alpha2listers.UserLister) authenticator.Token {
 	return &tokenAuthenticator{
-		tokenOperator: tokenOperator,
-		userLister:    userLister,
+		tokenOperator:       tokenOperator,
+		accessTokenOperator: accessTokenOperator,
+		userLister:          userLister,
 	}
 }
 
-func (t *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
-	verified, err := t.tokenOperator.Verify(token)
+func (t *tokenAuthenticator) AuthenticateToken(ctx context.Context, tokenStr string) (*authenticator.Response, bool, error) {
+	verified, err := t.tokenOperator.Verify(tokenStr)
 	if err != nil {
 		klog.Warning(err)
 		return nil, false, err
@@ -69,10 +74,44 @@ func (t *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string
 	if userInfo.Status.State == iamv1alpha2.UserDisabled {
 		return nil, false, auth.AccountIsNotActiveError
 	}
+
+	var extra map[string][]string
+	if verified.TokenType == token.PersonalAccessToken {
+		if extra, err = t.validateAccessToken(ctx, verified, userInfo); err != nil {
+			klog.V(4).Info(err)
+			return nil, false, err
+		}
+	}
 	return &authenticator.Response{
 		User: &user.DefaultInfo{
 			Name:   userInfo.GetName(),
 			Groups: append(userInfo.Spec.Groups, user.AllAuthenticated),
+			Extra:  extra,
 		},
 	}, true, nil
 }
+
+// validateAccessToken checks the personal access token is not revoked, the restrictions of the token
+// are passed to the authorizers via the extra of the user info.
+func (t *tokenAuthenticator) validateAccessToken(ctx context.Context, verified *token.VerifiedResponse, userInfo *iamv1alpha2.User) (map[string][]string, error) {
+	if t.accessTokenOperator == nil {
+		return nil, auth.ErrAccessTokenRevoked
+	}
+	// the client IP can't be spoofed by the forwarding headers unless they are set by the trusted proxies
+	var clientIP string
+	if requestInfo, ok := request.RequestInfoFrom(ctx); ok {
+		clientIP = requestInfo.ClientIP
+	}
+	accessToken, err := t.accessTokenOperator.Validate(verified, userInfo, clientIP)
+	if err != nil {
+		return nil, err
+	}
+	extra := map[string][]string{iamv1alpha2.ExtraAccessToken: {accessToken.Name}}
+	if len(accessToken.Spec.Workspaces) > 0 {
+		extra[iamv1alpha2.ExtraAccessTokenWorkspaces] = accessToken.Spec.Workspaces
+	}
+	if len(accessToken.Spec.Verbs) > 0 {
+		extra[iamv1alpha2.ExtraAccessTokenVerbs] = accessToken.Spec.Verbs
+	}
+	return extra, nil
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scim
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+const (
+	// ProvisionerUser is the user of the SCIM clients, it's not a valid name of the KubeSphere users.
+	ProvisionerUser = "system:scim-provisioner"
+	// PathPrefix is the path of the SCIM endpoint, the provisioning tokens are rejected by the other APIs.
+	PathPrefix = "/scim/v2"
+)
+
+// tokenAuthenticator authenticates the SCIM clients with the static provisioning tokens,
+// the tokens are only accepted by the SCIM endpoint so that they can't be used to access the other APIs.
+type tokenAuthenticator struct {
+	tokens [][]byte
+}
+
+func NewTokenAuthenticator(tokens []string) authenticator.Token {
+	t := &tokenAuthenticator{}
+	for _, token := range tokens {
+		t.tokens = append(t.tokens, []byte(token))
+	}
+	return t
+}
+
+func (t *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string) (*authenticator.Response, bool, error) {
+	info, ok := request.RequestInfoFrom(ctx)
+	if !ok || info.RequestInfo == nil || (info.Path != PathPrefix && !strings.HasPrefix(info.Path, PathPrefix+"/")) {
+		return nil, false, nil
+	}
+	for _, expected := range t.tokens {
+		if subtle.ConstantTimeCompare(expected, []byte(token)) == 1 {
+			return &authenticator.Response{
+				User: &user.DefaultInfo{Name: ProvisionerUser},
+			}, true, nil
+		}
+	}
+	return nil, false, nil
+}
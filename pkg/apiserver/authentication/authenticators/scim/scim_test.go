@@ -0,0 +1,54 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	k8srequest "k8s.io/apiserver/pkg/endpoints/request"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+func TestAuthenticateToken(t *testing.T) {
+	authenticator := NewTokenAuthenticator([]string{"provisioning-token"})
+	tests := []struct {
+		name     string
+		path     string
+		token    string
+		expected bool
+	}{
+		{name: "users", path: "/scim/v2/Users", token: "provisioning-token", expected: true},
+		{name: "root", path: "/scim/v2", token: "provisioning-token", expected: true},
+		{name: "wrong token", path: "/scim/v2/Users", token: "provisioning-tokem"},
+		{name: "other apis", path: "/kapis/iam.kubesphere.io/v1alpha2/users", token: "provisioning-token"},
+		{name: "path prefix", path: "/scim/v20/Users", token: "provisioning-token"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := request.WithRequestInfo(context.Background(), &request.RequestInfo{RequestInfo: &k8srequest.RequestInfo{Path: test.path}})
+			resp, ok, err := authenticator.AuthenticateToken(ctx, test.token)
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, ok)
+			if test.expected {
+				assert.Equal(t, ProvisionerUser, resp.User.GetName())
+			}
+		})
+	}
+}
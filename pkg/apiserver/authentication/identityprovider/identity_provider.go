@@ -19,18 +19,25 @@ package identityprovider
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"k8s.io/klog/v2"
 
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 )
 
 var (
 	oauthProviderFactories   = make(map[string]OAuthProviderFactory)
 	genericProviderFactories = make(map[string]GenericProviderFactory)
 	identityProviderNotFound = errors.New("identity provider not found")
-	oauthProviders           = make(map[string]OAuthProvider)
-	genericProviders         = make(map[string]GenericProvider)
+	// mutex guards the identity providers and their options, which are replaced as a whole when reloading.
+	mutex                   sync.RWMutex
+	oauthProviders          = make(map[string]OAuthProvider)
+	genericProviders        = make(map[string]GenericProvider)
+	identityProviderOptions = make([]oauth.IdentityProviderOptions, 0)
+	// sharedCache keeps the state of the identity providers which is shared by the replicas of ks-apiserver.
+	sharedCache cache.Interface
 )
 
 // Identity represents the account mapped to kubesphere
@@ -45,8 +52,17 @@ type Identity interface {
 	GetEmail() string
 }
 
+// GroupsIdentity is implemented by the Identity which carries the group claims,
+// the groups are synchronized to the user mapped to the identity.
+type GroupsIdentity interface {
+	Identity
+	GetGroups() []string
+}
+
 // SetupWithOptions will verify the configuration and initialize the identityProviders
 func SetupWithOptions(options []oauth.IdentityProviderOptions) error {
+	mutex.Lock()
+	defer mutex.Unlock()
 	for _, o := range options {
 		if oauthProviders[o.Name] != nil || genericProviders[o.Name] != nil {
 			err := fmt.Errorf("duplicate identity provider found: %s, name must be unique", o.Name)
@@ -58,29 +74,102 @@ func SetupWithOptions(options []oauth.IdentityProviderOptions) error {
 			klog.Error(err)
 			return err
 		}
-		if factory, ok := oauthProviderFactories[o.Type]; ok {
-			if provider, err := factory.Create(o.Provider); err != nil {
-				// don’t return errors, decoupling external dependencies
-				klog.Error(fmt.Sprintf("failed to create identity provider %s: %s", o.Name, err))
-			} else {
-				oauthProviders[o.Name] = provider
-				klog.V(4).Infof("create identity provider %s successfully", o.Name)
-			}
+		oauthProvider, genericProvider, err := createProvider(o)
+		if err != nil {
+			// don’t return errors, decoupling external dependencies
+			klog.Error(err)
 		}
-		if factory, ok := genericProviderFactories[o.Type]; ok {
-			if provider, err := factory.Create(o.Provider); err != nil {
-				klog.Error(fmt.Sprintf("failed to create identity provider %s: %s", o.Name, err))
-			} else {
-				genericProviders[o.Name] = provider
-				klog.V(4).Infof("create identity provider %s successfully", o.Name)
-			}
+		if oauthProvider != nil {
+			oauthProviders[o.Name] = oauthProvider
 		}
+		if genericProvider != nil {
+			genericProviders[o.Name] = genericProvider
+		}
+		identityProviderOptions = append(identityProviderOptions, o)
 	}
 	return nil
 }
 
+// ReplaceWithOptions verifies the configuration and replaces all the identityProviders with it.
+// Unlike SetupWithOptions, the replacement is all or nothing, the current identityProviders are kept
+// if any identity provider is invalid or fails to create. Requests holding the replaced providers
+// are not affected, they are released once the requests are completed.
+func ReplaceWithOptions(options []oauth.IdentityProviderOptions) error {
+	newOAuthProviders := make(map[string]OAuthProvider)
+	newGenericProviders := make(map[string]GenericProvider)
+	names := make(map[string]bool)
+	for _, o := range options {
+		if names[o.Name] {
+			return fmt.Errorf("duplicate identity provider found: %s, name must be unique", o.Name)
+		}
+		names[o.Name] = true
+		if genericProviderFactories[o.Type] == nil && oauthProviderFactories[o.Type] == nil {
+			return fmt.Errorf("identity provider %s with type %s is not supported", o.Name, o.Type)
+		}
+		oauthProvider, genericProvider, err := createProvider(o)
+		if err != nil {
+			return err
+		}
+		if oauthProvider != nil {
+			newOAuthProviders[o.Name] = oauthProvider
+		}
+		if genericProvider != nil {
+			newGenericProviders[o.Name] = genericProvider
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	oauthProviders = newOAuthProviders
+	genericProviders = newGenericProviders
+	identityProviderOptions = append(make([]oauth.IdentityProviderOptions, 0, len(options)), options...)
+	return nil
+}
+
+func createProvider(o oauth.IdentityProviderOptions) (OAuthProvider, GenericProvider, error) {
+	if factory, ok := oauthProviderFactories[o.Type]; ok {
+		provider, err := factory.Create(o.Provider)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create identity provider %s: %s", o.Name, err)
+		}
+		klog.V(4).Infof("create identity provider %s successfully", o.Name)
+		return provider, nil, nil
+	}
+	if factory, ok := genericProviderFactories[o.Type]; ok {
+		provider, err := factory.Create(o.Provider)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create identity provider %s: %s", o.Name, err)
+		}
+		klog.V(4).Infof("create identity provider %s successfully", o.Name)
+		return nil, provider, nil
+	}
+	return nil, nil, fmt.Errorf("identity provider %s with type %s is not supported", o.Name, o.Type)
+}
+
+// GetIdentityProviderOptions returns the options of the identity provider with given name
+func GetIdentityProviderOptions(providerName string) (*oauth.IdentityProviderOptions, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, o := range identityProviderOptions {
+		if o.Name == providerName {
+			found := o
+			return &found, nil
+		}
+	}
+	return nil, oauth.ErrorProviderNotFound
+}
+
+// ListIdentityProviderOptions returns the options of all the identity providers
+func ListIdentityProviderOptions() []oauth.IdentityProviderOptions {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return append(make([]oauth.IdentityProviderOptions, 0, len(identityProviderOptions)), identityProviderOptions...)
+}
+
 // GetGenericProvider returns GenericProvider with given name
 func GetGenericProvider(providerName string) (GenericProvider, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
 	if provider, ok := genericProviders[providerName]; ok {
 		return provider, nil
 	}
@@ -89,12 +178,32 @@ func GetGenericProvider(providerName string) (GenericProvider, error) {
 
 // GetOAuthProvider returns OAuthProvider with given name
 func GetOAuthProvider(providerName string) (OAuthProvider, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
 	if provider, ok := oauthProviders[providerName]; ok {
 		return provider, nil
 	}
 	return nil, identityProviderNotFound
 }
 
+// SetSharedCache sets the cache shared by the replicas of ks-apiserver, the identity providers keep
+// the state across the requests in it, e.g. the issued SAML authentication requests.
+func SetSharedCache(c cache.Interface) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	sharedCache = c
+}
+
+// SharedCache returns the cache shared by the replicas of ks-apiserver.
+func SharedCache() (cache.Interface, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if sharedCache == nil {
+		return nil, errors.New("shared cache is not configured")
+	}
+	return sharedCache, nil
+}
+
 // RegisterOAuthProvider register OAuthProviderFactory with the specified type
 func RegisterOAuthProvider(factory OAuthProviderFactory) {
 	oauthProviderFactories[factory.Type()] = factory
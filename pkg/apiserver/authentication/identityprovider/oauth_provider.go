@@ -33,3 +33,17 @@ type OAuthProviderFactory interface {
 	// Create Apply the dynamic options
 	Create(options oauth.DynamicOptions) (OAuthProvider, error)
 }
+
+// MetadataProvider is implemented by the OAuthProvider which publishes the metadata
+// required by the upstream identity provider, e.g. the SAML 2.0 SP metadata.
+type MetadataProvider interface {
+	// Metadata returns the content type and the content of the metadata document
+	Metadata() (string, []byte, error)
+}
+
+// LoginProvider is implemented by the OAuthProvider which issues the authentication requests itself,
+// e.g. the SAML 2.0 service provider, the identity provider only accepts the responses to the requests.
+type LoginProvider interface {
+	// Login redirects the user agent to the identity provider with an authentication request
+	Login(w http.ResponseWriter, req *http.Request) error
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identityprovider
+
+import (
+	"fmt"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+)
+
+const (
+	// IdentityProvidersSecretKey is the key of the Secret data which contains the identity providers
+	// configuration, the value is a YAML list in the same format as oauthOptions.identityProviders.
+	IdentityProvidersSecretKey = "identityProviders"
+
+	ReasonIdentityProvidersReloaded = "IdentityProvidersReloaded"
+	ReasonInvalidIdentityProviders  = "InvalidIdentityProviders"
+)
+
+// Reloader replaces the identity providers once the Secret which contains the identity providers
+// configuration changes, so the identity providers can be updated without restarting ks-apiserver.
+// The identity providers from the configuration file are restored if the Secret is deleted.
+type Reloader struct {
+	namespace string
+	name      string
+	defaults  []oauth.IdentityProviderOptions
+	recorder  record.EventRecorder
+
+	mutex sync.Mutex
+	// appliedVersion is the resourceVersion of the Secret applied lastly,
+	// the Secret is applied again on resync if it failed to apply.
+	appliedVersion string
+}
+
+// NewReloader creates a Reloader watching the Secret with given namespace and name, the events
+// of the reloading, including the validation errors, are recorded on the Secret.
+func NewReloader(informer cache.SharedIndexInformer, recorder record.EventRecorder, namespace, name string,
+	defaults []oauth.IdentityProviderOptions) *Reloader {
+	r := &Reloader{
+		namespace: namespace,
+		name:      name,
+		defaults:  defaults,
+		recorder:  recorder,
+	}
+	informer.AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: r.matches,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				r.reload(obj.(*corev1.Secret))
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				r.reload(obj.(*corev1.Secret))
+			},
+			DeleteFunc: func(interface{}) {
+				r.restore()
+			},
+		},
+	})
+	return r
+}
+
+func (r *Reloader) matches(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	return ok && secret.Namespace == r.namespace && secret.Name == r.name
+}
+
+func (r *Reloader) reload(secret *corev1.Secret) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if secret.ResourceVersion == r.appliedVersion {
+		return
+	}
+
+	data, ok := secret.Data[IdentityProvidersSecretKey]
+	if !ok {
+		r.recorder.Eventf(secret, corev1.EventTypeWarning, ReasonInvalidIdentityProviders,
+			"key %s not found", IdentityProvidersSecretKey)
+		return
+	}
+
+	var options []oauth.IdentityProviderOptions
+	if err := yaml.Unmarshal(data, &options); err != nil {
+		r.recorder.Eventf(secret, corev1.EventTypeWarning, ReasonInvalidIdentityProviders,
+			"failed to parse identity providers: %v", err)
+		return
+	}
+
+	if err := ReplaceWithOptions(options); err != nil {
+		klog.Errorf("failed to reload identity providers from secret %s/%s: %v", r.namespace, r.name, err)
+		r.recorder.Eventf(secret, corev1.EventTypeWarning, ReasonInvalidIdentityProviders,
+			"failed to reload identity providers, the current identity providers are kept: %v", err)
+		return
+	}
+
+	r.appliedVersion = secret.ResourceVersion
+	klog.Infof("reloaded %d identity providers from secret %s/%s", len(options), r.namespace, r.name)
+	r.recorder.Event(secret, corev1.EventTypeNormal, ReasonIdentityProvidersReloaded,
+		fmt.Sprintf("reloaded %d identity providers", len(options)))
+}
+
+func (r *Reloader) restore() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.appliedVersion = ""
+	if err := ReplaceWithOptions(r.defaults); err != nil {
+		klog.Errorf("failed to restore identity providers after secret %s/%s deleted: %v", r.namespace, r.name, err)
+		return
+	}
+	klog.Infof("restored identity providers after secret %s/%s deleted", r.namespace, r.name)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package identityprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+)
+
+func TestReplaceWithOptions(t *testing.T) {
+	RegisterOAuthProvider(emptyOAuthProviderFactory{typeName: "GitHubIdentityProvider"})
+	RegisterGenericProvider(emptyGenericProviderFactory{typeName: "LDAPIdentityProvider"})
+
+	assert.NoError(t, ReplaceWithOptions([]oauth.IdentityProviderOptions{
+		{Name: "github", Type: "GitHubIdentityProvider"},
+	}))
+	provider, err := GetOAuthProvider("github")
+	assert.NoError(t, err)
+	assert.NotNil(t, provider)
+
+	err = ReplaceWithOptions([]oauth.IdentityProviderOptions{
+		{Name: "ldap", Type: "LDAPIdentityProvider"},
+		{Name: "ldap", Type: "LDAPIdentityProvider"},
+	})
+	assert.Error(t, err)
+	// the current identity providers are kept if the configuration is invalid
+	_, err = GetOAuthProvider("github")
+	assert.NoError(t, err)
+
+	assert.NoError(t, ReplaceWithOptions([]oauth.IdentityProviderOptions{
+		{Name: "ldap", Type: "LDAPIdentityProvider", MappingMethod: oauth.MappingMethodLookup},
+	}))
+	_, err = GetOAuthProvider("github")
+	assert.Equal(t, identityProviderNotFound, err)
+	_, err = GetGenericProvider("ldap")
+	assert.NoError(t, err)
+	options, err := GetIdentityProviderOptions("ldap")
+	assert.NoError(t, err)
+	assert.Equal(t, oauth.MappingMethodLookup, options.MappingMethod)
+	assert.Len(t, ListIdentityProviderOptions(), 1)
+}
+
+func TestReloader(t *testing.T) {
+	RegisterOAuthProvider(emptyOAuthProviderFactory{typeName: "GitHubIdentityProvider"})
+	defaults := []oauth.IdentityProviderOptions{{Name: "default", Type: "GitHubIdentityProvider"}}
+	assert.NoError(t, ReplaceWithOptions(defaults))
+
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	recorder := record.NewFakeRecorder(10)
+	NewReloader(informerFactory.Core().V1().Secrets().Informer(), recorder, "kubesphere-system", "identity-providers", defaults)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informerFactory.Start(stopCh)
+	informerFactory.WaitForCacheSync(stopCh)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kubesphere-system", Name: "identity-providers", ResourceVersion: "1"},
+		Data: map[string][]byte{IdentityProvidersSecretKey: []byte(`
+- name: github
+  type: GitHubIdentityProvider
+  mappingMethod: auto
+  provider:
+    clientID: kubesphere
+    clientSecret: secret
+`)},
+	}
+	_, err := client.CoreV1().Secrets("kubesphere-system").Create(context.Background(), secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, waitForEvent(t, recorder), ReasonIdentityProvidersReloaded)
+	_, err = GetOAuthProvider("github")
+	assert.NoError(t, err)
+	_, err = GetOAuthProvider("default")
+	assert.Equal(t, identityProviderNotFound, err)
+
+	secret = secret.DeepCopy()
+	secret.ResourceVersion = "2"
+	secret.Data[IdentityProvidersSecretKey] = []byte(`
+- name: github
+  type: NotSupported
+`)
+	_, err = client.CoreV1().Secrets("kubesphere-system").Update(context.Background(), secret, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, waitForEvent(t, recorder), ReasonInvalidIdentityProviders)
+	options, err := GetIdentityProviderOptions("github")
+	assert.NoError(t, err)
+	assert.Equal(t, "GitHubIdentityProvider", options.Type)
+
+	assert.NoError(t, client.CoreV1().Secrets("kubesphere-system").Delete(context.Background(), secret.Name, metav1.DeleteOptions{}))
+	assert.Eventually(t, func() bool {
+		_, err := GetOAuthProvider("default")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func waitForEvent(t *testing.T, recorder *record.FakeRecorder) string {
+	select {
+	case event := <-recorder.Events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for event")
+		return ""
+	}
+}
+
+func TestReloaderIgnoresOtherSecrets(t *testing.T) {
+	r := &Reloader{namespace: "kubesphere-system", name: "identity-providers"}
+	assert.False(t, r.matches(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "identity-providers"}}))
+	assert.True(t, r.matches(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "kubesphere-system", Name: "identity-providers"}}))
+	assert.True(t, r.matches(cache.DeletedFinalStateUnknown{
+		Key: "kubesphere-system/identity-providers",
+		Obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: "kubesphere-system", Name: "identity-providers"}},
+	}))
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package saml
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+const (
+	namespaceXML = "http://www.w3.org/XML/1998/namespace"
+	// defaultPrefix represents the default namespace in the InclusiveNamespaces PrefixList.
+	defaultPrefix = "#default"
+)
+
+// canonicalizer serializes an element with the Exclusive XML Canonicalization Version 1.0
+// without comments, see also https://www.w3.org/TR/xml-exc-c14n/.
+type canonicalizer struct {
+	buf *bytes.Buffer
+	// inclusivePrefixes are the prefixes treated as the Canonical XML, they are declared
+	// in the output once they are in scope, no matter if they are visibly utilized.
+	inclusivePrefixes []string
+	// excluded is omitted from the output, it's used to implement the enveloped signature transform.
+	excluded *etree.Element
+}
+
+// canonicalize returns the canonical form of the element, the namespaces declared
+// by the ancestors of the element are taken into account.
+func canonicalize(el *etree.Element, inclusivePrefixes []string, excluded *etree.Element) []byte {
+	c := &canonicalizer{buf: &bytes.Buffer{}, excluded: excluded}
+	for _, prefix := range inclusivePrefixes {
+		if prefix == defaultPrefix {
+			prefix = ""
+		}
+		c.inclusivePrefixes = append(c.inclusivePrefixes, prefix)
+	}
+	c.writeElement(el, map[string]string{})
+	return c.buf.Bytes()
+}
+
+func (c *canonicalizer) writeElement(el *etree.Element, rendered map[string]string) {
+	// namespaces visibly utilized by the element and its attributes
+	prefixes := []string{el.Space}
+	attrs := make([]etree.Attr, 0, len(el.Attr))
+	for _, attr := range el.Attr {
+		if isNamespaceDeclaration(attr) {
+			continue
+		}
+		if attr.Space != "" && attr.Space != "xml" {
+			prefixes = append(prefixes, attr.Space)
+		}
+		attrs = append(attrs, attr)
+	}
+	for _, prefix := range c.inclusivePrefixes {
+		if _, ok := lookupNamespace(el, prefix); ok {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	declarations := make(map[string]string)
+	for _, prefix := range prefixes {
+		uri, ok := lookupNamespace(el, prefix)
+		if !ok && prefix != "" {
+			continue
+		}
+		if rendered[prefix] != uri {
+			declarations[prefix] = uri
+		}
+	}
+
+	c.buf.WriteByte('<')
+	c.buf.WriteString(el.FullTag())
+
+	declared := make([]string, 0, len(declarations))
+	for prefix := range declarations {
+		declared = append(declared, prefix)
+	}
+	// the default namespace declaration comes first since the empty prefix sorts first
+	sort.Strings(declared)
+	for _, prefix := range declared {
+		if prefix == "" {
+			c.buf.WriteString(` xmlns="`)
+		} else {
+			c.buf.WriteString(` xmlns:` + prefix + `="`)
+		}
+		c.buf.WriteString(escapeAttributeValue(declarations[prefix]))
+		c.buf.WriteByte('"')
+	}
+
+	// attributes are sorted by the namespace URI as the primary key and the local name as the secondary key,
+	// attributes without namespace have the empty namespace URI and come first
+	sort.SliceStable(attrs, func(i, j int) bool {
+		ni, nj := attributeNamespace(el, attrs[i]), attributeNamespace(el, attrs[j])
+		if ni != nj {
+			return ni < nj
+		}
+		return attrs[i].Key < attrs[j].Key
+	})
+	for _, attr := range attrs {
+		c.buf.WriteByte(' ')
+		c.buf.WriteString(attr.FullKey())
+		c.buf.WriteString(`="`)
+		c.buf.WriteString(escapeAttributeValue(attr.Value))
+		c.buf.WriteByte('"')
+	}
+	c.buf.WriteByte('>')
+
+	if len(declarations) > 0 {
+		scope := make(map[string]string, len(rendered)+len(declarations))
+		for prefix, uri := range rendered {
+			scope[prefix] = uri
+		}
+		for prefix, uri := range declarations {
+			scope[prefix] = uri
+		}
+		rendered = scope
+	}
+
+	for _, child := range el.Child {
+		switch token := child.(type) {
+		case *etree.Element:
+			if token != c.excluded {
+				c.writeElement(token, rendered)
+			}
+		case *etree.CharData:
+			c.buf.WriteString(escapeText(token.Data))
+		case *etree.ProcInst:
+			c.buf.WriteString("<?" + token.Target)
+			if token.Inst != "" {
+				c.buf.WriteString(" " + token.Inst)
+			}
+			c.buf.WriteString("?>")
+		}
+	}
+
+	c.buf.WriteString("</")
+	c.buf.WriteString(el.FullTag())
+	c.buf.WriteByte('>')
+}
+
+func isNamespaceDeclaration(attr etree.Attr) bool {
+	return attr.Space == "xmlns" || (attr.Space == "" && attr.Key == "xmlns")
+}
+
+// lookupNamespace returns the namespace URI bound to the prefix in the scope of the element.
+func lookupNamespace(el *etree.Element, prefix string) (string, bool) {
+	if prefix == "xml" {
+		return namespaceXML, true
+	}
+	for ; el != nil; el = el.Parent() {
+		for _, attr := range el.Attr {
+			if (prefix == "" && attr.Space == "" && attr.Key == "xmlns") ||
+				(prefix != "" && attr.Space == "xmlns" && attr.Key == prefix) {
+				return attr.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+func attributeNamespace(el *etree.Element, attr etree.Attr) string {
+	if attr.Space == "" {
+		return ""
+	}
+	uri, _ := lookupNamespace(el, attr.Space)
+	return uri
+}
+
+var (
+	textEscaper      = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attributeEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+func escapeAttributeValue(s string) string {
+	return attributeEscaper.Replace(s)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package saml
+
+import (
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+)
+
+const (
+	bindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	bindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+
+	// MetadataContentType is the media type of the SAML metadata document.
+	MetadataContentType = "application/samlmetadata+xml"
+)
+
+// entityDescriptor is the metadata of a SAML entity,
+// see also http://docs.oasis-open.org/security/saml/v2.0/saml-metadata-2.0-os.pdf.
+type entityDescriptor struct {
+	XMLName           xml.Name           `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+	EntityID          string             `xml:"entityID,attr"`
+	IDPSSODescriptors []idpSSODescriptor `xml:"IDPSSODescriptor,omitempty"`
+	SPSSODescriptors  []spSSODescriptor  `xml:"SPSSODescriptor,omitempty"`
+}
+
+type idpSSODescriptor struct {
+	KeyDescriptors      []keyDescriptor `xml:"KeyDescriptor"`
+	SingleSignOnService []endpoint      `xml:"SingleSignOnService"`
+}
+
+type spSSODescriptor struct {
+	AuthnRequestsSigned        bool              `xml:"AuthnRequestsSigned,attr"`
+	WantAssertionsSigned       bool              `xml:"WantAssertionsSigned,attr"`
+	ProtocolSupportEnumeration string            `xml:"protocolSupportEnumeration,attr"`
+	NameIDFormats              []string          `xml:"NameIDFormat,omitempty"`
+	AssertionConsumerServices  []indexedEndpoint `xml:"AssertionConsumerService"`
+}
+
+type keyDescriptor struct {
+	Use          string   `xml:"use,attr,omitempty"`
+	Certificates []string `xml:"KeyInfo>X509Data>X509Certificate"`
+}
+
+type endpoint struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
+type indexedEndpoint struct {
+	Binding   string `xml:"Binding,attr"`
+	Location  string `xml:"Location,attr"`
+	Index     int    `xml:"index,attr"`
+	IsDefault bool   `xml:"isDefault,attr"`
+}
+
+// identityProviderMetadata is the part of the IdP metadata used by the service provider.
+type identityProviderMetadata struct {
+	entityID     string
+	certificates []*x509.Certificate
+	// ssoURL is the single sign-on service with the HTTP-Redirect binding,
+	// where the authentication requests are sent to.
+	ssoURL string
+}
+
+func parseIdentityProviderMetadata(data []byte) (*identityProviderMetadata, error) {
+	descriptor := &entityDescriptor{}
+	if err := xml.Unmarshal(data, descriptor); err != nil {
+		return nil, fmt.Errorf("invalid identity provider metadata: %v", err)
+	}
+	if len(descriptor.IDPSSODescriptors) == 0 {
+		return nil, errors.New("IDPSSODescriptor not found in identity provider metadata")
+	}
+
+	metadata := &identityProviderMetadata{entityID: descriptor.EntityID}
+	for _, idp := range descriptor.IDPSSODescriptors {
+		for _, service := range idp.SingleSignOnService {
+			if service.Binding == bindingHTTPRedirect && metadata.ssoURL == "" {
+				metadata.ssoURL = service.Location
+			}
+		}
+		for _, key := range idp.KeyDescriptors {
+			if key.Use != "" && key.Use != "signing" {
+				continue
+			}
+			for _, encoded := range key.Certificates {
+				der, err := decodeBase64(encoded)
+				if err != nil {
+					return nil, fmt.Errorf("invalid certificate in identity provider metadata: %v", err)
+				}
+				certificate, err := x509.ParseCertificate(der)
+				if err != nil {
+					return nil, fmt.Errorf("invalid certificate in identity provider metadata: %v", err)
+				}
+				metadata.certificates = append(metadata.certificates, certificate)
+			}
+		}
+	}
+	if len(metadata.certificates) == 0 {
+		return nil, errors.New("signing certificate not found in identity provider metadata")
+	}
+	if metadata.ssoURL == "" {
+		return nil, errors.New("SingleSignOnService with the HTTP-Redirect binding not found in identity provider metadata")
+	}
+	return metadata, nil
+}
+
+// serviceProviderMetadata returns the SP metadata, the identity provider uses it to
+// establish the trust relationship with KubeSphere.
+func serviceProviderMetadata(entityID, acsURL, nameIDFormat string) ([]byte, error) {
+	descriptor := &entityDescriptor{
+		EntityID: entityID,
+		SPSSODescriptors: []spSSODescriptor{{
+			WantAssertionsSigned:       true,
+			ProtocolSupportEnumeration: namespaceProtocol,
+			AssertionConsumerServices: []indexedEndpoint{{
+				Binding:   bindingHTTPPost,
+				Location:  acsURL,
+				Index:     0,
+				IsDefault: true,
+			}},
+		}},
+	}
+	if nameIDFormat != "" {
+		descriptor.SPSSODescriptors[0].NameIDFormats = []string{nameIDFormat}
+	}
+	data, err := xml.MarshalIndent(descriptor, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,519 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/mitchellh/mapstructure"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+const (
+	namespaceProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	namespaceAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+	statusSuccess             = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	subjectConfirmationBearer = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	allowedClockSkew          = 90 * time.Second
+	defaultAssertionLifetime  = 5 * time.Minute
+	metadataFetchTimeout      = 10 * time.Second
+	authnRequestLifetime      = 10 * time.Minute
+
+	// requestCookie binds the authentication request to the user agent which initiates the login,
+	// the responses posted by other user agents are rejected, which prevents the login CSRF.
+	requestCookie = "saml-request"
+
+	requestCacheKeyPrefix   = "kubesphere:saml:request:"
+	assertionCacheKeyPrefix = "kubesphere:saml:assertion:"
+	requestPending          = "pending"
+	requestConsumed         = "consumed"
+)
+
+func init() {
+	identityprovider.RegisterOAuthProvider(&samlProviderFactory{})
+}
+
+// samlProvider is a SAML 2.0 service provider, it sends the authentication requests with the
+// HTTP-Redirect binding and consumes the SAML responses posted by the identity provider with the
+// HTTP-POST binding. Only the responses to the requests issued by KubeSphere are accepted, so the
+// single sign-on initiated by the identity provider is not supported.
+// See also http://docs.oasis-open.org/security/saml/v2.0/saml-profiles-2.0-os.pdf.
+type samlProvider struct {
+	// EntityID is the unique identifier of KubeSphere as a service provider,
+	// it defaults to RedirectURL.
+	EntityID string `json:"entityID" yaml:"entityID"`
+
+	// RedirectURL is the assertion consumer service URL, where the SAML responses are posted to,
+	// e.g. https://ks-console/oauth/callback/{callback}.
+	RedirectURL string `json:"redirectURL" yaml:"redirectURL"`
+
+	// IdentityProviderMetadata is the metadata document of the identity provider,
+	// it contains the entityID and the signing certificates of the identity provider.
+	IdentityProviderMetadata string `json:"identityProviderMetadata" yaml:"identityProviderMetadata"`
+
+	// IdentityProviderMetadataURL is used to fetch the metadata of the identity provider
+	// if IdentityProviderMetadata is not provided.
+	IdentityProviderMetadataURL string `json:"identityProviderMetadataURL" yaml:"identityProviderMetadataURL"`
+
+	// NameIDFormat is the NameID format requested in the SP metadata,
+	// e.g. urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress.
+	NameIDFormat string `json:"nameIDFormat" yaml:"nameIDFormat"`
+
+	// UsernameAttribute is the attribute which contains the username, NameID is used if it is empty.
+	UsernameAttribute string `json:"usernameAttribute" yaml:"usernameAttribute"`
+
+	// EmailAttribute is the attribute which contains the email.
+	EmailAttribute string `json:"emailAttribute" yaml:"emailAttribute"`
+
+	// GroupsAttribute is the attribute which contains the groups of the user.
+	GroupsAttribute string `json:"groupsAttribute" yaml:"groupsAttribute"`
+
+	// Used to turn off TLS certificate checks when fetching the identity provider metadata.
+	InsecureSkipVerify bool `json:"insecureSkipVerify" yaml:"insecureSkipVerify"`
+
+	idp *identityProviderMetadata
+	now func() time.Time
+}
+
+type samlProviderFactory struct {
+}
+
+type samlIdentity struct {
+	NameID   string   `json:"nameID"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Groups   []string `json:"groups"`
+}
+
+func (s samlIdentity) GetUserID() string {
+	return s.NameID
+}
+
+func (s samlIdentity) GetUsername() string {
+	if s.Username != "" {
+		return s.Username
+	}
+	return s.NameID
+}
+
+func (s samlIdentity) GetEmail() string {
+	return s.Email
+}
+
+func (s samlIdentity) GetGroups() []string {
+	return s.Groups
+}
+
+func (f *samlProviderFactory) Type() string {
+	return "SAMLIdentityProvider"
+}
+
+func (f *samlProviderFactory) Create(options oauth.DynamicOptions) (identityprovider.OAuthProvider, error) {
+	var provider samlProvider
+	if err := mapstructure.Decode(options, &provider); err != nil {
+		return nil, err
+	}
+	if provider.RedirectURL == "" {
+		return nil, errors.New("saml: redirectURL is required")
+	}
+	if provider.EntityID == "" {
+		provider.EntityID = provider.RedirectURL
+	}
+
+	metadata := []byte(provider.IdentityProviderMetadata)
+	if len(metadata) == 0 {
+		if provider.IdentityProviderMetadataURL == "" {
+			return nil, errors.New("saml: identityProviderMetadata or identityProviderMetadataURL is required")
+		}
+		var err error
+		if metadata, err = provider.fetchMetadata(); err != nil {
+			return nil, fmt.Errorf("saml: failed to fetch identity provider metadata: %v", err)
+		}
+	}
+
+	idp, err := parseIdentityProviderMetadata(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("saml: %v", err)
+	}
+	provider.idp = idp
+	provider.now = time.Now
+	return &provider, nil
+}
+
+func (s *samlProvider) fetchMetadata() ([]byte, error) {
+	client := &http.Client{
+		Timeout: metadataFetchTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: s.InsecureSkipVerify},
+		},
+	}
+	resp, err := client.Get(s.IdentityProviderMetadataURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Metadata returns the SP metadata, which should be registered to the identity provider.
+func (s *samlProvider) Metadata() (string, []byte, error) {
+	data, err := serviceProviderMetadata(s.EntityID, s.RedirectURL, s.NameIDFormat)
+	if err != nil {
+		return "", nil, err
+	}
+	return MetadataContentType, data, nil
+}
+
+// Login redirects the user agent to the identity provider with an authentication request, the ID of the
+// request is kept in the shared cache and a cookie of the user agent until the response is consumed.
+func (s *samlProvider) Login(w http.ResponseWriter, req *http.Request) error {
+	store, err := identityprovider.SharedCache()
+	if err != nil {
+		return fmt.Errorf("saml: %v", err)
+	}
+
+	id, err := newRequestID()
+	if err != nil {
+		return fmt.Errorf("saml: %v", err)
+	}
+	location, err := s.authnRequestURL(id)
+	if err != nil {
+		return fmt.Errorf("saml: %v", err)
+	}
+	if err := store.Set(requestCacheKeyPrefix+id, requestPending, authnRequestLifetime); err != nil {
+		return fmt.Errorf("saml: %v", err)
+	}
+
+	cookie := &http.Cookie{
+		Name:     requestCookie,
+		Value:    id,
+		MaxAge:   int(authnRequestLifetime.Seconds()),
+		HttpOnly: true,
+	}
+	if redirectURL, err := url.Parse(s.RedirectURL); err == nil {
+		cookie.Path = redirectURL.Path
+		// the response is posted cross-site by the identity provider, which requires SameSite=None
+		if redirectURL.Scheme == "https" {
+			cookie.Secure = true
+			cookie.SameSite = http.SameSiteNoneMode
+		}
+	}
+	http.SetCookie(w, cookie)
+	http.Redirect(w, req, location, http.StatusFound)
+	return nil
+}
+
+// authnRequestURL returns the URL of the authentication request with the HTTP-Redirect binding,
+// see also http://docs.oasis-open.org/security/saml/v2.0/saml-bindings-2.0-os.pdf section 3.4.
+func (s *samlProvider) authnRequestURL(id string) (string, error) {
+	doc := etree.NewDocument()
+	request := doc.CreateElement("samlp:AuthnRequest")
+	request.CreateAttr("xmlns:samlp", namespaceProtocol)
+	request.CreateAttr("xmlns:saml", namespaceAssertion)
+	request.CreateAttr("ID", id)
+	request.CreateAttr("Version", "2.0")
+	request.CreateAttr("IssueInstant", s.now().UTC().Format(time.RFC3339))
+	request.CreateAttr("Destination", s.idp.ssoURL)
+	request.CreateAttr("ProtocolBinding", bindingHTTPPost)
+	request.CreateAttr("AssertionConsumerServiceURL", s.RedirectURL)
+	request.CreateElement("saml:Issuer").SetText(s.EntityID)
+	if s.NameIDFormat != "" {
+		policy := request.CreateElement("samlp:NameIDPolicy")
+		policy.CreateAttr("Format", s.NameIDFormat)
+		policy.CreateAttr("AllowCreate", "true")
+	}
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		return "", err
+	}
+
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	location, err := url.Parse(s.idp.ssoURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid SingleSignOnService location: %v", err)
+	}
+	query := location.Query()
+	query.Set("SAMLRequest", base64.StdEncoding.EncodeToString(compressed.Bytes()))
+	location.RawQuery = query.Encode()
+	return location.String(), nil
+}
+
+// newRequestID returns a random ID, which starts with a letter as required by the xs:ID type.
+func newRequestID() (string, error) {
+	id := make([]byte, 20)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "id-" + hex.EncodeToString(id), nil
+}
+
+func (s *samlProvider) IdentityExchangeCallback(req *http.Request) (identityprovider.Identity, error) {
+	store, err := identityprovider.SharedCache()
+	if err != nil {
+		return nil, fmt.Errorf("saml: %v", err)
+	}
+	cookie, err := req.Cookie(requestCookie)
+	if err != nil || cookie.Value == "" {
+		return nil, errors.New("saml: authentication request not found, the login must be initiated by KubeSphere")
+	}
+
+	// HTTP-POST binding, see also http://docs.oasis-open.org/security/saml/v2.0/saml-bindings-2.0-os.pdf
+	encoded := req.PostFormValue("SAMLResponse")
+	if encoded == "" {
+		return nil, errors.New("saml: SAMLResponse is required")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("saml: invalid SAMLResponse: %v", err)
+	}
+	assertion, err := s.verifyResponse(store, data, cookie.Value)
+	if err != nil {
+		return nil, fmt.Errorf("saml: %v", err)
+	}
+
+	// the authentication request is consumed once the response is accepted
+	state, err := store.GetSet(requestCacheKeyPrefix+cookie.Value, requestConsumed, authnRequestLifetime)
+	if err != nil && err != cache.ErrNoSuchKey {
+		return nil, fmt.Errorf("saml: %v", err)
+	}
+	if state != requestPending {
+		return nil, errors.New("saml: authentication request has expired or been used")
+	}
+	return s.identityOf(assertion), nil
+}
+
+// verifyResponse verifies the SAML response to the authentication request and returns the assertion in it,
+// either the response or the assertion must be signed by the identity provider.
+func (s *samlProvider) verifyResponse(store cache.Interface, data []byte, requestID string) (*etree.Element, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		return nil, fmt.Errorf("invalid SAML response: %v", err)
+	}
+	response := doc.Root()
+	if response == nil || response.Tag != "Response" || response.NamespaceURI() != namespaceProtocol {
+		return nil, errors.New("invalid SAML response: Response not found")
+	}
+	// comments are not covered by the signature, they can be used to truncate the signed text
+	if containsComment(response) {
+		return nil, errors.New("invalid SAML response: comments are not allowed")
+	}
+	if inResponseTo := response.SelectAttrValue("InResponseTo", ""); inResponseTo != requestID {
+		return nil, errors.New("the response is not in response to the authentication request")
+	}
+
+	if destination := response.SelectAttrValue("Destination", ""); destination != "" && destination != s.RedirectURL {
+		return nil, fmt.Errorf("unexpected destination %s", destination)
+	}
+	if issuer := textOf(childElement(response, namespaceAssertion, "Issuer")); issuer != "" && issuer != s.idp.entityID {
+		return nil, fmt.Errorf("unexpected issuer %s", issuer)
+	}
+	status := attrOf(childElement(childElement(response, namespaceProtocol, "Status"), namespaceProtocol, "StatusCode"), "Value")
+	if status != statusSuccess {
+		return nil, fmt.Errorf("authentication failed with status %s", status)
+	}
+
+	responseSigned := true
+	if err := verifySignature(response, s.idp.certificates); err == errSignatureNotFound {
+		responseSigned = false
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid response signature: %v", err)
+	}
+
+	if len(childElements(response, namespaceAssertion, "EncryptedAssertion")) > 0 {
+		return nil, errors.New("encrypted assertion is not supported")
+	}
+	assertions := childElements(response, namespaceAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return nil, errors.New("exactly one assertion is expected")
+	}
+	assertion := assertions[0]
+
+	if err := verifySignature(assertion, s.idp.certificates); err == errSignatureNotFound {
+		if !responseSigned {
+			return nil, errors.New("neither the response nor the assertion is signed")
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("invalid assertion signature: %v", err)
+	}
+
+	if err := s.validateAssertion(store, assertion, requestID); err != nil {
+		return nil, err
+	}
+	return assertion, nil
+}
+
+// validateAssertion validates the issuer, the conditions and the subject confirmations of the assertion,
+// see also http://docs.oasis-open.org/security/saml/v2.0/saml-profiles-2.0-os.pdf section 4.1.4.3.
+// The subject confirmation is signed with the assertion, it binds the assertion to the authentication request.
+func (s *samlProvider) validateAssertion(store cache.Interface, assertion *etree.Element, requestID string) error {
+	now := s.now()
+
+	if issuer := textOf(childElement(assertion, namespaceAssertion, "Issuer")); issuer != s.idp.entityID {
+		return fmt.Errorf("unexpected assertion issuer %s", issuer)
+	}
+
+	expiresAt := now.Add(defaultAssertionLifetime)
+	if conditions := childElement(assertion, namespaceAssertion, "Conditions"); conditions != nil {
+		notBefore, notOnOrAfter, err := validityOf(conditions)
+		if err != nil {
+			return err
+		}
+		if !notBefore.IsZero() && now.Add(allowedClockSkew).Before(notBefore) {
+			return errors.New("assertion is not yet valid")
+		}
+		if !notOnOrAfter.IsZero() {
+			if !now.Add(-allowedClockSkew).Before(notOnOrAfter) {
+				return errors.New("assertion has expired")
+			}
+			expiresAt = notOnOrAfter
+		}
+		for _, restriction := range childElements(conditions, namespaceAssertion, "AudienceRestriction") {
+			matched := false
+			for _, audience := range childElements(restriction, namespaceAssertion, "Audience") {
+				if textOf(audience) == s.EntityID {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return errors.New("the service provider is not in the audience of the assertion")
+			}
+		}
+	}
+
+	subject := childElement(assertion, namespaceAssertion, "Subject")
+	if textOf(childElement(subject, namespaceAssertion, "NameID")) == "" {
+		return errors.New("NameID not found in the assertion")
+	}
+	confirmed := false
+	for _, confirmation := range childElements(subject, namespaceAssertion, "SubjectConfirmation") {
+		if confirmation.SelectAttrValue("Method", "") != subjectConfirmationBearer {
+			continue
+		}
+		data := childElement(confirmation, namespaceAssertion, "SubjectConfirmationData")
+		if data == nil {
+			continue
+		}
+		if recipient := data.SelectAttrValue("Recipient", ""); recipient != "" && recipient != s.RedirectURL {
+			continue
+		}
+		if data.SelectAttrValue("InResponseTo", "") != requestID {
+			continue
+		}
+		_, notOnOrAfter, err := validityOf(data)
+		if err != nil {
+			return err
+		}
+		if notOnOrAfter.IsZero() || !now.Add(-allowedClockSkew).Before(notOnOrAfter) {
+			continue
+		}
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return errors.New("no valid bearer subject confirmation found in the assertion")
+	}
+
+	// bearer assertions must not be used more than once, the IDs are kept in the shared cache until they expire
+	key := assertionCacheKeyPrefix + s.idp.entityID + ":" + assertion.SelectAttrValue("ID", "")
+	observed, err := store.SetNX(key, now.UTC().Format(time.RFC3339), expiresAt.Add(allowedClockSkew).Sub(now))
+	if err != nil {
+		return err
+	}
+	if !observed {
+		return errors.New("assertion has been used")
+	}
+	return nil
+}
+
+func (s *samlProvider) identityOf(assertion *etree.Element) *samlIdentity {
+	subject := childElement(assertion, namespaceAssertion, "Subject")
+	identity := &samlIdentity{NameID: textOf(childElement(subject, namespaceAssertion, "NameID"))}
+
+	attributes := make(map[string][]string)
+	for _, statement := range childElements(assertion, namespaceAssertion, "AttributeStatement") {
+		for _, attribute := range childElements(statement, namespaceAssertion, "Attribute") {
+			var values []string
+			for _, value := range childElements(attribute, namespaceAssertion, "AttributeValue") {
+				if text := textOf(value); text != "" {
+					values = append(values, text)
+				}
+			}
+			// attributes can be referred by either the name or the friendly name
+			for _, name := range []string{attribute.SelectAttrValue("Name", ""), attribute.SelectAttrValue("FriendlyName", "")} {
+				if name != "" {
+					attributes[name] = append(attributes[name], values...)
+				}
+			}
+		}
+	}
+
+	if values := attributes[s.UsernameAttribute]; s.UsernameAttribute != "" && len(values) > 0 {
+		identity.Username = values[0]
+	}
+	if values := attributes[s.EmailAttribute]; s.EmailAttribute != "" && len(values) > 0 {
+		identity.Email = values[0]
+	}
+	if s.GroupsAttribute != "" {
+		identity.Groups = attributes[s.GroupsAttribute]
+	}
+	return identity
+}
+
+func validityOf(el *etree.Element) (notBefore time.Time, notOnOrAfter time.Time, err error) {
+	if value := el.SelectAttrValue("NotBefore", ""); value != "" {
+		if notBefore, err = time.Parse(time.RFC3339, value); err != nil {
+			return notBefore, notOnOrAfter, fmt.Errorf("invalid NotBefore: %v", err)
+		}
+	}
+	if value := el.SelectAttrValue("NotOnOrAfter", ""); value != "" {
+		if notOnOrAfter, err = time.Parse(time.RFC3339, value); err != nil {
+			return notBefore, notOnOrAfter, fmt.Errorf("invalid NotOnOrAfter: %v", err)
+		}
+	}
+	return notBefore, notOnOrAfter, nil
+}
@@ -0,0 +1,376 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+const (
+	testIdPEntityID = "https://idp.example.com/metadata"
+	testIdPSSOURL   = "https://idp.example.com/sso"
+	testRedirectURL = "https://ks-console.example.com/oauth/callback/saml"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		path     string
+		expected string
+	}{
+		{
+			name:     "attributes are sorted and empty elements are expanded",
+			input:    `<a:root xmlns:b="urn:b" xmlns:a="urn:a" z="1" b:y="2" a="3"><a:child/>text &amp; more &gt;</a:root>`,
+			path:     "/root",
+			expected: `<a:root xmlns:a="urn:a" xmlns:b="urn:b" a="3" z="1" b:y="2"><a:child></a:child>text &amp; more &gt;</a:root>`,
+		},
+		{
+			name:     "unused namespaces are omitted",
+			input:    `<root xmlns="urn:default" xmlns:unused="urn:unused"><child attr="&quot;"/><!-- comment --></root>`,
+			path:     "/root",
+			expected: `<root xmlns="urn:default"><child attr="&quot;"></child></root>`,
+		},
+		{
+			name:     "namespaces of the ancestors are declared",
+			input:    `<p:root xmlns:p="urn:p"><p:child><p:grandchild/></p:child></p:root>`,
+			path:     "/root/child",
+			expected: `<p:child xmlns:p="urn:p"><p:grandchild></p:grandchild></p:child>`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := etree.NewDocument()
+			assert.NoError(t, doc.ReadFromString(test.input))
+			el := doc.FindElement(test.path)
+			assert.NotNil(t, el)
+			assert.Equal(t, test.expected, string(canonicalize(el, nil, nil)))
+		})
+	}
+}
+
+type testIdP struct {
+	key         *rsa.PrivateKey
+	certificate []byte
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testIdP{key: key, certificate: certificate}
+}
+
+func (i *testIdP) metadata() string {
+	return fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="%s">
+  <md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="%s"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, testIdPEntityID, base64.StdEncoding.EncodeToString(i.certificate), testIdPSSOURL)
+}
+
+// response returns a SAML response to the authentication request of which the assertion is signed.
+func (i *testIdP) response(t *testing.T, requestID, assertionID, audience string, now time.Time) *etree.Document {
+	doc := etree.NewDocument()
+	err := doc.ReadFromString(fmt.Sprintf(`<samlp:Response xmlns:samlp="urn:oasis:names:tc:SAML:2.0:protocol" xmlns:saml="urn:oasis:names:tc:SAML:2.0:assertion" ID="response" Version="2.0" IssueInstant="%[1]s" Destination="%[2]s" InResponseTo="%[7]s">
+  <saml:Issuer>%[3]s</saml:Issuer>
+  <samlp:Status><samlp:StatusCode Value="urn:oasis:names:tc:SAML:2.0:status:Success"/></samlp:Status>
+  <saml:Assertion ID="%[4]s" Version="2.0" IssueInstant="%[1]s">
+    <saml:Issuer>%[3]s</saml:Issuer>
+    <saml:Subject>
+      <saml:NameID Format="urn:oasis:names:tc:SAML:2.0:nameid-format:persistent">u-1001</saml:NameID>
+      <saml:SubjectConfirmation Method="urn:oasis:names:tc:SAML:2.0:cm:bearer">
+        <saml:SubjectConfirmationData NotOnOrAfter="%[5]s" Recipient="%[2]s" InResponseTo="%[7]s"/>
+      </saml:SubjectConfirmation>
+    </saml:Subject>
+    <saml:Conditions NotBefore="%[1]s" NotOnOrAfter="%[5]s">
+      <saml:AudienceRestriction><saml:Audience>%[6]s</saml:Audience></saml:AudienceRestriction>
+    </saml:Conditions>
+    <saml:AttributeStatement>
+      <saml:Attribute Name="urn:oid:0.9.2342.19200300.100.1.1" FriendlyName="uid"><saml:AttributeValue>alice</saml:AttributeValue></saml:Attribute>
+      <saml:Attribute Name="email"><saml:AttributeValue>alice@example.com</saml:AttributeValue></saml:Attribute>
+      <saml:Attribute Name="groups"><saml:AttributeValue>developers</saml:AttributeValue><saml:AttributeValue>admins</saml:AttributeValue></saml:Attribute>
+    </saml:AttributeStatement>
+  </saml:Assertion>
+</samlp:Response>`, now.UTC().Format(time.RFC3339), testRedirectURL, testIdPEntityID, assertionID,
+		now.Add(5*time.Minute).UTC().Format(time.RFC3339), audience, requestID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.sign(t, doc.FindElement("/Response/Assertion"))
+	return doc
+}
+
+// sign adds an enveloped signature to the element.
+func (i *testIdP) sign(t *testing.T, el *etree.Element) {
+	digest := sha256.Sum256(canonicalize(el, nil, nil))
+
+	signature := etree.NewElement("ds:Signature")
+	signature.CreateAttr("xmlns:ds", namespaceDSig)
+	signedInfo := signature.CreateElement("ds:SignedInfo")
+	signedInfo.CreateElement("ds:CanonicalizationMethod").CreateAttr("Algorithm", algorithmExclusiveC14N)
+	signedInfo.CreateElement("ds:SignatureMethod").CreateAttr("Algorithm", "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256")
+	reference := signedInfo.CreateElement("ds:Reference")
+	reference.CreateAttr("URI", "#"+el.SelectAttrValue("ID", ""))
+	transforms := reference.CreateElement("ds:Transforms")
+	transforms.CreateElement("ds:Transform").CreateAttr("Algorithm", algorithmEnvelopedSignature)
+	transforms.CreateElement("ds:Transform").CreateAttr("Algorithm", algorithmExclusiveC14N)
+	reference.CreateElement("ds:DigestMethod").CreateAttr("Algorithm", "http://www.w3.org/2001/04/xmlenc#sha256")
+	reference.CreateElement("ds:DigestValue").SetText(base64.StdEncoding.EncodeToString(digest[:]))
+	// the signature is inserted after the issuer as required by the SAML schema
+	el.InsertChildAt(childElement(el, namespaceAssertion, "Issuer").Index()+1, signature)
+
+	hashed := sha256.Sum256(canonicalize(signedInfo, nil, nil))
+	value, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature.CreateElement("ds:SignatureValue").SetText(base64.StdEncoding.EncodeToString(value))
+}
+
+// login initiates the login and returns the ID of the authentication request and the cookie bound to it.
+func login(t *testing.T, provider identityprovider.OAuthProvider) (string, *http.Cookie) {
+	recorder := httptest.NewRecorder()
+	if err := provider.(identityprovider.LoginProvider).Login(recorder, httptest.NewRequest(http.MethodGet, "/oauth/login/saml", nil)); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusFound, recorder.Code)
+
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, testIdPSSOURL, fmt.Sprintf("%s://%s%s", location.Scheme, location.Host, location.Path))
+	compressed, err := base64.StdEncoding.DecodeString(location.Query().Get("SAMLRequest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(flate.NewReader(bytes.NewReader(compressed)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(data); err != nil {
+		t.Fatal(err)
+	}
+	request := doc.FindElement("/AuthnRequest")
+	assert.NotNil(t, request)
+	assert.Equal(t, testRedirectURL, request.SelectAttrValue("AssertionConsumerServiceURL", ""))
+	assert.Equal(t, "kubesphere", textOf(childElement(request, namespaceAssertion, "Issuer")))
+
+	cookies := recorder.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, request.SelectAttrValue("ID", ""), cookies[0].Value)
+	return cookies[0].Value, cookies[0]
+}
+
+func newCallbackRequest(t *testing.T, doc *etree.Document, cookie *http.Cookie) *http.Request {
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := url.Values{"SAMLResponse": {base64.StdEncoding.EncodeToString(data)}}
+	req := httptest.NewRequest(http.MethodPost, "/oauth/callback/saml", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestSAMLProvider(t *testing.T) {
+	sharedCache, err := cache.NewInMemoryCache(nil, nil)
+	assert.NoError(t, err)
+	identityprovider.SetSharedCache(sharedCache)
+	defer identityprovider.SetSharedCache(nil)
+
+	idp := newTestIdP(t)
+	factory := &samlProviderFactory{}
+	provider, err := factory.Create(oauth.DynamicOptions{
+		"redirectURL":              testRedirectURL,
+		"entityID":                 "kubesphere",
+		"identityProviderMetadata": idp.metadata(),
+		"usernameAttribute":        "uid",
+		"emailAttribute":           "email",
+		"groupsAttribute":          "groups",
+	})
+	assert.NoError(t, err)
+	now := time.Now()
+	provider.(*samlProvider).now = func() time.Time { return now }
+
+	_, metadata, err := provider.(identityprovider.MetadataProvider).Metadata()
+	assert.NoError(t, err)
+	assert.Contains(t, string(metadata), `entityID="kubesphere"`)
+	assert.Contains(t, string(metadata), fmt.Sprintf(`Location="%s"`, testRedirectURL))
+
+	requestID, cookie := login(t, provider)
+	identity, err := provider.IdentityExchangeCallback(newCallbackRequest(t, idp.response(t, requestID, "assertion-1", "kubesphere", now), cookie))
+	assert.NoError(t, err)
+	assert.Equal(t, "u-1001", identity.GetUserID())
+	assert.Equal(t, "alice", identity.GetUsername())
+	assert.Equal(t, "alice@example.com", identity.GetEmail())
+	assert.Equal(t, []string{"developers", "admins"}, identity.(identityprovider.GroupsIdentity).GetGroups())
+
+	tests := []struct {
+		name string
+		// doc returns the response to the authentication request
+		doc    func(requestID string) *etree.Document
+		cookie func(cookie *http.Cookie) *http.Cookie
+		expect string
+	}{
+		{
+			name: "replayed assertion",
+			doc: func(requestID string) *etree.Document {
+				return idp.response(t, requestID, "assertion-1", "kubesphere", now)
+			},
+			expect: "assertion has been used",
+		},
+		{
+			name: "used authentication request",
+			doc: func(string) *etree.Document {
+				return idp.response(t, requestID, "assertion-7", "kubesphere", now)
+			},
+			cookie: func(*http.Cookie) *http.Cookie {
+				return cookie
+			},
+			expect: "authentication request has expired or been used",
+		},
+		{
+			name: "response to another authentication request",
+			doc: func(string) *etree.Document {
+				return idp.response(t, "id-others", "assertion-8", "kubesphere", now)
+			},
+			expect: "not in response to the authentication request",
+		},
+		{
+			name: "login initiated by another user agent",
+			doc: func(requestID string) *etree.Document {
+				return idp.response(t, requestID, "assertion-9", "kubesphere", now)
+			},
+			cookie: func(*http.Cookie) *http.Cookie {
+				return nil
+			},
+			expect: "authentication request not found",
+		},
+		{
+			name: "comment in the signed NameID",
+			doc: func(requestID string) *etree.Document {
+				doc := idp.response(t, requestID, "assertion-10", "kubesphere", now)
+				// u-1<!---->001 has the same canonical form as u-1001, since comments are removed
+				nameID := doc.FindElement("/Response/Assertion/Subject/NameID")
+				nameID.SetText("u-1")
+				nameID.CreateComment("")
+				nameID.CreateCharData("001")
+				return doc
+			},
+			expect: "comments are not allowed",
+		},
+		{
+			name: "tampered assertion",
+			doc: func(requestID string) *etree.Document {
+				doc := idp.response(t, requestID, "assertion-2", "kubesphere", now)
+				doc.FindElement("/Response/Assertion/Subject/NameID").SetText("admin")
+				return doc
+			},
+			expect: "digest mismatch",
+		},
+		{
+			name: "unsigned assertion",
+			doc: func(requestID string) *etree.Document {
+				doc := idp.response(t, requestID, "assertion-3", "kubesphere", now)
+				assertion := doc.FindElement("/Response/Assertion")
+				assertion.RemoveChild(assertion.SelectElement("ds:Signature"))
+				return doc
+			},
+			expect: "neither the response nor the assertion is signed",
+		},
+		{
+			name: "wrong audience",
+			doc: func(requestID string) *etree.Document {
+				return idp.response(t, requestID, "assertion-4", "others", now)
+			},
+			expect: "not in the audience",
+		},
+		{
+			name: "expired assertion",
+			doc: func(requestID string) *etree.Document {
+				return idp.response(t, requestID, "assertion-5", "kubesphere", now.Add(-time.Hour))
+			},
+			expect: "assertion has expired",
+		},
+		{
+			name: "untrusted identity provider",
+			doc: func(requestID string) *etree.Document {
+				return newTestIdP(t).response(t, requestID, "assertion-6", "kubesphere", now)
+			},
+			expect: "signature verification failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requestID, cookie := login(t, provider)
+			if test.cookie != nil {
+				cookie = test.cookie(cookie)
+			}
+			_, err := provider.IdentityExchangeCallback(newCallbackRequest(t, test.doc(requestID), cookie))
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), test.expect)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package saml
+
+import (
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/beevik/etree"
+)
+
+const (
+	namespaceDSig = "http://www.w3.org/2000/09/xmldsig#"
+
+	algorithmExclusiveC14N      = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algorithmEnvelopedSignature = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+)
+
+var (
+	errSignatureNotFound = errors.New("signature not found")
+
+	digestAlgorithms = map[string]crypto.Hash{
+		"http://www.w3.org/2000/09/xmldsig#sha1":  crypto.SHA1,
+		"http://www.w3.org/2001/04/xmlenc#sha256": crypto.SHA256,
+		"http://www.w3.org/2001/04/xmlenc#sha512": crypto.SHA512,
+	}
+
+	signatureAlgorithms = map[string]crypto.Hash{
+		"http://www.w3.org/2000/09/xmldsig#rsa-sha1":        crypto.SHA1,
+		"http://www.w3.org/2001/04/xmldsig-more#rsa-sha256": crypto.SHA256,
+		"http://www.w3.org/2001/04/xmldsig-more#rsa-sha512": crypto.SHA512,
+	}
+)
+
+// verifySignature verifies the enveloped XML signature of the element against the certificates,
+// see also https://www.w3.org/TR/xmldsig-core1/. Only the exclusive canonicalization and the
+// RSA signatures are supported, which are used by the SAML 2.0 identity providers in practice.
+// It returns errSignatureNotFound if the element is not signed.
+func verifySignature(el *etree.Element, certificates []*x509.Certificate) error {
+	signature := childElement(el, namespaceDSig, "Signature")
+	if signature == nil {
+		return errSignatureNotFound
+	}
+
+	signedInfo := childElement(signature, namespaceDSig, "SignedInfo")
+	if signedInfo == nil {
+		return errors.New("SignedInfo not found")
+	}
+
+	canonicalizationMethod := childElement(signedInfo, namespaceDSig, "CanonicalizationMethod")
+	if canonicalizationMethod == nil || canonicalizationMethod.SelectAttrValue("Algorithm", "") != algorithmExclusiveC14N {
+		return errors.New("unsupported canonicalization method")
+	}
+
+	hash, ok := signatureAlgorithms[attrOf(childElement(signedInfo, namespaceDSig, "SignatureMethod"), "Algorithm")]
+	if !ok {
+		return errors.New("unsupported signature method")
+	}
+
+	references := childElements(signedInfo, namespaceDSig, "Reference")
+	if len(references) != 1 {
+		return errors.New("exactly one reference is expected")
+	}
+	reference := references[0]
+
+	// the reference must point to the signed element itself, which prevents the signature wrapping attacks
+	id := el.SelectAttrValue("ID", "")
+	if id == "" || reference.SelectAttrValue("URI", "") != "#"+id {
+		return errors.New("the reference does not refer to the signed element")
+	}
+
+	var excluded *etree.Element
+	var inclusivePrefixes []string
+	canonicalized := false
+	if transforms := childElement(reference, namespaceDSig, "Transforms"); transforms != nil {
+		for _, transform := range childElements(transforms, namespaceDSig, "Transform") {
+			switch transform.SelectAttrValue("Algorithm", "") {
+			case algorithmEnvelopedSignature:
+				excluded = signature
+			case algorithmExclusiveC14N:
+				canonicalized = true
+				inclusivePrefixes = inclusiveNamespacePrefixes(transform)
+			default:
+				return fmt.Errorf("unsupported transform %s", transform.SelectAttrValue("Algorithm", ""))
+			}
+		}
+	}
+	if !canonicalized {
+		return errors.New("the exclusive canonicalization transform is required")
+	}
+
+	digestHash, ok := digestAlgorithms[attrOf(childElement(reference, namespaceDSig, "DigestMethod"), "Algorithm")]
+	if !ok {
+		return errors.New("unsupported digest method")
+	}
+	expectedDigest, err := decodeBase64(textOf(childElement(reference, namespaceDSig, "DigestValue")))
+	if err != nil {
+		return fmt.Errorf("invalid digest value: %v", err)
+	}
+	digest := digestHash.New()
+	digest.Write(canonicalize(el, inclusivePrefixes, excluded))
+	if subtle.ConstantTimeCompare(digest.Sum(nil), expectedDigest) != 1 {
+		return errors.New("digest mismatch")
+	}
+
+	signatureValue, err := decodeBase64(textOf(childElement(signature, namespaceDSig, "SignatureValue")))
+	if err != nil {
+		return fmt.Errorf("invalid signature value: %v", err)
+	}
+	signedInfoHash := hash.New()
+	signedInfoHash.Write(canonicalize(signedInfo, inclusiveNamespacePrefixes(canonicalizationMethod), nil))
+	hashed := signedInfoHash.Sum(nil)
+
+	for _, certificate := range certificates {
+		publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+		if rsa.VerifyPKCS1v15(publicKey, hash, hashed, signatureValue) == nil {
+			return nil
+		}
+	}
+	return errors.New("signature verification failed")
+}
+
+func inclusiveNamespacePrefixes(el *etree.Element) []string {
+	for _, child := range el.ChildElements() {
+		if child.Tag == "InclusiveNamespaces" && child.NamespaceURI() == algorithmExclusiveC14N {
+			return strings.Fields(child.SelectAttrValue("PrefixList", ""))
+		}
+	}
+	return nil
+}
+
+// childElement returns the first child element with the namespace and local name.
+func childElement(el *etree.Element, namespace, tag string) *etree.Element {
+	elements := childElements(el, namespace, tag)
+	if len(elements) == 0 {
+		return nil
+	}
+	return elements[0]
+}
+
+// childElements returns the child elements with the namespace and local name.
+func childElements(el *etree.Element, namespace, tag string) []*etree.Element {
+	if el == nil {
+		return nil
+	}
+	var elements []*etree.Element
+	for _, child := range el.ChildElements() {
+		if child.Tag == tag && child.NamespaceURI() == namespace {
+			elements = append(elements, child)
+		}
+	}
+	return elements
+}
+
+func attrOf(el *etree.Element, key string) string {
+	if el == nil {
+		return ""
+	}
+	return el.SelectAttrValue(key, "")
+}
+
+// textOf returns all the character data of the element. Unlike etree.Element.Text, the character data
+// after a comment or a processing instruction is not dropped, the signed text can't be truncated by them.
+func textOf(el *etree.Element) string {
+	if el == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, child := range el.Child {
+		if data, ok := child.(*etree.CharData); ok {
+			text.WriteString(data.Data)
+		}
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// containsComment returns true if there is a comment in the element or its descendants,
+// comments are removed by the canonicalization, so they are not protected by the signature.
+func containsComment(el *etree.Element) bool {
+	for _, child := range el.Child {
+		switch token := child.(type) {
+		case *etree.Comment:
+			return true
+		case *etree.Element:
+			if containsComment(token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeBase64 decodes the base64 encoded value, which may be wrapped by whitespaces.
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
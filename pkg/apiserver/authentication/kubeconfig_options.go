@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// KubeconfigModeCertificate authenticates the kubeconfig of the users with a client certificate
+	// signed through a CertificateSigningRequest, the certificate can't be revoked.
+	KubeconfigModeCertificate = "certificate"
+	// KubeconfigModeToken authenticates the kubeconfig of the users with the tokens issued by the
+	// KubeSphere OAuth server, the tokens are revoked with the other tokens of the user.
+	KubeconfigModeToken = "token"
+)
+
+// KubeconfigOptions configures the kubeconfig the users download from /users/{user}/kubeconfig.
+type KubeconfigOptions struct {
+	// Mode is either certificate or token, defaults to certificate.
+	Mode string `json:"mode" yaml:"mode"`
+	// Server is the URL of ks-apiserver the kubeconfig connects to in the token mode,
+	// the requests are authenticated by ks-apiserver and proxied to the clusters.
+	Server string `json:"server,omitempty" yaml:"server,omitempty"`
+	// TokenMaxAge is the lifetime of the token embedded in the kubeconfig in the token mode.
+	TokenMaxAge time.Duration `json:"tokenMaxAge,omitempty" yaml:"tokenMaxAge,omitempty"`
+	// ExecCommand is the exec credential plugin the kubeconfig obtains the tokens with in the token mode,
+	// e.g. a kubectl plugin using the device authorization grant, no token is embedded if it's set.
+	// The plugin finds the server in the KUBESPHERE_SERVER environment variable.
+	ExecCommand string `json:"execCommand,omitempty" yaml:"execCommand,omitempty"`
+	// ExecArgs are the arguments of the exec credential plugin.
+	ExecArgs []string `json:"execArgs,omitempty" yaml:"execArgs,omitempty"`
+}
+
+func NewKubeconfigOptions() *KubeconfigOptions {
+	return &KubeconfigOptions{
+		Mode:        KubeconfigModeCertificate,
+		TokenMaxAge: 8 * time.Hour,
+	}
+}
+
+func (o *KubeconfigOptions) Validate() []error {
+	switch o.Mode {
+	case KubeconfigModeCertificate:
+		return nil
+	case KubeconfigModeToken:
+	default:
+		return []error{fmt.Errorf("kubeconfigOptions.mode MUST be one of %s, %s", KubeconfigModeCertificate, KubeconfigModeToken)}
+	}
+	var errs []error
+	if o.Server == "" {
+		errs = append(errs, errors.New("kubeconfigOptions.server MUST not be empty in the token mode"))
+	}
+	if o.ExecCommand == "" && o.TokenMaxAge <= 0 {
+		errs = append(errs, errors.New("kubeconfigOptions.tokenMaxAge MUST be greater than 0 in the token mode"))
+	}
+	return errs
+}
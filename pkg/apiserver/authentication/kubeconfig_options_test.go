@@ -0,0 +1,38 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKubeconfigOptionsValidate(t *testing.T) {
+	assert.Empty(t, NewKubeconfigOptions().Validate())
+	assert.Len(t, (&KubeconfigOptions{Mode: "password"}).Validate(), 1)
+	assert.Len(t, (&KubeconfigOptions{Mode: KubeconfigModeToken}).Validate(), 2)
+	assert.Empty(t, (&KubeconfigOptions{Mode: KubeconfigModeToken, Server: "https://ks-apiserver", ExecCommand: "kubectl"}).Validate())
+
+	options := NewOptions()
+	options.JwtSecret = "test-secret"
+	options.KubeconfigOptions = &KubeconfigOptions{Mode: KubeconfigModeToken, Server: "https://ks-apiserver", TokenMaxAge: time.Hour}
+	assert.Empty(t, options.Validate())
+	options.OAuthOptions.AccessTokenMaxAge = 0
+	assert.Len(t, options.Validate(), 1)
+}
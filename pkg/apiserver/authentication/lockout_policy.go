@@ -0,0 +1,100 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"kubesphere.io/kubesphere/pkg/utils/iputil"
+)
+
+// LockoutPolicy defines how long an account is locked once its failed login attempts reach
+// AuthenticateRateLimiterMaxTries in AuthenticateRateLimiterDuration, and how the failed login
+// attempts from a source IP are throttled regardless of the username.
+type LockoutPolicy struct {
+	// LockDuration is how long an account is locked on the first lockout,
+	// zero means AuthenticateRateLimiterDuration.
+	LockDuration time.Duration `json:"lockDuration,omitempty" yaml:"lockDuration,omitempty"`
+	// BackoffFactor multiplies the lock duration on each consecutive lockout of an account,
+	// the consecutive lockouts are reset by a successful login or by an administrator.
+	// One, the default, means the lock duration never grows.
+	BackoffFactor int `json:"backoffFactor" yaml:"backoffFactor"`
+	// MaxLockDuration caps the lock duration if BackoffFactor is greater than one, zero means no cap.
+	// Note that anyone who knows a username can keep failing its logins to lock the account out,
+	// a growing lock duration slows down password guessing but locks the owner out for as long as the cap,
+	// so keep the cap short enough for the owner to wait out or for an administrator to respond.
+	MaxLockDuration time.Duration `json:"maxLockDuration" yaml:"maxLockDuration"`
+	// SourceIPMaxTries is the maximum failed login attempts from a source IP in SourceIPDuration,
+	// the further login requests from the source IP are rejected. Zero means the source IPs are not throttled.
+	SourceIPMaxTries int `json:"sourceIPMaxTries,omitempty" yaml:"sourceIPMaxTries,omitempty"`
+	// SourceIPDuration is the sliding window to count the failed login attempts of a source IP.
+	SourceIPDuration time.Duration `json:"sourceIPDuration,omitempty" yaml:"sourceIPDuration,omitempty"`
+	// TrustedProxies are the IPs or CIDRs of the reverse proxies in front of ks-apiserver, e.g. ks-console,
+	// the source IP is read from the X-Forwarded-For header set by them. The peer address of the connection
+	// is the source IP if it's not a trusted proxy, since the forwarding headers can be spoofed by the clients.
+	TrustedProxies []string `json:"trustedProxies,omitempty" yaml:"trustedProxies,omitempty"`
+}
+
+func NewLockoutPolicy() *LockoutPolicy {
+	return &LockoutPolicy{
+		BackoffFactor:   1,
+		MaxLockDuration: 24 * time.Hour,
+	}
+}
+
+func (p *LockoutPolicy) Validate() []error {
+	var errs []error
+	if p.LockDuration < 0 || p.MaxLockDuration < 0 {
+		errs = append(errs, errors.New("lockoutPolicy.lockDuration and lockoutPolicy.maxLockDuration MUST not be negative"))
+	}
+	if p.BackoffFactor < 1 {
+		errs = append(errs, errors.New("lockoutPolicy.backoffFactor MUST be at least 1"))
+	}
+	if p.SourceIPMaxTries < 0 {
+		errs = append(errs, errors.New("lockoutPolicy.sourceIPMaxTries MUST not be negative"))
+	}
+	if p.SourceIPMaxTries > 0 && p.SourceIPDuration <= 0 {
+		errs = append(errs, errors.New("lockoutPolicy.sourceIPDuration MUST be positive if source IPs are throttled"))
+	}
+	if _, err := iputil.ParseCIDRs(p.TrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("lockoutPolicy.trustedProxies is invalid: %v", err))
+	}
+	return errs
+}
+
+// LockDurationOf returns how long an account is locked on the given consecutive lockout,
+// which starts from 1. The base is used if LockDuration is not set.
+func (p *LockoutPolicy) LockDurationOf(lockouts int32, base time.Duration) time.Duration {
+	duration := base
+	if p.LockDuration > 0 {
+		duration = p.LockDuration
+	}
+	for i := int32(1); i < lockouts && p.BackoffFactor > 1; i++ {
+		if p.MaxLockDuration > 0 && duration >= p.MaxLockDuration ||
+			duration > math.MaxInt64/time.Duration(p.BackoffFactor) {
+			break
+		}
+		duration *= time.Duration(p.BackoffFactor)
+	}
+	if p.MaxLockDuration > 0 && duration > p.MaxLockDuration {
+		duration = p.MaxLockDuration
+	}
+	return duration
+}
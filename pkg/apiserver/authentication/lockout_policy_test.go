@@ -0,0 +1,50 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockDurationOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *LockoutPolicy
+		lockouts int32
+		expected time.Duration
+	}{
+		{name: "first lockout", policy: NewLockoutPolicy(), lockouts: 1, expected: 10 * time.Minute},
+		{name: "flat by default", policy: NewLockoutPolicy(), lockouts: 4, expected: 10 * time.Minute},
+		{name: "exponential backoff", policy: &LockoutPolicy{BackoffFactor: 2, MaxLockDuration: 24 * time.Hour}, lockouts: 4, expected: 80 * time.Minute},
+		{name: "capped", policy: &LockoutPolicy{BackoffFactor: 2, MaxLockDuration: 24 * time.Hour}, lockouts: 10, expected: 24 * time.Hour},
+		{name: "no overflow", policy: &LockoutPolicy{BackoffFactor: 2}, lockouts: 100, expected: 10 * time.Minute * (1 << 23)},
+		{name: "fixed duration", policy: &LockoutPolicy{LockDuration: time.Hour, BackoffFactor: 1}, lockouts: 3, expected: time.Hour},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.policy.LockDurationOf(test.lockouts, 10*time.Minute))
+		})
+	}
+}
+
+func TestLockoutPolicyValidate(t *testing.T) {
+	assert.Empty(t, NewLockoutPolicy().Validate())
+	assert.Len(t, (&LockoutPolicy{BackoffFactor: 0, SourceIPMaxTries: 10}).Validate(), 2)
+}
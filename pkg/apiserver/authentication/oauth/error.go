@@ -74,6 +74,32 @@ var (
 	// Error HTTP status code cannot be returned to the client
 	// via an HTTP redirect.)
 	ErrorServerError = Error{Type: "server_error"}
+
+	// ErrorMultiFactorAuthRequired
+	// The resource owner credentials are valid, but the user must pass the multi-factor
+	// authentication challenge before the tokens are issued. It's not defined in RFC 6749.
+	ErrorMultiFactorAuthRequired = Error{Type: "mfa_required"}
+
+	// The following error types are defined in https://datatracker.ietf.org/doc/html/rfc8628#section-3.5
+
+	// ErrorAuthorizationPending
+	// The authorization request is still pending as the end user hasn't
+	// yet completed the user-interaction steps.
+	ErrorAuthorizationPending = Error{Type: "authorization_pending"}
+
+	// ErrorSlowDown
+	// A variant of "authorization_pending", the authorization request is
+	// still pending and polling should continue, but the interval MUST
+	// be increased by 5 seconds for this and all subsequent requests.
+	ErrorSlowDown = Error{Type: "slow_down"}
+
+	// ErrorAccessDenied The authorization request was denied.
+	ErrorAccessDenied = Error{Type: "access_denied"}
+
+	// ErrorExpiredToken
+	// The "device_code" has expired, and the device authorization
+	// session has concluded.
+	ErrorExpiredToken = Error{Type: "expired_token"}
 )
 
 func NewInvalidRequest(error error) Error {
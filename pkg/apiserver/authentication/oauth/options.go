@@ -68,12 +68,30 @@ type Options struct {
 	// Raw RSA private key. Base64 encoded PEM file
 	SignKeyData string `json:"-,omitempty" yaml:"signKeyData,omitempty"`
 
+	// SignKeysSecret is the name of the Secret in the kubesphere-system namespace which stores the keys to sign
+	// the id token, the keys are shared by the ks-apiserver replicas. The Secret is created with the key above
+	// if not found.
+	SignKeysSecret string `json:"signKeysSecret,omitempty" yaml:"signKeysSecret,omitempty"`
+
+	// SignKeyRotationPeriod is how often the key to sign the id token is rotated, the replaced key keeps
+	// verifying the issued id tokens until they expire. 0 means the key is never rotated, it requires SignKeysSecret.
+	SignKeyRotationPeriod time.Duration `json:"signKeyRotationPeriod,omitempty" yaml:"signKeyRotationPeriod,omitempty"`
+
 	// Register identity providers.
 	IdentityProviders []IdentityProviderOptions `json:"identityProviders,omitempty" yaml:"identityProviders,omitempty"`
 
+	// IdentityProvidersSecret is the name of the Secret in the kubesphere-system namespace, which contains
+	// the identity providers under the identityProviders key. The identity providers in the Secret replace
+	// IdentityProviders and are reloaded without restarting once the Secret changes.
+	IdentityProvidersSecret string `json:"identityProvidersSecret,omitempty" yaml:"identityProvidersSecret,omitempty"`
+
 	// Register additional OAuth clients.
 	Clients []Client `json:"clients,omitempty" yaml:"clients,omitempty"`
 
+	// DeviceVerificationURI is the URL of the console page where the users enter the user code of the
+	// device authorization grant, the grant is disabled if it's empty.
+	DeviceVerificationURI string `json:"deviceVerificationURI,omitempty" yaml:"deviceVerificationURI,omitempty"`
+
 	// AccessTokenMaxAgeSeconds  control the lifetime of access tokens. The default lifetime is 24 hours.
 	// 0 means no expiration.
 	AccessTokenMaxAge time.Duration `json:"accessTokenMaxAge" yaml:"accessTokenMaxAge"`
@@ -186,6 +204,10 @@ type Token struct {
 
 	// ExpiresIn is the optional expiration second of the access token.
 	ExpiresIn int `json:"expires_in,omitempty"`
+
+	// RecoveryCodes are the single-use codes generated when the TOTP enrollment is confirmed on login,
+	// they are only shown once.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 type Client struct {
@@ -20,6 +20,7 @@ package authentication
 
 import (
 	"errors"
+	"net"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -30,7 +31,9 @@ import (
 	_ "kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/github"
 	_ "kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/ldap"
 	_ "kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/oidc"
+	_ "kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/saml"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/utils/iputil"
 )
 
 type Options struct {
@@ -60,6 +63,21 @@ type Options struct {
 	OAuthOptions *oauth.Options `json:"oauthOptions" yaml:"oauthOptions"`
 	// KubectlImage is the image address we use to create kubectl pod for users who have admin access to the cluster.
 	KubectlImage string `json:"kubectlImage" yaml:"kubectlImage"`
+	// RequireMultiFactorAuthForPlatformAdmins requires the KubeSphere-local accounts bound to the platform-admin
+	// GlobalRole to login with a TOTP passcode, the accounts not enrolled yet enroll on the next login.
+	RequireMultiFactorAuthForPlatformAdmins bool `json:"requireMultiFactorAuthForPlatformAdmins,omitempty" yaml:"requireMultiFactorAuthForPlatformAdmins,omitempty"`
+	// PasswordPolicy defines the requirements of the passwords of the KubeSphere-local accounts.
+	PasswordPolicy *PasswordPolicy `json:"passwordPolicy,omitempty" yaml:"passwordPolicy,omitempty"`
+	// LockoutPolicy defines the lock duration of the accounts and the throttling of the source IPs
+	// after too many failed login attempts.
+	LockoutPolicy *LockoutPolicy `json:"lockoutPolicy,omitempty" yaml:"lockoutPolicy,omitempty"`
+	// SCIMOptions configures the SCIM 2.0 provisioning endpoint.
+	SCIMOptions *SCIMOptions `json:"scimOptions,omitempty" yaml:"scimOptions,omitempty"`
+	// PersonalAccessTokenMaxAge is the maximum lifetime of the personal access tokens created by the users,
+	// the tokens without an expiration time expire after it.
+	PersonalAccessTokenMaxAge time.Duration `json:"personalAccessTokenMaxAge,omitempty" yaml:"personalAccessTokenMaxAge,omitempty"`
+	// KubeconfigOptions configures how the kubeconfig of the users authenticates.
+	KubeconfigOptions *KubeconfigOptions `json:"kubeconfigOptions,omitempty" yaml:"kubeconfigOptions,omitempty"`
 }
 
 func NewOptions() *Options {
@@ -73,6 +91,11 @@ func NewOptions() *Options {
 		MultipleLogin:                   false,
 		JwtSecret:                       "",
 		KubectlImage:                    "kubesphere/kubectl:v1.0.0",
+		PasswordPolicy:                  NewPasswordPolicy(),
+		LockoutPolicy:                   NewLockoutPolicy(),
+		SCIMOptions:                     NewSCIMOptions(),
+		PersonalAccessTokenMaxAge:       time.Hour * 24 * 365,
+		KubeconfigOptions:               NewKubeconfigOptions(),
 	}
 }
 
@@ -84,12 +107,60 @@ func (options *Options) Validate() []error {
 	if options.AuthenticateRateLimiterMaxTries > options.LoginHistoryMaximumEntries {
 		errs = append(errs, errors.New("authenticateRateLimiterMaxTries MUST not be greater than loginHistoryMaximumEntries"))
 	}
+	if options.PasswordPolicy != nil {
+		errs = append(errs, options.PasswordPolicy.Validate()...)
+	}
+	if options.LockoutPolicy != nil {
+		errs = append(errs, options.LockoutPolicy.Validate()...)
+	}
+	if options.SCIMOptions != nil {
+		errs = append(errs, options.SCIMOptions.Validate()...)
+	}
+	if options.KubeconfigOptions != nil {
+		errs = append(errs, options.KubeconfigOptions.Validate()...)
+		// the tokens are only revocable if they are cached
+		if options.KubeconfigTokenMode() && options.OAuthOptions.AccessTokenMaxAge == 0 {
+			errs = append(errs, errors.New("kubeconfigOptions.mode token requires accessTokenMaxAge greater than 0"))
+		}
+	}
+	if options.PersonalAccessTokenMaxAge <= 0 {
+		errs = append(errs, errors.New("personalAccessTokenMaxAge MUST be greater than 0"))
+	}
+	if options.OAuthOptions.SignKeyRotationPeriod < 0 {
+		errs = append(errs, errors.New("signKeyRotationPeriod MUST not be negative"))
+	} else if options.OAuthOptions.SignKeyRotationPeriod > 0 && options.OAuthOptions.SignKeysSecret == "" {
+		errs = append(errs, errors.New("signKeyRotationPeriod requires signKeysSecret"))
+	}
 	if err := identityprovider.SetupWithOptions(options.OAuthOptions.IdentityProviders); err != nil {
 		errs = append(errs, err)
 	}
 	return errs
 }
 
+// LockDuration returns how long an account is locked on the given consecutive lockout.
+func (options *Options) LockDuration(lockouts int32) time.Duration {
+	if options.LockoutPolicy == nil {
+		return options.AuthenticateRateLimiterDuration
+	}
+	return options.LockoutPolicy.LockDurationOf(lockouts, options.AuthenticateRateLimiterDuration)
+}
+
+// TrustedProxies returns the networks of the reverse proxies whose forwarding headers are honoured.
+func (options *Options) TrustedProxies() []*net.IPNet {
+	if options.LockoutPolicy == nil {
+		return nil
+	}
+	// validated on startup
+	proxies, _ := iputil.ParseCIDRs(options.LockoutPolicy.TrustedProxies)
+	return proxies
+}
+
+// KubeconfigTokenMode returns whether the kubeconfig of the users authenticates with the tokens
+// issued by the KubeSphere OAuth server instead of the client certificates.
+func (options *Options) KubeconfigTokenMode() bool {
+	return options.KubeconfigOptions != nil && options.KubeconfigOptions.Mode == KubeconfigModeToken
+}
+
 func (options *Options) AddFlags(fs *pflag.FlagSet, s *Options) {
 	fs.IntVar(&options.AuthenticateRateLimiterMaxTries, "authenticate-rate-limiter-max-retries", s.AuthenticateRateLimiterMaxTries, "")
 	fs.DurationVar(&options.AuthenticateRateLimiterDuration, "authenticate-rate-limiter-duration", s.AuthenticateRateLimiterDuration, "")
@@ -99,5 +170,7 @@ func (options *Options) AddFlags(fs *pflag.FlagSet, s *Options) {
 	fs.IntVar(&options.LoginHistoryMaximumEntries, "login-history-maximum-entries", s.LoginHistoryMaximumEntries, "login-history-maximum-entries defines how many entries of login history should be kept.")
 	fs.DurationVar(&options.OAuthOptions.AccessTokenMaxAge, "access-token-max-age", s.OAuthOptions.AccessTokenMaxAge, "access-token-max-age control the lifetime of access tokens, 0 means no expiration.")
 	fs.StringVar(&s.KubectlImage, "kubectl-image", s.KubectlImage, "Setup the image used by kubectl terminal pod")
+	fs.BoolVar(&options.RequireMultiFactorAuthForPlatformAdmins, "require-mfa-for-platform-admins", s.RequireMultiFactorAuthForPlatformAdmins, "Require the platform admins to login with a TOTP passcode.")
+	fs.DurationVar(&options.PersonalAccessTokenMaxAge, "personal-access-token-max-age", s.PersonalAccessTokenMaxAge, "The maximum lifetime of the personal access tokens.")
 	fs.DurationVar(&options.MaximumClockSkew, "maximum-clock-skew", s.MaximumClockSkew, "The maximum time difference between the system clocks of the ks-apiserver that issued a JWT and the ks-apiserver that verified the JWT.")
 }
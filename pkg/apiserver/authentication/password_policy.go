@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// passwordMaxLength is the maximum length of the password allowed by the User CRD.
+const passwordMaxLength = 64
+
+// PasswordPolicy defines the requirements of the passwords of the KubeSphere-local accounts,
+// it's enforced by the users admission webhook on top of the validation of the User CRD.
+type PasswordPolicy struct {
+	// MinLength is the minimum number of characters, the User CRD requires at least 8 characters.
+	MinLength int `json:"minLength" yaml:"minLength"`
+	// RequireUppercase requires at least one uppercase letter.
+	RequireUppercase bool `json:"requireUppercase" yaml:"requireUppercase"`
+	// RequireLowercase requires at least one lowercase letter.
+	RequireLowercase bool `json:"requireLowercase" yaml:"requireLowercase"`
+	// RequireDigit requires at least one digit.
+	RequireDigit bool `json:"requireDigit" yaml:"requireDigit"`
+	// RequireSpecialCharacter requires at least one punctuation or symbol, e.g. "@" or "#".
+	RequireSpecialCharacter bool `json:"requireSpecialCharacter" yaml:"requireSpecialCharacter"`
+	// HistorySize is the number of the latest passwords, including the current one, which can't be reused.
+	// Zero means the passwords can be reused.
+	HistorySize int `json:"historySize" yaml:"historySize"`
+	// MaxAge is how long a password can be used, the user has to reset the password on the next login
+	// once it expires. Zero means the passwords never expire.
+	MaxAge time.Duration `json:"maxAge" yaml:"maxAge"`
+}
+
+func NewPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:        8,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+	}
+}
+
+func (p *PasswordPolicy) Validate() []error {
+	var errs []error
+	if p.MinLength > passwordMaxLength {
+		errs = append(errs, fmt.Errorf("passwordPolicy.minLength MUST not be greater than %d", passwordMaxLength))
+	}
+	if p.HistorySize < 0 {
+		errs = append(errs, errors.New("passwordPolicy.historySize MUST not be negative"))
+	}
+	if p.MaxAge < 0 {
+		errs = append(errs, errors.New("passwordPolicy.maxAge MUST not be negative"))
+	}
+	return errs
+}
+
+// Check returns an error describing all the requirements the plain text password doesn't meet.
+func (p *PasswordPolicy) Check(password string) error {
+	var upper, lower, digit, special bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			special = true
+		}
+	}
+
+	var violations []string
+	if length := len([]rune(password)); length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("be at least %d characters long", p.MinLength))
+	}
+	if p.RequireUppercase && !upper {
+		violations = append(violations, "contain at least one uppercase letter")
+	}
+	if p.RequireLowercase && !lower {
+		violations = append(violations, "contain at least one lowercase letter")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "contain at least one digit")
+	}
+	if p.RequireSpecialCharacter && !special {
+		violations = append(violations, "contain at least one special character")
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("password must %s", strings.Join(violations, ", "))
+	}
+	return nil
+}
+
+// Expired checks whether the password changed at the given time has expired.
+func (p *PasswordPolicy) Expired(lastChangeTime time.Time, now time.Time) bool {
+	return p.MaxAge > 0 && lastChangeTime.Add(p.MaxAge).Before(now)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *PasswordPolicy
+		password string
+		expected string
+	}{
+		{name: "default policy", policy: NewPasswordPolicy(), password: "P@88w0rd"},
+		{name: "too short", policy: NewPasswordPolicy(), password: "P@88w0r", expected: "password must be at least 8 characters long"},
+		{name: "character classes", policy: NewPasswordPolicy(), password: "password",
+			expected: "password must contain at least one uppercase letter, contain at least one digit"},
+		{name: "special character", policy: &PasswordPolicy{RequireSpecialCharacter: true}, password: "Passw0rd",
+			expected: "password must contain at least one special character"},
+		{name: "length counts characters", policy: &PasswordPolicy{MinLength: 4}, password: "密码密码"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.policy.Check(test.password)
+			if test.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyExpired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, NewPasswordPolicy().Expired(now.Add(-365*24*time.Hour), now))
+	policy := &PasswordPolicy{MaxAge: 90 * 24 * time.Hour}
+	assert.False(t, policy.Expired(now.Add(-89*24*time.Hour), now))
+	assert.True(t, policy.Expired(now.Add(-91*24*time.Hour), now))
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	assert.Empty(t, NewPasswordPolicy().Validate())
+	assert.Len(t, (&PasswordPolicy{MinLength: 65, HistorySize: -1, MaxAge: -time.Hour}).Validate(), 3)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authentication
+
+import (
+	"errors"
+	"fmt"
+)
+
+// scimTokenMinLength makes the provisioning tokens hard to guess.
+const scimTokenMinLength = 32
+
+// SCIMOptions configures the SCIM 2.0 endpoint /scim/v2, which an identity provider uses
+// to provision the users and the groups.
+type SCIMOptions struct {
+	// Enable installs the SCIM endpoint.
+	Enable bool `json:"enable" yaml:"enable"`
+	// Tokens are the bearer tokens of the SCIM clients, they are only accepted by the SCIM endpoint.
+	Tokens []string `json:"-" yaml:"tokens,omitempty"`
+	// Workspace is the workspace the provisioned groups belong to.
+	Workspace string `json:"workspace" yaml:"workspace"`
+	// IdentityProvider is the name of the identity provider the provisioned users login with,
+	// the users are mapped to the identities whose UID equals the externalId at the first login.
+	// The users without a password are disabled if it's empty.
+	IdentityProvider string `json:"identityProvider,omitempty" yaml:"identityProvider,omitempty"`
+}
+
+func NewSCIMOptions() *SCIMOptions {
+	return &SCIMOptions{
+		Workspace: "system-workspace",
+	}
+}
+
+func (o *SCIMOptions) Validate() []error {
+	if !o.Enable {
+		return nil
+	}
+	var errs []error
+	if len(o.Tokens) == 0 {
+		errs = append(errs, errors.New("scimOptions.tokens MUST not be empty if SCIM is enabled"))
+	}
+	for _, token := range o.Tokens {
+		if len(token) < scimTokenMinLength {
+			errs = append(errs, fmt.Errorf("scimOptions.tokens MUST be at least %d characters long", scimTokenMinLength))
+			break
+		}
+	}
+	if o.Workspace == "" {
+		errs = append(errs, errors.New("scimOptions.workspace MUST not be empty if SCIM is enabled"))
+	}
+	return errs
+}
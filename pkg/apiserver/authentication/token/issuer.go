@@ -44,6 +44,9 @@ const (
 	IDToken           Type   = "id_token"
 	headerKeyID       string = "kid"
 	headerAlgorithm   string = "alg"
+
+	// PersonalAccessToken is created by the user for the API automation, its ID is the name of the AccessToken
+	PersonalAccessToken Type = "personal_access_token"
 )
 
 type Type string
@@ -63,6 +66,9 @@ type VerifiedResponse struct {
 type Keys struct {
 	SigningKey    *jose.JSONWebKey
 	SigningKeyPub *jose.JSONWebKey
+	// VerificationKeys are the public keys of the active and the retiring keys,
+	// the tokens signed with any of them are valid.
+	VerificationKeys []jose.JSONWebKey
 }
 
 // Issuer issues token to user, tokens are required to perform mutating requests to resources
@@ -75,6 +81,9 @@ type Issuer interface {
 
 	// Keys hold encryption and signing keys.
 	Keys() *Keys
+
+	// Keyring holds the signing keys, the keys are rotated by replacing them in the Keyring.
+	Keyring() *Keyring
 }
 
 type Claims struct {
@@ -90,6 +99,9 @@ type Claims struct {
 	// Used for issuing authorization code
 	// Scopes can be used to request that specific sets of information be made available as Claim Values.
 	Scopes []string `json:"scopes,omitempty"`
+	// SessionID identifies the login session the token is issued in,
+	// tokens of a session are revoked together when the session is terminated.
+	SessionID string `json:"sid,omitempty"`
 
 	// The following is well-known ID Token fields
 
@@ -113,7 +125,7 @@ type issuer struct {
 	// signing access_token and refresh_token
 	secret []byte
 	// signing id_token
-	signKey *Keys
+	keyring *Keyring
 	// Token verification maximum time difference
 	maximumClockSkew time.Duration
 }
@@ -152,6 +164,12 @@ func (s *issuer) IssueTo(request *IssueRequest) (string, error) {
 	if len(request.Scopes) > 0 {
 		claims.Scopes = request.Scopes
 	}
+	if request.SessionID != "" {
+		claims.SessionID = request.SessionID
+	}
+	if request.ID != "" {
+		claims.ID = request.ID
+	}
 	if request.ExpiresIn > 0 {
 		claims.ExpiresAt = jwt.NewNumericDate(issueAt.Add(request.ExpiresIn))
 	}
@@ -159,9 +177,10 @@ func (s *issuer) IssueTo(request *IssueRequest) (string, error) {
 	var token string
 	var err error
 	if request.TokenType == IDToken {
+		signKey := s.keyring.Active()
 		t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-		t.Header[headerKeyID] = s.signKey.SigningKey.KeyID
-		token, err = t.SignedString(s.signKey.SigningKey.Key)
+		t.Header[headerKeyID] = signKey.KeyID
+		token, err = t.SignedString(signKey.PrivateKey)
 	} else {
 		token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
 	}
@@ -211,7 +230,11 @@ func (s *issuer) Verify(token string) (*VerifiedResponse, error) {
 }
 
 func (s *issuer) Keys() *Keys {
-	return s.signKey
+	return s.keyring.Keys()
+}
+
+func (s *issuer) Keyring() *Keyring {
+	return s.keyring
 }
 
 func (s *issuer) keyFunc(token *jwt.Token) (i interface{}, err error) {
@@ -220,7 +243,8 @@ func (s *issuer) keyFunc(token *jwt.Token) (i interface{}, err error) {
 	case jwt.SigningMethodHS256.Alg():
 		return s.secret, nil
 	case jwt.SigningMethodRS256.Alg():
-		return s.signKey.SigningKey.Key, nil
+		keyID, _ := token.Header[headerKeyID].(string)
+		return s.keyring.VerificationKey(keyID)
 	default:
 		return nil, fmt.Errorf("unexpect signature algorithm %v", token.Header[headerAlgorithm])
 	}
@@ -243,14 +267,16 @@ func generatePrivateKeyData() ([]byte, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate private key: %v", err)
 	}
-	data := x509.MarshalPKCS1PrivateKey(privateKey)
-	pemData := pem.EncodeToMemory(
+	return encodePrivateKey(privateKey), nil
+}
+
+func encodePrivateKey(privateKey *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(
 		&pem.Block{
 			Type:  "RSA PRIVATE KEY",
-			Bytes: data,
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
 		},
 	)
-	return pemData, nil
 }
 
 func loadSignKey(options *authentication.Options) (*rsa.PrivateKey, string, error) {
@@ -293,29 +319,16 @@ func loadSignKey(options *authentication.Options) (*rsa.PrivateKey, string, erro
 }
 
 func NewIssuer(options *authentication.Options) (Issuer, error) {
-	// TODO(hongming) automatically rotates keys
 	signKey, keyID, err := loadSignKey(options)
 	if err != nil {
 		return nil, err
 	}
+	// the keys are replaced by the KeyRotator if the signing keys are stored in a Secret
 	return &issuer{
 		name:             options.OAuthOptions.Issuer,
 		secret:           []byte(options.JwtSecret),
 		maximumClockSkew: options.MaximumClockSkew,
-		signKey: &Keys{
-			SigningKey: &jose.JSONWebKey{
-				Key:       signKey,
-				KeyID:     keyID,
-				Algorithm: jwt.SigningMethodRS256.Alg(),
-				Use:       "sig",
-			},
-			SigningKeyPub: &jose.JSONWebKey{
-				Key:       signKey.Public(),
-				KeyID:     keyID,
-				Algorithm: jwt.SigningMethodRS256.Alg(),
-				Use:       "sig",
-			},
-		},
+		keyring:          NewKeyring(SigningKey{KeyID: keyID, PrivateKey: signKey}),
 	}, nil
 }
 
@@ -80,28 +80,35 @@ func TestNewIssuer(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	want := &issuer{
-		name:             options.OAuthOptions.Issuer,
-		secret:           []byte(options.JwtSecret),
-		maximumClockSkew: options.MaximumClockSkew,
-		signKey: &Keys{
-			SigningKey: &jose.JSONWebKey{
-				Key:       signKey,
-				KeyID:     keyID,
-				Algorithm: jwt.SigningMethodRS256.Alg(),
-				Use:       "sig",
-			},
-			SigningKeyPub: &jose.JSONWebKey{
-				Key:       signKey.Public(),
-				KeyID:     keyID,
-				Algorithm: jwt.SigningMethodRS256.Alg(),
-				Use:       "sig",
-			},
+	iss := got.(*issuer)
+	assert.Equal(t, options.OAuthOptions.Issuer, iss.name)
+	assert.Equal(t, []byte(options.JwtSecret), iss.secret)
+	assert.Equal(t, options.MaximumClockSkew, iss.maximumClockSkew)
+	assert.Equal(t, SigningKey{KeyID: keyID, PrivateKey: signKey}, iss.keyring.Active())
+
+	keys := got.Keys()
+	want := &Keys{
+		SigningKey: &jose.JSONWebKey{
+			Key:       signKey,
+			KeyID:     keyID,
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			Use:       "sig",
+		},
+		SigningKeyPub: &jose.JSONWebKey{
+			Key:       signKey.Public(),
+			KeyID:     keyID,
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			Use:       "sig",
 		},
+		VerificationKeys: []jose.JSONWebKey{{
+			Key:       signKey.Public(),
+			KeyID:     keyID,
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			Use:       "sig",
+		}},
 	}
-	if !reflect.DeepEqual(got, want) {
-		t.Errorf("NewIssuer() got = %v, want %v", got, want)
-		return
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("Keys() got = %v, want %v", keys, want)
 	}
 }
 
@@ -119,12 +126,13 @@ func TestNewIssuerGenerateSignKey(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	iss := got.(*issuer)
-	assert.NotNil(t, iss.signKey)
-	assert.NotNil(t, iss.signKey.SigningKey)
-	assert.NotNil(t, iss.signKey.SigningKeyPub)
-	assert.NotNil(t, iss.signKey.SigningKey.KeyID)
-	assert.NotNil(t, iss.signKey.SigningKeyPub.KeyID)
+	keys := got.Keys()
+	assert.NotNil(t, keys)
+	assert.NotNil(t, keys.SigningKey)
+	assert.NotNil(t, keys.SigningKeyPub)
+	assert.NotNil(t, keys.SigningKey.KeyID)
+	assert.NotNil(t, keys.SigningKeyPub.KeyID)
+	assert.Len(t, keys.VerificationKeys, 1)
 }
 
 func Test_issuer_IssueTo(t *testing.T) {
@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// SigningKey is an RSA key of the Keyring.
+type SigningKey struct {
+	// KeyID identifies the key in the kid header of the tokens
+	KeyID      string
+	PrivateKey *rsa.PrivateKey
+	CreatedAt  time.Time
+	// ExpiresAt is the time a retiring key stops verifying the tokens, it's zero for the active key
+	ExpiresAt time.Time
+}
+
+func (k SigningKey) expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
+}
+
+func (k SigningKey) jsonWebKey() *jose.JSONWebKey {
+	return &jose.JSONWebKey{
+		Key:       k.PrivateKey,
+		KeyID:     k.KeyID,
+		Algorithm: jwt.SigningMethodRS256.Alg(),
+		Use:       "sig",
+	}
+}
+
+func (k SigningKey) publicJSONWebKey() *jose.JSONWebKey {
+	return &jose.JSONWebKey{
+		Key:       k.PrivateKey.Public(),
+		KeyID:     k.KeyID,
+		Algorithm: jwt.SigningMethodRS256.Alg(),
+		Use:       "sig",
+	}
+}
+
+// Keyring holds the keys to sign and verify the ID tokens. The tokens are signed with the active key and
+// verified with the key selected by the kid header, the retiring keys keep verifying the tokens signed
+// before the rotation until they expire, so rotating the active key doesn't invalidate the issued tokens.
+type Keyring struct {
+	mutex    sync.RWMutex
+	active   SigningKey
+	retiring []SigningKey
+	now      func() time.Time
+}
+
+func NewKeyring(active SigningKey, retiring ...SigningKey) *Keyring {
+	return &Keyring{active: active, retiring: retiring, now: time.Now}
+}
+
+// Active returns the key to sign the tokens.
+func (k *Keyring) Active() SigningKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	return k.active
+}
+
+// Retiring returns the retiring keys not expired yet.
+func (k *Keyring) Retiring() []SigningKey {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	now := k.now()
+	retiring := make([]SigningKey, 0, len(k.retiring))
+	for _, key := range k.retiring {
+		if !key.expired(now) {
+			retiring = append(retiring, key)
+		}
+	}
+	return retiring
+}
+
+// Replace replaces all the keys of the Keyring.
+func (k *Keyring) Replace(active SigningKey, retiring []SigningKey) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.active = active
+	k.retiring = retiring
+}
+
+// VerificationKey returns the public key with the given key ID, the active key is
+// returned if the key ID is empty for the tokens issued without the kid header.
+func (k *Keyring) VerificationKey(keyID string) (*rsa.PublicKey, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+	if keyID == "" || keyID == k.active.KeyID {
+		return &k.active.PrivateKey.PublicKey, nil
+	}
+	now := k.now()
+	for _, key := range k.retiring {
+		if key.KeyID != keyID {
+			continue
+		}
+		if key.expired(now) {
+			return nil, fmt.Errorf("signing key %s has expired", keyID)
+		}
+		return &key.PrivateKey.PublicKey, nil
+	}
+	return nil, fmt.Errorf("signing key %s not found", keyID)
+}
+
+// Keys returns the active key and the public keys of all the keys not expired yet.
+func (k *Keyring) Keys() *Keys {
+	active := k.Active()
+	keys := &Keys{
+		SigningKey:       active.jsonWebKey(),
+		SigningKeyPub:    active.publicJSONWebKey(),
+		VerificationKeys: []jose.JSONWebKey{*active.publicJSONWebKey()},
+	}
+	for _, key := range k.Retiring() {
+		keys.VerificationKeys = append(keys.VerificationKeys, *key.publicJSONWebKey())
+	}
+	return keys
+}
@@ -0,0 +1,254 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// SignKeysSecretKey is the key of the Secret data which contains the signing keys, the value is a JSON list
+	// of the keys, the key without an expiration time is the active one.
+	SignKeysSecretKey = "signKeys"
+
+	// keyRotationCheckInterval is how often the KeyRotator checks whether the active key should be rotated.
+	keyRotationCheckInterval = time.Minute
+)
+
+type storedSigningKey struct {
+	KeyID string `json:"kid"`
+	// PrivateKey is the PEM encoded RSA private key
+	PrivateKey string     `json:"privateKey"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+}
+
+// KeyRotator shares the signing keys between the ks-apiserver replicas through a Secret and rotates the active
+// key periodically. The replaced key is kept for the retention period to verify the tokens signed with it,
+// the replicas reload the keys once the Secret changes.
+type KeyRotator struct {
+	client         kubernetes.Interface
+	secretLister   corev1listers.SecretLister
+	secretSynced   cache.InformerSynced
+	namespace      string
+	name           string
+	keyring        *Keyring
+	rotationPeriod time.Duration
+	retention      time.Duration
+	now            func() time.Time
+}
+
+// NewKeyRotator creates a KeyRotator storing the keys in the Secret with given namespace and name,
+// the active key is never rotated if the rotation period is 0.
+func NewKeyRotator(client kubernetes.Interface, informer corev1informers.SecretInformer, namespace, name string,
+	keyring *Keyring, rotationPeriod, retention time.Duration) *KeyRotator {
+	r := &KeyRotator{
+		client:         client,
+		secretLister:   informer.Lister(),
+		secretSynced:   informer.Informer().HasSynced,
+		namespace:      namespace,
+		name:           name,
+		keyring:        keyring,
+		rotationPeriod: rotationPeriod,
+		retention:      retention,
+		now:            time.Now,
+	}
+	informer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: r.matches,
+		Handler: cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				r.load(obj.(*corev1.Secret))
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				r.load(obj.(*corev1.Secret))
+			},
+		},
+	})
+	return r
+}
+
+// Run rotates the keys until the stop channel is closed, the Secret is created with the current keys if not found.
+func (r *KeyRotator) Run(stopCh <-chan struct{}) {
+	if !cache.WaitForCacheSync(stopCh, r.secretSynced) {
+		return
+	}
+	wait.Until(func() {
+		if err := r.rotate(); err != nil {
+			klog.Errorf("failed to rotate signing keys in secret %s/%s: %v", r.namespace, r.name, err)
+		}
+	}, keyRotationCheckInterval, stopCh)
+}
+
+func (r *KeyRotator) matches(obj interface{}) bool {
+	secret, ok := obj.(*corev1.Secret)
+	return ok && secret.Namespace == r.namespace && secret.Name == r.name
+}
+
+func (r *KeyRotator) load(secret *corev1.Secret) {
+	active, retiring, err := decodeSigningKeys(secret.Data[SignKeysSecretKey])
+	if err != nil {
+		klog.Errorf("failed to load signing keys from secret %s/%s, the current keys are kept: %v", r.namespace, r.name, err)
+		return
+	}
+	r.keyring.Replace(active, retiring)
+	klog.V(4).Infof("loaded signing key %s and %d retiring keys from secret %s/%s", active.KeyID, len(retiring), r.namespace, r.name)
+}
+
+func (r *KeyRotator) rotate() error {
+	now := r.now()
+	secret, err := r.secretLister.Secrets(r.namespace).Get(r.name)
+	if apierrors.IsNotFound(err) {
+		active := r.keyring.Active()
+		if active.CreatedAt.IsZero() {
+			active.CreatedAt = now
+		}
+		data, err := encodeSigningKeys(active, r.keyring.Retiring())
+		if err != nil {
+			return err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: r.namespace, Name: r.name},
+			Data:       map[string][]byte{SignKeysSecretKey: data},
+		}
+		// the Secret may be created by another replica, the keys are loaded from it then
+		_, err = r.client.CoreV1().Secrets(r.namespace).Create(context.Background(), secret, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	active, retiring, err := decodeSigningKeys(secret.Data[SignKeysSecretKey])
+	if err != nil {
+		return err
+	}
+	changed := false
+	valid := make([]SigningKey, 0, len(retiring))
+	for _, key := range retiring {
+		if key.expired(now) {
+			changed = true
+			continue
+		}
+		valid = append(valid, key)
+	}
+	retiring = valid
+	if r.rotationPeriod > 0 && !now.Before(active.CreatedAt.Add(r.rotationPeriod)) {
+		next, err := generateSigningKey(now)
+		if err != nil {
+			return err
+		}
+		active.ExpiresAt = now.Add(r.retention)
+		retiring = append([]SigningKey{active}, retiring...)
+		active = next
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	data, err := encodeSigningKeys(active, retiring)
+	if err != nil {
+		return err
+	}
+	secret = secret.DeepCopy()
+	secret.Data[SignKeysSecretKey] = data
+	// the update conflicts if another replica rotated the keys at the same time, the keys are loaded from it then
+	if _, err = r.client.CoreV1().Secrets(r.namespace).Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	klog.Infof("rotated signing key to %s, %d retiring keys", active.KeyID, len(retiring))
+	r.keyring.Replace(active, retiring)
+	return nil
+}
+
+func generateSigningKey(now time.Time) (SigningKey, error) {
+	data, err := generatePrivateKeyData()
+	if err != nil {
+		return SigningKey{}, err
+	}
+	privateKey, err := loadPrivateKey(data)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return SigningKey{KeyID: fmt.Sprint(fnv32a(data)), PrivateKey: privateKey, CreatedAt: now}, nil
+}
+
+func encodeSigningKeys(active SigningKey, retiring []SigningKey) ([]byte, error) {
+	stored := make([]storedSigningKey, 0, len(retiring)+1)
+	for _, key := range append([]SigningKey{active}, retiring...) {
+		storedKey := storedSigningKey{
+			KeyID:      key.KeyID,
+			PrivateKey: string(encodePrivateKey(key.PrivateKey)),
+			CreatedAt:  key.CreatedAt,
+		}
+		if !key.ExpiresAt.IsZero() {
+			expiresAt := key.ExpiresAt
+			storedKey.ExpiresAt = &expiresAt
+		}
+		stored = append(stored, storedKey)
+	}
+	return json.Marshal(stored)
+}
+
+func decodeSigningKeys(data []byte) (SigningKey, []SigningKey, error) {
+	if len(data) == 0 {
+		return SigningKey{}, nil, fmt.Errorf("key %s not found", SignKeysSecretKey)
+	}
+	var stored []storedSigningKey
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return SigningKey{}, nil, err
+	}
+	var active *SigningKey
+	retiring := make([]SigningKey, 0, len(stored))
+	for _, storedKey := range stored {
+		privateKey, err := loadPrivateKey([]byte(storedKey.PrivateKey))
+		if err != nil {
+			return SigningKey{}, nil, fmt.Errorf("invalid signing key %s: %v", storedKey.KeyID, err)
+		}
+		key := SigningKey{KeyID: storedKey.KeyID, PrivateKey: privateKey, CreatedAt: storedKey.CreatedAt}
+		if storedKey.ExpiresAt == nil {
+			if active != nil {
+				return SigningKey{}, nil, errors.New("more than one active signing key")
+			}
+			active = &key
+			continue
+		}
+		key.ExpiresAt = *storedKey.ExpiresAt
+		retiring = append(retiring, key)
+	}
+	if active == nil {
+		return SigningKey{}, nil, errors.New("active signing key not found")
+	}
+	return *active, retiring, nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+)
+
+func TestKeyRotator(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	secretInformer := informerFactory.Core().V1().Secrets()
+
+	options := authentication.NewOptions()
+	options.JwtSecret = "test-secret"
+	iss, err := NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replica, err := NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotator := NewKeyRotator(client, secretInformer, "kubesphere-system", "signing-keys", iss.Keyring(), 24*time.Hour, time.Hour)
+	// the other replica loads the keys from the Secret
+	NewKeyRotator(client, secretInformer, "kubesphere-system", "signing-keys", replica.Keyring(), 24*time.Hour, time.Hour)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	informerFactory.Start(stopCh)
+	cache.WaitForCacheSync(stopCh, secretInformer.Informer().HasSynced)
+
+	issueIDToken := func() string {
+		idToken, err := iss.IssueTo(&IssueRequest{
+			User:      &user.DefaultInfo{Name: "admin"},
+			Claims:    Claims{TokenType: IDToken},
+			ExpiresIn: time.Hour,
+		})
+		assert.NoError(t, err)
+		return idToken
+	}
+	initial := iss.Keyring().Active()
+
+	// the Secret is created with the current keys
+	assert.NoError(t, rotator.rotate())
+	secret, err := client.CoreV1().Secrets("kubesphere-system").Get(context.Background(), "signing-keys", metav1.GetOptions{})
+	assert.NoError(t, err)
+	active, retiring, err := decodeSigningKeys(secret.Data[SignKeysSecretKey])
+	assert.NoError(t, err)
+	assert.Equal(t, initial.KeyID, active.KeyID)
+	assert.Empty(t, retiring)
+	assert.Eventually(t, func() bool {
+		return replica.Keyring().Active().KeyID == initial.KeyID
+	}, time.Second, 10*time.Millisecond)
+	oldToken := issueIDToken()
+
+	// nothing changes before the rotation period
+	rotator.now = func() time.Time { return time.Now().Add(time.Hour) }
+	assert.NoError(t, rotator.rotate())
+	assert.Equal(t, initial.KeyID, iss.Keyring().Active().KeyID)
+
+	rotator.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	assert.Eventually(t, func() bool {
+		return rotator.rotate() == nil && iss.Keyring().Active().KeyID != initial.KeyID
+	}, time.Second, 10*time.Millisecond)
+	rotated := iss.Keyring().Active()
+	assert.Len(t, iss.Keys().VerificationKeys, 2)
+	assert.Eventually(t, func() bool {
+		return replica.Keyring().Active().KeyID == rotated.KeyID
+	}, time.Second, 10*time.Millisecond)
+
+	// the tokens signed with the retiring key are still valid on both replicas
+	_, err = iss.Verify(oldToken)
+	assert.NoError(t, err)
+	_, err = replica.Verify(oldToken)
+	assert.NoError(t, err)
+	newToken := issueIDToken()
+	_, err = replica.Verify(newToken)
+	assert.NoError(t, err)
+
+	// the retiring key is removed after the retention period
+	rotator.now = func() time.Time { return time.Now().Add(26 * time.Hour) }
+	iss.Keyring().now = rotator.now
+	_, err = iss.Verify(oldToken)
+	assert.Error(t, err)
+	assert.Eventually(t, func() bool {
+		return rotator.rotate() == nil && len(iss.Keyring().Retiring()) == 0
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, rotated.KeyID, iss.Keyring().Active().KeyID)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package totp implements the time-based one-time password algorithm defined in
+// https://www.ietf.org/rfc/rfc6238.txt with the parameters supported by all the
+// popular authenticator apps: HMAC-SHA1, 6 digits and 30 seconds time step.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits is the number of digits of a passcode.
+	Digits = 6
+	// Period is the time step of the passcodes.
+	Period = 30 * time.Second
+	// Skew is the number of time steps before and after the current one which are also accepted,
+	// it tolerates the clock drift of the devices and the delay of typing the passcode.
+	Skew = 1
+
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generates a random base32 encoded secret of 160 bits as recommended by RFC 4226.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// Counter returns the time step counter of the time.
+func Counter(t time.Time) uint64 {
+	return uint64(t.Unix() / int64(Period/time.Second))
+}
+
+// Passcode generates the passcode of the secret at the time step counter.
+func Passcode(secret string, counter uint64) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return passcode(key, counter), nil
+}
+
+// Validate checks the passcode against the secret at the time, it returns the time step
+// counter the passcode matches, so that the caller can reject the passcode reused in the same step.
+func Validate(secret, code string, t time.Time) (uint64, bool, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false, err
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false, nil
+	}
+	counter := Counter(t)
+	for i := -Skew; i <= Skew; i++ {
+		c := counter + uint64(i)
+		if subtle.ConstantTimeCompare([]byte(passcode(key, c)), []byte(code)) == 1 {
+			return c, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// URI returns the key URI which is encoded into the QR code scanned by the authenticator apps,
+// see also https://github.com/google/google-authenticator/wiki/Key-Uri-Format.
+func URI(issuer, account, secret string) string {
+	values := url.Values{}
+	values.Set("secret", secret)
+	values.Set("issuer", issuer)
+	values.Set("algorithm", "SHA1")
+	values.Set("digits", fmt.Sprint(Digits))
+	values.Set("period", fmt.Sprint(int(Period/time.Second)))
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: values.Encode(),
+	}
+	return u.String()
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret: %v", err)
+	}
+	return key, nil
+}
+
+// passcode implements the HOTP algorithm defined in https://www.ietf.org/rfc/rfc4226.txt.
+func passcode(key []byte, counter uint64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1000000)
+}
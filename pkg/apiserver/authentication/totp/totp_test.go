@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package totp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// base32 encoded "12345678901234567890", the secret of the test vectors in RFC 6238
+const testSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestPasscode(t *testing.T) {
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{unix: 59, expected: "287082"},
+		{unix: 1111111109, expected: "081804"},
+		{unix: 1234567890, expected: "005924"},
+		{unix: 2000000000, expected: "279037"},
+	}
+	for _, test := range tests {
+		code, err := Passcode(testSecret, Counter(time.Unix(test.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	counter, ok, err := Validate(testSecret, "005924", now)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Counter(now), counter)
+
+	// the passcode of the previous time step is accepted
+	_, ok, err = Validate(testSecret, "005924", now.Add(Period))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = Validate(testSecret, "005924", now.Add(2*Period))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = Validate(testSecret, "12345", now)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = Validate("not-base32!", "005924", now)
+	assert.Error(t, err)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+	code, err := Passcode(secret, Counter(time.Now()))
+	assert.NoError(t, err)
+	_, ok, err := Validate(secret, code, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestURI(t *testing.T) {
+	assert.Equal(t, "otpauth://totp/KubeSphere:admin?algorithm=SHA1&digits=6&issuer=KubeSphere&period=30&secret="+testSecret,
+		URI("KubeSphere", "admin", testSecret))
+}
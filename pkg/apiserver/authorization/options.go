@@ -29,6 +29,8 @@ import (
 
 type Options struct {
 	Mode string `json:"mode" yaml:"mode"`
+	// Webhook consults an external decision service alongside RBAC in the RBAC mode, disabled if it's nil.
+	Webhook *WebhookOptions `json:"webhook,omitempty" yaml:"webhook,omitempty"`
 }
 
 func NewOptions() *Options {
@@ -52,5 +54,8 @@ func (o *Options) Validate() []error {
 		klog.Error(err)
 		errs = append(errs, err)
 	}
+	if o.Webhook != nil {
+		errs = append(errs, o.Webhook.Validate()...)
+	}
 	return errs
 }
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"fmt"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+// RuleEvaluation is a rule of the role referred by a binding.
+type RuleEvaluation struct {
+	Rule rbacv1.PolicyRule `json:"rule"`
+	// Allowed is whether the rule allows the request
+	Allowed bool `json:"allowed"`
+}
+
+// BindingEvaluation is a binding which applies to the user, and the rules of the role it refers to.
+type BindingEvaluation struct {
+	Kind      string         `json:"kind"`
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace,omitempty"`
+	RoleRef   rbacv1.RoleRef `json:"roleRef"`
+	// Subject is the subject of the binding which matches the user
+	Subject rbacv1.Subject `json:"subject"`
+	// RegoPolicy is the rego policy of the role if any
+	RegoPolicy string           `json:"regoPolicy,omitempty"`
+	Rules      []RuleEvaluation `json:"rules"`
+	// Allowed is whether the rego policy or any rule allows the request
+	Allowed bool `json:"allowed"`
+}
+
+// ScopeEvaluation is the bindings evaluated at a scope, the scope is Global, Workspace, Namespace or Cluster.
+type ScopeEvaluation struct {
+	Scope    string              `json:"scope"`
+	Bindings []BindingEvaluation `json:"bindings"`
+}
+
+// RuleList is the bindings applied to a user at each scope of a request, in the order they are evaluated.
+type RuleList struct {
+	Scopes []ScopeEvaluation `json:"scopes"`
+	// Errors are the errors encountered resolving the bindings and the roles
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Explanation explains the decision of RBACAuthorizer on a request.
+type Explanation struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+	// Note tells the limits of the explanation, the authorizers other than RBAC are not evaluated
+	Note string `json:"note"`
+	RuleList
+}
+
+// explanationNote is the limits of the explanation, the authorizers before RBAC in the chain may deny
+// or allow the request regardless of the RBAC decision.
+const explanationNote = "Only the RBAC bindings are evaluated. The request may still be denied by the restrictions of " +
+	"a personal access token, and the authorization webhook, if configured, may allow or deny it before RBAC."
+
+// explainingVisitor records all the bindings and the rules visited, it never short-circuits.
+type explainingVisitor struct {
+	requestAttributes authorizer.Attributes
+
+	allowed bool
+	reason  string
+	list    RuleList
+	// source is the description of the binding visited last
+	source string
+}
+
+func (v *explainingVisitor) visit(source fmt.Stringer, regoPolicy string, rule *rbacv1.PolicyRule, err error) bool {
+	if err != nil {
+		v.list.Errors = append(v.list.Errors, err.Error())
+	}
+	if source == nil {
+		return true
+	}
+	binding := v.binding(source)
+	if regoPolicy != "" {
+		binding.RegoPolicy = regoPolicy
+		if v.requestAttributes != nil && regoPolicyAllows(v.requestAttributes, regoPolicy) {
+			v.allow(binding, source)
+		}
+	}
+	if rule != nil {
+		evaluation := RuleEvaluation{Rule: *rule}
+		if v.requestAttributes != nil && ruleAllows(v.requestAttributes, rule) {
+			evaluation.Allowed = true
+			v.allow(binding, source)
+		}
+		binding.Rules = append(binding.Rules, evaluation)
+	}
+	return true
+}
+
+func (v *explainingVisitor) allow(binding *BindingEvaluation, source fmt.Stringer) {
+	binding.Allowed = true
+	if !v.allowed {
+		v.allowed = true
+		v.reason = fmt.Sprintf("RBAC: allowed by %s", source.String())
+	}
+}
+
+// binding returns the evaluation of the binding described by the source, the describers are reused
+// between the bindings, so a new binding is visited once the description changes.
+func (v *explainingVisitor) binding(source fmt.Stringer) *BindingEvaluation {
+	description := source.String()
+	scope, binding := describeBinding(source)
+	if len(v.list.Scopes) == 0 || v.list.Scopes[len(v.list.Scopes)-1].Scope != scope {
+		v.list.Scopes = append(v.list.Scopes, ScopeEvaluation{Scope: scope, Bindings: []BindingEvaluation{}})
+	}
+	current := &v.list.Scopes[len(v.list.Scopes)-1]
+	if v.source != description {
+		binding.Rules = []RuleEvaluation{}
+		current.Bindings = append(current.Bindings, binding)
+		v.source = description
+	}
+	return &current.Bindings[len(current.Bindings)-1]
+}
+
+func describeBinding(source fmt.Stringer) (string, BindingEvaluation) {
+	switch d := source.(type) {
+	case *globalRoleBindingDescriber:
+		return request.GlobalScope, BindingEvaluation{Kind: iamv1alpha2.ResourceKindGlobalRoleBinding, Name: d.binding.Name,
+			RoleRef: d.binding.RoleRef, Subject: *d.subject}
+	case *workspaceRoleBindingDescriber:
+		return request.WorkspaceScope, BindingEvaluation{Kind: iamv1alpha2.ResourceKindWorkspaceRoleBinding, Name: d.binding.Name,
+			RoleRef: d.binding.RoleRef, Subject: *d.subject}
+	case *roleBindingDescriber:
+		return request.NamespaceScope, BindingEvaluation{Kind: iamv1alpha2.ResourceKindRoleBinding, Name: d.binding.Name,
+			Namespace: d.binding.Namespace, RoleRef: d.binding.RoleRef, Subject: *d.subject}
+	case *clusterRoleBindingDescriber:
+		return request.ClusterScope, BindingEvaluation{Kind: iamv1alpha2.ResourceKindClusterRoleBinding, Name: d.binding.Name,
+			RoleRef: d.binding.RoleRef, Subject: *d.subject}
+	default:
+		return "", BindingEvaluation{Name: source.String()}
+	}
+}
+
+// Explain evaluates all the bindings applied to the user of the request rather than stopping at the first
+// allowing rule, and returns the decision with the bindings and the rules evaluated at each scope.
+func (r *RBACAuthorizer) Explain(requestAttributes authorizer.Attributes) *Explanation {
+	visitor := &explainingVisitor{requestAttributes: requestAttributes}
+	r.visitRulesFor(requestAttributes, visitor.visit)
+	explanation := &Explanation{Decision: "NoOpinion", Reason: visitor.reason, Note: explanationNote, RuleList: visitor.list}
+	if visitor.allowed {
+		explanation.Decision = "Allow"
+	}
+	return explanation
+}
+
+// ListWorkspaceRules returns the bindings and the rules applied to the user in the workspace, which are the
+// WorkspaceRoleBindings of the workspace and the RoleBindings in its namespaces and DevOps projects.
+// The GlobalRoleBindings and the ClusterRoleBindings are not listed since they are not managed in the workspace.
+func (r *RBACAuthorizer) ListWorkspaceRules(user user.Info, workspace string) *RuleList {
+	// the rules are only listed without the request attributes to evaluate
+	visitor := &explainingVisitor{}
+	r.visitWorkspaceRules(user, workspace, visitor.visit)
+	return &visitor.list
+}
+
+func (r *RBACAuthorizer) visitWorkspaceRules(user user.Info, workspace string, visitor func(source fmt.Stringer, regoPolicy string, rule *rbacv1.PolicyRule, err error) bool) {
+	if workspaceRoleBindings, err := r.am.ListWorkspaceRoleBindings("", nil, workspace); err != nil {
+		visitor(nil, "", nil, err)
+	} else {
+		sourceDescriber := &workspaceRoleBindingDescriber{}
+		for _, workspaceRoleBinding := range workspaceRoleBindings {
+			subjectIndex, applies := appliesTo(user, workspaceRoleBinding.Subjects, "")
+			if !applies {
+				continue
+			}
+			regoPolicy, rules, err := r.am.GetRoleReferenceRules(workspaceRoleBinding.RoleRef, "")
+			if err != nil {
+				visitor(nil, "", nil, err)
+				continue
+			}
+			sourceDescriber.binding = workspaceRoleBinding
+			sourceDescriber.subject = &workspaceRoleBinding.Subjects[subjectIndex]
+			visitor(sourceDescriber, regoPolicy, nil, nil)
+			for i := range rules {
+				visitor(sourceDescriber, "", &rules[i], nil)
+			}
+		}
+	}
+
+	roleBindings, err := r.am.ListGroupRoleBindings(workspace, query.New())
+	if err != nil {
+		visitor(nil, "", nil, err)
+		return
+	}
+	sort.Slice(roleBindings, func(i, j int) bool {
+		if roleBindings[i].Namespace != roleBindings[j].Namespace {
+			return roleBindings[i].Namespace < roleBindings[j].Namespace
+		}
+		return roleBindings[i].Name < roleBindings[j].Name
+	})
+	sourceDescriber := &roleBindingDescriber{}
+	for _, roleBinding := range roleBindings {
+		subjectIndex, applies := appliesTo(user, roleBinding.Subjects, roleBinding.Namespace)
+		if !applies {
+			continue
+		}
+		regoPolicy, rules, err := r.am.GetRoleReferenceRules(roleBinding.RoleRef, roleBinding.Namespace)
+		if err != nil {
+			visitor(nil, "", nil, err)
+			continue
+		}
+		sourceDescriber.binding = roleBinding
+		sourceDescriber.subject = &roleBinding.Subjects[subjectIndex]
+		visitor(sourceDescriber, regoPolicy, nil, nil)
+		for i := range rules {
+			visitor(sourceDescriber, "", &rules[i], nil)
+		}
+	}
+}
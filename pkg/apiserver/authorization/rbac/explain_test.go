@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+func TestExplain(t *testing.T) {
+	staticRoles := &StaticRoles{
+		globalRoles: []*iamv1alpha2.GlobalRole{{
+			ObjectMeta: metav1.ObjectMeta{Name: "authenticated"},
+			Rules: []rbacv1.PolicyRule{{
+				Verbs:     []string{"get"},
+				APIGroups: []string{"*"},
+				Resources: []string{"users"},
+			}},
+		}},
+		globalRoleBindings: []*iamv1alpha2.GlobalRoleBinding{{
+			ObjectMeta: metav1.ObjectMeta{Name: "authenticated"},
+			RoleRef:    rbacv1.RoleRef{APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Kind: iamv1alpha2.ResourceKindGlobalRole, Name: "authenticated"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: user.AllAuthenticated}},
+		}},
+		workspaceRoles: []*iamv1alpha2.WorkspaceRole{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "finance-viewer",
+				Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "finance"},
+			},
+			Rules: []rbacv1.PolicyRule{{
+				Verbs:     []string{"get", "list", "watch"},
+				APIGroups: []string{"*"},
+				Resources: []string{"*"},
+			}},
+		}},
+		workspaceRoleBindings: []*iamv1alpha2.WorkspaceRoleBinding{{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "dev-finance-viewer",
+				Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "finance"},
+			},
+			RoleRef:  rbacv1.RoleRef{APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Kind: iamv1alpha2.ResourceKindWorkspaceRole, Name: "finance-viewer"},
+			Subjects: []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "dev"}},
+		}},
+		namespaces: []*corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "finance-dev", Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "finance"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "sales-dev", Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "sales"}}},
+		},
+		roles: []*rbacv1.Role{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "finance-dev"},
+				Rules:      []rbacv1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "sales-dev"},
+				Rules:      []rbacv1.PolicyRule{{Verbs: []string{"*"}, APIGroups: []string{"*"}, Resources: []string{"*"}}},
+			},
+		},
+		roleBindings: []*rbacv1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-admin", Namespace: "finance-dev"},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "admin"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "dev"}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev-admin", Namespace: "sales-dev"},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "admin"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "dev"}},
+			},
+		},
+	}
+	rbacAuthorizer, err := newMockRBACAuthorizer(staticRoles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev := &user.DefaultInfo{Name: "dev", Groups: []string{user.AllAuthenticated}}
+	attributes := func(verb string) authorizer.Attributes {
+		return authorizer.AttributesRecord{
+			User:            dev,
+			Verb:            verb,
+			Workspace:       "finance",
+			Resource:        "namespaces",
+			ResourceRequest: true,
+			ResourceScope:   request.WorkspaceScope,
+		}
+	}
+
+	explanation := rbacAuthorizer.Explain(attributes("delete"))
+	assert.Equal(t, "NoOpinion", explanation.Decision)
+	assert.Empty(t, explanation.Reason)
+	if assert.Len(t, explanation.Scopes, 2) {
+		assert.Equal(t, request.GlobalScope, explanation.Scopes[0].Scope)
+		assert.Equal(t, "authenticated", explanation.Scopes[0].Bindings[0].Name)
+		assert.Equal(t, iamv1alpha2.ResourceKindGlobalRoleBinding, explanation.Scopes[0].Bindings[0].Kind)
+		assert.Equal(t, user.AllAuthenticated, explanation.Scopes[0].Bindings[0].Subject.Name)
+		assert.Equal(t, request.WorkspaceScope, explanation.Scopes[1].Scope)
+		binding := explanation.Scopes[1].Bindings[0]
+		assert.Equal(t, "dev-finance-viewer", binding.Name)
+		assert.Equal(t, "finance-viewer", binding.RoleRef.Name)
+		assert.False(t, binding.Allowed)
+		assert.Len(t, binding.Rules, 1)
+	}
+
+	explanation = rbacAuthorizer.Explain(attributes("list"))
+	assert.Equal(t, "Allow", explanation.Decision)
+	assert.Contains(t, explanation.Reason, `WorkspaceRoleBinding "dev-finance-viewer"`)
+	assert.True(t, explanation.Scopes[1].Bindings[0].Allowed)
+	assert.True(t, explanation.Scopes[1].Bindings[0].Rules[0].Allowed)
+	assert.False(t, explanation.Scopes[0].Bindings[0].Allowed)
+
+	// the decision is the same as the one of Authorize
+	decision, _, err := rbacAuthorizer.Authorize(attributes("list"))
+	assert.NoError(t, err)
+	assert.Equal(t, authorizer.DecisionAllow, decision)
+
+	assert.NotEmpty(t, explanation.Note)
+
+	// only the bindings in the workspace are listed
+	rules := rbacAuthorizer.ListWorkspaceRules(dev, "finance")
+	assert.Empty(t, rules.Errors)
+	if assert.Len(t, rules.Scopes, 2) {
+		assert.Equal(t, request.WorkspaceScope, rules.Scopes[0].Scope)
+		assert.Equal(t, "dev-finance-viewer", rules.Scopes[0].Bindings[0].Name)
+		assert.False(t, rules.Scopes[0].Bindings[0].Rules[0].Allowed)
+		assert.Equal(t, request.NamespaceScope, rules.Scopes[1].Scope)
+		if assert.Len(t, rules.Scopes[1].Bindings, 1) {
+			assert.Equal(t, "finance-dev", rules.Scopes[1].Bindings[0].Namespace)
+		}
+	}
+	assert.Empty(t, rbacAuthorizer.ListWorkspaceRules(&user.DefaultInfo{Name: "ops"}, "finance").Scopes)
+}
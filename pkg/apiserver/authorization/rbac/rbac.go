@@ -436,7 +436,7 @@ type workspaceRoleBindingDescriber struct {
 }
 
 func (d *workspaceRoleBindingDescriber) String() string {
-	return fmt.Sprintf("GlobalRoleBinding %q of %s %q to %s",
+	return fmt.Sprintf("WorkspaceRoleBinding %q of %s %q to %s",
 		d.binding.Name,
 		d.binding.RoleRef.Kind,
 		d.binding.RoleRef.Name,
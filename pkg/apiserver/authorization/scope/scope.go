@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scope contains an authorizer that enforces the restrictions of the personal access tokens.
+package scope
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+// WorkspaceResolver resolves the workspaces of the namespaces and the DevOps projects.
+type WorkspaceResolver interface {
+	GetNamespaceControlledWorkspace(namespace string) (string, error)
+	GetDevOpsControlledWorkspace(devops string) (string, error)
+}
+
+// NewAuthorizer returns an authorizer which denies the requests beyond the workspaces or the verbs
+// the personal access token is restricted to, it has no opinion on the other requests.
+func NewAuthorizer(resolver WorkspaceResolver) authorizer.Authorizer {
+	return authorizer.AuthorizerFunc(func(a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser() == nil {
+			return authorizer.DecisionNoOpinion, "", nil
+		}
+		if workspaces, ok := RestrictedWorkspaces(a.GetUser()); ok {
+			workspace, err := workspaceOf(resolver, a)
+			if err != nil {
+				return authorizer.DecisionDeny, "", err
+			}
+			if !workspaces.Has(workspace) {
+				return authorizer.DecisionDeny, fmt.Sprintf("access token is restricted to the workspaces %v", workspaces.List()), nil
+			}
+		}
+		if verbs, ok := a.GetUser().GetExtra()[iamv1alpha2.ExtraAccessTokenVerbs]; ok && len(verbs) > 0 {
+			if !sets.NewString(verbs...).Has(a.GetVerb()) {
+				return authorizer.DecisionDeny, fmt.Sprintf("access token is restricted to the verbs %v", verbs), nil
+			}
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+}
+
+// RestrictedWorkspaces returns the workspaces the personal access token of the user is restricted to,
+// ok is false if the user is not restricted to any workspace. The handlers listing the resources the user
+// can access through the role bindings filter them by the workspaces, which the authorizer is not asked about.
+func RestrictedWorkspaces(user user.Info) (workspaces sets.String, ok bool) {
+	if user == nil {
+		return nil, false
+	}
+	if values := user.GetExtra()[iamv1alpha2.ExtraAccessTokenWorkspaces]; len(values) > 0 {
+		return sets.NewString(values...), true
+	}
+	return nil, false
+}
+
+// workspaceOf returns the workspace the requested resource belongs to, the resources under the namespaces
+// and the DevOps projects belong to their workspaces as the RBAC authorizer considers.
+func workspaceOf(resolver WorkspaceResolver, a authorizer.Attributes) (string, error) {
+	switch a.GetResourceScope() {
+	case request.NamespaceScope:
+		return resolver.GetNamespaceControlledWorkspace(a.GetNamespace())
+	case request.DevOpsScope:
+		return resolver.GetDevOpsControlledWorkspace(a.GetDevOps())
+	case request.WorkspaceScope:
+		return a.GetWorkspace(), nil
+	default:
+		return "", nil
+	}
+}
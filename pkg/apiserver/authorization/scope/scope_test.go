@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+type fakeResolver struct {
+	namespaces map[string]string
+	devops     map[string]string
+}
+
+func (f *fakeResolver) GetNamespaceControlledWorkspace(namespace string) (string, error) {
+	if namespace == "broken" {
+		return "", fmt.Errorf("namespace %s can not be resolved", namespace)
+	}
+	return f.namespaces[namespace], nil
+}
+
+func (f *fakeResolver) GetDevOpsControlledWorkspace(devops string) (string, error) {
+	return f.devops[devops], nil
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	restricted := &user.DefaultInfo{
+		Name: "admin",
+		Extra: map[string][]string{
+			iamv1alpha2.ExtraAccessToken:           {"admin-x7k2p"},
+			iamv1alpha2.ExtraAccessTokenWorkspaces: {"system-workspace"},
+			iamv1alpha2.ExtraAccessTokenVerbs:      {"get", "list"},
+		},
+	}
+	tests := []struct {
+		name       string
+		attributes authorizer.AttributesRecord
+		want       authorizer.Decision
+		wantErr    bool
+	}{
+		{
+			name:       "anonymous",
+			attributes: authorizer.AttributesRecord{Verb: "delete"},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "session token",
+			attributes: authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "admin"}, Verb: "delete", Workspace: "ws1"},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "unrestricted access token",
+			attributes: authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "admin", Extra: map[string][]string{iamv1alpha2.ExtraAccessToken: {"admin-x7k2p"}}}, Verb: "delete"},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "within the scope",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list", ResourceScope: request.WorkspaceScope, Workspace: "system-workspace"},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "other workspace",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list", ResourceScope: request.WorkspaceScope, Workspace: "ws1"},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "namespace in the workspace",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list", ResourceScope: request.NamespaceScope, Namespace: "kubesphere-system"},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "namespace in other workspace",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list", ResourceScope: request.NamespaceScope, Namespace: "ns1"},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "namespace out of workspaces",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list", ResourceScope: request.NamespaceScope, Namespace: "default"},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "namespace can not be resolved",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list", ResourceScope: request.NamespaceScope, Namespace: "broken"},
+			want:       authorizer.DecisionDeny,
+			wantErr:    true,
+		},
+		{
+			name:       "devops project in the workspace",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list", ResourceScope: request.DevOpsScope, DevOps: "project1"},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "workspace requested with the namespace scope",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list", ResourceScope: request.NamespaceScope, Workspace: "system-workspace", Namespace: "ns1"},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "outside of the workspaces",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "list"},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "other verb",
+			attributes: authorizer.AttributesRecord{User: restricted, Verb: "delete", ResourceScope: request.WorkspaceScope, Workspace: "system-workspace"},
+			want:       authorizer.DecisionDeny,
+		},
+	}
+	a := NewAuthorizer(&fakeResolver{
+		namespaces: map[string]string{"kubesphere-system": "system-workspace", "ns1": "ws1"},
+		devops:     map[string]string{"project1": "system-workspace"},
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := a.Authorize(tt.attributes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Authorize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
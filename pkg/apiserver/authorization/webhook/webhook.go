@@ -0,0 +1,219 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+)
+
+// The KubeSphere specific attributes are sent as the annotations of the SubjectAccessReview.
+const (
+	ClusterAnnotation       = "authorization.kubesphere.io/cluster"
+	WorkspaceAnnotation     = "authorization.kubesphere.io/workspace"
+	DevOpsAnnotation        = "authorization.kubesphere.io/devops"
+	ResourceScopeAnnotation = "authorization.kubesphere.io/resource-scope"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	// maxCacheSize bounds the memory used by the cached decisions
+	maxCacheSize = 10000
+)
+
+type decision struct {
+	decision authorizer.Decision
+	reason   string
+}
+
+// webhookAuthorizer consults an external decision service with SubjectAccessReview objects,
+// the allowed, the denied and the no-opinion responses are mapped to the decisions as they are.
+type webhookAuthorizer struct {
+	client          *http.Client
+	url             string
+	authorizedTTL   time.Duration
+	unauthorizedTTL time.Duration
+	failClosed      bool
+	responseCache   *cache.LRUExpireCache
+}
+
+// NewAuthorizer creates an authorizer calling the webhook described by options.ConfigFile.
+func NewAuthorizer(options *authorization.WebhookOptions) (authorizer.Authorizer, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", options.ConfigFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load authorization webhook config %s: %v", options.ConfigFile, err)
+	}
+	client, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	client.Timeout = options.Timeout
+	if client.Timeout == 0 {
+		client.Timeout = defaultTimeout
+	}
+	return &webhookAuthorizer{
+		client:          client,
+		url:             config.Host,
+		authorizedTTL:   options.AuthorizedTTL,
+		unauthorizedTTL: options.UnauthorizedTTL,
+		failClosed:      options.FailurePolicy == authorization.WebhookFailurePolicyFail,
+		responseCache:   cache.NewLRUExpireCache(maxCacheSize),
+	}, nil
+}
+
+func (w *webhookAuthorizer) Authorize(a authorizer.Attributes) (authorizer.Decision, string, error) {
+	review := newSubjectAccessReview(a)
+	key, err := json.Marshal(review)
+	if err != nil {
+		return w.onError(err)
+	}
+	if cached, ok := w.responseCache.Get(string(key)); ok {
+		d := cached.(decision)
+		return d.decision, d.reason, nil
+	}
+
+	status, err := w.review(key)
+	if err != nil {
+		klog.Errorf("failed to call authorization webhook: %v", err)
+		return w.onError(err)
+	}
+
+	d := decision{decision: authorizer.DecisionNoOpinion, reason: status.Reason}
+	switch {
+	case status.Allowed && status.Denied:
+		return w.onError(fmt.Errorf("authorization webhook returned both allowed and denied"))
+	case status.Allowed:
+		d.decision = authorizer.DecisionAllow
+	case status.Denied:
+		d.decision = authorizer.DecisionDeny
+	}
+	// the decision with an evaluation error is not cached, it may succeed next time
+	if status.EvaluationError != "" {
+		return d.decision, d.reason, fmt.Errorf("authorization webhook evaluation error: %s", status.EvaluationError)
+	}
+	ttl := w.unauthorizedTTL
+	if d.decision == authorizer.DecisionAllow {
+		ttl = w.authorizedTTL
+	}
+	if ttl > 0 {
+		w.responseCache.Add(string(key), d, ttl)
+	}
+	return d.decision, d.reason, nil
+}
+
+// onError denies the request if the webhook fails closed, otherwise leaves the decision to the next authorizer.
+// The error is only logged if the webhook fails open, or the request would fail with an internal error
+// instead of being forbidden if none of the next authorizers allows it.
+func (w *webhookAuthorizer) onError(err error) (authorizer.Decision, string, error) {
+	if w.failClosed {
+		return authorizer.DecisionDeny, "authorization webhook is unavailable", err
+	}
+	klog.Warningf("authorization webhook failed, leaving the decision to the next authorizer: %v", err)
+	return authorizer.DecisionNoOpinion, "", nil
+}
+
+func (w *webhookAuthorizer) review(body []byte) (*authorizationv1.SubjectAccessReviewStatus, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, data)
+	}
+	result := &authorizationv1.SubjectAccessReview{}
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, err
+	}
+	return &result.Status, nil
+}
+
+func newSubjectAccessReview(a authorizer.Attributes) *authorizationv1.SubjectAccessReview {
+	review := &authorizationv1.SubjectAccessReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: authorizationv1.SchemeGroupVersion.String(),
+			Kind:       "SubjectAccessReview",
+		},
+	}
+	if u := a.GetUser(); u != nil {
+		review.Spec.User = u.GetName()
+		review.Spec.UID = u.GetUID()
+		review.Spec.Groups = u.GetGroups()
+		if extra := u.GetExtra(); len(extra) > 0 {
+			review.Spec.Extra = make(map[string]authorizationv1.ExtraValue, len(extra))
+			for k, v := range extra {
+				review.Spec.Extra[k] = v
+			}
+		}
+	}
+	if a.IsResourceRequest() {
+		review.Spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace:   a.GetNamespace(),
+			Verb:        a.GetVerb(),
+			Group:       a.GetAPIGroup(),
+			Version:     a.GetAPIVersion(),
+			Resource:    a.GetResource(),
+			Subresource: a.GetSubresource(),
+			Name:        a.GetName(),
+		}
+	} else {
+		review.Spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{
+			Path: a.GetPath(),
+			Verb: a.GetVerb(),
+		}
+	}
+	annotations := map[string]string{
+		ClusterAnnotation:       a.GetCluster(),
+		WorkspaceAnnotation:     a.GetWorkspace(),
+		DevOpsAnnotation:        a.GetDevOps(),
+		ResourceScopeAnnotation: a.GetResourceScope(),
+	}
+	for k, v := range annotations {
+		if v == "" {
+			delete(annotations, k)
+		}
+	}
+	if len(annotations) > 0 {
+		review.Annotations = annotations
+	}
+	return review
+}
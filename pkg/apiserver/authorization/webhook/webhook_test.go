@@ -0,0 +1,148 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizerfactory"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/union"
+)
+
+const kubeconfigTemplate = `
+apiVersion: v1
+kind: Config
+clusters:
+- name: policy-engine
+  cluster:
+    server: %s
+    insecure-skip-tls-verify: true
+users:
+- name: ks-apiserver
+  user:
+    token: test-token
+contexts:
+- name: webhook
+  context:
+    cluster: policy-engine
+    user: ks-apiserver
+current-context: webhook
+`
+
+func newTestAuthorizer(t *testing.T, url, failurePolicy string) authorizer.Authorizer {
+	configFile := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(configFile, []byte(fmt.Sprintf(kubeconfigTemplate, url)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	webhookAuthorizer, err := NewAuthorizer(&authorization.WebhookOptions{
+		ConfigFile:      configFile,
+		AuthorizedTTL:   time.Minute,
+		UnauthorizedTTL: time.Minute,
+		FailurePolicy:   failurePolicy,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return webhookAuthorizer
+}
+
+func TestWebhookAuthorizer(t *testing.T) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+		review := &authorizationv1.SubjectAccessReview{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(review))
+		switch review.Spec.User {
+		case "admin":
+			review.Status.Allowed = true
+		case "contractor":
+			review.Status.Denied = true
+			review.Status.Reason = "contractors can't access workspace " + review.Annotations[WorkspaceAnnotation]
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(review))
+	}))
+	defer server.Close()
+	webhookAuthorizer := newTestAuthorizer(t, server.URL, authorization.WebhookFailurePolicyFail)
+
+	attributes := func(username string) authorizer.Attributes {
+		return authorizer.AttributesRecord{
+			User:            &user.DefaultInfo{Name: username},
+			Verb:            "list",
+			Workspace:       "finance",
+			Resource:        "namespaces",
+			ResourceRequest: true,
+			ResourceScope:   "workspace",
+		}
+	}
+	tests := []struct {
+		username string
+		expected authorizer.Decision
+		reason   string
+	}{
+		{username: "admin", expected: authorizer.DecisionAllow},
+		{username: "contractor", expected: authorizer.DecisionDeny, reason: "contractors can't access workspace finance"},
+		{username: "developer", expected: authorizer.DecisionNoOpinion},
+	}
+	for _, test := range tests {
+		t.Run(test.username, func(t *testing.T) {
+			decision, reason, err := webhookAuthorizer.Authorize(attributes(test.username))
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, decision)
+			assert.Equal(t, test.reason, reason)
+		})
+	}
+
+	// the decisions are cached
+	decision, _, err := webhookAuthorizer.Authorize(attributes("contractor"))
+	assert.NoError(t, err)
+	assert.Equal(t, authorizer.DecisionDeny, decision)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestWebhookAuthorizerFailurePolicy(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	attributes := authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "admin"}, Verb: "get", Path: "/kapis/version"}
+
+	// the error is dropped if the webhook fails open, so that the request is forbidden rather than failed
+	// if the next authorizers have no opinion either
+	decision, _, err := union.New(newTestAuthorizer(t, server.URL, authorization.WebhookFailurePolicyIgnore),
+		authorizerfactory.NewAlwaysDenyAuthorizer()).Authorize(attributes)
+	assert.NoError(t, err)
+	assert.Equal(t, authorizer.DecisionNoOpinion, decision)
+
+	decision, _, err = newTestAuthorizer(t, server.URL, authorization.WebhookFailurePolicyFail).Authorize(attributes)
+	assert.Error(t, err)
+	assert.Equal(t, authorizer.DecisionDeny, decision)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authorization
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// WebhookFailurePolicyIgnore leaves the decision to RBAC if the webhook fails, i.e. fail open.
+	WebhookFailurePolicyIgnore = "Ignore"
+	// WebhookFailurePolicyFail denies the request if the webhook fails, i.e. fail closed.
+	WebhookFailurePolicyFail = "Fail"
+)
+
+// WebhookOptions configures an external decision service which authorizes the requests with
+// SubjectAccessReview objects, the same as the authorization webhook of kube-apiserver.
+// The webhook is consulted before RBAC in the RBAC mode, it can grant or veto a request,
+// or return no opinion to leave the decision to RBAC.
+type WebhookOptions struct {
+	// ConfigFile is a kubeconfig file describing how to access the webhook, the server of the
+	// cluster is the URL of the webhook.
+	ConfigFile string `json:"configFile" yaml:"configFile"`
+	// Timeout is the maximum duration to wait for a decision, defaults to 5s.
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// AuthorizedTTL is the duration to cache the allowed decisions, 0 disables the caching.
+	AuthorizedTTL time.Duration `json:"authorizedTTL,omitempty" yaml:"authorizedTTL,omitempty"`
+	// UnauthorizedTTL is the duration to cache the denied and the no-opinion decisions, 0 disables the caching.
+	UnauthorizedTTL time.Duration `json:"unauthorizedTTL,omitempty" yaml:"unauthorizedTTL,omitempty"`
+	// FailurePolicy is either Ignore or Fail, defaults to Ignore.
+	FailurePolicy string `json:"failurePolicy,omitempty" yaml:"failurePolicy,omitempty"`
+}
+
+func (o *WebhookOptions) Validate() []error {
+	var errs []error
+	if o.ConfigFile == "" {
+		errs = append(errs, errors.New("authorization.webhook.configFile MUST not be empty"))
+	}
+	if o.Timeout < 0 || o.AuthorizedTTL < 0 || o.UnauthorizedTTL < 0 {
+		errs = append(errs, errors.New("authorization.webhook.timeout and the TTLs MUST not be negative"))
+	}
+	switch o.FailurePolicy {
+	case "", WebhookFailurePolicyIgnore, WebhookFailurePolicyFail:
+	default:
+		errs = append(errs, fmt.Errorf("authorization.webhook.failurePolicy MUST be one of %s, %s",
+			WebhookFailurePolicyIgnore, WebhookFailurePolicyFail))
+	}
+	return errs
+}
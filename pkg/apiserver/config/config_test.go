@@ -164,6 +164,11 @@ func newTestConfig() (*Config, error) {
 				AccessTokenMaxAge:            time.Hour * 24,
 				AccessTokenInactivityTimeout: 0,
 			},
+			PasswordPolicy:            authentication.NewPasswordPolicy(),
+			LockoutPolicy:             authentication.NewLockoutPolicy(),
+			SCIMOptions:               authentication.NewSCIMOptions(),
+			KubeconfigOptions:         authentication.NewKubeconfigOptions(),
+			PersonalAccessTokenMaxAge: time.Hour * 24 * 365,
 		},
 		MultiClusterOptions: multicluster.NewOptions(),
 		EventsOptions: &events.Options{
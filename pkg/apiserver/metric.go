@@ -16,6 +16,7 @@ package apiserver
 import (
 	compbasemetrics "k8s.io/component-base/metrics"
 
+	audit "kubesphere.io/kubesphere/pkg/apiserver/auditing"
 	"kubesphere.io/kubesphere/pkg/utils/metrics"
 )
 
@@ -53,4 +54,5 @@ func registerMetrics() {
 	for _, m := range metricsList {
 		metrics.MustRegister(m)
 	}
+	metrics.MustRegister(audit.Metrics...)
 }
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const continueTokenVersion = 3
+
+var ErrInvalidContinueToken = errors.New("invalid continue token")
+
+// ContinueToken records the last object of a page and the sort order of the query, the next page
+// starts after the object, so objects created or deleted between requests do not cause duplicates or gaps.
+// Only the sort keys in the object meta are recorded, Offset is the index of the next object which is used
+// instead if the query is sorted by another field and the last object is updated or deleted, or if the
+// objects have no object meta at all.
+type ContinueToken struct {
+	Version           int       `json:"v"`
+	SortBy            Field     `json:"s,omitempty"`
+	Ascending         bool      `json:"a,omitempty"`
+	Namespace         string    `json:"ns,omitempty"`
+	Name              string    `json:"n,omitempty"`
+	ResourceVersion   string    `json:"rv,omitempty"`
+	CreationTimestamp time.Time `json:"t,omitempty"`
+	Offset            int       `json:"i,omitempty"`
+}
+
+// Encode returns the opaque string of the continue token.
+func (t *ContinueToken) Encode() string {
+	t.Version = continueTokenVersion
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeContinueToken decodes the opaque continue token.
+func DecodeContinueToken(s string) (*ContinueToken, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidContinueToken
+	}
+
+	token := &ContinueToken{}
+	if err := json.Unmarshal(data, token); err != nil || token.Version != continueTokenVersion ||
+		(len(token.Name) == 0 && token.Offset <= 0) || token.Offset < 0 {
+		return nil, ErrInvalidContinueToken
+	}
+
+	return token, nil
+}
+
+// ContinueToken returns the continue token of the query, it returns nil if the query has no continue token.
+// An error is returned if the token is invalid or the query is sorted in a different order from the token.
+func (q *Query) ContinueToken() (*ContinueToken, error) {
+	if len(q.Continue) == 0 {
+		return nil, nil
+	}
+
+	token, err := DecodeContinueToken(q.Continue)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.SortBy != q.SortBy || token.Ascending != q.Ascending {
+		return nil, errors.New("the sort order of the query does not match the continue token")
+	}
+
+	return token, nil
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package query
 
 import (
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/emicklei/go-restful"
 	"k8s.io/apimachinery/pkg/labels"
@@ -33,8 +35,14 @@ const (
 	ParameterLimit         = "limit"
 	ParameterOrderBy       = "sortBy"
 	ParameterAscending     = "ascending"
+	ParameterContinue      = "continue"
+	ParameterFields        = "fields"
+	ParameterFormat        = "format"
 )
 
+// FormatTable returns the list in the format of the Kubernetes Table
+const FormatTable = "table"
+
 // Query represents api search terms
 type Query struct {
 	Pagination *Pagination
@@ -45,10 +53,22 @@ type Query struct {
 	// sort result in ascending or descending order, default to descending
 	Ascending bool
 
-	//
+	// filters with a single value, the object matches if it matches all the filters
 	Filters map[Field]Value
 
+	// conditions with multiple values or negated, the object matches if it matches all the conditions
+	Conditions []Condition
+
 	LabelSelector string
+
+	// the opaque continue token returned in the previous page, the offset is ignored if it is set
+	Continue string
+
+	// field paths of the objects to return, e.g. metadata.name, the whole objects are returned if it is empty
+	Fields []string
+
+	// format of the list, e.g. table, default to the ListResult
+	Format string
 }
 
 type Pagination struct {
@@ -113,6 +133,102 @@ type Filter struct {
 	Value Value
 }
 
+// Condition matches a field against multiple values, e.g. ?status=running&status=pending
+// or ?status=in(running,pending). The object matches if it matches any of the values, a negated
+// condition, e.g. ?status!=running or ?status=notin(running,pending), matches if none of them matches.
+type Condition struct {
+	Field   Field
+	Values  []Value
+	Negated bool
+}
+
+// Match returns true if the object matches all the filters and conditions of the query,
+// matchFunc reports whether the object matches a single filter.
+func (q *Query) Match(matchFunc func(Filter) bool) bool {
+	for field, value := range q.Filters {
+		if !matchFunc(Filter{Field: field, Value: value}) {
+			return false
+		}
+	}
+
+	for _, condition := range q.Conditions {
+		matched := false
+		for _, value := range condition.Values {
+			if matchFunc(Filter{Field: condition.Field, Value: value}) {
+				matched = true
+				break
+			}
+		}
+
+		if matched == condition.Negated {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Values returns the values of the field allowed by all of its filters and conditions, it is for the fields
+// selecting where to list the objects from instead of being matched against the objects, e.g. the scopes
+// of the role bindings. The excluded values are the values of the negated conditions if the field has
+// no other filter or condition, otherwise they are already removed from the values.
+func (q *Query) Values(field Field) (values []Value, excluded []Value) {
+	var included [][]Value
+	if value, ok := q.Filters[field]; ok {
+		included = append(included, []Value{value})
+	}
+	for _, condition := range q.Conditions {
+		if condition.Field != field {
+			continue
+		}
+		if condition.Negated {
+			excluded = append(excluded, condition.Values...)
+		} else {
+			included = append(included, condition.Values)
+		}
+	}
+	if len(included) == 0 {
+		return nil, excluded
+	}
+
+	for _, value := range included[0] {
+		allowed := !containsValue(excluded, value) && !containsValue(values, value)
+		for _, other := range included[1:] {
+			allowed = allowed && containsValue(other, value)
+		}
+		if allowed {
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// Delete removes the filter and the conditions of the field.
+func (q *Query) Delete(field Field) {
+	delete(q.Filters, field)
+	conditions := q.Conditions[:0]
+	for _, condition := range q.Conditions {
+		if condition.Field != field {
+			conditions = append(conditions, condition)
+		}
+	}
+	q.Conditions = conditions
+}
+
+func containsValue(values []Value, value Value) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFilters returns true if the query has any filter or condition.
+func (q *Query) HasFilters() bool {
+	return len(q.Filters) != 0 || len(q.Conditions) != 0
+}
+
 func ParseQueryParameter(request *restful.Request) *Query {
 	query := New()
 
@@ -140,18 +256,87 @@ func ParseQueryParameter(request *restful.Request) *Query {
 
 	query.LabelSelector = request.QueryParameter(ParameterLabelSelector)
 
+	query.Continue = request.QueryParameter(ParameterContinue)
+
+	// ?fields=metadata.name,status.phase or ?fields=metadata.name&fields=status.phase
+	for _, value := range request.Request.URL.Query()[ParameterFields] {
+		for _, field := range strings.Split(value, ",") {
+			if field = strings.TrimSpace(field); len(field) > 0 {
+				query.Fields = append(query.Fields, field)
+			}
+		}
+	}
+
+	query.Format = strings.ToLower(request.QueryParameter(ParameterFormat))
+	// the Table format can also be requested as the Kubernetes API, e.g. Accept: application/json;as=Table;v=v1;g=meta.k8s.io
+	if len(query.Format) == 0 && strings.Contains(request.HeaderParameter("Accept"), "as=Table") {
+		query.Format = FormatTable
+	}
+
 	for key, values := range request.Request.URL.Query() {
-		if !sliceutil.HasString([]string{ParameterPage, ParameterLimit, ParameterOrderBy, ParameterAscending, ParameterLabelSelector}, key) {
-			// support multiple query condition
+		if !sliceutil.HasString([]string{ParameterPage, ParameterLimit, ParameterOrderBy, ParameterAscending, ParameterLabelSelector, ParameterContinue, ParameterFields, ParameterFormat}, key) {
+			// ?status!=running is parsed as the key "status!"
+			field, negated := Field(strings.TrimSuffix(key, "!")), strings.HasSuffix(key, "!")
+
+			var included, excluded []Value
 			for _, value := range values {
-				query.Filters[Field(key)] = Value(value)
+				setValues, notIn, ok := parseSetValue(field, value)
+				if !ok {
+					setValues, notIn = []Value{Value(value)}, false
+				}
+
+				if notIn != negated {
+					excluded = append(excluded, setValues...)
+				} else {
+					included = append(included, setValues...)
+				}
+			}
+
+			// a single value is kept in the filters for compatibility
+			if len(included) == 1 {
+				query.Filters[field] = included[0]
+			} else if len(included) > 1 {
+				query.Conditions = append(query.Conditions, Condition{Field: field, Values: included})
+			}
+
+			if len(excluded) > 0 {
+				query.Conditions = append(query.Conditions, Condition{Field: field, Values: excluded, Negated: true})
 			}
 		}
 	}
 
+	sort.SliceStable(query.Conditions, func(i, j int) bool {
+		return query.Conditions[i].Field < query.Conditions[j].Field
+	})
+
 	return query
 }
 
+// parseSetValue parses the value in format in(a,b) or notin(a,b), the label and annotation
+// filters are excluded since they are label selectors.
+func parseSetValue(field Field, value string) (values []Value, notIn bool, ok bool) {
+	if field == FieldLabel || field == FieldAnnotation || !strings.HasSuffix(value, ")") {
+		return nil, false, false
+	}
+
+	var set string
+	if strings.HasPrefix(value, "in(") {
+		set = strings.TrimSuffix(strings.TrimPrefix(value, "in("), ")")
+	} else if strings.HasPrefix(value, "notin(") {
+		set, notIn = strings.TrimSuffix(strings.TrimPrefix(value, "notin("), ")"), true
+	} else {
+		return nil, false, false
+	}
+
+	for _, v := range strings.Split(set, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			values = append(values, Value(v))
+		}
+	}
+
+	return values, notIn, true
+}
+
 func defaultString(value, defaultValue string) string {
 	if len(value) == 0 {
 		return defaultValue
@@ -58,6 +58,38 @@ func TestParseQueryParameter(t *testing.T) {
 				},
 			},
 		},
+		{
+			"test multiple values and negation",
+			"status=running&status=pending&namespace!=kube-system&namespace!=default&name=notin(foo,bar)&uid=in(a,b)&label=app in (a,b)",
+			&Query{
+				Pagination: NoPagination,
+				SortBy:     FieldCreationTimeStamp,
+				Ascending:  false,
+				Filters: map[Field]Value{
+					FieldLabel: Value("app in (a,b)"),
+				},
+				Conditions: []Condition{
+					{Field: FieldName, Values: []Value{"foo", "bar"}, Negated: true},
+					{Field: FieldNamespace, Values: []Value{"kube-system", "default"}, Negated: true},
+					{Field: FieldStatus, Values: []Value{"running", "pending"}},
+					{Field: FieldUID, Values: []Value{"a", "b"}},
+				},
+			},
+		},
+		{
+			"test fields and format",
+			"fields=metadata.name,metadata.labels&fields=status.phase&format=Table&name=foo",
+			&Query{
+				Pagination: NoPagination,
+				SortBy:     FieldCreationTimeStamp,
+				Ascending:  false,
+				Filters: map[Field]Value{
+					FieldName: Value("foo"),
+				},
+				Fields: []string{"metadata.name", "metadata.labels", "status.phase"},
+				Format: FormatTable,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -79,3 +111,171 @@ func TestParseQueryParameter(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryMatch(t *testing.T) {
+	object := map[Field]Value{
+		FieldName:   "foo",
+		FieldStatus: "running",
+	}
+	matchFunc := func(filter Filter) bool {
+		return object[filter.Field] == filter.Value
+	}
+
+	tests := []struct {
+		description string
+		query       *Query
+		expected    bool
+	}{
+		{
+			"no filters",
+			New(),
+			true,
+		},
+		{
+			"any of the values matches",
+			&Query{Conditions: []Condition{{Field: FieldStatus, Values: []Value{"pending", "running"}}}},
+			true,
+		},
+		{
+			"none of the values matches",
+			&Query{Conditions: []Condition{{Field: FieldStatus, Values: []Value{"pending", "failed"}}}},
+			false,
+		},
+		{
+			"negated value matches",
+			&Query{Conditions: []Condition{{Field: FieldStatus, Values: []Value{"pending", "running"}, Negated: true}}},
+			false,
+		},
+		{
+			"negated value does not match",
+			&Query{Conditions: []Condition{{Field: FieldStatus, Values: []Value{"pending"}, Negated: true}}},
+			true,
+		},
+		{
+			"filters and conditions are all required",
+			&Query{
+				Filters:    map[Field]Value{FieldName: "bar"},
+				Conditions: []Condition{{Field: FieldStatus, Values: []Value{"running"}}},
+			},
+			false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			if got := test.query.Match(matchFunc); got != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestQueryValues(t *testing.T) {
+	tests := []struct {
+		description      string
+		query            *Query
+		expectedValues   []Value
+		expectedExcluded []Value
+	}{
+		{
+			"no filters",
+			New(),
+			nil,
+			nil,
+		},
+		{
+			"single value",
+			&Query{Filters: map[Field]Value{FieldName: "foo"}},
+			[]Value{"foo"},
+			nil,
+		},
+		{
+			"multiple values",
+			&Query{Conditions: []Condition{{Field: FieldName, Values: []Value{"foo", "bar", "foo"}}}},
+			[]Value{"foo", "bar"},
+			nil,
+		},
+		{
+			"filters and conditions are all required",
+			&Query{
+				Filters: map[Field]Value{FieldName: "foo"},
+				Conditions: []Condition{
+					{Field: FieldName, Values: []Value{"foo", "bar"}},
+					{Field: FieldStatus, Values: []Value{"running"}},
+				},
+			},
+			[]Value{"foo"},
+			nil,
+		},
+		{
+			"negated values are removed",
+			&Query{Conditions: []Condition{
+				{Field: FieldName, Values: []Value{"foo", "bar"}},
+				{Field: FieldName, Values: []Value{"foo"}, Negated: true},
+			}},
+			[]Value{"bar"},
+			nil,
+		},
+		{
+			"negated values only",
+			&Query{Conditions: []Condition{{Field: FieldName, Values: []Value{"foo"}, Negated: true}}},
+			nil,
+			[]Value{"foo"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			values, excluded := test.query.Values(FieldName)
+			if diff := cmp.Diff(test.expectedValues, values); diff != "" {
+				t.Errorf("values differ (-want, +got): %s", diff)
+			}
+			if diff := cmp.Diff(test.expectedExcluded, excluded); diff != "" {
+				t.Errorf("excluded values differ (-want, +got): %s", diff)
+			}
+		})
+	}
+
+	q := &Query{
+		Filters:    map[Field]Value{FieldName: "foo"},
+		Conditions: []Condition{{Field: FieldName, Values: []Value{"bar"}}, {Field: FieldStatus, Values: []Value{"running"}}},
+	}
+	q.Delete(FieldName)
+	if len(q.Filters) != 0 || len(q.Conditions) != 1 || q.Conditions[0].Field != FieldStatus {
+		t.Errorf("unexpected query after delete %+v", q)
+	}
+}
+
+func TestContinueToken(t *testing.T) {
+	token := &ContinueToken{
+		SortBy:    FieldCreationTimeStamp,
+		Namespace: "default",
+		Name:      "foo",
+	}
+
+	q := &Query{SortBy: FieldCreationTimeStamp, Continue: token.Encode()}
+	got, err := q.ContinueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(token, got); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", token, diff)
+	}
+
+	q.Ascending = true
+	if _, err := q.ContinueToken(); err == nil {
+		t.Errorf("expected error for continue token with different sort order")
+	}
+
+	// objects without object meta are paged by the offset
+	token = &ContinueToken{Offset: 10}
+	if got, err = DecodeContinueToken(token.Encode()); err != nil || got.Offset != 10 {
+		t.Errorf("expected continue token with offset 10, got %+v, %v", got, err)
+	}
+
+	for _, invalid := range []string{"foo", "e30", (&ContinueToken{Offset: -1, Name: "foo"}).Encode()} {
+		if _, err := DecodeContinueToken(invalid); err != ErrInvalidContinueToken {
+			t.Errorf("expected invalid continue token error for %s, got %v", invalid, err)
+		}
+	}
+}
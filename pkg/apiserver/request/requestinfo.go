@@ -22,6 +22,7 @@ package request
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
@@ -81,6 +82,10 @@ type RequestInfo struct {
 	// Source IP
 	SourceIP string
 
+	// ClientIP is the source IP which can't be spoofed, the forwarding headers are only honoured
+	// if the request is sent by the trusted proxies. It's used to throttle the clients.
+	ClientIP string
+
 	// User agent
 	UserAgent string
 }
@@ -89,6 +94,8 @@ type RequestInfoFactory struct {
 	APIPrefixes          sets.String
 	GrouplessAPIPrefixes sets.String
 	GlobalResources      []schema.GroupResource
+	// TrustedProxies are the networks of the proxies whose forwarding headers are honoured to find the ClientIP.
+	TrustedProxies []*net.IPNet
 }
 
 // NewRequestInfo returns the information from the http request.  If error is not nil, RequestInfo holds the information as best it is known before the failure
@@ -128,6 +135,7 @@ func (r *RequestInfoFactory) NewRequestInfo(req *http.Request) (*RequestInfo, er
 		Workspace: api.WorkspaceNone,
 		Cluster:   api.ClusterNone,
 		SourceIP:  iputil.RemoteIp(req),
+		ClientIP:  iputil.ClientIP(req, r.TrustedProxies),
 		UserAgent: req.UserAgent(),
 	}
 
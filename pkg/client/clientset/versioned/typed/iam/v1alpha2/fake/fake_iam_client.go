@@ -28,6 +28,10 @@ type FakeIamV1alpha2 struct {
 	*testing.Fake
 }
 
+func (c *FakeIamV1alpha2) AccessTokens() v1alpha2.AccessTokenInterface {
+	return &FakeAccessTokens{c}
+}
+
 func (c *FakeIamV1alpha2) GlobalRoles() v1alpha2.GlobalRoleInterface {
 	return &FakeGlobalRoles{c}
 }
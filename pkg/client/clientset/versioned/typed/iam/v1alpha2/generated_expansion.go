@@ -18,6 +18,8 @@ limitations under the License.
 
 package v1alpha2
 
+type AccessTokenExpansion interface{}
+
 type GlobalRoleExpansion interface{}
 
 type GlobalRoleBindingExpansion interface{}
@@ -26,6 +26,7 @@ import (
 
 type IamV1alpha2Interface interface {
 	RESTClient() rest.Interface
+	AccessTokensGetter
 	GlobalRolesGetter
 	GlobalRoleBindingsGetter
 	GroupsGetter
@@ -42,6 +43,10 @@ type IamV1alpha2Client struct {
 	restClient rest.Interface
 }
 
+func (c *IamV1alpha2Client) AccessTokens() AccessTokenInterface {
+	return newAccessTokens(c)
+}
+
 func (c *IamV1alpha2Client) GlobalRoles() GlobalRoleInterface {
 	return newGlobalRoles(c)
 }
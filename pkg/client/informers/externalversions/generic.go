@@ -115,6 +115,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Devops().V1alpha3().Pipelines().Informer()}, nil
 
 		// Group=iam.kubesphere.io, Version=v1alpha2
+	case v1alpha2.SchemeGroupVersion.WithResource("accesstokens"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Iam().V1alpha2().AccessTokens().Informer()}, nil
 	case v1alpha2.SchemeGroupVersion.WithResource("globalroles"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Iam().V1alpha2().GlobalRoles().Informer()}, nil
 	case v1alpha2.SchemeGroupVersion.WithResource("globalrolebindings"):
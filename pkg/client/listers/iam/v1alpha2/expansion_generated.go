@@ -18,6 +18,10 @@ limitations under the License.
 
 package v1alpha2
 
+// AccessTokenListerExpansion allows custom methods to be added to
+// AccessTokenLister.
+type AccessTokenListerExpansion interface{}
+
 // GlobalRoleListerExpansion allows custom methods to be added to
 // GlobalRoleLister.
 type GlobalRoleListerExpansion interface{}
@@ -54,6 +54,7 @@ const (
 	AuthenticationTag = "Authentication"
 	UserTag           = "User"
 	GroupTag          = "Group"
+	SCIMTag           = "SCIM"
 
 	WorkspaceMemberTag     = "Workspace Member"
 	DevOpsProjectMemberTag = "DevOps Project Member"
@@ -0,0 +1,348 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package savedsearch
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	searchv1alpha1 "kubesphere.io/api/search/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
+	"kubesphere.io/kubesphere/pkg/simple/client/events"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/simple/client/notification"
+)
+
+const (
+	controllerName = "savedsearch-controller"
+
+	// MinInterval protects the log stores from the searches evaluated too often
+	MinInterval = time.Minute
+
+	AlertLabelAlertName   = "alertname"
+	AlertLabelAlertType   = "alerttype"
+	AlertLabelNamespace   = "namespace"
+	AlertLabelSeverity    = "severity"
+	AlertLabelSavedSearch = "savedsearch"
+	AlertTypeSavedSearch  = "savedsearch"
+
+	reasonEvaluationFailed   = "EvaluationFailed"
+	reasonNotificationFailed = "NotificationFailed"
+	reasonFiring             = "Firing"
+	reasonResolved           = "Resolved"
+)
+
+// Reconciler evaluates the alerts of the SavedSearches periodically
+type Reconciler struct {
+	client.Client
+	Logger                  logr.Logger
+	Recorder                record.EventRecorder
+	MaxConcurrentReconciles int
+
+	// The clients are nil if the corresponding components are disabled,
+	// the searches of the disabled components fail to evaluate then.
+	LoggingClient      logging.Client
+	EventsClient       events.Client
+	AuditingClient     auditing.Client
+	NotificationClient notification.Client
+
+	// now is replaced in the tests
+	now func() time.Time
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger.GetSink() == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	if r.MaxConcurrentReconciles <= 0 {
+		r.MaxConcurrentReconciles = 1
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		}).
+		// the status updated after each evaluation should not trigger another one
+		For(&searchv1alpha1.SavedSearch{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=search.kubesphere.io,resources=savedsearches,verbs=get;list;watch
+// +kubebuilder:rbac:groups=search.kubesphere.io,resources=savedsearches/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("savedsearch", req.NamespacedName)
+	search := &searchv1alpha1.SavedSearch{}
+	if err := r.Get(ctx, req.NamespacedName, search); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !search.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	now := r.clock()
+	alert := search.Spec.Alert
+	if alert == nil {
+		// the alert was removed, resolve it if it's firing
+		if !search.Status.Firing {
+			return ctrl.Result{}, nil
+		}
+		if err := r.notify(ctx, search, false, now); err != nil {
+			return ctrl.Result{}, err
+		}
+		search.Status = searchv1alpha1.SavedSearchStatus{}
+		return ctrl.Result{}, r.Status().Update(ctx, search)
+	}
+
+	interval := alert.Interval.Duration
+	if interval < MinInterval {
+		interval = MinInterval
+	}
+	if last := search.Status.LastEvaluationTime; last != nil {
+		if next := last.Add(interval); now.Before(next) {
+			return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+		}
+	}
+
+	window := interval
+	if alert.Window != nil && alert.Window.Duration > 0 {
+		window = alert.Window.Duration
+	}
+
+	search.Status.LastEvaluationTime = &metav1.Time{Time: now}
+	count, err := r.count(ctx, search, now.Add(-window), now, window)
+	if err != nil {
+		logger.Error(err, "failed to evaluate saved search")
+		r.Recorder.Event(search, corev1.EventTypeWarning, reasonEvaluationFailed, err.Error())
+		search.Status.LastError = err.Error()
+	} else {
+		search.Status.LastError = ""
+		search.Status.LastCount = &count
+		firing := compare(alert.Comparison, count, alert.Threshold)
+		if firing != search.Status.Firing {
+			if err := r.notify(ctx, search, firing, now); err != nil {
+				// keep the state, the notification is sent again in the next evaluation
+				logger.Error(err, "failed to send notification")
+				r.Recorder.Event(search, corev1.EventTypeWarning, reasonNotificationFailed, err.Error())
+				search.Status.LastError = err.Error()
+			} else if firing {
+				r.Recorder.Eventf(search, corev1.EventTypeWarning, reasonFiring, "%d records matched in the last %s", count, window)
+				search.Status.Firing = true
+				search.Status.FiringSince = &metav1.Time{Time: now}
+			} else {
+				r.Recorder.Eventf(search, corev1.EventTypeNormal, reasonResolved, "%d records matched in the last %s", count, window)
+				search.Status.Firing = false
+				search.Status.FiringSince = nil
+			}
+		}
+	}
+
+	if err := r.Status().Update(ctx, search); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: interval}, nil
+}
+
+func (r *Reconciler) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// count returns the number of the records matched by the search in the namespace of the search between start and end,
+// the records of the deleted namespace with the same name are excluded.
+func (r *Reconciler) count(ctx context.Context, search *searchv1alpha1.SavedSearch, start, end time.Time, window time.Duration) (int64, error) {
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: search.Namespace}, namespace); err != nil {
+		return 0, err
+	}
+	createTime := namespace.CreationTimestamp.Time
+	// a single bucket covers the whole window
+	interval := fmt.Sprintf("%ds", int64(window/time.Second))
+
+	switch search.Spec.Type {
+	case searchv1alpha1.SearchTypeLogs:
+		if r.LoggingClient == nil {
+			return 0, fmt.Errorf("logging is not enabled")
+		}
+		q := search.Spec.Logs
+		if q == nil {
+			q = &searchv1alpha1.LogQuery{}
+		}
+		sf := logging.SearchFilter{
+			NamespaceFilter: map[string]*time.Time{search.Namespace: &createTime},
+			WorkloadSearch:  q.Workloads,
+			WorkloadFilter:  q.WorkloadQuery,
+			PodSearch:       q.Pods,
+			PodFilter:       q.PodQuery,
+			ContainerSearch: q.Containers,
+			ContainerFilter: q.ContainerQuery,
+			LogSearch:       q.Keywords,
+			Starttime:       start,
+			Endtime:         end,
+		}
+		h, err := r.LoggingClient.CountLogsByInterval(sf, interval)
+		if err != nil {
+			return 0, err
+		}
+		return h.Total, nil
+	case searchv1alpha1.SearchTypeEvents:
+		if r.EventsClient == nil {
+			return 0, fmt.Errorf("events is not enabled")
+		}
+		q := search.Spec.Events
+		if q == nil {
+			q = &searchv1alpha1.EventQuery{}
+		}
+		filter := &events.Filter{
+			InvolvedObjectNamespaceMap: map[string]time.Time{search.Namespace: createTime},
+			InvolvedObjectNames:        q.InvolvedObjectNames,
+			InvolvedObjectNameFuzzy:    q.InvolvedObjectNameQuery,
+			InvolvedObjectkinds:        q.InvolvedObjectKinds,
+			Reasons:                    q.Reasons,
+			ReasonFuzzy:                q.ReasonQuery,
+			MessageFuzzy:               q.MessageQuery,
+			Type:                       q.Type,
+			StartTime:                  start,
+			EndTime:                    end,
+		}
+		h, err := r.EventsClient.CountOverTime(filter, interval)
+		if err != nil {
+			return 0, err
+		}
+		return h.Total, nil
+	case searchv1alpha1.SearchTypeAuditing:
+		if r.AuditingClient == nil {
+			return 0, fmt.Errorf("auditing is not enabled")
+		}
+		q := search.Spec.Auditing
+		if q == nil {
+			q = &searchv1alpha1.AuditingQuery{}
+		}
+		filter := &auditing.Filter{
+			ObjectRefNamespaceMap: map[string]time.Time{search.Namespace: createTime},
+			ObjectRefNames:        q.ObjectRefNames,
+			ObjectRefNameFuzzy:    q.ObjectRefNameQuery,
+			ObjectRefResources:    q.ObjectRefResources,
+			ObjectRefSubresources: q.ObjectRefSubresources,
+			Verbs:                 q.Verbs,
+			Levels:                q.Levels,
+			Users:                 q.Users,
+			UserFuzzy:             q.UserQuery,
+			ResponseCodes:         q.ResponseCodes,
+			ResponseStatus:        q.ResponseStatus,
+			StartTime:             start,
+			EndTime:               end,
+		}
+		h, err := r.AuditingClient.CountOverTime(filter, interval)
+		if err != nil {
+			return 0, err
+		}
+		return h.Total, nil
+	default:
+		return 0, fmt.Errorf("unknown search type %s", search.Spec.Type)
+	}
+}
+
+func (r *Reconciler) notify(ctx context.Context, search *searchv1alpha1.SavedSearch, firing bool, now time.Time) error {
+	if r.NotificationClient == nil {
+		return fmt.Errorf("notification is not enabled")
+	}
+	return r.NotificationClient.SendAlerts(ctx, newAlert(search, firing, now))
+}
+
+func newAlert(search *searchv1alpha1.SavedSearch, firing bool, now time.Time) *notification.Alert {
+	alert := &notification.Alert{
+		Labels:      make(map[string]string),
+		Annotations: make(map[string]string),
+	}
+
+	var count int64
+	if search.Status.LastCount != nil {
+		count = *search.Status.LastCount
+	}
+	message := fmt.Sprintf("%d %s matched by the saved search %s", count, search.Spec.Type, search.Name)
+
+	spec := search.Spec.Alert
+	if spec != nil {
+		for k, v := range spec.Labels {
+			alert.Labels[k] = v
+		}
+		if spec.Severity != "" {
+			alert.Labels[AlertLabelSeverity] = spec.Severity
+		}
+		if spec.Message != "" {
+			message = spec.Message
+		}
+	}
+	// set after the labels of the spec, so the alert can't be routed to the receivers of other namespaces
+	alert.Labels[AlertLabelAlertName] = search.Name
+	alert.Labels[AlertLabelAlertType] = AlertTypeSavedSearch
+	alert.Labels[AlertLabelNamespace] = search.Namespace
+	alert.Labels[AlertLabelSavedSearch] = search.Name
+
+	alert.Annotations["message"] = message
+	if search.Spec.Description != "" {
+		alert.Annotations["description"] = search.Spec.Description
+	}
+
+	if search.Status.FiringSince != nil {
+		alert.StartsAt = search.Status.FiringSince.Time
+	} else {
+		alert.StartsAt = now
+	}
+	if firing {
+		alert.Status = notification.AlertStatusFiring
+	} else {
+		alert.Status = notification.AlertStatusResolved
+		alert.EndsAt = now
+	}
+	return alert
+}
+
+func compare(op searchv1alpha1.ComparisonOperator, count, threshold int64) bool {
+	switch op {
+	case searchv1alpha1.ComparisonGreaterThan:
+		return count > threshold
+	case searchv1alpha1.ComparisonLessThan:
+		return count < threshold
+	case searchv1alpha1.ComparisonLessThanOrEqual:
+		return count <= threshold
+	default:
+		return count >= threshold
+	}
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package savedsearch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	searchv1alpha1 "kubesphere.io/api/search/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/events"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/simple/client/notification"
+)
+
+type fakeLoggingClient struct {
+	total   int64
+	filters []logging.SearchFilter
+}
+
+func (c *fakeLoggingClient) GetCurrentStats(sf logging.SearchFilter) (logging.Statistics, error) {
+	return logging.Statistics{}, nil
+}
+
+func (c *fakeLoggingClient) CountLogsByInterval(sf logging.SearchFilter, interval string) (logging.Histogram, error) {
+	c.filters = append(c.filters, sf)
+	return logging.Histogram{Total: c.total}, nil
+}
+
+func (c *fakeLoggingClient) SearchLogs(sf logging.SearchFilter, from, size int64, order string) (logging.Logs, error) {
+	return logging.Logs{}, nil
+}
+
+func (c *fakeLoggingClient) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
+	return nil
+}
+
+func (c *fakeLoggingClient) GetLogContext(cf logging.ContextFilter) (logging.LogContext, error) {
+	return logging.LogContext{}, nil
+}
+
+func (c *fakeLoggingClient) CountFieldValues(sf logging.SearchFilter, fields []string, size int64) (logging.Facets, error) {
+	return logging.Facets{}, nil
+}
+
+type fakeEventsClient struct {
+	total int64
+}
+
+func (c *fakeEventsClient) SearchEvents(filter *events.Filter, from, size int64, sort string) (*events.Events, error) {
+	return &events.Events{}, nil
+}
+
+func (c *fakeEventsClient) CountOverTime(filter *events.Filter, interval string) (*events.Histogram, error) {
+	return &events.Histogram{Total: c.total}, nil
+}
+
+func (c *fakeEventsClient) StatisticsOnResources(filter *events.Filter) (*events.Statistics, error) {
+	return &events.Statistics{}, nil
+}
+
+type fakeNotificationClient struct {
+	alerts []*notification.Alert
+	err    error
+}
+
+func (c *fakeNotificationClient) SendAlerts(ctx context.Context, alerts ...*notification.Alert) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.alerts = append(c.alerts, alerts...)
+	return nil
+}
+
+func newReconciler(t *testing.T, search *searchv1alpha1.SavedSearch, now *time.Time) *Reconciler {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := searchv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              search.Namespace,
+		CreationTimestamp: metav1.NewTime(now.Add(-24 * time.Hour)),
+	}}
+	return &Reconciler{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(ns, search).Build(),
+		Logger:   ctrl.Log.WithName("controllers").WithName(controllerName),
+		Recorder: record.NewFakeRecorder(10),
+		now:      func() time.Time { return *now },
+	}
+}
+
+func reconcileSearch(t *testing.T, r *Reconciler, search *searchv1alpha1.SavedSearch) (ctrl.Result, *searchv1alpha1.SavedSearch) {
+	key := types.NamespacedName{Namespace: search.Namespace, Name: search.Name}
+	result, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &searchv1alpha1.SavedSearch{}
+	if err := r.Get(context.Background(), key, got); err != nil {
+		t.Fatal(err)
+	}
+	return result, got
+}
+
+func TestReconcileLogs(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	search := &searchv1alpha1.SavedSearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "errors", Namespace: "demo"},
+		Spec: searchv1alpha1.SavedSearchSpec{
+			Type: searchv1alpha1.SearchTypeLogs,
+			Logs: &searchv1alpha1.LogQuery{
+				Workloads: []string{"api"},
+				Keywords:  []string{"error"},
+			},
+			Alert: &searchv1alpha1.AlertSpec{
+				Interval:  metav1.Duration{Duration: 5 * time.Minute},
+				Threshold: 10,
+				Severity:  "error",
+				Labels:    map[string]string{"team": "api", AlertLabelNamespace: "kube-system"},
+			},
+		},
+	}
+
+	r := newReconciler(t, search, &now)
+	loggingClient := &fakeLoggingClient{total: 12}
+	notificationClient := &fakeNotificationClient{}
+	r.LoggingClient = loggingClient
+	r.NotificationClient = notificationClient
+
+	result, got := reconcileSearch(t, r, search)
+	if result.RequeueAfter != 5*time.Minute {
+		t.Errorf("expected to requeue after 5m, got %s", result.RequeueAfter)
+	}
+	if !got.Status.Firing || got.Status.FiringSince == nil || *got.Status.LastCount != 12 || got.Status.LastError != "" {
+		t.Fatalf("unexpected status %+v", got.Status)
+	}
+	sf := loggingClient.filters[0]
+	if !sf.Starttime.Equal(now.Add(-5*time.Minute)) || !sf.Endtime.Equal(now) ||
+		len(sf.NamespaceFilter) != 1 || sf.NamespaceFilter["demo"] == nil ||
+		sf.WorkloadSearch[0] != "api" || sf.LogSearch[0] != "error" {
+		t.Errorf("unexpected search filter %+v", sf)
+	}
+	if len(notificationClient.alerts) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(notificationClient.alerts))
+	}
+	alert := notificationClient.alerts[0]
+	if alert.Status != notification.AlertStatusFiring || alert.Labels[AlertLabelNamespace] != "demo" ||
+		alert.Labels[AlertLabelAlertName] != "errors" || alert.Labels["team"] != "api" || alert.Labels[AlertLabelSeverity] != "error" {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+
+	// not evaluated again within the interval
+	now = now.Add(time.Minute)
+	result, _ = reconcileSearch(t, r, got)
+	if result.RequeueAfter != 4*time.Minute || len(loggingClient.filters) != 1 {
+		t.Errorf("expected to requeue after 4m without evaluation, got %s", result.RequeueAfter)
+	}
+
+	// still firing, no notification is sent again
+	now = now.Add(4 * time.Minute)
+	_, got = reconcileSearch(t, r, got)
+	if !got.Status.Firing || len(notificationClient.alerts) != 1 {
+		t.Errorf("expected to keep firing without notification, got %+v", got.Status)
+	}
+
+	// resolved
+	loggingClient.total = 3
+	now = now.Add(5 * time.Minute)
+	_, got = reconcileSearch(t, r, got)
+	if got.Status.Firing || got.Status.FiringSince != nil || *got.Status.LastCount != 3 {
+		t.Errorf("expected to be resolved, got %+v", got.Status)
+	}
+	if len(notificationClient.alerts) != 2 || notificationClient.alerts[1].Status != notification.AlertStatusResolved {
+		t.Errorf("expected a resolved alert, got %+v", notificationClient.alerts)
+	}
+}
+
+func TestReconcileFailures(t *testing.T) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	search := &searchv1alpha1.SavedSearch{
+		ObjectMeta: metav1.ObjectMeta{Name: "warnings", Namespace: "demo"},
+		Spec: searchv1alpha1.SavedSearchSpec{
+			Type:   searchv1alpha1.SearchTypeEvents,
+			Events: &searchv1alpha1.EventQuery{Type: "Warning"},
+			Alert: &searchv1alpha1.AlertSpec{
+				Interval:   metav1.Duration{Duration: 10 * time.Second},
+				Threshold:  5,
+				Comparison: searchv1alpha1.ComparisonGreaterThan,
+			},
+		},
+	}
+
+	// events is disabled
+	r := newReconciler(t, search, &now)
+	notificationClient := &fakeNotificationClient{err: fmt.Errorf("connection refused")}
+	r.NotificationClient = notificationClient
+	result, got := reconcileSearch(t, r, search)
+	if result.RequeueAfter != MinInterval {
+		t.Errorf("expected to requeue after the min interval, got %s", result.RequeueAfter)
+	}
+	if got.Status.LastError != "events is not enabled" || got.Status.LastEvaluationTime == nil {
+		t.Errorf("unexpected status %+v", got.Status)
+	}
+
+	// the notification fails, the alert fires in the next evaluation
+	r.EventsClient = &fakeEventsClient{total: 6}
+	now = now.Add(MinInterval)
+	_, got = reconcileSearch(t, r, got)
+	if got.Status.Firing || got.Status.LastError != "connection refused" {
+		t.Errorf("expected not firing because of the notification error, got %+v", got.Status)
+	}
+
+	notificationClient.err = nil
+	now = now.Add(MinInterval)
+	_, got = reconcileSearch(t, r, got)
+	if !got.Status.Firing || got.Status.LastError != "" || len(notificationClient.alerts) != 1 {
+		t.Errorf("expected firing, got %+v", got.Status)
+	}
+}
@@ -18,6 +18,7 @@ package user
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"time"
@@ -162,6 +163,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 				return ctrl.Result{}, err
 			}
 
+			if err = r.deleteAccessTokens(ctx, user); err != nil {
+				r.Recorder.Event(user, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+				return ctrl.Result{}, err
+			}
+
 			// remove our finalizer from the list and update it.
 			user.Finalizers = sliceutil.RemoveString(user.ObjectMeta.Finalizers, func(item string) bool {
 				return item == finalizer
@@ -230,9 +236,9 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 
 	r.Recorder.Event(user, corev1.EventTypeNormal, successSynced, messageResourceSynced)
 
-	// block user for AuthenticateRateLimiterDuration duration, after that put it back to the queue to unblock
+	// block user until the lock expires, after that put it back to the queue to unblock
 	if user.Status.State == iamv1alpha2.UserAuthLimitExceeded {
-		return ctrl.Result{Requeue: true, RequeueAfter: r.AuthenticationOptions.AuthenticateRateLimiterDuration}, nil
+		return ctrl.Result{Requeue: true, RequeueAfter: time.Until(r.lockedUntil(user))}, nil
 	}
 
 	return ctrl.Result{}, nil
@@ -252,6 +258,9 @@ func (r *Reconciler) encryptPassword(ctx context.Context, user *iamv1alpha2.User
 			user.Annotations = make(map[string]string)
 		}
 		user.Annotations[iamv1alpha2.LastPasswordChangeTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		if err = r.recordPasswordHistory(user); err != nil {
+			return err
+		}
 		// ensure plain text password won't be kept anywhere
 		delete(user.Annotations, corev1.LastAppliedConfigAnnotation)
 		err = r.Update(ctx, user, &client.UpdateOptions{})
@@ -262,6 +271,29 @@ func (r *Reconciler) encryptPassword(ctx context.Context, user *iamv1alpha2.User
 	return nil
 }
 
+// recordPasswordHistory keeps the latest encrypted passwords of the user, which can't be reused
+// until they are rotated out by the newer passwords.
+func (r *Reconciler) recordPasswordHistory(user *iamv1alpha2.User) error {
+	historySize := 0
+	if r.AuthenticationOptions != nil && r.AuthenticationOptions.PasswordPolicy != nil {
+		historySize = r.AuthenticationOptions.PasswordPolicy.HistorySize
+	}
+	if historySize <= 0 {
+		delete(user.Annotations, iamv1alpha2.PasswordHistoryAnnotation)
+		return nil
+	}
+	history := append([]string{user.Spec.EncryptedPassword}, passwordHistory(user)...)
+	if len(history) > historySize {
+		history = history[:historySize]
+	}
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	user.Annotations[iamv1alpha2.PasswordHistoryAnnotation] = string(data)
+	return nil
+}
+
 func (r *Reconciler) ensureNotControlledByKubefed(ctx context.Context, user *iamv1alpha2.User) error {
 	if user.Labels[constants.KubefedManagedLabel] != "false" {
 		if user.Labels == nil {
@@ -452,6 +484,11 @@ func (r *Reconciler) deleteLoginRecords(ctx context.Context, user *iamv1alpha2.U
 	return r.Client.DeleteAllOf(ctx, loginRecord, client.MatchingLabels{iamv1alpha2.UserReferenceLabel: user.Name})
 }
 
+func (r *Reconciler) deleteAccessTokens(ctx context.Context, user *iamv1alpha2.User) error {
+	accessToken := &iamv1alpha2.AccessToken{}
+	return r.Client.DeleteAllOf(ctx, accessToken, client.MatchingLabels{iamv1alpha2.UserReferenceLabel: user.Name})
+}
+
 // syncUserStatus Update the user status
 func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User) error {
 	// skip status sync if the user is disabled
@@ -503,19 +540,18 @@ func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User)
 
 	// blocked user, check if need to unblock user
 	if user.Status.State == iamv1alpha2.UserAuthLimitExceeded {
-		if user.Status.LastTransitionTime != nil &&
-			user.Status.LastTransitionTime.Add(r.AuthenticationOptions.AuthenticateRateLimiterDuration).Before(time.Now()) {
-			// unblock user
-			user.Status = iamv1alpha2.UserStatus{
-				State:              iamv1alpha2.UserActive,
-				LastTransitionTime: &metav1.Time{Time: time.Now()},
-			}
-			err := r.Update(ctx, user, &client.UpdateOptions{})
-			if err != nil {
-				return err
-			}
+		if r.lockedUntil(user).After(time.Now()) {
 			return nil
 		}
+		// unblock user, the consecutive lockouts are kept to extend the next lock
+		user.Status = iamv1alpha2.UserStatus{
+			State:              iamv1alpha2.UserActive,
+			Reason:             "Lock expired",
+			LastTransitionTime: &metav1.Time{Time: time.Now()},
+			LastLoginTime:      user.Status.LastLoginTime,
+			LockoutCount:       user.Status.LockoutCount,
+		}
+		return r.Update(ctx, user, &client.UpdateOptions{})
 	}
 
 	records := &iamv1alpha2.LoginRecordList{}
@@ -529,37 +565,72 @@ func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User)
 	// count failed login attempts during last AuthenticateRateLimiterDuration
 	now := time.Now()
 	failedLoginAttempts := 0
+	lockouts := user.Status.LockoutCount
 	for _, loginRecord := range records.Items {
 		afterStateTransition := user.Status.LastTransitionTime == nil || loginRecord.CreationTimestamp.After(user.Status.LastTransitionTime.Time)
-		if !loginRecord.Spec.Success &&
-			afterStateTransition &&
-			loginRecord.CreationTimestamp.Add(r.AuthenticationOptions.AuthenticateRateLimiterDuration).After(now) {
+		if !afterStateTransition {
+			continue
+		}
+		if loginRecord.Spec.Success {
+			// a successful login resets the consecutive lockouts
+			lockouts = 0
+		} else if loginRecord.CreationTimestamp.Add(r.AuthenticationOptions.AuthenticateRateLimiterDuration).After(now) {
 			failedLoginAttempts++
 		}
 	}
 
 	// block user if failed login attempts exceeds maximum tries setting
 	if failedLoginAttempts >= r.AuthenticationOptions.AuthenticateRateLimiterMaxTries {
+		lockouts++
+		lockDuration := r.AuthenticationOptions.LockDuration(lockouts)
 		user.Status = iamv1alpha2.UserStatus{
-			State:              iamv1alpha2.UserAuthLimitExceeded,
-			Reason:             fmt.Sprintf("Failed login attempts exceed %d in last %s", failedLoginAttempts, r.AuthenticationOptions.AuthenticateRateLimiterDuration),
-			LastTransitionTime: &metav1.Time{Time: time.Now()},
+			State: iamv1alpha2.UserAuthLimitExceeded,
+			Reason: fmt.Sprintf("Failed login attempts exceed %d in last %s, locked for %s (lockout %d)",
+				failedLoginAttempts, r.AuthenticationOptions.AuthenticateRateLimiterDuration, lockDuration, lockouts),
+			LastTransitionTime: &metav1.Time{Time: now},
+			LastLoginTime:      user.Status.LastLoginTime,
+			LockoutCount:       lockouts,
+			LockedUntil:        &metav1.Time{Time: now.Add(lockDuration)},
 		}
+		return r.Update(ctx, user, &client.UpdateOptions{})
+	}
 
-		err = r.Update(ctx, user, &client.UpdateOptions{})
-		if err != nil {
-			return err
-		}
+	if lockouts != user.Status.LockoutCount {
+		user.Status.LockoutCount = lockouts
+		return r.Update(ctx, user, &client.UpdateOptions{})
 	}
 
 	return nil
 }
 
+// lockedUntil returns the time the blocked user will be unlocked, the users blocked before
+// LockedUntil was introduced are blocked for AuthenticateRateLimiterDuration.
+func (r *Reconciler) lockedUntil(user *iamv1alpha2.User) time.Time {
+	if user.Status.LockedUntil != nil {
+		return user.Status.LockedUntil.Time
+	}
+	if user.Status.LastTransitionTime != nil {
+		return user.Status.LastTransitionTime.Add(r.AuthenticationOptions.AuthenticateRateLimiterDuration)
+	}
+	return time.Now()
+}
+
 func encrypt(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
 }
 
+// passwordHistory returns the encrypted passwords used lately, the latest comes first.
+func passwordHistory(user *iamv1alpha2.User) []string {
+	var history []string
+	if value := user.Annotations[iamv1alpha2.PasswordHistoryAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &history); err != nil {
+			klog.Warningf("invalid password history of user %s: %v", user.Name, err)
+		}
+	}
+	return history
+}
+
 // isEncrypted returns whether the given password is encrypted
 func isEncrypted(password string) bool {
 	// bcrypt.Cost returns the hashing cost used to create the given hashed
@@ -148,3 +148,59 @@ func TestDoNothing(t *testing.T) {
 	user = updateEvent.Object.(*iamv1alpha2.User)
 	assert.Equal(t, iamv1alpha2.UserActive, user.Status.State)
 }
+
+func TestLockoutBackoff(t *testing.T) {
+	authenticateOptions := authentication.NewOptions()
+	authenticateOptions.AuthenticateRateLimiterMaxTries = 1
+	authenticateOptions.AuthenticateRateLimiterDuration = time.Minute
+	// the lock duration is flat by default
+	authenticateOptions.LockoutPolicy.BackoffFactor = 2
+	user := newUser("test")
+	user.Spec.EncryptedPassword, _ = encrypt("P@88w0rd")
+	user.Status = iamv1alpha2.UserStatus{
+		State:              iamv1alpha2.UserAuthLimitExceeded,
+		LastTransitionTime: &metav1.Time{Time: time.Now().Add(-2 * time.Minute)},
+		LockoutCount:       1,
+		LockedUntil:        &metav1.Time{Time: time.Now().Add(-time.Minute)},
+	}
+	loginRecord := &iamv1alpha2.LoginRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test-0",
+			Labels: map[string]string{iamv1alpha2.UserReferenceLabel: user.Name},
+			// counted after the user is unlocked
+			CreationTimestamp: metav1.NewTime(time.Now().Add(time.Second)),
+		},
+		Spec: iamv1alpha2.LoginRecordSpec{Success: false},
+	}
+	sch := scheme.Scheme
+	if err := apis.AddToScheme(sch); err != nil {
+		t.Fatalf("unable add APIs to scheme: %v", err)
+	}
+	client := runtimefakeclient.NewClientBuilder().WithScheme(sch).WithRuntimeObjects(user, loginRecord).Build()
+	c := &Reconciler{Client: client, AuthenticationOptions: authenticateOptions}
+	get := func() *iamv1alpha2.User {
+		u := &iamv1alpha2.User{}
+		if err := client.Get(context.Background(), types.NamespacedName{Name: user.Name}, u); err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	// the expired lock is released, the consecutive lockouts are kept
+	assert.NoError(t, c.syncUserStatus(context.Background(), get()))
+	user = get()
+	assert.Equal(t, iamv1alpha2.UserActive, user.Status.State)
+	assert.Equal(t, int32(1), user.Status.LockoutCount)
+
+	// the second lockout doubles the lock duration
+	assert.NoError(t, c.syncUserStatus(context.Background(), get()))
+	user = get()
+	assert.Equal(t, iamv1alpha2.UserAuthLimitExceeded, user.Status.State)
+	assert.Equal(t, int32(2), user.Status.LockoutCount)
+	assert.Equal(t, 2*time.Minute, user.Status.LockedUntil.Sub(user.Status.LastTransitionTime.Time))
+	assert.Contains(t, user.Status.Reason, "locked for 2m0s (lockout 2)")
+
+	// the user is still locked
+	assert.NoError(t, c.syncUserStatus(context.Background(), get()))
+	assert.Equal(t, iamv1alpha2.UserAuthLimitExceeded, get().Status.State)
+}
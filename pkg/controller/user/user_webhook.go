@@ -22,10 +22,13 @@ import (
 	"net/http"
 	"net/mail"
 
+	"golang.org/x/crypto/bcrypt"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
 )
 
 type EmailValidator struct {
@@ -75,3 +78,65 @@ func (a *EmailValidator) InjectDecoder(d *admission.Decoder) error {
 	a.decoder = d
 	return nil
 }
+
+// PasswordValidator validates the plain text password of the user against the password policy,
+// the encrypted passwords are skipped.
+type PasswordValidator struct {
+	Policy  *authentication.PasswordPolicy
+	decoder *admission.Decoder
+}
+
+func (a *PasswordValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	user := &v1alpha2.User{}
+	err := a.decoder.Decode(req, user)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	password := user.Spec.EncryptedPassword
+	if a.Policy == nil || password == "" || isEncrypted(password) {
+		return admission.Allowed("")
+	}
+
+	if err = a.Policy.Check(password); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	if a.Policy.HistorySize > 0 && len(req.OldObject.Raw) > 0 {
+		old := &v1alpha2.User{}
+		if err = a.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if passwordUsedLately(old, password, a.Policy.HistorySize) {
+			return admission.Errored(http.StatusBadRequest,
+				fmt.Errorf("password must not be the same as any of the last %d passwords", a.Policy.HistorySize))
+		}
+	}
+
+	return admission.Allowed("")
+}
+
+// passwordUsedLately checks whether the password is the current password or in the password history.
+// The current password is recorded as the first entry of the history, it's only prepended if not recorded,
+// e.g. the password is set before the history is enabled.
+func passwordUsedLately(user *v1alpha2.User, password string, historySize int) bool {
+	history := passwordHistory(user)
+	if current := user.Spec.EncryptedPassword; isEncrypted(current) && (len(history) == 0 || history[0] != current) {
+		history = append([]string{current}, history...)
+	}
+	for i, encrypted := range history {
+		if i >= historySize {
+			break
+		}
+		if bcrypt.CompareHashAndPassword([]byte(encrypted), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// InjectDecoder injects the decoder.
+func (a *PasswordValidator) InjectDecoder(d *admission.Decoder) error {
+	a.decoder = d
+	return nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+)
+
+func newAdmissionRequest(t *testing.T, user, old *iamv1alpha2.User) admission.Request {
+	raw := func(u *iamv1alpha2.User) runtime.RawExtension {
+		if u == nil {
+			return runtime.RawExtension{}
+		}
+		data, err := json.Marshal(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return runtime.RawExtension{Raw: data}
+	}
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Object:    raw(user),
+		OldObject: raw(old),
+	}}
+}
+
+func TestPasswordValidator(t *testing.T) {
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.NoError(t, err)
+	policy := authentication.NewPasswordPolicy()
+	policy.MinLength = 10
+	policy.RequireSpecialCharacter = true
+	policy.HistorySize = 3
+	validator := &PasswordValidator{Policy: policy}
+	assert.NoError(t, validator.InjectDecoder(decoder))
+
+	encrypt := func(password string) string {
+		encrypted, err := encrypt(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encrypted
+	}
+	// the current password is the first entry of the history recorded by the controller
+	current := encrypt("P@88w0rd-3")
+	history, err := json.Marshal([]string{current, encrypt("P@88w0rd-2"), encrypt("P@88w0rd-1")})
+	assert.NoError(t, err)
+	old := newUser("test")
+	old.Spec.EncryptedPassword = current
+	old.Annotations = map[string]string{iamv1alpha2.PasswordHistoryAnnotation: string(history)}
+	// the password set before the history is enabled
+	unrecorded := newUser("test")
+	unrecorded.Spec.EncryptedPassword = current
+
+	tests := []struct {
+		name     string
+		password string
+		old      *iamv1alpha2.User
+		expected string
+	}{
+		{name: "strong password", password: "P@88w0rd-4", old: old},
+		{name: "encrypted password", password: encrypt("weak"), old: old},
+		{name: "weak password", password: "password", expected: "password must be at least 10 characters long, " +
+			"contain at least one uppercase letter, contain at least one digit, contain at least one special character"},
+		{name: "current password", password: "P@88w0rd-3", old: old, expected: "the last 3 passwords"},
+		{name: "password in history", password: "P@88w0rd-1", old: old, expected: "the last 3 passwords"},
+		{name: "unrecorded current password", password: "P@88w0rd-3", old: unrecorded, expected: "the last 3 passwords"},
+		{name: "new user", password: "P@88w0rd-1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			user := newUser("test")
+			user.Spec.EncryptedPassword = test.password
+			response := validator.Handle(context.Background(), newAdmissionRequest(t, user, test.old))
+			if test.expected == "" {
+				assert.True(t, response.Allowed, response.Result.Message)
+			} else {
+				assert.False(t, response.Allowed)
+				assert.Contains(t, response.Result.Message, test.expected)
+			}
+		})
+	}
+}
+
+func TestRecordPasswordHistory(t *testing.T) {
+	options := authentication.NewOptions()
+	options.PasswordPolicy.HistorySize = 2
+	r := &Reconciler{AuthenticationOptions: options}
+	user := newUser("test")
+	user.Annotations = map[string]string{}
+	for _, password := range []string{"hash-1", "hash-2", "hash-3"} {
+		user.Spec.EncryptedPassword = password
+		assert.NoError(t, r.recordPasswordHistory(user))
+	}
+	assert.Equal(t, []string{"hash-3", "hash-2"}, passwordHistory(user))
+
+	options.PasswordPolicy.HistorySize = 0
+	assert.NoError(t, r.recordPasswordHistory(user))
+	assert.NotContains(t, user.Annotations, iamv1alpha2.PasswordHistoryAnnotation)
+}
@@ -22,6 +22,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/simple/client/gpu"
 
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
 	kubesphereconfig "kubesphere.io/kubesphere/pkg/apiserver/config"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 )
@@ -38,7 +39,10 @@ func AddToContainer(c *restful.Container, config *kubesphereconfig.Config) error
 	webservice.Route(webservice.GET("/configs/oauth").
 		Doc("Information about the authorization server are published.").
 		To(func(request *restful.Request, response *restful.Response) {
-			response.WriteEntity(config.AuthenticationOptions.OAuthOptions)
+			// the identity providers may be reloaded after the server started
+			oauthOptions := *config.AuthenticationOptions.OAuthOptions
+			oauthOptions.IdentityProviders = identityprovider.ListIdentityProviderOptions()
+			response.WriteEntity(oauthOptions)
 		}))
 
 	webservice.Route(webservice.GET("/configs/configz").
@@ -221,6 +221,7 @@ func (h *handler) PodLogSearch(request *restful.Request, response *restful.Respo
 		ContainerSearch: stringutils.Split(logQuery.ContainerSearch, ","),
 		ContainerFilter: stringutils.Split(logQuery.ContainerFilter, ","),
 		LogSearch:       stringutils.Split(logQuery.LogSearch, ","),
+		FieldFilter:     logQuery.FieldFilter,
 		Starttime:       logQuery.StartTime,
 		Endtime:         logQuery.EndTime,
 	}
@@ -34,6 +34,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/rbac"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	apirequest "kubesphere.io/kubesphere/pkg/apiserver/request"
 	"kubesphere.io/kubesphere/pkg/models/iam/am"
@@ -57,19 +58,88 @@ type PasswordReset struct {
 	Password        string `json:"password"`
 }
 
+type TOTPConfirmation struct {
+	Passcode string `json:"passcode" description:"the passcode generated by the TOTP authenticator"`
+}
+
+type MultiFactorAuthVerification struct {
+	Passcode string `json:"passcode" description:"the current passcode generated by the TOTP authenticator or a recovery code"`
+}
+
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes" description:"the single-use codes to login if the TOTP authenticator is unavailable, they are only shown once"`
+}
+
+type AccessTokenCreated struct {
+	AccessToken *iamv1alpha2.AccessToken `json:"accessToken"`
+	Token       string                   `json:"token" description:"the personal access token, it's only shown once"`
+}
+
+// AccessReview describes a request to explain the authorization decision on, the request is a resource request
+// unless the path is set, its scope is the innermost one of the namespace, the devops project and the workspace.
+type AccessReview struct {
+	Verb        string `json:"verb" description:"the verb of the request, e.g. get, list, create, delete"`
+	APIGroup    string `json:"apiGroup,omitempty" description:"the API group of the resource"`
+	Resource    string `json:"resource,omitempty" description:"the resource, e.g. namespaces"`
+	Subresource string `json:"subresource,omitempty" description:"the subresource, e.g. log"`
+	Name        string `json:"name,omitempty" description:"the name of the resource"`
+	Workspace   string `json:"workspace,omitempty" description:"the workspace of the resource"`
+	Namespace   string `json:"namespace,omitempty" description:"the namespace of the resource"`
+	DevOps      string `json:"devops,omitempty" description:"the devops project of the resource"`
+	Global      bool   `json:"global,omitempty" description:"whether the resource is a global resource of KubeSphere, e.g. users, only the global role bindings apply then"`
+	Path        string `json:"path,omitempty" description:"the non-resource URL, e.g. /kapis/version"`
+}
+
+func (r *AccessReview) attributes(user authuser.Info) authorizer.AttributesRecord {
+	attributes := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            r.Verb,
+		APIGroup:        r.APIGroup,
+		Resource:        r.Resource,
+		Subresource:     r.Subresource,
+		Name:            r.Name,
+		Workspace:       r.Workspace,
+		Namespace:       r.Namespace,
+		DevOps:          r.DevOps,
+		Path:            r.Path,
+		ResourceRequest: r.Path == "",
+	}
+	switch {
+	case r.Namespace != "":
+		attributes.ResourceScope = apirequest.NamespaceScope
+	case r.DevOps != "":
+		attributes.ResourceScope = apirequest.DevOpsScope
+	case r.Workspace != "":
+		attributes.ResourceScope = apirequest.WorkspaceScope
+	case r.Global:
+		attributes.ResourceScope = apirequest.GlobalScope
+	default:
+		attributes.ResourceScope = apirequest.ClusterScope
+	}
+	return attributes
+}
+
 type iamHandler struct {
-	am         am.AccessManagementInterface
-	im         im.IdentityManagementInterface
-	group      group.GroupOperator
-	authorizer authorizer.Authorizer
+	am                  am.AccessManagementInterface
+	im                  im.IdentityManagementInterface
+	group               group.GroupOperator
+	authorizer          *rbac.RBACAuthorizer
+	tokenOperator       auth.TokenManagementInterface
+	mfaAuthenticator    auth.MultiFactorAuthenticator
+	lockoutOperator     auth.AccountLockoutOperator
+	accessTokenOperator auth.AccessTokenOperator
 }
 
-func newIAMHandler(im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, authorizer authorizer.Authorizer) *iamHandler {
+func newIAMHandler(im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, authorizer *rbac.RBACAuthorizer, tokenOperator auth.TokenManagementInterface, mfaAuthenticator auth.MultiFactorAuthenticator, lockoutOperator auth.AccountLockoutOperator, accessTokenOperator auth.AccessTokenOperator) *iamHandler {
 	return &iamHandler{
-		am:         am,
-		im:         im,
-		group:      group,
-		authorizer: authorizer,
+		am:                  am,
+		im:                  im,
+		group:               group,
+		authorizer:          authorizer,
+		tokenOperator:       tokenOperator,
+		mfaAuthenticator:    mfaAuthenticator,
+		lockoutOperator:     lockoutOperator,
+		accessTokenOperator: accessTokenOperator,
 	}
 }
 
@@ -379,10 +449,21 @@ func (h *iamHandler) ListWorkspaceRoles(request *restful.Request, response *rest
 
 	queryParam.Filters[iamv1alpha2.ScopeWorkspace] = query.Value(workspace)
 	// shared workspace role template
-	if string(queryParam.Filters[query.FieldLabel]) == fmt.Sprintf("%s=%s", iamv1alpha2.RoleTemplateLabel, "true") ||
-		strings.Contains(queryParam.LabelSelector, iamv1alpha2.RoleTemplateLabel) ||
-		queryParam.Filters[iamv1alpha2.AggregateTo] != "" {
-		delete(queryParam.Filters, iamv1alpha2.ScopeWorkspace)
+	isTemplate := strings.Contains(queryParam.LabelSelector, iamv1alpha2.RoleTemplateLabel)
+	labelFilters, _ := queryParam.Values(query.FieldLabel)
+	for _, labelFilter := range labelFilters {
+		if string(labelFilter) == fmt.Sprintf("%s=%s", iamv1alpha2.RoleTemplateLabel, "true") {
+			isTemplate = true
+		}
+	}
+	aggregateTo, _ := queryParam.Values(iamv1alpha2.AggregateTo)
+	for _, name := range aggregateTo {
+		if name != "" {
+			isTemplate = true
+		}
+	}
+	if isTemplate {
+		queryParam.Delete(iamv1alpha2.ScopeWorkspace)
 	}
 
 	result, err := h.am.ListWorkspaceRoles(queryParam)
@@ -1240,6 +1321,203 @@ func (h *iamHandler) ListUserLoginRecords(request *restful.Request, response *re
 	response.WriteEntity(result)
 }
 
+func (h *iamHandler) ListUserSessions(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	sessions, err := h.tokenOperator.ListSessions(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	result := &api.ListResult{Items: make([]interface{}, 0, len(sessions)), TotalItems: len(sessions)}
+	for _, session := range sessions {
+		result.Items = append(result.Items, session)
+	}
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) RevokeUserSession(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	session := request.PathParameter("session")
+	if err := h.tokenOperator.RevokeSession(username, session); err != nil {
+		if err == auth.ErrSessionNotFound {
+			api.HandleNotFound(response, request, err)
+			return
+		}
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) ListAccessTokens(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	accessTokens, err := h.accessTokenOperator.ListAccessTokens(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	result := &api.ListResult{Items: make([]interface{}, 0, len(accessTokens)), TotalItems: len(accessTokens)}
+	for _, accessToken := range accessTokens {
+		result.Items = append(result.Items, accessToken)
+	}
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) CreateAccessToken(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		api.HandleInternalError(response, request, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+	// the restrictions of a personal access token can't be escaped by creating another one
+	if _, ok := operator.GetExtra()[iamv1alpha2.ExtraAccessToken]; ok {
+		api.HandleForbidden(response, request, fmt.Errorf("personal access tokens can not be created with a personal access token"))
+		return
+	}
+	// only the user manager can create the personal access tokens of the other users
+	if operator.GetName() != username {
+		userManagement := authorizer.AttributesRecord{
+			Resource:        iamv1alpha2.ResourcesPluralUser,
+			Verb:            "update",
+			ResourceScope:   apirequest.GlobalScope,
+			ResourceRequest: true,
+			User:            operator,
+		}
+		decision, _, err := h.authorizer.Authorize(userManagement)
+		if err != nil {
+			api.HandleInternalError(response, request, err)
+			return
+		}
+		if decision != authorizer.DecisionAllow {
+			api.HandleForbidden(response, request, fmt.Errorf("personal access tokens of user %s can not be created by %s", username, operator.GetName()))
+			return
+		}
+	}
+
+	var accessToken iamv1alpha2.AccessToken
+	if err := request.ReadEntity(&accessToken); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+	if _, err := h.im.DescribeUser(username); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	created, token, err := h.accessTokenOperator.CreateAccessToken(username, &accessToken)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(AccessTokenCreated{AccessToken: created, Token: token})
+}
+
+func (h *iamHandler) RevokeAccessToken(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	name := request.PathParameter("accesstoken")
+	if err := h.accessTokenOperator.RevokeAccessToken(username, name); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) UnlockUser(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		api.HandleInternalError(response, request, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+	if err := h.lockoutOperator.Unlock(username, operator.GetName()); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) EnrollTOTP(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	enrollment, err := h.mfaAuthenticator.EnrollTOTP(username)
+	if err != nil {
+		handleMultiFactorAuthError(response, request, err)
+		return
+	}
+	response.WriteEntity(enrollment)
+}
+
+func (h *iamHandler) ConfirmTOTP(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	var confirmation TOTPConfirmation
+	if err := request.ReadEntity(&confirmation); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+	codes, err := h.mfaAuthenticator.ConfirmTOTP(username, confirmation.Passcode)
+	if err != nil {
+		handleMultiFactorAuthError(response, request, err)
+		return
+	}
+	response.WriteEntity(RecoveryCodes{RecoveryCodes: codes})
+}
+
+func (h *iamHandler) RegenerateRecoveryCodes(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	var verification MultiFactorAuthVerification
+	if err := request.ReadEntity(&verification); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+	codes, err := h.mfaAuthenticator.RegenerateRecoveryCodes(username, verification.Passcode)
+	if err != nil {
+		handleMultiFactorAuthError(response, request, err)
+		return
+	}
+	response.WriteEntity(RecoveryCodes{RecoveryCodes: codes})
+}
+
+// DisableMultiFactorAuth removes the second factor of the user, the user must provide a current passcode,
+// while the administrators reset the second factor of the other users without the passcode.
+func (h *iamHandler) DisableMultiFactorAuth(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		api.HandleInternalError(response, request, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+
+	var err error
+	if operator.GetName() == username {
+		var verification MultiFactorAuthVerification
+		if err = request.ReadEntity(&verification); err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		err = h.mfaAuthenticator.DisableMultiFactorAuth(username, verification.Passcode)
+	} else {
+		err = h.mfaAuthenticator.ResetMultiFactorAuth(username)
+	}
+	if err != nil {
+		handleMultiFactorAuthError(response, request, err)
+		return
+	}
+	response.WriteEntity(servererr.None)
+}
+
+func handleMultiFactorAuthError(response *restful.Response, request *restful.Request, err error) {
+	switch err {
+	case auth.ErrTOTPEnrolled:
+		api.HandleConflict(response, request, err)
+	case auth.ErrTOTPNotEnrolled, auth.ErrIncorrectPasscode:
+		api.HandleBadRequest(response, request, err)
+	default:
+		api.HandleError(response, request, err)
+	}
+}
+
 func (h *iamHandler) ListWorkspaceGroups(request *restful.Request, response *restful.Response) {
 	workspaceName := request.PathParameter("workspace")
 	queryParam := query.ParseQueryParameter(request)
@@ -1488,3 +1766,63 @@ func (h *iamHandler) DeleteGroupBinding(request *restful.Request, response *rest
 
 	response.WriteEntity(servererr.None)
 }
+
+// userInfo returns the user info the authenticators build for the user.
+func (h *iamHandler) userInfo(username string) (authuser.Info, error) {
+	user, err := h.im.DescribeUser(username)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]string, 0, len(user.Spec.Groups)+1)
+	groups = append(groups, user.Spec.Groups...)
+	return &authuser.DefaultInfo{
+		Name:   user.Name,
+		Groups: append(groups, authuser.AllAuthenticated),
+	}, nil
+}
+
+func (h *iamHandler) ExplainAccessReview(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	var review AccessReview
+	if err := request.ReadEntity(&review); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+	if review.Verb == "" || (review.Resource == "" && review.Path == "") {
+		api.HandleBadRequest(response, request, fmt.Errorf("verb and either resource or path are required"))
+		return
+	}
+	user, err := h.userInfo(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(h.authorizer.Explain(review.attributes(user)))
+}
+
+func (h *iamHandler) ListWorkspaceMemberRules(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	username := request.PathParameter("workspacemember")
+
+	queryParam := query.New()
+	queryParam.Filters[query.FieldNames] = query.Value(username)
+	queryParam.Filters[iamv1alpha2.ScopeWorkspace] = query.Value(workspace)
+	result, err := h.im.ListUsers(queryParam)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+	// the rules of the users out of the workspace are not disclosed to the workspace managers
+	if len(result.Items) == 0 {
+		err := errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcesSingularUser), username)
+		api.HandleNotFound(response, request, err)
+		return
+	}
+
+	user, err := h.userInfo(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(h.authorizer.ListWorkspaceRules(user, workspace))
+}
@@ -19,7 +19,7 @@ package v1alpha2
 import (
 	"net/http"
 
-	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/rbac"
 
 	"github.com/emicklei/go-restful"
 	restfulspec "github.com/emicklei/go-restful-openapi"
@@ -32,6 +32,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/auth"
 	"kubesphere.io/kubesphere/pkg/models/iam/am"
 	"kubesphere.io/kubesphere/pkg/models/iam/group"
 	"kubesphere.io/kubesphere/pkg/models/iam/im"
@@ -44,9 +45,9 @@ const (
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
 
-func AddToContainer(container *restful.Container, im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, authorizer authorizer.Authorizer) error {
+func AddToContainer(container *restful.Container, im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, authorizer *rbac.RBACAuthorizer, tokenOperator auth.TokenManagementInterface, mfaAuthenticator auth.MultiFactorAuthenticator, lockoutOperator auth.AccountLockoutOperator, accessTokenOperator auth.AccessTokenOperator) error {
 	ws := runtime.NewWebService(GroupVersion)
-	handler := newIAMHandler(im, am, group, authorizer)
+	handler := newIAMHandler(im, am, group, authorizer, tokenOperator, mfaAuthenticator, lockoutOperator, accessTokenOperator)
 
 	// users
 	ws.Route(ws.POST("/users").
@@ -87,6 +88,13 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.User{}}}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
 
+	ws.Route(ws.POST("/users/{user}/unlock").
+		To(handler.UnlockUser).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("Unlock the user locked after too many failed login attempts, the consecutive lockouts of the user are reset.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+
 	ws.Route(ws.GET("/users/{user}/loginrecords").
 		To(handler.ListUserLoginRecords).
 		Param(ws.PathParameter("user", "username of the user")).
@@ -94,6 +102,82 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.LoginRecord{}}}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
 
+	ws.Route(ws.GET("/users/{user}/sessions").
+		To(handler.ListUserSessions).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("List active login sessions of the specified user.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{auth.Session{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.DELETE("/users/{user}/sessions/{session}").
+		To(handler.RevokeUserSession).
+		Param(ws.PathParameter("user", "username of the user")).
+		Param(ws.PathParameter("session", "session id")).
+		Doc("Terminate a login session of the specified user, all the tokens issued in the session are revoked.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+
+	ws.Route(ws.GET("/users/{user}/accesstokens").
+		To(handler.ListAccessTokens).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("List personal access tokens of the specified user.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.AccessToken{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.POST("/users/{user}/accesstokens").
+		To(handler.CreateAccessToken).
+		Param(ws.PathParameter("user", "username of the user")).
+		Reads(iamv1alpha2.AccessToken{}).
+		Doc("Create a personal access token for the specified user, the token is only returned once. Only the user manager can create the tokens of the other users.").
+		Returns(http.StatusOK, api.StatusOK, AccessTokenCreated{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.DELETE("/users/{user}/accesstokens/{accesstoken}").
+		To(handler.RevokeAccessToken).
+		Param(ws.PathParameter("user", "username of the user")).
+		Param(ws.PathParameter("accesstoken", "name of the personal access token")).
+		Doc("Revoke a personal access token of the specified user.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+
+	ws.Route(ws.POST("/users/{user}/accessreviews").
+		To(handler.ExplainAccessReview).
+		Param(ws.PathParameter("user", "username of the user")).
+		Reads(AccessReview{}).
+		Doc("Explain the RBAC decision on a request of the specified user with the bindings and the rules evaluated at each scope. "+
+			"The bindings of the current cluster are evaluated, use the /clusters/{cluster} prefix for a member cluster. "+
+			"Only RBAC is evaluated, the restrictions of the personal access tokens and the authorization webhook are not.").
+		Returns(http.StatusOK, api.StatusOK, rbac.Explanation{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+
+	ws.Route(ws.POST("/users/{user}/mfa/totp").
+		To(handler.EnrollTOTP).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("Generate a TOTP secret for the specified user, the secret takes effect after confirmed with a passcode.").
+		Returns(http.StatusOK, api.StatusOK, auth.TOTPEnrollment{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.POST("/users/{user}/mfa/totp/confirm").
+		To(handler.ConfirmTOTP).
+		Param(ws.PathParameter("user", "username of the user")).
+		Reads(TOTPConfirmation{}).
+		Doc("Confirm the TOTP enrollment of the specified user with a passcode, the recovery codes are returned.").
+		Returns(http.StatusOK, api.StatusOK, RecoveryCodes{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.POST("/users/{user}/mfa/recoverycodes").
+		To(handler.RegenerateRecoveryCodes).
+		Param(ws.PathParameter("user", "username of the user")).
+		Reads(MultiFactorAuthVerification{}).
+		Doc("Regenerate the recovery codes of the specified user with a current passcode or recovery code, "+
+			"the previous recovery codes are invalidated.").
+		Returns(http.StatusOK, api.StatusOK, RecoveryCodes{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.DELETE("/users/{user}/mfa").
+		To(handler.DisableMultiFactorAuth).
+		Param(ws.PathParameter("user", "username of the user")).
+		Reads(MultiFactorAuthVerification{}).
+		Doc("Remove the TOTP authenticator and the recovery codes of the specified user. A current passcode or "+
+			"recovery code is required to remove the confirmed TOTP of the current user, the administrators "+
+			"reset the other users without it.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+
 	// clustermembers
 	ws.Route(ws.POST("/clustermembers").
 		To(handler.CreateClusterMembers).
@@ -139,6 +223,13 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Param(ws.PathParameter("workspacemember", "workspace member's username")).
 		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.User{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceMemberTag}))
+	ws.Route(ws.GET("/workspaces/{workspace}/workspacemembers/{workspacemember}/rules").
+		To(handler.ListWorkspaceMemberRules).
+		Doc("List the WorkspaceRoleBindings and the RoleBindings applied to the member in the workspace and its projects, with the rules of the roles referred.").
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("workspacemember", "workspace member's username")).
+		Returns(http.StatusOK, api.StatusOK, rbac.RuleList{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.WorkspaceMemberTag}))
 	ws.Route(ws.POST("/workspaces/{workspace}/workspacemembers").
 		To(handler.CreateWorkspaceMembers).
 		Doc("Add members to current cluster in bulk.").
@@ -35,6 +35,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
@@ -50,6 +51,10 @@ const (
 	grantTypePassword     = "password"
 	grantTypeRefreshToken = "refresh_token"
 	grantTypeCode         = "code"
+	// grantTypeMultiFactorOTP completes the password grant challenged by the multi-factor authentication
+	grantTypeMultiFactorOTP = "mfa_otp"
+	// grantTypeDeviceCode https://datatracker.ietf.org/doc/html/rfc8628#section-3.4
+	grantTypeDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
 )
 
 type Spec struct {
@@ -73,6 +78,25 @@ type LoginRequest struct {
 	Password string `json:"password" description:"password"`
 }
 
+// MultiFactorAuthRequired is the response of the password grant if the user must pass the multi-factor
+// authentication challenge, the client continues the login by the mfa_otp grant with the mfa_token.
+type MultiFactorAuthRequired struct {
+	oauth.Error
+	MFAToken string `json:"mfa_token" description:"the token of the multi-factor authentication challenge"`
+	// TOTPEnrollment is set if the user must enroll TOTP before login
+	TOTPEnrollment *auth.TOTPEnrollment `json:"totp_enrollment,omitempty" description:"the TOTP secret to enroll"`
+}
+
+// DeviceAuthorizationResponse https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code" description:"the device verification code"`
+	UserCode                string `json:"user_code" description:"the end-user verification code"`
+	VerificationURI         string `json:"verification_uri" description:"the end-user verification URI on the authorization server"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty" description:"the verification URI that includes the user_code"`
+	ExpiresIn               int    `json:"expires_in" description:"the lifetime in seconds of the device_code and user_code"`
+	Interval                int    `json:"interval" description:"the minimum amount of time in seconds that the client SHOULD wait between polling requests"`
+}
+
 func (request *TokenReview) Validate() error {
 	if request.Spec == nil || request.Spec.Token == "" {
 		return fmt.Errorf("token must not be null")
@@ -89,6 +113,8 @@ type discovery struct {
 	Auth string `json:"authorization_endpoint"`
 	// URL of the OP's OAuth 2.0 Token Endpoint.
 	Token string `json:"token_endpoint"`
+	// URL of the OP's OAuth 2.0 Device Authorization Endpoint.
+	DeviceAuth string `json:"device_authorization_endpoint,omitempty"`
 	// URL of the OP's UserInfo Endpoint
 	UserInfo string `json:"userinfo_endpoint"`
 	// URL of the OP's JSON Web Key Set [JWK] document.
@@ -120,20 +146,26 @@ type handler struct {
 	tokenOperator         auth.TokenManagementInterface
 	passwordAuthenticator auth.PasswordAuthenticator
 	oauthAuthenticator    auth.OAuthAuthenticator
+	mfaAuthenticator      auth.MultiFactorAuthenticator
 	loginRecorder         auth.LoginRecorder
+	deviceOperator        auth.DeviceAuthorizationOperator
 }
 
 func newHandler(im im.IdentityManagementInterface,
 	tokenOperator auth.TokenManagementInterface,
 	passwordAuthenticator auth.PasswordAuthenticator,
 	oauthAuthenticator auth.OAuthAuthenticator,
+	mfaAuthenticator auth.MultiFactorAuthenticator,
 	loginRecorder auth.LoginRecorder,
+	deviceOperator auth.DeviceAuthorizationOperator,
 	options *authentication.Options) *handler {
 	return &handler{im: im,
 		tokenOperator:         tokenOperator,
 		passwordAuthenticator: passwordAuthenticator,
 		oauthAuthenticator:    oauthAuthenticator,
+		mfaAuthenticator:      mfaAuthenticator,
 		loginRecorder:         loginRecorder,
+		deviceOperator:        deviceOperator,
 		options:               options}
 }
 
@@ -158,6 +190,11 @@ func (h *handler) tokenReview(req *restful.Request, resp *restful.Response) {
 		api.HandleBadRequest(resp, req, err)
 		return
 	}
+	// the restrictions of the personal access tokens are only enforced by ks-apiserver
+	if verified.TokenType == token.PersonalAccessToken {
+		api.HandleBadRequest(resp, req, fmt.Errorf("personal access tokens are not supported"))
+		return
+	}
 
 	authenticated := verified.User
 	success := TokenReview{APIVersion: tokenReview.APIVersion,
@@ -194,12 +231,17 @@ func (h *handler) discovery(req *restful.Request, response *restful.Response) {
 		},
 	}
 
+	if h.deviceAuthorizationEnabled() {
+		result.DeviceAuth = h.options.OAuthOptions.Issuer + "/device_authorization"
+		result.GrantTypes = append(result.GrantTypes, grantTypeDeviceCode)
+	}
+
 	response.WriteEntity(result)
 }
 
 func (h *handler) keys(req *restful.Request, response *restful.Response) {
 	jwks := jose.JSONWebKeySet{
-		Keys: []jose.JSONWebKey{*h.tokenOperator.Keys().SigningKeyPub},
+		Keys: h.tokenOperator.Keys().VerificationKeys,
 	}
 	response.WriteEntity(jwks)
 }
@@ -289,6 +331,11 @@ func (h *handler) authorize(req *restful.Request, response *restful.Response) {
 	// TODO(hongming) support Hybrid Flow
 	// Authorization Code Flow
 	if responseType == oauth.ResponseCode {
+		sessionID, err := h.newSession(req, authenticated, "")
+		if err != nil {
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+			return
+		}
 		code, err := h.tokenOperator.IssueTo(&token.IssueRequest{
 			User: authenticated,
 			Claims: token.Claims{
@@ -298,6 +345,7 @@ func (h *handler) authorize(req *restful.Request, response *restful.Response) {
 				TokenType: token.AuthorizationCode,
 				Nonce:     nonce,
 				Scopes:    scopes,
+				SessionID: sessionID,
 			},
 			// A maximum authorization code lifetime of 10 minutes is
 			ExpiresIn: 10 * time.Minute,
@@ -318,7 +366,13 @@ func (h *handler) authorize(req *restful.Request, response *restful.Response) {
 		return
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	sessionID, err := h.newSession(req, authenticated, "")
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	result, err := h.issueTokenTo(authenticated, sessionID)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -341,7 +395,13 @@ func (h *handler) oauthCallback(req *restful.Request, response *restful.Response
 		return
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	sessionID, err := h.newSession(req, authenticated, provider)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	result, err := h.issueTokenTo(authenticated, sessionID)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -355,34 +415,55 @@ func (h *handler) oauthCallback(req *restful.Request, response *restful.Response
 	response.WriteEntity(result)
 }
 
-// To obtain an Access Token, an ID Token, and optionally a Refresh Token,
-// the RP (Client) sends a Token Request to the Token Endpoint to obtain a Token Response,
-// as described in Section 3.2 of OAuth 2.0 [RFC6749], when using the Authorization Code Flow.
-// Communication with the Token Endpoint MUST utilize TLS.
-func (h *handler) token(req *restful.Request, response *restful.Response) {
-	// TODO(hongming) support basic auth
-	// https://datatracker.ietf.org/doc/html/rfc6749#section-2.3
-	clientID, err := req.BodyParameter("client_id")
+// metadata serves the metadata which is used to register KubeSphere to the identity provider.
+func (h *handler) metadata(req *restful.Request, response *restful.Response) {
+	provider, err := identityprovider.GetOAuthProvider(req.PathParameter("callback"))
 	if err != nil {
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
+		api.HandleNotFound(response, req, err)
 		return
 	}
-	clientSecret, err := req.BodyParameter("client_secret")
+	metadataProvider, ok := provider.(identityprovider.MetadataProvider)
+	if !ok {
+		api.HandleNotFound(response, req, fmt.Errorf("identity provider %s has no metadata", req.PathParameter("callback")))
+		return
+	}
+	contentType, data, err := metadataProvider.Metadata()
 	if err != nil {
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
+		api.HandleInternalError(response, req, err)
 		return
 	}
+	response.AddHeader(restful.HEADER_ContentType, contentType)
+	response.WriteHeader(http.StatusOK)
+	_, _ = response.Write(data)
+}
 
-	client, err := h.options.OAuthOptions.OAuthClient(clientID)
+// login redirects the user agent to the identity provider which issues the authentication requests itself,
+// e.g. the SAML 2.0 identity provider, the response is posted to the callback URL.
+func (h *handler) login(req *restful.Request, response *restful.Response) {
+	provider, err := identityprovider.GetOAuthProvider(req.PathParameter("identityprovider"))
 	if err != nil {
-		oauthError := oauth.NewInvalidClient(err)
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauthError)
+		api.HandleNotFound(response, req, err)
+		return
+	}
+	loginProvider, ok := provider.(identityprovider.LoginProvider)
+	if !ok {
+		api.HandleNotFound(response, req, fmt.Errorf("identity provider %s does not issue authentication requests", req.PathParameter("identityprovider")))
+		return
+	}
+	if err := loginProvider.Login(response.ResponseWriter, req.Request); err != nil {
+		api.HandleInternalError(response, req, err)
 		return
 	}
+}
 
-	if client.Secret != clientSecret {
-		oauthError := oauth.NewInvalidClient(fmt.Errorf("invalid client credential"))
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauthError)
+// To obtain an Access Token, an ID Token, and optionally a Refresh Token,
+// the RP (Client) sends a Token Request to the Token Endpoint to obtain a Token Response,
+// as described in Section 3.2 of OAuth 2.0 [RFC6749], when using the Authorization Code Flow.
+// Communication with the Token Endpoint MUST utilize TLS.
+func (h *handler) token(req *restful.Request, response *restful.Response) {
+	client, err := h.authenticateClient(req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, err)
 		return
 	}
 
@@ -404,12 +485,42 @@ func (h *handler) token(req *restful.Request, response *restful.Response) {
 	case grantTypeCode:
 		h.codeGrant(req, response)
 		return
+	case grantTypeMultiFactorOTP:
+		h.multiFactorGrant(req, response)
+		return
+	case grantTypeDeviceCode:
+		h.deviceCodeGrant(client.Name, req, response)
+		return
 	default:
 		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorUnsupportedGrantType)
 		return
 	}
 }
 
+// authenticateClient authenticates the client with the credential in the request body.
+func (h *handler) authenticateClient(req *restful.Request) (*oauth.Client, error) {
+	// TODO(hongming) support basic auth
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-2.3
+	clientID, err := req.BodyParameter("client_id")
+	if err != nil {
+		return nil, oauth.NewInvalidClient(err)
+	}
+	clientSecret, err := req.BodyParameter("client_secret")
+	if err != nil {
+		return nil, oauth.NewInvalidClient(err)
+	}
+
+	client, err := h.options.OAuthOptions.OAuthClient(clientID)
+	if err != nil {
+		return nil, oauth.NewInvalidClient(err)
+	}
+
+	if client.Secret != clientSecret {
+		return nil, oauth.NewInvalidClient(fmt.Errorf("invalid client credential"))
+	}
+	return &client, nil
+}
+
 // passwordGrant handle Resource Owner Password Credentials Grant
 // for more details: https://datatracker.ietf.org/doc/html/rfc6749#section-4.3
 // The resource owner password credentials grant type is suitable in
@@ -440,7 +551,181 @@ func (h *handler) passwordGrant(provider, username string, password string, req
 		}
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	challenge, err := h.mfaAuthenticator.Challenge(authenticated, provider)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+	if challenge != nil {
+		response.WriteHeaderAndEntity(http.StatusForbidden, MultiFactorAuthRequired{
+			Error:          oauth.ErrorMultiFactorAuthRequired,
+			MFAToken:       challenge.Token,
+			TOTPEnrollment: challenge.Enrollment,
+		})
+		return
+	}
+
+	h.completeLogin(req, response, authenticated, provider, nil)
+}
+
+// multiFactorGrant completes the password grant with a TOTP passcode or a recovery code.
+func (h *handler) multiFactorGrant(req *restful.Request, response *restful.Response) {
+	mfaToken, _ := req.BodyParameter("mfa_token")
+	otp, _ := req.BodyParameter("otp")
+	authenticated, provider, recoveryCodes, err := h.mfaAuthenticator.Verify(mfaToken, otp)
+	if err != nil {
+		switch err {
+		case auth.ErrIncorrectPasscode, auth.ErrMultiFactorChallengeNotFound, auth.ErrTOTPNotEnrolled:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+			return
+		default:
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+			return
+		}
+	}
+
+	h.completeLogin(req, response, authenticated, provider, recoveryCodes)
+}
+
+func (h *handler) deviceAuthorizationEnabled() bool {
+	return h.options.OAuthOptions.DeviceVerificationURI != ""
+}
+
+// deviceAuthorization handles the Device Authorization Request of the device authorization grant, the user
+// approves the request on the verification page while the client polls the token endpoint with the device code.
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.1
+func (h *handler) deviceAuthorization(req *restful.Request, response *restful.Response) {
+	if !h.deviceAuthorizationEnabled() {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorUnsupportedGrantType)
+		return
+	}
+	client, err := h.authenticateClient(req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, err)
+		return
+	}
+
+	var scopes []string
+	if scope, _ := req.BodyParameter("scope"); scope != "" {
+		scopes = strings.Split(scope, " ")
+	}
+	if !oauth.IsValidScopes(scopes) {
+		klog.Warningf("Some requested scopes were invalid: %v", scopes)
+	}
+
+	verificationURL, err := url.Parse(h.options.OAuthOptions.DeviceVerificationURI)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	authorization, err := h.deviceOperator.CreateDeviceAuthorization(client.Name, scopes)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	values := verificationURL.Query()
+	values.Set("user_code", authorization.UserCode)
+	verificationURL.RawQuery = values.Encode()
+	response.WriteEntity(DeviceAuthorizationResponse{
+		DeviceCode:              authorization.DeviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         h.options.OAuthOptions.DeviceVerificationURI,
+		VerificationURIComplete: verificationURL.String(),
+		ExpiresIn:               int(auth.DeviceCodeTTL.Seconds()),
+		Interval:                int(auth.DevicePollingInterval.Seconds()),
+	})
+}
+
+// describeDeviceAuthorization returns the pending device authorization request of the user code,
+// the verification page shows it to the user before the user approves it.
+func (h *handler) describeDeviceAuthorization(req *restful.Request, response *restful.Response) {
+	authenticated, _ := request.UserFrom(req.Request.Context())
+	if authenticated == nil || authenticated.GetName() == user.Anonymous {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.ErrorLoginRequired)
+		return
+	}
+
+	authorization, err := h.deviceOperator.DescribeDeviceAuthorization(req.QueryParameter("user_code"))
+	if err != nil {
+		if err == auth.ErrUserCodeNotFound {
+			api.HandleNotFound(response, req, err)
+			return
+		}
+		api.HandleInternalError(response, req, err)
+		return
+	}
+	response.WriteEntity(authorization)
+}
+
+// verifyDeviceAuthorization approves or denies the device authorization request of the user code on behalf of the
+// authenticated user, the user logs in with any identity provider on the verification page before that.
+func (h *handler) verifyDeviceAuthorization(req *restful.Request, response *restful.Response) {
+	authenticated, _ := request.UserFrom(req.Request.Context())
+	if authenticated == nil || authenticated.GetName() == user.Anonymous {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.ErrorLoginRequired)
+		return
+	}
+	// the restrictions of a personal access token can't be escaped by approving a device
+	if _, ok := authenticated.GetExtra()[iamv1alpha2.ExtraAccessToken]; ok {
+		api.HandleForbidden(response, req, fmt.Errorf("device authorization requests can not be approved with a personal access token"))
+		return
+	}
+
+	userCode, _ := req.BodyParameter("user_code")
+	approve, _ := req.BodyParameter("approve")
+	var err error
+	if approve == "true" {
+		err = h.deviceOperator.ApproveDeviceAuthorization(userCode, authenticated.GetName())
+	} else {
+		err = h.deviceOperator.DenyDeviceAuthorization(userCode)
+	}
+	if err != nil {
+		if err == auth.ErrUserCodeNotFound {
+			api.HandleNotFound(response, req, err)
+			return
+		}
+		api.HandleInternalError(response, req, err)
+		return
+	}
+	response.WriteEntity(errors.None)
+}
+
+// deviceCodeGrant issues the tokens to the user who approved the device authorization request,
+// the client polls with the device code until the request is approved, denied or expired.
+// https://datatracker.ietf.org/doc/html/rfc8628#section-3.4
+func (h *handler) deviceCodeGrant(clientID string, req *restful.Request, response *restful.Response) {
+	deviceCode, _ := req.BodyParameter("device_code")
+	username, err := h.deviceOperator.PollDeviceAuthorization(clientID, deviceCode)
+	if err != nil {
+		switch err {
+		case auth.ErrAuthorizationPending:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorAuthorizationPending)
+		case auth.ErrDevicePollingTooFrequent:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorSlowDown)
+		case auth.ErrDeviceAuthorizationDenied:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorAccessDenied)
+		case auth.ErrDeviceCodeExpired:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorExpiredToken)
+		default:
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		}
+		return
+	}
+
+	h.completeLogin(req, response, &user.DefaultInfo{Name: username}, "", nil)
+}
+
+// completeLogin creates a login session and issues the tokens to the authenticated user.
+func (h *handler) completeLogin(req *restful.Request, response *restful.Response, authenticated user.Info, provider string, recoveryCodes []string) {
+	sessionID, err := h.newSession(req, authenticated, provider)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	result, err := h.issueTokenTo(authenticated, sessionID)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -451,13 +736,28 @@ func (h *handler) passwordGrant(provider, username string, password string, req
 		klog.Errorf("Failed to record successful login for user %s, error: %v", authenticated.GetName(), err)
 	}
 
+	result.RecoveryCodes = recoveryCodes
 	response.WriteEntity(result)
 }
 
-func (h *handler) issueTokenTo(user user.Info) (*oauth.Token, error) {
+// newSession creates a login session of the authenticated user, the tokens issued after the login belong to the session.
+func (h *handler) newSession(req *restful.Request, authenticated user.Info, provider string) (string, error) {
+	session := &auth.Session{Username: authenticated.GetName(), IdentityProvider: provider}
+	if requestInfo, ok := request.RequestInfoFrom(req.Request.Context()); ok {
+		session.ClientIP = requestInfo.SourceIP
+		session.UserAgent = requestInfo.UserAgent
+	}
+	session, err := h.tokenOperator.CreateSession(session)
+	if err != nil {
+		return "", err
+	}
+	return session.ID, nil
+}
+
+func (h *handler) issueTokenTo(user user.Info, sessionID string) (*oauth.Token, error) {
 	accessToken, err := h.tokenOperator.IssueTo(&token.IssueRequest{
 		User:      user,
-		Claims:    token.Claims{TokenType: token.AccessToken},
+		Claims:    token.Claims{TokenType: token.AccessToken, SessionID: sessionID},
 		ExpiresIn: h.options.OAuthOptions.AccessTokenMaxAge,
 	})
 	if err != nil {
@@ -465,7 +765,7 @@ func (h *handler) issueTokenTo(user user.Info) (*oauth.Token, error) {
 	}
 	refreshToken, err := h.tokenOperator.IssueTo(&token.IssueRequest{
 		User:      user,
-		Claims:    token.Claims{TokenType: token.RefreshToken},
+		Claims:    token.Claims{TokenType: token.RefreshToken, SessionID: sessionID},
 		ExpiresIn: h.options.OAuthOptions.AccessTokenMaxAge + h.options.OAuthOptions.AccessTokenInactivityTimeout,
 	})
 	if err != nil {
@@ -503,6 +803,7 @@ func (h *handler) refreshTokenGrant(req *restful.Request, response *restful.Resp
 	}
 
 	authenticated := verified.User
+	sessionID := verified.SessionID
 	// update token after registration
 	if authenticated.GetName() == iamv1alpha2.PreRegistrationUser &&
 		authenticated.GetExtra() != nil &&
@@ -526,9 +827,19 @@ func (h *handler) refreshTokenGrant(req *restful.Request, response *restful.Resp
 		}
 
 		authenticated = &user.DefaultInfo{Name: result.Items[0].(*iamv1alpha2.User).Name}
+		// the session of the pre-registration user is not inherited
+		sessionID = ""
+	}
+
+	// a new session is created if the refresh token is not issued in a session
+	if sessionID == "" {
+		if sessionID, err = h.newSession(req, authenticated, ""); err != nil {
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+			return
+		}
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	result, err := h.issueTokenTo(authenticated, sessionID)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -564,7 +875,15 @@ func (h *handler) codeGrant(req *restful.Request, response *restful.Response) {
 		}
 	}()
 
-	result, err := h.issueTokenTo(authorizeContext.User)
+	sessionID := authorizeContext.SessionID
+	if sessionID == "" {
+		if sessionID, err = h.newSession(req, authorizeContext.User, ""); err != nil {
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+			return
+		}
+	}
+
+	result, err := h.issueTokenTo(authorizeContext.User, sessionID)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -648,6 +967,46 @@ func (h *handler) logout(req *restful.Request, resp *restful.Response) {
 	http.Redirect(resp, req.Request, redirectURL.String(), http.StatusFound)
 }
 
+// listSessions lists the active sessions of the authenticated user.
+func (h *handler) listSessions(req *restful.Request, response *restful.Response) {
+	authenticated, _ := request.UserFrom(req.Request.Context())
+	if authenticated == nil || authenticated.GetName() == user.Anonymous {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.ErrorLoginRequired)
+		return
+	}
+
+	sessions, err := h.tokenOperator.ListSessions(authenticated.GetName())
+	if err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+
+	result := &api.ListResult{Items: make([]interface{}, 0, len(sessions)), TotalItems: len(sessions)}
+	for _, session := range sessions {
+		result.Items = append(result.Items, session)
+	}
+	response.WriteEntity(result)
+}
+
+// revokeSession terminates a session of the authenticated user.
+func (h *handler) revokeSession(req *restful.Request, response *restful.Response) {
+	authenticated, _ := request.UserFrom(req.Request.Context())
+	if authenticated == nil || authenticated.GetName() == user.Anonymous {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.ErrorLoginRequired)
+		return
+	}
+
+	if err := h.tokenOperator.RevokeSession(authenticated.GetName(), req.PathParameter("session")); err != nil {
+		if err == auth.ErrSessionNotFound {
+			api.HandleNotFound(response, req, err)
+			return
+		}
+		api.HandleInternalError(response, req, err)
+		return
+	}
+	response.WriteEntity(errors.None)
+}
+
 // userinfo Endpoint is an OAuth 2.0 Protected Resource that returns Claims about the authenticated End-User.
 func (h *handler) userinfo(req *restful.Request, response *restful.Response) {
 	authenticated, _ := request.UserFrom(req.Request.Context())
@@ -25,10 +25,12 @@ import (
 	restfulspec "github.com/emicklei/go-restful-openapi"
 
 	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/saml"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/models/auth"
 	"kubesphere.io/kubesphere/pkg/models/iam/im"
+	"kubesphere.io/kubesphere/pkg/server/errors"
 )
 
 const contentTypeFormData = "application/x-www-form-urlencoded"
@@ -43,7 +45,9 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 	tokenOperator auth.TokenManagementInterface,
 	passwordAuthenticator auth.PasswordAuthenticator,
 	oauth2Authenticator auth.OAuthAuthenticator,
+	mfaAuthenticator auth.MultiFactorAuthenticator,
 	loginRecorder auth.LoginRecorder,
+	deviceOperator auth.DeviceAuthorizationOperator,
 	options *authentication.Options) error {
 
 	ws := &restful.WebService{}
@@ -51,7 +55,7 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	handler := newHandler(im, tokenOperator, passwordAuthenticator, oauth2Authenticator, loginRecorder, options)
+	handler := newHandler(im, tokenOperator, passwordAuthenticator, oauth2Authenticator, mfaAuthenticator, loginRecorder, deviceOperator, options)
 
 	ws.Route(ws.GET("/.well-known/openid-configuration").To(handler.discovery).
 		Doc("The OpenID Provider's configuration information can be retrieved."))
@@ -116,8 +120,41 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 		Param(ws.FormParameter("username", "The resource owner username.").Required(false)).
 		Param(ws.FormParameter("password", "The resource owner password.").Required(false)).
 		Param(ws.FormParameter("code", "Valid authorization code.").Required(false)).
+		Param(ws.FormParameter("mfa_token", "The token of the multi-factor authentication challenge "+
+			"returned by the password grant, required by the mfa_otp grant.").Required(false)).
+		Param(ws.FormParameter("otp", "The TOTP passcode or a recovery code, required by the mfa_otp grant.").Required(false)).
+		Param(ws.FormParameter("device_code", "The device verification code returned by the device authorization "+
+			"endpoint, required by the urn:ietf:params:oauth:grant-type:device_code grant.").Required(false)).
 		To(handler.token).
 		Returns(http.StatusOK, http.StatusText(http.StatusOK), &oauth.Token{}).
+		Returns(http.StatusForbidden, "The user must pass the multi-factor authentication challenge.", MultiFactorAuthRequired{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://datatracker.ietf.org/doc/html/rfc8628#section-3.1
+	ws.Route(ws.POST("/device_authorization").
+		Consumes(contentTypeFormData).
+		Doc("The device authorization endpoint is used by the clients on the devices which lack a browser, "+
+			"the user approves the request on the verification page while the client polls the token endpoint.").
+		Param(ws.FormParameter("client_id", "Valid client credential.").Required(true)).
+		Param(ws.FormParameter("client_secret", "Valid client credential.").Required(true)).
+		Param(ws.FormParameter("scope", "The scope of the access request.").Required(false)).
+		To(handler.deviceAuthorization).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), DeviceAuthorizationResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+	ws.Route(ws.GET("/device").
+		Doc("Retrieve the pending device authorization request of the user code for the current user to verify.").
+		Param(ws.QueryParameter("user_code", "The end-user verification code.").Required(true)).
+		To(handler.describeDeviceAuthorization).
+		Returns(http.StatusOK, api.StatusOK, auth.DeviceAuthorization{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+	ws.Route(ws.POST("/device").
+		Consumes(contentTypeFormData).
+		Doc("Approve or deny the device authorization request of the user code, the tokens are issued "+
+			"to the current user once approved.").
+		Param(ws.FormParameter("user_code", "The end-user verification code.").Required(true)).
+		Param(ws.FormParameter("approve", "Approve the request if true, otherwise deny it.").Required(true)).
+		To(handler.verifyDeviceAuthorization).
+		Returns(http.StatusOK, api.StatusOK, errors.None).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
 
 	// Authorization callback URL, where the end of the URL contains the identity provider name.
@@ -141,6 +178,26 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 		Returns(http.StatusOK, api.StatusOK, oauth.Token{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
 
+	// The SAML 2.0 identity providers post the SAML responses to the callback URL with the HTTP-POST binding.
+	ws.Route(ws.POST("/callback/{callback}").
+		Consumes(contentTypeFormData).
+		Doc("OAuth callback API for the identity providers which post the authentication response, "+
+			"the path param callback is config by identity provider").
+		Param(ws.FormParameter("SAMLResponse", "The base64 encoded SAML response.").Required(false)).
+		Param(ws.FormParameter("RelayState", "The opaque value sent along with the SAML response.").Required(false)).
+		To(handler.oauthCallback).
+		Returns(http.StatusOK, api.StatusOK, oauth.Token{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.GET("/metadata/{callback}").
+		Produces(saml.MetadataContentType, restful.MIME_XML).
+		Doc("The metadata of KubeSphere as a relying party of the identity provider, e.g. the SAML 2.0 SP metadata, "+
+			"the path param callback is config by identity provider").
+		Param(ws.PathParameter("callback", "The name of the identity provider.")).
+		To(handler.metadata).
+		Returns(http.StatusOK, api.StatusOK, "").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
 	// https://openid.net/specs/openid-connect-rpinitiated-1_0.html
 	ws.Route(ws.GET("/logout").
 		Doc("This endpoint takes an ID token and logs the user out of KubeSphere if the "+
@@ -158,6 +215,27 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 		Returns(http.StatusOK, http.StatusText(http.StatusOK), "").
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
 
+	ws.Route(ws.GET("/sessions").
+		Doc("List the active login sessions of the current user.").
+		To(handler.listSessions).
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{auth.Session{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.DELETE("/sessions/{session}").
+		Doc("Terminate a login session of the current user, all the tokens issued in the session are revoked.").
+		Param(ws.PathParameter("session", "session id")).
+		To(handler.revokeSession).
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.GET("/login/{identityprovider}").
+		Doc("Redirect to the identity provider with an authentication request, e.g. the SAML 2.0 AuthnRequest, "+
+			"the identity provider posts the response to the callback URL.").
+		Param(ws.PathParameter("identityprovider", "The identity provider name")).
+		To(handler.login).
+		Returns(http.StatusFound, http.StatusText(http.StatusFound), "").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
 	ws.Route(ws.POST("/login/{identityprovider}").
 		Consumes(contentTypeFormData).
 		Doc("Login by identity provider user").
@@ -28,7 +28,10 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
 	"kubesphere.io/kubesphere/pkg/api"
+	apirequest "kubesphere.io/kubesphere/pkg/apiserver/request"
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models/components"
 	"kubesphere.io/kubesphere/pkg/models/git"
@@ -56,7 +59,7 @@ type resourceHandler struct {
 	kubectlOperator     kubectl.Interface
 }
 
-func newResourceHandler(k8sClient kubernetes.Interface, factory informers.InformerFactory, masterURL string) *resourceHandler {
+func newResourceHandler(k8sClient kubernetes.Interface, factory informers.InformerFactory, kubeconfigOperator kubeconfig.Interface) *resourceHandler {
 
 	return &resourceHandler{
 		resourcesGetter:     resource.NewResourceGetter(factory),
@@ -66,7 +69,7 @@ func newResourceHandler(k8sClient kubernetes.Interface, factory informers.Inform
 		routerOperator:      routers.NewRouterOperator(k8sClient, factory.KubernetesSharedInformerFactory()),
 		gitVerifier:         git.NewGitVerifier(factory.KubernetesSharedInformerFactory()),
 		registryGetter:      registries.NewRegistryGetter(factory.KubernetesSharedInformerFactory()),
-		kubeconfigOperator:  kubeconfig.NewReadOnlyOperator(factory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps().Lister(), masterURL),
+		kubeconfigOperator:  kubeconfigOperator,
 		kubectlOperator: kubectl.NewOperator(nil, factory.KubernetesSharedInformerFactory().Apps().V1().Deployments(),
 			factory.KubernetesSharedInformerFactory().Core().V1().Pods(),
 			factory.KubeSphereSharedInformerFactory().Iam().V1alpha2().Users(), ""),
@@ -382,6 +385,21 @@ func (r *resourceHandler) GetKubectlPod(request *restful.Request, response *rest
 
 func (r *resourceHandler) GetKubeconfig(request *restful.Request, response *restful.Response) {
 	user := request.PathParameter("user")
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		api.HandleInternalError(response, request, fmt.Errorf("cannot obtain user info"))
+		return
+	}
+	// the kubeconfig carries the credentials of the user, which are only given to the user self
+	if operator.GetName() != user {
+		api.HandleForbidden(response, request, fmt.Errorf("kubeconfig of user %s can not be obtained by %s", user, operator.GetName()))
+		return
+	}
+	// the restrictions of a personal access token can't be escaped by a token issued for the kubeconfig
+	if _, ok := operator.GetExtra()[iamv1alpha2.ExtraAccessToken]; ok {
+		api.HandleForbidden(response, request, fmt.Errorf("kubeconfig can not be obtained with a personal access token"))
+		return
+	}
 
 	kubectlConfig, err := r.kubeconfigOperator.GetKubeConfig(user)
 
@@ -33,6 +33,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models"
 	gitmodel "kubesphere.io/kubesphere/pkg/models/git"
+	"kubesphere.io/kubesphere/pkg/models/kubeconfig"
 	registriesmodel "kubesphere.io/kubesphere/pkg/models/registries"
 	"kubesphere.io/kubesphere/pkg/server/errors"
 	"kubesphere.io/kubesphere/pkg/server/params"
@@ -44,9 +45,9 @@ const (
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
 
-func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, factory informers.InformerFactory, masterURL string) error {
+func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, factory informers.InformerFactory, kubeconfigOperator kubeconfig.Interface) error {
 	webservice := runtime.NewWebService(GroupVersion)
-	handler := newResourceHandler(k8sClient, factory, masterURL)
+	handler := newResourceHandler(k8sClient, factory, kubeconfigOperator)
 
 	webservice.Route(webservice.GET("/namespaces/{namespace}/{resources}").
 		To(handler.handleListNamespaceResources).
@@ -94,7 +95,7 @@ func AddToContainer(c *restful.Container, k8sClient kubernetes.Interface, factor
 	webservice.Route(webservice.GET("/users/{user}/kubeconfig").
 		Produces("text/plain", restful.MIME_JSON).
 		To(handler.GetKubeconfig).
-		Doc("get users' kubeconfig").
+		Doc("get users' kubeconfig, only the user self can get it without a personal access token").
 		Param(webservice.PathParameter("user", "username")).
 		Returns(http.StatusOK, api.StatusOK, "").
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.ToolboxTag}))
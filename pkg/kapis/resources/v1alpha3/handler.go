@@ -32,6 +32,7 @@ import (
 	v2 "kubesphere.io/kubesphere/pkg/models/registries/v2"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha2"
 	resourcev1alpha2 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha2/resource"
+	resources "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
 	"kubesphere.io/kubesphere/pkg/server/params"
 )
@@ -92,15 +93,21 @@ func (h *Handler) handleListResources(request *restful.Request, response *restfu
 	resourceType := request.PathParameter("resources")
 	namespace := request.PathParameter("namespace")
 
+	fieldPaths, err := validateListQuery(query)
+	if err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
 	result, err := h.resourceGetterV1alpha3.List(resourceType, namespace, query)
 	if err == nil {
-		response.WriteEntity(result)
+		writeListResult(response, request, query, fieldPaths, result)
 		return
 	}
 
 	if err != resourcev1alpha3.ErrResourceNotSupported {
 		klog.Errorf("%s, resource type: %s", err, resourceType)
-		api.HandleInternalError(response, request, err)
+		api.HandleError(response, request, err)
 		return
 	}
 
@@ -115,6 +122,44 @@ func (h *Handler) handleListResources(request *restful.Request, response *restfu
 		api.HandleError(response, request, err)
 		return
 	}
+	writeListResult(response, request, query, fieldPaths, result)
+}
+
+// validateListQuery validates the continue token, fields and format of the query, it returns the parsed field paths.
+func validateListQuery(q *query.Query) ([]*resources.FieldPath, error) {
+	if _, err := q.ContinueToken(); err != nil {
+		return nil, err
+	}
+	if len(q.Format) > 0 && q.Format != query.FormatTable {
+		return nil, fmt.Errorf("unsupported format %s", q.Format)
+	}
+	return resources.ParseFieldPaths(q.Fields)
+}
+
+// writeListResult writes the list in the requested format, with only the requested fields of the items.
+func writeListResult(response *restful.Response, request *restful.Request, q *query.Query, fieldPaths []*resources.FieldPath, result *api.ListResult) {
+	if q.Format == query.FormatTable {
+		if len(fieldPaths) == 0 {
+			fieldPaths, _ = resources.ParseFieldPaths(resources.DefaultTableColumns)
+		}
+		table, err := resources.ToTable(result.Items, fieldPaths)
+		if err != nil {
+			api.HandleInternalError(response, request, err)
+			return
+		}
+		table.Continue = result.Continue
+		response.WriteEntity(table)
+		return
+	}
+
+	if len(fieldPaths) > 0 {
+		items, err := resources.ProjectFields(result.Items, fieldPaths)
+		if err != nil {
+			api.HandleInternalError(response, request, err)
+			return
+		}
+		result.Items = items
+	}
 	response.WriteEntity(result)
 }
 
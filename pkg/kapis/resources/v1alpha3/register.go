@@ -65,6 +65,9 @@ func AddToContainer(c *restful.Container, informerFactory informers.InformerFact
 		Param(webservice.QueryParameter(query.ParameterName, "name used to do filtering").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webservice.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterContinue, "continue token returned in the previous page, the page parameter is ignored if it is set").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterFields, "field paths of the items to return, e.g. fields=metadata.name,status.phase, multiple separated by comma").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterFormat, "format of the list, set to table to return the Kubernetes Table with the fields as columns").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterAscending, "sort parameters, e.g. reverse=true").Required(false).DefaultValue("ascending=false")).
 		Param(webservice.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime")).
 		Returns(http.StatusOK, ok, api.ListResult{}))
@@ -86,6 +89,9 @@ func AddToContainer(c *restful.Container, informerFactory informers.InformerFact
 		Param(webservice.QueryParameter(query.ParameterName, "name used to do filtering").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webservice.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterContinue, "continue token returned in the previous page, the page parameter is ignored if it is set").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterFields, "field paths of the items to return, e.g. fields=metadata.name,status.phase, multiple separated by comma").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterFormat, "format of the list, set to table to return the Kubernetes Table with the fields as columns").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterAscending, "sort parameters, e.g. reverse=true").Required(false).DefaultValue("ascending=false")).
 		Param(webservice.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime")).
 		Param(webservice.QueryParameter(query.ParameterFieldSelector, "field selector used for filtering, you can use the = , == and != operators with field selectors( = and == mean the same thing), e.g. fieldSelector=type=kubernetes.io/dockerconfigjson, multiple separated by comma").Required(false)).
@@ -0,0 +1,452 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// filter is a parsed SCIM filter expression defined in RFC 7644 section 3.4.2.2,
+// it's evaluated against the JSON representation of a resource.
+type filter interface {
+	match(attributes map[string]interface{}) bool
+}
+
+type logicalFilter struct {
+	and         bool
+	left, right filter
+}
+
+func (f *logicalFilter) match(attributes map[string]interface{}) bool {
+	if f.and {
+		return f.left.match(attributes) && f.right.match(attributes)
+	}
+	return f.left.match(attributes) || f.right.match(attributes)
+}
+
+type notFilter struct {
+	filter filter
+}
+
+func (f *notFilter) match(attributes map[string]interface{}) bool {
+	return !f.filter.match(attributes)
+}
+
+// valuePathFilter matches the resource if any element of the multi-valued attribute matches the filter,
+// e.g. emails[type eq "work" and value co "@example.com"].
+type valuePathFilter struct {
+	attribute string
+	filter    filter
+}
+
+func (f *valuePathFilter) match(attributes map[string]interface{}) bool {
+	for _, element := range elements(attributes, f.attribute) {
+		if f.filter.match(element) {
+			return true
+		}
+	}
+	return false
+}
+
+type attributeFilter struct {
+	path     []string
+	operator string
+	value    interface{}
+}
+
+func (f *attributeFilter) match(attributes map[string]interface{}) bool {
+	values := lookup(attributes, f.path)
+	if f.operator == "pr" {
+		return len(values) > 0
+	}
+	if len(values) == 0 {
+		// absent attributes equal to null only
+		return (f.operator == "eq" && f.value == nil) || (f.operator == "ne" && f.value != nil)
+	}
+	for _, value := range values {
+		if compare(value, f.operator, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the non-null values of the attribute path, the values of the multi-valued attributes are flattened.
+// The "value" sub-attribute is used if a complex multi-valued attribute is compared directly, e.g. emails eq "a@b.c".
+func lookup(value interface{}, path []string) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var values []interface{}
+		for _, element := range v {
+			values = append(values, lookup(element, path)...)
+		}
+		return values
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return lookup(v, []string{"value"})
+		}
+		key, ok := findKey(v, path[0])
+		if !ok {
+			return nil
+		}
+		return lookup(v[key], path[1:])
+	default:
+		if len(path) > 0 {
+			return nil
+		}
+		return []interface{}{v}
+	}
+}
+
+// elements returns the elements of the complex multi-valued attribute.
+func elements(attributes map[string]interface{}, attribute string) []map[string]interface{} {
+	key, ok := findKey(attributes, attribute)
+	if !ok {
+		return nil
+	}
+	var result []map[string]interface{}
+	switch v := attributes[key].(type) {
+	case []interface{}:
+		for _, element := range v {
+			if m, ok := element.(map[string]interface{}); ok {
+				result = append(result, m)
+			}
+		}
+	case map[string]interface{}:
+		result = append(result, v)
+	}
+	return result
+}
+
+// findKey finds the attribute case-insensitively as the attribute names are case-insensitive in SCIM.
+func findKey(attributes map[string]interface{}, name string) (string, bool) {
+	if _, ok := attributes[name]; ok {
+		return name, true
+	}
+	for key := range attributes {
+		if strings.EqualFold(key, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// compare compares the attribute value with the filter value, the strings are compared case-insensitively.
+func compare(actual interface{}, operator string, expected interface{}) bool {
+	if expected == nil {
+		return operator == "ne"
+	}
+	switch a := actual.(type) {
+	case string:
+		e, ok := expected.(string)
+		if !ok {
+			return operator == "ne"
+		}
+		a, e = strings.ToLower(a), strings.ToLower(e)
+		switch operator {
+		case "eq":
+			return a == e
+		case "ne":
+			return a != e
+		case "co":
+			return strings.Contains(a, e)
+		case "sw":
+			return strings.HasPrefix(a, e)
+		case "ew":
+			return strings.HasSuffix(a, e)
+		case "gt":
+			return a > e
+		case "ge":
+			return a >= e
+		case "lt":
+			return a < e
+		case "le":
+			return a <= e
+		}
+	case bool:
+		e, ok := expected.(bool)
+		if !ok {
+			return operator == "ne"
+		}
+		switch operator {
+		case "eq":
+			return a == e
+		case "ne":
+			return a != e
+		}
+	case float64, json.Number:
+		a64, err := toFloat(a)
+		e64, ok := expected.(float64)
+		if err != nil || !ok {
+			return operator == "ne"
+		}
+		switch operator {
+		case "eq":
+			return a64 == e64
+		case "ne":
+			return a64 != e64
+		case "gt":
+			return a64 > e64
+		case "ge":
+			return a64 >= e64
+		case "lt":
+			return a64 < e64
+		case "le":
+			return a64 <= e64
+		}
+	}
+	return false
+}
+
+func toFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	}
+	return 0, fmt.Errorf("%v is not a number", value)
+}
+
+var comparisonOperators = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// parseFilter parses the filter expression, e.g. userName eq "admin" and not (emails pr).
+func parseFilter(expression string) (filter, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q in filter", p.peek())
+	}
+	return f, nil
+}
+
+type token struct {
+	text string
+	// quoted is true for string literals, the text is unquoted
+	quoted bool
+}
+
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '[' || c == ']':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(expression) && expression[end] != '"'; end++ {
+				if expression[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(expression) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			var text string
+			if err := json.Unmarshal([]byte(expression[i:end+1]), &text); err != nil {
+				return nil, fmt.Errorf("invalid string %s in filter", expression[i:end+1])
+			}
+			tokens = append(tokens, token{text: text, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for ; end < len(expression) && !strings.ContainsRune(" \t()[]\"", rune(expression[end])); end++ {
+			}
+			tokens = append(tokens, token{text: expression[i:end]})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *filterParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *filterParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+// keyword checks whether the next token is the unquoted keyword, the keywords are case-insensitive.
+func (p *filterParser) keyword(keyword string) bool {
+	return !p.done() && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *filterParser) expect(text string) error {
+	if !p.keyword(text) {
+		return fmt.Errorf("expected %q in filter", text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *filterParser) parseOr() (filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalFilter{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filter, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+	if p.keyword("not") {
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &notFilter{filter: f}, nil
+	}
+	if p.keyword("(") {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	if p.tokens[p.pos].quoted {
+		return nil, fmt.Errorf("expected attribute path in filter, got %q", p.peek())
+	}
+	path := attributePath(p.tokens[p.pos].text)
+	p.pos++
+	if p.keyword("[") {
+		p.pos++
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect("]"); err != nil {
+			return nil, err
+		}
+		return &valuePathFilter{attribute: strings.Join(path, "."), filter: f}, nil
+	}
+
+	if p.done() {
+		return nil, fmt.Errorf("expected operator in filter")
+	}
+	operator := strings.ToLower(p.tokens[p.pos].text)
+	p.pos++
+	if operator == "pr" {
+		return &attributeFilter{path: path, operator: operator}, nil
+	}
+	if !comparisonOperators[operator] {
+		return nil, fmt.Errorf("unsupported operator %q in filter", operator)
+	}
+	if p.done() {
+		return nil, fmt.Errorf("expected value in filter")
+	}
+	value, err := p.tokens[p.pos].value()
+	if err != nil {
+		return nil, err
+	}
+	p.pos++
+	return &attributeFilter{path: path, operator: operator, value: value}, nil
+}
+
+func (t token) value() (interface{}, error) {
+	if t.quoted {
+		return t.text, nil
+	}
+	switch strings.ToLower(t.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(t.text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q in filter", t.text)
+	}
+	return number, nil
+}
+
+// attributePath splits the attribute path into the attribute and the sub-attributes,
+// the schema URN prefix is removed, e.g. urn:ietf:params:scim:schemas:core:2.0:User:name.givenName.
+func attributePath(path string) []string {
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		// the schema URN contains dots, e.g. 2.0, so the attribute is after the last colon
+		path = path[strings.LastIndex(path, ":")+1:]
+	}
+	return strings.Split(path, ".")
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	active := true
+	attributes, err := toAttributes(&User{
+		Schemas:     []string{SchemaUser},
+		ID:          "admin",
+		UserName:    "admin",
+		DisplayName: "Administrator",
+		ExternalID:  "00u1",
+		Emails:      []MultiValuedAttribute{{Value: "admin@kubesphere.io", Type: "work", Primary: true}},
+		Active:      &active,
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		expression string
+		match      bool
+		wantErr    bool
+	}{
+		{expression: `userName eq "admin"`, match: true},
+		{expression: `USERNAME Eq "ADMIN"`, match: true},
+		{expression: `userName eq "bob"`, match: false},
+		{expression: `userName ne "bob"`, match: true},
+		{expression: `urn:ietf:params:scim:schemas:core:2.0:User:userName eq "admin"`, match: true},
+		{expression: `displayName sw "admin"`, match: true},
+		{expression: `displayName ew "tor"`, match: true},
+		{expression: `displayName co "nist"`, match: true},
+		{expression: `externalId pr`, match: true},
+		{expression: `preferredLanguage pr`, match: false},
+		{expression: `active eq true`, match: true},
+		{expression: `active eq false`, match: false},
+		{expression: `emails.value eq "admin@kubesphere.io"`, match: true},
+		{expression: `emails eq "admin@kubesphere.io"`, match: true},
+		{expression: `emails[type eq "work" and value ew "@kubesphere.io"]`, match: true},
+		{expression: `emails[type eq "home"]`, match: false},
+		{expression: `userName eq "bob" or displayName eq "Administrator"`, match: true},
+		{expression: `userName eq "admin" and not (active eq true)`, match: false},
+		{expression: `(userName eq "bob" or userName eq "admin") and active eq true`, match: true},
+		{expression: `userName eq`, wantErr: true},
+		{expression: `userName foo "admin"`, wantErr: true},
+		{expression: `userName eq "admin`, wantErr: true},
+		{expression: `(userName eq "admin"`, wantErr: true},
+		{expression: `userName eq "admin" extra`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			f, err := parseFilter(test.expression)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.match, f.match(attributes))
+		})
+	}
+}
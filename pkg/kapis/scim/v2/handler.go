@@ -0,0 +1,750 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/go-restful"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/authenticators/scim"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	apirequest "kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/iam/group"
+	"kubesphere.io/kubesphere/pkg/models/iam/im"
+)
+
+const (
+	scimTypeInvalidFilter = "invalidFilter"
+	scimTypeInvalidSyntax = "invalidSyntax"
+	scimTypeInvalidPath   = "invalidPath"
+	scimTypeInvalidValue  = "invalidValue"
+	scimTypeNoTarget      = "noTarget"
+	scimTypeMutability    = "mutability"
+	scimTypeUniqueness    = "uniqueness"
+)
+
+// scimError is responded as the SCIM error response.
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func newError(status int, scimType, detail string) *scimError {
+	return &scimError{status: status, scimType: scimType, detail: detail}
+}
+
+func (e *scimError) Error() string {
+	return e.detail
+}
+
+type handler struct {
+	im            im.IdentityManagementInterface
+	groupOperator group.GroupOperator
+	options       *authentication.SCIMOptions
+}
+
+func newHandler(im im.IdentityManagementInterface, groupOperator group.GroupOperator, options *authentication.SCIMOptions) *handler {
+	return &handler{im: im, groupOperator: groupOperator, options: options}
+}
+
+// requireProvisioner only allows the SCIM clients authenticated by the provisioning tokens.
+func requireProvisioner(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	info, ok := apirequest.UserFrom(req.Request.Context())
+	if !ok || info.GetName() == user.Anonymous {
+		writeError(resp, newError(http.StatusUnauthorized, "", "a provisioning token is required"))
+		return
+	}
+	if info.GetName() != scim.ProvisionerUser {
+		writeError(resp, newError(http.StatusForbidden, "", "a provisioning token is required"))
+		return
+	}
+	chain.ProcessFilter(req, resp)
+}
+
+func writeError(resp *restful.Response, err error) {
+	e, ok := err.(*scimError)
+	if !ok {
+		e = &scimError{status: http.StatusInternalServerError, detail: err.Error()}
+		if status, ok := err.(apierrors.APIStatus); ok {
+			e.status = int(status.Status().Code)
+			e.detail = status.Status().Message
+		}
+		switch {
+		case apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err):
+			e.scimType = scimTypeUniqueness
+		case apierrors.IsInvalid(err) || apierrors.IsBadRequest(err):
+			e.scimType = scimTypeInvalidValue
+		}
+	}
+	if e.status >= http.StatusInternalServerError {
+		klog.Error(e.detail)
+	}
+	_ = resp.WriteHeaderAndJson(e.status, &Error{
+		Schemas:  []string{SchemaError},
+		Status:   strconv.Itoa(e.status),
+		ScimType: e.scimType,
+		Detail:   e.detail,
+	}, MIMESCIM)
+}
+
+func writeResource(resp *restful.Response, status int, resource interface{}, location string) {
+	if location != "" {
+		resp.AddHeader("Location", location)
+	}
+	_ = resp.WriteHeaderAndJson(status, resource, MIMESCIM)
+}
+
+func (h *handler) serviceProviderConfig(_ *restful.Request, resp *restful.Response) {
+	writeResource(resp, http.StatusOK, &ServiceProviderConfig{
+		Schemas:        []string{SchemaServiceProviderConfig},
+		Patch:          Supported{Supported: true},
+		Filter:         FilterSupported{Supported: true, MaxResults: defaultCount},
+		ChangePassword: Supported{Supported: true},
+		AuthenticationSchemes: []AuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "Bearer Token",
+			Description: "Authentication with a provisioning token configured in ks-apiserver",
+		}},
+	}, "")
+}
+
+func (h *handler) resourceTypes(_ *restful.Request, resp *restful.Response) {
+	resourceTypes := []interface{}{
+		&ResourceType{Schemas: []string{SchemaResourceType}, ID: ResourceTypeUser, Name: ResourceTypeUser, Endpoint: "/Users", Schema: SchemaUser},
+		&ResourceType{Schemas: []string{SchemaResourceType}, ID: ResourceTypeGroup, Name: ResourceTypeGroup, Endpoint: "/Groups", Schema: SchemaGroup},
+	}
+	writeResource(resp, http.StatusOK, &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(resourceTypes),
+		StartIndex:   1,
+		ItemsPerPage: len(resourceTypes),
+		Resources:    resourceTypes,
+	}, "")
+}
+
+// listResponse filters and paginates the resources with the query parameters filter, startIndex and count.
+func listResponse(req *restful.Request, resources []interface{}) (*ListResponse, error) {
+	if expression := req.QueryParameter("filter"); expression != "" {
+		f, err := parseFilter(expression)
+		if err != nil {
+			return nil, newError(http.StatusBadRequest, scimTypeInvalidFilter, err.Error())
+		}
+		var matched []interface{}
+		for _, resource := range resources {
+			attributes, err := toAttributes(resource)
+			if err != nil {
+				return nil, err
+			}
+			if f.match(attributes) {
+				matched = append(matched, resource)
+			}
+		}
+		resources = matched
+	}
+
+	startIndex, count := 1, defaultCount
+	if value := req.QueryParameter("startIndex"); value != "" {
+		if i, err := strconv.Atoi(value); err == nil && i > 1 {
+			startIndex = i
+		}
+	}
+	if value := req.QueryParameter("count"); value != "" {
+		if i, err := strconv.Atoi(value); err == nil && i >= 0 && i < defaultCount {
+			count = i
+		}
+	}
+	page := make([]interface{}, 0)
+	if start := startIndex - 1; start < len(resources) {
+		end := start + count
+		if end > len(resources) {
+			end = len(resources)
+		}
+		page = append(page, resources[start:end]...)
+	}
+	return &ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}, nil
+}
+
+func sortedByName() *query.Query {
+	q := query.New()
+	q.SortBy = query.FieldName
+	q.Ascending = true
+	return q
+}
+
+// memberships returns the groups of the users in the workspace, the values are the group names.
+func (h *handler) memberships() (map[string][]MultiValuedAttribute, error) {
+	groups, err := h.groupOperator.ListGroups(h.options.Workspace, sortedByName())
+	if err != nil {
+		return nil, err
+	}
+	displayNames := make(map[string]string)
+	for _, item := range groups.Items {
+		g := item.(*iamv1alpha2.Group)
+		displayNames[g.Name] = groupDisplayName(g)
+	}
+	bindings, err := h.groupOperator.ListGroupBindings(h.options.Workspace, query.New())
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]MultiValuedAttribute)
+	for _, item := range bindings.Items {
+		binding := item.(*iamv1alpha2.GroupBinding)
+		displayName, ok := displayNames[binding.GroupRef.Name]
+		if !ok {
+			continue
+		}
+		for _, username := range binding.Users {
+			result[username] = append(result[username], MultiValuedAttribute{
+				Value:   binding.GroupRef.Name,
+				Display: displayName,
+				Ref:     groupLocation(binding.GroupRef.Name),
+				Type:    "direct",
+			})
+		}
+	}
+	for username := range result {
+		sort.Slice(result[username], func(i, j int) bool { return result[username][i].Value < result[username][j].Value })
+	}
+	return result, nil
+}
+
+func userLocation(name string) string {
+	return fmt.Sprintf("%s/Users/%s", scim.PathPrefix, name)
+}
+
+func groupLocation(name string) string {
+	return fmt.Sprintf("%s/Groups/%s", scim.PathPrefix, name)
+}
+
+func version(object metav1.Object) string {
+	return fmt.Sprintf("W/%q", object.GetResourceVersion())
+}
+
+func toSCIMUser(user *iamv1alpha2.User, groups []MultiValuedAttribute) *User {
+	active := user.Status.State != iamv1alpha2.UserDisabled
+	created := user.CreationTimestamp.Time
+	result := &User{
+		Schemas:           []string{SchemaUser},
+		ID:                user.Name,
+		ExternalID:        user.Annotations[ExternalIDAnnotation],
+		UserName:          user.Name,
+		DisplayName:       user.Annotations[constants.DisplayNameAnnotationKey],
+		PreferredLanguage: user.Spec.Lang,
+		Active:            &active,
+		Groups:            groups,
+		Meta: &Meta{
+			ResourceType: ResourceTypeUser,
+			Created:      &created,
+			Location:     userLocation(user.Name),
+			Version:      version(user),
+		},
+	}
+	if user.Spec.Email != "" {
+		result.Emails = []MultiValuedAttribute{{Value: user.Spec.Email, Type: "work", Primary: true}}
+	}
+	return result
+}
+
+// primaryEmail returns the primary email, or the first one if no email is primary.
+func primaryEmail(emails []MultiValuedAttribute) string {
+	for _, email := range emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(emails) > 0 {
+		return emails[0].Value
+	}
+	return ""
+}
+
+func setAnnotation(object metav1.Object, key, value string) {
+	annotations := object.GetAnnotations()
+	if value == "" {
+		delete(annotations, key)
+		return
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[key] = value
+	object.SetAnnotations(annotations)
+}
+
+// applyUser sets the attributes of the SCIM user to the IAM user, the password is not included.
+func (h *handler) applyUser(in *User, user *iamv1alpha2.User) {
+	setAnnotation(user, ExternalIDAnnotation, in.ExternalID)
+	setAnnotation(user, constants.DisplayNameAnnotationKey, in.DisplayName)
+	user.Spec.Email = primaryEmail(in.Emails)
+	user.Spec.Lang = in.PreferredLanguage
+	if h.options.IdentityProvider != "" && in.ExternalID != "" {
+		if user.Labels == nil {
+			user.Labels = make(map[string]string)
+		}
+		user.Labels[iamv1alpha2.IdentifyProviderLabel] = h.options.IdentityProvider
+		user.Labels[iamv1alpha2.OriginUIDLabel] = in.ExternalID
+	}
+	// the users locked for the failed login attempts are still active in SCIM
+	if in.Active != nil {
+		if !*in.Active {
+			user.Status.State = iamv1alpha2.UserDisabled
+		} else if user.Status.State == iamv1alpha2.UserDisabled {
+			user.Status.State = iamv1alpha2.UserActive
+		}
+	}
+}
+
+// managedUser returns the user provisioned by the SCIM client, the users created otherwise, e.g. admin,
+// can't be changed to avoid taking over the accounts by linking them to the identities of the SCIM client.
+func (h *handler) managedUser(name string) (*iamv1alpha2.User, error) {
+	user, err := h.im.DescribeUser(name)
+	if err != nil {
+		return nil, err
+	}
+	if user.Labels[ManagedByLabel] != ManagedBySCIM {
+		return nil, newError(http.StatusForbidden, "", fmt.Sprintf("user %s is not provisioned by SCIM", name))
+	}
+	return user, nil
+}
+
+func (h *handler) describeUser(name string) (*User, error) {
+	user, err := h.im.DescribeUser(name)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := h.memberships()
+	if err != nil {
+		return nil, err
+	}
+	return toSCIMUser(user, memberships[user.Name]), nil
+}
+
+func (h *handler) listUsers(req *restful.Request, resp *restful.Response) {
+	result, err := h.im.ListUsers(sortedByName())
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	memberships, err := h.memberships()
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	users := make([]interface{}, 0, len(result.Items))
+	for _, item := range result.Items {
+		user := item.(*iamv1alpha2.User)
+		users = append(users, toSCIMUser(user, memberships[user.Name]))
+	}
+	list, err := listResponse(req, users)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	writeResource(resp, http.StatusOK, list, "")
+}
+
+func (h *handler) getUser(req *restful.Request, resp *restful.Response) {
+	user, err := h.describeUser(req.PathParameter("id"))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	writeResource(resp, http.StatusOK, user, "")
+}
+
+func (h *handler) createUser(req *restful.Request, resp *restful.Response) {
+	in := &User{}
+	if err := req.ReadEntity(in); err != nil {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidSyntax, err.Error()))
+		return
+	}
+	if in.UserName == "" {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidValue, "userName is required"))
+		return
+	}
+	user := &iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{
+		Name:   in.UserName,
+		Labels: map[string]string{ManagedByLabel: ManagedBySCIM},
+	}}
+	// the state is left empty unless disabled, the user controller activates the user after the password is encrypted
+	h.applyUser(in, user)
+	user.Spec.EncryptedPassword = in.Password
+	created, err := h.im.CreateUser(user)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	writeResource(resp, http.StatusCreated, toSCIMUser(created, nil), userLocation(created.Name))
+}
+
+func (h *handler) replaceUser(req *restful.Request, resp *restful.Response) {
+	in := &User{}
+	if err := req.ReadEntity(in); err != nil {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidSyntax, err.Error()))
+		return
+	}
+	h.updateUser(req.PathParameter("id"), in, resp)
+}
+
+func (h *handler) patchUser(req *restful.Request, resp *restful.Response) {
+	patch := &PatchRequest{}
+	if err := req.ReadEntity(patch); err != nil {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidSyntax, err.Error()))
+		return
+	}
+	current, err := h.describeUser(req.PathParameter("id"))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	attributes, err := toAttributes(current)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	if err = applyPatch(attributes, patch.Operations); err != nil {
+		writeError(resp, err)
+		return
+	}
+	in := &User{}
+	if err = fromAttributes(attributes, in); err != nil {
+		writeError(resp, err)
+		return
+	}
+	h.updateUser(current.ID, in, resp)
+}
+
+func (h *handler) updateUser(name string, in *User, resp *restful.Response) {
+	if in.UserName != "" && in.UserName != name {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeMutability, "userName can't be changed"))
+		return
+	}
+	user, err := h.managedUser(name)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	user = user.DeepCopy()
+	h.applyUser(in, user)
+	updated, err := h.im.UpdateUser(user)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	if in.Password != "" {
+		if err = h.im.ModifyPassword(name, in.Password); err != nil {
+			writeError(resp, err)
+			return
+		}
+	}
+	memberships, err := h.memberships()
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	writeResource(resp, http.StatusOK, toSCIMUser(updated, memberships[name]), "")
+}
+
+func (h *handler) deleteUser(req *restful.Request, resp *restful.Response) {
+	name := req.PathParameter("id")
+	if _, err := h.managedUser(name); err != nil {
+		writeError(resp, err)
+		return
+	}
+	if err := h.im.DeleteUser(name); err != nil {
+		writeError(resp, err)
+		return
+	}
+	resp.WriteHeader(http.StatusNoContent)
+}
+
+// groupDisplayName returns the display name of the group, the generateName is used
+// as the display name of the groups created from the console.
+func groupDisplayName(group *iamv1alpha2.Group) string {
+	if displayName := group.Annotations[constants.DisplayNameAnnotationKey]; displayName != "" {
+		return displayName
+	}
+	if group.GenerateName != "" {
+		return group.GenerateName
+	}
+	return group.Name
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// generateName converts the display name to the prefix of the group name, which is a DNS-1123 label.
+func generateName(displayName string) string {
+	name := invalidNameCharacters.ReplaceAllString(strings.ToLower(displayName), "-")
+	if len(name) > 50 {
+		name = name[:50]
+	}
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = "group"
+	}
+	return name
+}
+
+// members returns the users of the group in the workspace, the bindings are returned by the usernames.
+func (h *handler) members(groupName string) (map[string]*iamv1alpha2.GroupBinding, error) {
+	q := query.New()
+	q.LabelSelector = fmt.Sprintf("%s=%s", iamv1alpha2.GroupReferenceLabel, groupName)
+	bindings, err := h.groupOperator.ListGroupBindings(h.options.Workspace, q)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*iamv1alpha2.GroupBinding)
+	for _, item := range bindings.Items {
+		binding := item.(*iamv1alpha2.GroupBinding)
+		for _, username := range binding.Users {
+			result[username] = binding
+		}
+	}
+	return result, nil
+}
+
+func toSCIMGroup(group *iamv1alpha2.Group, members map[string]*iamv1alpha2.GroupBinding) *Group {
+	created := group.CreationTimestamp.Time
+	result := &Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          group.Name,
+		ExternalID:  group.Annotations[ExternalIDAnnotation],
+		DisplayName: groupDisplayName(group),
+		Meta: &Meta{
+			ResourceType: ResourceTypeGroup,
+			Created:      &created,
+			Location:     groupLocation(group.Name),
+			Version:      version(group),
+		},
+	}
+	for username := range members {
+		result.Members = append(result.Members, MultiValuedAttribute{
+			Value:   username,
+			Display: username,
+			Ref:     userLocation(username),
+			Type:    ResourceTypeUser,
+		})
+	}
+	sort.Slice(result.Members, func(i, j int) bool { return result.Members[i].Value < result.Members[j].Value })
+	return result
+}
+
+// syncMembers creates and deletes the group bindings to make the users of the group the same as the members.
+func (h *handler) syncMembers(groupName string, members []MultiValuedAttribute) (map[string]*iamv1alpha2.GroupBinding, error) {
+	existing, err := h.members(groupName)
+	if err != nil {
+		return nil, err
+	}
+	desired := make(map[string]bool)
+	for _, member := range members {
+		if member.Value == "" {
+			return nil, newError(http.StatusBadRequest, scimTypeInvalidValue, "value of the member is required")
+		}
+		desired[member.Value] = true
+	}
+	for username := range desired {
+		if _, ok := existing[username]; ok {
+			continue
+		}
+		if _, err = h.im.DescribeUser(username); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, newError(http.StatusBadRequest, scimTypeInvalidValue, fmt.Sprintf("member %s is not a user", username))
+			}
+			return nil, err
+		}
+		binding, err := h.groupOperator.CreateGroupBinding(h.options.Workspace, groupName, username)
+		if err != nil {
+			return nil, err
+		}
+		existing[username] = binding
+	}
+	for username, binding := range existing {
+		if desired[username] {
+			continue
+		}
+		if err = h.groupOperator.DeleteGroupBinding(h.options.Workspace, binding.Name); err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		delete(existing, username)
+	}
+	return existing, nil
+}
+
+func (h *handler) listGroups(req *restful.Request, resp *restful.Response) {
+	result, err := h.groupOperator.ListGroups(h.options.Workspace, sortedByName())
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	groups := make([]interface{}, 0, len(result.Items))
+	for _, item := range result.Items {
+		g := item.(*iamv1alpha2.Group)
+		members, err := h.members(g.Name)
+		if err != nil {
+			writeError(resp, err)
+			return
+		}
+		groups = append(groups, toSCIMGroup(g, members))
+	}
+	list, err := listResponse(req, groups)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	writeResource(resp, http.StatusOK, list, "")
+}
+
+func (h *handler) describeGroup(name string) (*iamv1alpha2.Group, *Group, error) {
+	g, err := h.groupOperator.DescribeGroup(h.options.Workspace, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := h.members(g.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, toSCIMGroup(g, members), nil
+}
+
+func (h *handler) getGroup(req *restful.Request, resp *restful.Response) {
+	_, g, err := h.describeGroup(req.PathParameter("id"))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	writeResource(resp, http.StatusOK, g, "")
+}
+
+func (h *handler) createGroup(req *restful.Request, resp *restful.Response) {
+	in := &Group{}
+	if err := req.ReadEntity(in); err != nil {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidSyntax, err.Error()))
+		return
+	}
+	if in.DisplayName == "" {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidValue, "displayName is required"))
+		return
+	}
+	g := &iamv1alpha2.Group{ObjectMeta: metav1.ObjectMeta{GenerateName: generateName(in.DisplayName)}}
+	setAnnotation(g, constants.DisplayNameAnnotationKey, in.DisplayName)
+	setAnnotation(g, ExternalIDAnnotation, in.ExternalID)
+	created, err := h.groupOperator.CreateGroup(h.options.Workspace, g)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	members, err := h.syncMembers(created.Name, in.Members)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	writeResource(resp, http.StatusCreated, toSCIMGroup(created, members), groupLocation(created.Name))
+}
+
+func (h *handler) replaceGroup(req *restful.Request, resp *restful.Response) {
+	in := &Group{}
+	if err := req.ReadEntity(in); err != nil {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidSyntax, err.Error()))
+		return
+	}
+	g, err := h.groupOperator.DescribeGroup(h.options.Workspace, req.PathParameter("id"))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	h.updateGroup(g, in, resp)
+}
+
+func (h *handler) patchGroup(req *restful.Request, resp *restful.Response) {
+	patch := &PatchRequest{}
+	if err := req.ReadEntity(patch); err != nil {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidSyntax, err.Error()))
+		return
+	}
+	g, current, err := h.describeGroup(req.PathParameter("id"))
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	attributes, err := toAttributes(current)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	if err = applyPatch(attributes, patch.Operations); err != nil {
+		writeError(resp, err)
+		return
+	}
+	in := &Group{}
+	if err = fromAttributes(attributes, in); err != nil {
+		writeError(resp, err)
+		return
+	}
+	h.updateGroup(g, in, resp)
+}
+
+func (h *handler) updateGroup(g *iamv1alpha2.Group, in *Group, resp *restful.Response) {
+	if in.DisplayName == "" {
+		writeError(resp, newError(http.StatusBadRequest, scimTypeInvalidValue, "displayName is required"))
+		return
+	}
+	updated := g.DeepCopy()
+	setAnnotation(updated, constants.DisplayNameAnnotationKey, in.DisplayName)
+	setAnnotation(updated, ExternalIDAnnotation, in.ExternalID)
+	var err error
+	if groupDisplayName(updated) != groupDisplayName(g) || updated.Annotations[ExternalIDAnnotation] != g.Annotations[ExternalIDAnnotation] {
+		if updated, err = h.groupOperator.UpdateGroup(h.options.Workspace, updated); err != nil {
+			writeError(resp, err)
+			return
+		}
+	}
+	members, err := h.syncMembers(updated.Name, in.Members)
+	if err != nil {
+		writeError(resp, err)
+		return
+	}
+	writeResource(resp, http.StatusOK, toSCIMGroup(updated, members), "")
+}
+
+func (h *handler) deleteGroup(req *restful.Request, resp *restful.Response) {
+	if err := h.groupOperator.DeleteGroup(h.options.Workspace, req.PathParameter("id")); err != nil {
+		writeError(resp, err)
+		return
+	}
+	resp.WriteHeader(http.StatusNoContent)
+}
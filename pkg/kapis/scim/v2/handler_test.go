@@ -0,0 +1,394 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/authenticators/scim"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	apirequest "kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/iam/group"
+	"kubesphere.io/kubesphere/pkg/models/iam/im"
+)
+
+type fakeIM struct {
+	im.IdentityManagementInterface
+	users     map[string]*iamv1alpha2.User
+	passwords map[string]string
+}
+
+func (f *fakeIM) CreateUser(u *iamv1alpha2.User) (*iamv1alpha2.User, error) {
+	if _, ok := f.users[u.Name]; ok {
+		return nil, apierrors.NewAlreadyExists(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), u.Name)
+	}
+	u = u.DeepCopy()
+	u.ResourceVersion = "1"
+	f.users[u.Name] = u
+	return u, nil
+}
+
+func (f *fakeIM) ListUsers(_ *query.Query) (*api.ListResult, error) {
+	var names []string
+	for name := range f.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := &api.ListResult{TotalItems: len(names)}
+	for _, name := range names {
+		result.Items = append(result.Items, f.users[name])
+	}
+	return result, nil
+}
+
+func (f *fakeIM) DescribeUser(name string) (*iamv1alpha2.User, error) {
+	if u, ok := f.users[name]; ok {
+		return u, nil
+	}
+	return nil, apierrors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser), name)
+}
+
+func (f *fakeIM) UpdateUser(u *iamv1alpha2.User) (*iamv1alpha2.User, error) {
+	if _, err := f.DescribeUser(u.Name); err != nil {
+		return nil, err
+	}
+	f.users[u.Name] = u
+	return u, nil
+}
+
+func (f *fakeIM) DeleteUser(name string) error {
+	delete(f.users, name)
+	return nil
+}
+
+func (f *fakeIM) ModifyPassword(name string, password string) error {
+	f.passwords[name] = password
+	return nil
+}
+
+type fakeGroupOperator struct {
+	group.GroupOperator
+	groups   map[string]*iamv1alpha2.Group
+	bindings map[string]*iamv1alpha2.GroupBinding
+	sequence int
+}
+
+func (f *fakeGroupOperator) inWorkspace(object runtime.Object, workspace string, selector labels.Selector) bool {
+	l := object.(interface{ GetLabels() map[string]string }).GetLabels()
+	return l[tenantv1alpha1.WorkspaceLabel] == workspace && selector.Matches(labels.Set(l))
+}
+
+func (f *fakeGroupOperator) ListGroups(workspace string, _ *query.Query) (*api.ListResult, error) {
+	var names []string
+	for name, g := range f.groups {
+		if f.inWorkspace(g, workspace, labels.Everything()) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	result := &api.ListResult{TotalItems: len(names)}
+	for _, name := range names {
+		result.Items = append(result.Items, f.groups[name])
+	}
+	return result, nil
+}
+
+func (f *fakeGroupOperator) CreateGroup(workspace string, g *iamv1alpha2.Group) (*iamv1alpha2.Group, error) {
+	for _, existing := range f.groups {
+		if existing.GenerateName == g.GenerateName && f.inWorkspace(existing, workspace, labels.Everything()) {
+			return nil, apierrors.NewConflict(iamv1alpha2.Resource(iamv1alpha2.ResourcePluralGroup), g.GenerateName, fmt.Errorf("already exists"))
+		}
+	}
+	f.sequence++
+	g = g.DeepCopy()
+	g.Name = fmt.Sprintf("%s%d", g.GenerateName, f.sequence)
+	g.Labels = map[string]string{tenantv1alpha1.WorkspaceLabel: workspace}
+	g.ResourceVersion = "1"
+	f.groups[g.Name] = g
+	return g, nil
+}
+
+func (f *fakeGroupOperator) DescribeGroup(workspace, name string) (*iamv1alpha2.Group, error) {
+	if g, ok := f.groups[name]; ok && f.inWorkspace(g, workspace, labels.Everything()) {
+		return g, nil
+	}
+	return nil, apierrors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcePluralGroup), name)
+}
+
+func (f *fakeGroupOperator) UpdateGroup(workspace string, g *iamv1alpha2.Group) (*iamv1alpha2.Group, error) {
+	if _, err := f.DescribeGroup(workspace, g.Name); err != nil {
+		return nil, err
+	}
+	f.groups[g.Name] = g
+	return g, nil
+}
+
+func (f *fakeGroupOperator) DeleteGroup(workspace, name string) error {
+	if _, err := f.DescribeGroup(workspace, name); err != nil {
+		return err
+	}
+	delete(f.groups, name)
+	return nil
+}
+
+func (f *fakeGroupOperator) CreateGroupBinding(workspace, groupName, userName string) (*iamv1alpha2.GroupBinding, error) {
+	f.sequence++
+	binding := &iamv1alpha2.GroupBinding{}
+	binding.Name = fmt.Sprintf("%s-%s-%d", groupName, userName, f.sequence)
+	binding.Labels = map[string]string{
+		iamv1alpha2.UserReferenceLabel:  userName,
+		iamv1alpha2.GroupReferenceLabel: groupName,
+		tenantv1alpha1.WorkspaceLabel:   workspace,
+	}
+	binding.Users = []string{userName}
+	binding.GroupRef = iamv1alpha2.GroupRef{Name: groupName}
+	f.bindings[binding.Name] = binding
+	return binding, nil
+}
+
+func (f *fakeGroupOperator) DeleteGroupBinding(_, name string) error {
+	delete(f.bindings, name)
+	return nil
+}
+
+func (f *fakeGroupOperator) ListGroupBindings(workspace string, q *query.Query) (*api.ListResult, error) {
+	selector, err := labels.Parse(q.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	result := &api.ListResult{}
+	for _, binding := range f.bindings {
+		if f.inWorkspace(binding, workspace, selector) {
+			result.Items = append(result.Items, binding)
+		}
+	}
+	result.TotalItems = len(result.Items)
+	return result, nil
+}
+
+type fixture struct {
+	im            *fakeIM
+	groupOperator *fakeGroupOperator
+	container     *restful.Container
+}
+
+func newFixture(t *testing.T) *fixture {
+	f := &fixture{
+		im: &fakeIM{users: map[string]*iamv1alpha2.User{
+			"alice": {},
+			"bob":   {},
+		}, passwords: map[string]string{}},
+		groupOperator: &fakeGroupOperator{groups: map[string]*iamv1alpha2.Group{}, bindings: map[string]*iamv1alpha2.GroupBinding{}},
+		container:     restful.NewContainer(),
+	}
+	for name, u := range f.im.users {
+		u.Name = name
+		u.Status.State = iamv1alpha2.UserActive
+	}
+	options := authentication.NewSCIMOptions()
+	options.Enable = true
+	assert.NoError(t, AddToContainer(f.container, f.im, f.groupOperator, options))
+	return f
+}
+
+func (f *fixture) do(t *testing.T, method, path string, body interface{}, out interface{}) *httptest.ResponseRecorder {
+	return f.doAs(t, scim.ProvisionerUser, method, path, body, out)
+}
+
+func (f *fixture) doAs(t *testing.T, username, method, path string, body interface{}, out interface{}) *httptest.ResponseRecorder {
+	var data string
+	if body != nil {
+		raw, err := json.Marshal(body)
+		assert.NoError(t, err)
+		data = string(raw)
+	}
+	req := httptest.NewRequest(method, scim.PathPrefix+path, strings.NewReader(data))
+	req.Header.Set("Content-Type", MIMESCIM)
+	req = req.WithContext(apirequest.WithUser(req.Context(), &user.DefaultInfo{Name: username}))
+	recorder := httptest.NewRecorder()
+	f.container.ServeHTTP(recorder, req)
+	if out != nil {
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), out), recorder.Body.String())
+	}
+	return recorder
+}
+
+func TestRequireProvisioner(t *testing.T) {
+	f := newFixture(t)
+	e := &Error{}
+	assert.Equal(t, http.StatusUnauthorized, f.doAs(t, user.Anonymous, http.MethodGet, "/Users", nil, e).Code)
+	assert.Equal(t, "401", e.Status)
+	assert.Equal(t, http.StatusForbidden, f.doAs(t, "admin", http.MethodGet, "/Users", nil, nil).Code)
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodGet, "/ServiceProviderConfig", nil, nil).Code)
+}
+
+func TestUsers(t *testing.T) {
+	f := newFixture(t)
+
+	created := &User{}
+	active := false
+	resp := f.do(t, http.MethodPost, "/Users", &User{
+		Schemas:     []string{SchemaUser},
+		UserName:    "carol",
+		ExternalID:  "00u3",
+		DisplayName: "Carol",
+		Emails:      []MultiValuedAttribute{{Value: "carol@kubesphere.io", Primary: true}},
+		Active:      &active,
+		Password:    "P@88w0rd",
+	}, created)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, scim.PathPrefix+"/Users/carol", resp.Header().Get("Location"))
+	assert.Equal(t, "carol", created.ID)
+	assert.Empty(t, created.Password)
+	stored := f.im.users["carol"]
+	assert.Equal(t, "P@88w0rd", stored.Spec.EncryptedPassword)
+	assert.Equal(t, iamv1alpha2.UserDisabled, stored.Status.State)
+	assert.Equal(t, "carol@kubesphere.io", stored.Spec.Email)
+	assert.Equal(t, "Carol", stored.Annotations[constants.DisplayNameAnnotationKey])
+	assert.Equal(t, "00u3", stored.Annotations[ExternalIDAnnotation])
+	assert.Equal(t, ManagedBySCIM, stored.Labels[ManagedByLabel])
+
+	e := &Error{}
+	assert.Equal(t, http.StatusConflict, f.do(t, http.MethodPost, "/Users", &User{UserName: "carol"}, e).Code)
+	assert.Equal(t, scimTypeUniqueness, e.ScimType)
+
+	list := &ListResponse{}
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodGet, `/Users?filter=externalId+eq+"00u3"`, nil, list).Code)
+	assert.Equal(t, 1, list.TotalResults)
+	list = &ListResponse{}
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodGet, "/Users?startIndex=2&count=1", nil, list).Code)
+	assert.Equal(t, 3, list.TotalResults)
+	assert.Equal(t, 1, list.ItemsPerPage)
+	assert.Equal(t, "bob", list.Resources[0].(map[string]interface{})["userName"])
+	e = &Error{}
+	assert.Equal(t, http.StatusBadRequest, f.do(t, http.MethodGet, `/Users?filter=userName+eq`, nil, e).Code)
+	assert.Equal(t, scimTypeInvalidFilter, e.ScimType)
+
+	patched := &User{}
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodPatch, "/Users/carol", &PatchRequest{
+		Schemas: []string{SchemaPatchOp},
+		Operations: []PatchOperation{
+			{Op: "replace", Path: "active", Value: true},
+			{Op: "replace", Path: "password", Value: "N3wP@88w0rd"},
+		},
+	}, patched).Code)
+	assert.True(t, *patched.Active)
+	assert.Equal(t, "Carol", patched.DisplayName)
+	assert.Equal(t, iamv1alpha2.UserActive, f.im.users["carol"].Status.State)
+	assert.Equal(t, "N3wP@88w0rd", f.im.passwords["carol"])
+
+	e = &Error{}
+	assert.Equal(t, http.StatusBadRequest, f.do(t, http.MethodPut, "/Users/carol", &User{UserName: "dave"}, e).Code)
+	assert.Equal(t, scimTypeMutability, e.ScimType)
+
+	replaced := &User{}
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodPut, "/Users/carol", &User{UserName: "carol"}, replaced).Code)
+	assert.Empty(t, replaced.DisplayName)
+	assert.Empty(t, f.im.users["carol"].Spec.Email)
+
+	// the users not provisioned by SCIM can't be changed
+	for _, req := range []struct {
+		method string
+		body   interface{}
+	}{
+		{method: http.MethodPut, body: &User{UserName: "alice", ExternalID: "00u1", Password: "P@88w0rd"}},
+		{method: http.MethodPatch, body: &PatchRequest{
+			Schemas:    []string{SchemaPatchOp},
+			Operations: []PatchOperation{{Op: "replace", Path: "password", Value: "P@88w0rd"}},
+		}},
+		{method: http.MethodDelete},
+	} {
+		assert.Equal(t, http.StatusForbidden, f.do(t, req.method, "/Users/alice", req.body, nil).Code, req.method)
+	}
+	assert.Empty(t, f.im.passwords["alice"])
+	assert.Empty(t, f.im.users["alice"].Labels)
+	assert.Contains(t, f.im.users, "alice")
+
+	assert.Equal(t, http.StatusNoContent, f.do(t, http.MethodDelete, "/Users/carol", nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, f.do(t, http.MethodGet, "/Users/carol", nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, f.do(t, http.MethodDelete, "/Users/carol", nil, nil).Code)
+}
+
+func TestGroups(t *testing.T) {
+	f := newFixture(t)
+
+	created := &Group{}
+	resp := f.do(t, http.MethodPost, "/Groups", &Group{
+		Schemas:     []string{SchemaGroup},
+		DisplayName: "Dev Team",
+		ExternalID:  "g1",
+		Members:     []MultiValuedAttribute{{Value: "alice"}},
+	}, created)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, "dev-team1", created.ID)
+	assert.Equal(t, "Dev Team", created.DisplayName)
+	assert.Equal(t, []MultiValuedAttribute{{Value: "alice", Display: "alice", Type: ResourceTypeUser, Ref: scim.PathPrefix + "/Users/alice"}}, created.Members)
+
+	e := &Error{}
+	assert.Equal(t, http.StatusBadRequest, f.do(t, http.MethodPatch, "/Groups/dev-team1", &PatchRequest{
+		Operations: []PatchOperation{{Op: "add", Path: "members", Value: []interface{}{map[string]interface{}{"value": "nobody"}}}},
+	}, e).Code)
+	assert.Equal(t, scimTypeInvalidValue, e.ScimType)
+
+	patched := &Group{}
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodPatch, "/Groups/dev-team1", &PatchRequest{
+		Operations: []PatchOperation{
+			{Op: "add", Path: "members", Value: []interface{}{map[string]interface{}{"value": "bob"}}},
+			{Op: "remove", Path: `members[value eq "alice"]`},
+			{Op: "replace", Path: "displayName", Value: "Developers"},
+		},
+	}, patched).Code)
+	assert.Equal(t, "Developers", patched.DisplayName)
+	assert.Len(t, patched.Members, 1)
+	assert.Equal(t, "bob", patched.Members[0].Value)
+	assert.Len(t, f.groupOperator.bindings, 1)
+
+	u := &User{}
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodGet, "/Users/bob", nil, u).Code)
+	assert.Equal(t, []MultiValuedAttribute{{Value: "dev-team1", Display: "Developers", Type: "direct", Ref: scim.PathPrefix + "/Groups/dev-team1"}}, u.Groups)
+
+	list := &ListResponse{}
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodGet, `/Groups?filter=displayName+eq+"developers"`, nil, list).Code)
+	assert.Equal(t, 1, list.TotalResults)
+
+	replaced := &Group{}
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodPut, "/Groups/dev-team1", &Group{DisplayName: "Developers"}, replaced).Code)
+	assert.Empty(t, replaced.Members)
+	assert.Empty(t, f.groupOperator.bindings)
+
+	assert.Equal(t, http.StatusNoContent, f.do(t, http.MethodDelete, "/Groups/dev-team1", nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, f.do(t, http.MethodGet, "/Groups/dev-team1", nil, nil).Code)
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// patchPath is the target of a PATCH operation defined in RFC 7644 section 3.5.2,
+// e.g. displayName, name.givenName, members[value eq "admin"] or emails[type eq "work"].value.
+type patchPath struct {
+	attribute    string
+	subAttribute string
+	// valueFilter selects the elements of a multi-valued attribute
+	valueFilter filter
+	// valueFilterExpression is kept to create the element if a replace operation matches nothing
+	valueFilterExpression *attributeFilter
+}
+
+func parsePatchPath(path string) (*patchPath, error) {
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		// the schema URN contains dots, e.g. 2.0, so the attribute is after the last colon outside the brackets
+		end := strings.Index(path, "[")
+		if end < 0 {
+			end = len(path)
+		}
+		path = path[strings.LastIndex(path[:end], ":")+1:]
+	}
+	result := &patchPath{}
+	if start := strings.Index(path, "["); start >= 0 {
+		end := strings.LastIndex(path, "]")
+		if end < start {
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+		f, err := parseFilter(path[start+1 : end])
+		if err != nil {
+			return nil, err
+		}
+		result.attribute = path[:start]
+		result.valueFilter = f
+		if af, ok := f.(*attributeFilter); ok && af.operator == "eq" && len(af.path) == 1 {
+			result.valueFilterExpression = af
+		}
+		rest := path[end+1:]
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+				return nil, fmt.Errorf("invalid path %q", path)
+			}
+			result.subAttribute = rest[1:]
+		}
+	} else if parts := strings.SplitN(path, ".", 2); len(parts) == 2 {
+		result.attribute, result.subAttribute = parts[0], parts[1]
+	} else {
+		result.attribute = path
+	}
+	if result.attribute == "" {
+		return nil, fmt.Errorf("invalid path %q", path)
+	}
+	return result, nil
+}
+
+// applyPatch applies the PATCH operations to the JSON representation of the resource.
+func applyPatch(attributes map[string]interface{}, operations []PatchOperation) error {
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != "add" && op != "replace" && op != "remove" {
+			return newError(http.StatusBadRequest, scimTypeInvalidSyntax, fmt.Sprintf("unsupported operation %q", operation.Op))
+		}
+		if operation.Path == "" {
+			if op == "remove" {
+				return newError(http.StatusBadRequest, scimTypeNoTarget, "path is required by remove operations")
+			}
+			values, ok := operation.Value.(map[string]interface{})
+			if !ok {
+				return newError(http.StatusBadRequest, scimTypeInvalidValue, "value must be an object if path is not specified")
+			}
+			for name, value := range values {
+				p, err := parsePatchPath(name)
+				if err != nil {
+					return newError(http.StatusBadRequest, scimTypeInvalidPath, err.Error())
+				}
+				if err = applyOperation(attributes, op, p, value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		p, err := parsePatchPath(operation.Path)
+		if err != nil {
+			return newError(http.StatusBadRequest, scimTypeInvalidPath, err.Error())
+		}
+		if err = applyOperation(attributes, op, p, operation.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applyOperation(attributes map[string]interface{}, op string, p *patchPath, value interface{}) error {
+	key, found := findKey(attributes, p.attribute)
+	if !found {
+		key = p.attribute
+	}
+
+	if p.valueFilter != nil {
+		return applyToElements(attributes, key, op, p, value)
+	}
+
+	if p.subAttribute != "" {
+		parent, ok := attributes[key].(map[string]interface{})
+		if !ok {
+			if op == "remove" {
+				return nil
+			}
+			parent = map[string]interface{}{}
+			attributes[key] = parent
+		}
+		return applyOperation(parent, op, &patchPath{attribute: p.subAttribute}, value)
+	}
+
+	switch op {
+	case "remove":
+		if value == nil {
+			delete(attributes, key)
+			return nil
+		}
+		// the elements in the value are removed from the multi-valued attribute, e.g. the members
+		existing, _ := attributes[key].([]interface{})
+		attributes[key] = removeElements(existing, toSlice(value))
+	case "add":
+		if existing, ok := attributes[key].([]interface{}); ok {
+			attributes[key] = appendElements(existing, toSlice(value))
+		} else if m, ok := value.(map[string]interface{}); ok {
+			// the sub-attributes are merged into the complex attribute
+			parent, _ := attributes[key].(map[string]interface{})
+			if parent == nil {
+				parent = map[string]interface{}{}
+			}
+			for name, v := range m {
+				if err := applyOperation(parent, op, &patchPath{attribute: name}, v); err != nil {
+					return err
+				}
+			}
+			attributes[key] = parent
+		} else {
+			attributes[key] = value
+		}
+	case "replace":
+		attributes[key] = value
+	}
+	return nil
+}
+
+// applyToElements applies the operation to the elements of the multi-valued attribute matched by the value filter.
+func applyToElements(attributes map[string]interface{}, key, op string, p *patchPath, value interface{}) error {
+	existing, _ := attributes[key].([]interface{})
+	var result []interface{}
+	matched := false
+	for _, element := range existing {
+		m, ok := element.(map[string]interface{})
+		if !ok || !p.valueFilter.match(m) {
+			result = append(result, element)
+			continue
+		}
+		matched = true
+		switch {
+		case op == "remove" && p.subAttribute == "":
+			// drop the element
+		case p.subAttribute != "":
+			if err := applyOperation(m, op, &patchPath{attribute: p.subAttribute}, value); err != nil {
+				return err
+			}
+			result = append(result, m)
+		default:
+			replacement, ok := value.(map[string]interface{})
+			if !ok {
+				return newError(http.StatusBadRequest, scimTypeInvalidValue, "value must be an object")
+			}
+			for name, v := range replacement {
+				m[name] = v
+			}
+			result = append(result, m)
+		}
+	}
+
+	if !matched && op != "remove" {
+		// the element is created if the filter is a simple equality, e.g. emails[type eq "work"].value,
+		// which is used by the identity providers to set the attributes not present yet
+		if p.valueFilterExpression == nil {
+			return newError(http.StatusBadRequest, scimTypeNoTarget, "no element matches the path")
+		}
+		element := map[string]interface{}{p.valueFilterExpression.path[0]: p.valueFilterExpression.value}
+		if p.subAttribute != "" {
+			element[p.subAttribute] = value
+		} else if m, ok := value.(map[string]interface{}); ok {
+			for name, v := range m {
+				element[name] = v
+			}
+		}
+		result = append(result, element)
+	}
+	attributes[key] = result
+	return nil
+}
+
+func toSlice(value interface{}) []interface{} {
+	if values, ok := value.([]interface{}); ok {
+		return values
+	}
+	return []interface{}{value}
+}
+
+// elementValue returns the "value" sub-attribute which identifies the element of a multi-valued attribute.
+func elementValue(element interface{}) string {
+	if m, ok := element.(map[string]interface{}); ok {
+		if key, ok := findKey(m, "value"); ok {
+			return fmt.Sprint(m[key])
+		}
+		return ""
+	}
+	return fmt.Sprint(element)
+}
+
+func appendElements(existing []interface{}, elements []interface{}) []interface{} {
+	for _, element := range elements {
+		duplicated := false
+		for _, e := range existing {
+			if value := elementValue(e); value != "" && value == elementValue(element) {
+				duplicated = true
+				break
+			}
+		}
+		if !duplicated {
+			existing = append(existing, element)
+		}
+	}
+	return existing
+}
+
+func removeElements(existing []interface{}, elements []interface{}) []interface{} {
+	var result []interface{}
+	for _, e := range existing {
+		removed := false
+		for _, element := range elements {
+			if elementValue(e) == elementValue(element) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// toAttributes converts the resource to its JSON representation.
+func toAttributes(resource interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	attributes := make(map[string]interface{})
+	if err = json.Unmarshal(data, &attributes); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// fromAttributes converts the JSON representation back to the resource.
+func fromAttributes(attributes map[string]interface{}, resource interface{}) error {
+	// some identity providers send the booleans as strings, e.g. {"active": "False"}
+	if key, ok := findKey(attributes, "active"); ok {
+		if s, ok := attributes[key].(string); ok {
+			attributes[key] = strings.EqualFold(s, "true")
+		}
+	}
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, resource); err != nil {
+		return newError(http.StatusBadRequest, scimTypeInvalidValue, err.Error())
+	}
+	return nil
+}
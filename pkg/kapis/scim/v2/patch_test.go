@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []PatchOperation
+		expected   *Group
+		wantErr    *scimError
+	}{
+		{
+			name:       "replace without path",
+			operations: []PatchOperation{{Op: "Replace", Value: map[string]interface{}{"displayName": "ops", "externalId": "g2"}}},
+			expected:   &Group{DisplayName: "ops", ExternalID: "g2", Members: []MultiValuedAttribute{{Value: "alice"}, {Value: "bob"}}},
+		},
+		{
+			name:       "replace attribute",
+			operations: []PatchOperation{{Op: "replace", Path: "displayName", Value: "ops"}},
+			expected:   &Group{DisplayName: "ops", ExternalID: "g1", Members: []MultiValuedAttribute{{Value: "alice"}, {Value: "bob"}}},
+		},
+		{
+			name: "add members",
+			operations: []PatchOperation{{Op: "add", Path: "members", Value: []interface{}{
+				map[string]interface{}{"value": "bob"},
+				map[string]interface{}{"value": "carol"},
+			}}},
+			expected: &Group{DisplayName: "dev", ExternalID: "g1", Members: []MultiValuedAttribute{{Value: "alice"}, {Value: "bob"}, {Value: "carol"}}},
+		},
+		{
+			name:       "remove member by filter",
+			operations: []PatchOperation{{Op: "remove", Path: `members[value eq "alice"]`}},
+			expected:   &Group{DisplayName: "dev", ExternalID: "g1", Members: []MultiValuedAttribute{{Value: "bob"}}},
+		},
+		{
+			name:       "remove member by value",
+			operations: []PatchOperation{{Op: "remove", Path: "members", Value: []interface{}{map[string]interface{}{"value": "bob"}}}},
+			expected:   &Group{DisplayName: "dev", ExternalID: "g1", Members: []MultiValuedAttribute{{Value: "alice"}}},
+		},
+		{
+			name:       "remove all members",
+			operations: []PatchOperation{{Op: "remove", Path: "members"}},
+			expected:   &Group{DisplayName: "dev", ExternalID: "g1"},
+		},
+		{
+			name:       "replace members",
+			operations: []PatchOperation{{Op: "replace", Path: "members", Value: []interface{}{map[string]interface{}{"value": "carol"}}}},
+			expected:   &Group{DisplayName: "dev", ExternalID: "g1", Members: []MultiValuedAttribute{{Value: "carol"}}},
+		},
+		{
+			name:       "remove externalId",
+			operations: []PatchOperation{{Op: "remove", Path: "externalId"}},
+			expected:   &Group{DisplayName: "dev", Members: []MultiValuedAttribute{{Value: "alice"}, {Value: "bob"}}},
+		},
+		{
+			name:       "unsupported operation",
+			operations: []PatchOperation{{Op: "move", Path: "displayName"}},
+			wantErr:    newError(http.StatusBadRequest, scimTypeInvalidSyntax, ""),
+		},
+		{
+			name:       "remove without path",
+			operations: []PatchOperation{{Op: "remove"}},
+			wantErr:    newError(http.StatusBadRequest, scimTypeNoTarget, ""),
+		},
+		{
+			name:       "invalid path",
+			operations: []PatchOperation{{Op: "remove", Path: `members[value eq]`}},
+			wantErr:    newError(http.StatusBadRequest, scimTypeInvalidPath, ""),
+		},
+		{
+			name:       "replace sub-attribute of matched elements",
+			operations: []PatchOperation{{Op: "replace", Path: `members[value sw "a"].display`, Value: "x"}},
+			expected:   &Group{DisplayName: "dev", ExternalID: "g1", Members: []MultiValuedAttribute{{Value: "alice", Display: "x"}, {Value: "bob"}}},
+		},
+		{
+			name:       "no element matched by complex filter",
+			operations: []PatchOperation{{Op: "replace", Path: `members[value sw "z"].display`, Value: "x"}},
+			wantErr:    newError(http.StatusBadRequest, scimTypeNoTarget, ""),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attributes, err := toAttributes(&Group{
+				DisplayName: "dev",
+				ExternalID:  "g1",
+				Members:     []MultiValuedAttribute{{Value: "alice"}, {Value: "bob"}},
+			})
+			assert.NoError(t, err)
+			err = applyPatch(attributes, test.operations)
+			if test.wantErr != nil {
+				if assert.IsType(t, &scimError{}, err) {
+					assert.Equal(t, test.wantErr.status, err.(*scimError).status)
+					assert.Equal(t, test.wantErr.scimType, err.(*scimError).scimType)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			actual := &Group{}
+			assert.NoError(t, fromAttributes(attributes, actual))
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+func TestApplyPatchToUser(t *testing.T) {
+	attributes, err := toAttributes(&User{UserName: "alice"})
+	assert.NoError(t, err)
+	err = applyPatch(attributes, []PatchOperation{
+		{Op: "replace", Path: "active", Value: "False"},
+		{Op: "add", Path: `emails[type eq "work"].value`, Value: "alice@kubesphere.io"},
+		{Op: "add", Path: "urn:ietf:params:scim:schemas:core:2.0:User:displayName", Value: "Alice"},
+	})
+	assert.NoError(t, err)
+	actual := &User{}
+	assert.NoError(t, fromAttributes(attributes, actual))
+	active := false
+	assert.Equal(t, &User{
+		UserName:    "alice",
+		DisplayName: "Alice",
+		Emails:      []MultiValuedAttribute{{Value: "alice@kubesphere.io", Type: "work"}},
+		Active:      &active,
+	}, actual)
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"net/http"
+
+	"github.com/emicklei/go-restful"
+	restfulspec "github.com/emicklei/go-restful-openapi"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/authenticators/scim"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/iam/group"
+	"kubesphere.io/kubesphere/pkg/models/iam/im"
+)
+
+func init() {
+	restful.RegisterEntityAccessor(MIMESCIM, restful.NewEntityAccessorJSON(MIMESCIM))
+}
+
+// AddToContainer installs the SCIM 2.0 endpoint defined in RFC 7644, the identity providers use it to
+// provision the users and the groups of the workspace configured in the options.
+// Only the requests authenticated by the provisioning tokens are allowed.
+func AddToContainer(c *restful.Container, im im.IdentityManagementInterface, groupOperator group.GroupOperator,
+	options *authentication.SCIMOptions) error {
+	ws := &restful.WebService{}
+	ws.Path(scim.PathPrefix).
+		Consumes(MIMESCIM, restful.MIME_JSON).
+		Produces(MIMESCIM, restful.MIME_JSON).
+		Filter(requireProvisioner)
+
+	handler := newHandler(im, groupOperator, options)
+
+	ws.Route(ws.GET("/ServiceProviderConfig").
+		To(handler.serviceProviderConfig).
+		Doc("The features of the SCIM specification supported.").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), ServiceProviderConfig{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.GET("/ResourceTypes").
+		To(handler.resourceTypes).
+		Doc("The resource types supported.").
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), ListResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+
+	ws.Route(ws.GET("/Users").
+		To(handler.listUsers).
+		Doc("List the users.").
+		Param(ws.QueryParameter("filter", "The filter expression, e.g. userName eq \"admin\".").Required(false)).
+		Param(ws.QueryParameter("startIndex", "The 1-based index of the first result.").Required(false).DataFormat("startIndex=%d").DefaultValue("startIndex=1")).
+		Param(ws.QueryParameter("count", "The maximum number of the results per page.").Required(false).DataFormat("count=%d").DefaultValue("count=100")).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), ListResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.POST("/Users").
+		To(handler.createUser).
+		Doc("Create a user.").
+		Reads(User{}).
+		Returns(http.StatusCreated, http.StatusText(http.StatusCreated), User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.GET("/Users/{id}").
+		To(handler.getUser).
+		Doc("Retrieve the user.").
+		Param(ws.PathParameter("id", "The username.")).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.PUT("/Users/{id}").
+		To(handler.replaceUser).
+		Doc("Replace the attributes of the user, only the users provisioned by SCIM can be changed.").
+		Param(ws.PathParameter("id", "The username.")).
+		Reads(User{}).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.PATCH("/Users/{id}").
+		To(handler.patchUser).
+		Doc("Modify the attributes of the user, only the users provisioned by SCIM can be changed.").
+		Param(ws.PathParameter("id", "The username.")).
+		Reads(PatchRequest{}).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), User{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.DELETE("/Users/{id}").
+		To(handler.deleteUser).
+		Doc("Delete the user, only the users provisioned by SCIM can be deleted.").
+		Param(ws.PathParameter("id", "The username.")).
+		Returns(http.StatusNoContent, http.StatusText(http.StatusNoContent), nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+
+	ws.Route(ws.GET("/Groups").
+		To(handler.listGroups).
+		Doc("List the groups.").
+		Param(ws.QueryParameter("filter", "The filter expression, e.g. displayName eq \"developers\".").Required(false)).
+		Param(ws.QueryParameter("startIndex", "The 1-based index of the first result.").Required(false).DataFormat("startIndex=%d").DefaultValue("startIndex=1")).
+		Param(ws.QueryParameter("count", "The maximum number of the results per page.").Required(false).DataFormat("count=%d").DefaultValue("count=100")).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), ListResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.POST("/Groups").
+		To(handler.createGroup).
+		Doc("Create a group.").
+		Reads(Group{}).
+		Returns(http.StatusCreated, http.StatusText(http.StatusCreated), Group{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.GET("/Groups/{id}").
+		To(handler.getGroup).
+		Doc("Retrieve the group.").
+		Param(ws.PathParameter("id", "The group name.")).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), Group{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.PUT("/Groups/{id}").
+		To(handler.replaceGroup).
+		Doc("Replace the attributes and the members of the group.").
+		Param(ws.PathParameter("id", "The group name.")).
+		Reads(Group{}).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), Group{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.PATCH("/Groups/{id}").
+		To(handler.patchGroup).
+		Doc("Modify the attributes and the members of the group.").
+		Param(ws.PathParameter("id", "The group name.")).
+		Reads(PatchRequest{}).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), Group{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+	ws.Route(ws.DELETE("/Groups/{id}").
+		To(handler.deleteGroup).
+		Doc("Delete the group.").
+		Param(ws.PathParameter("id", "The group name.")).
+		Returns(http.StatusNoContent, http.StatusText(http.StatusNoContent), nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.SCIMTag}))
+
+	c.Add(ws)
+	return nil
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/emicklei/go-restful"
+	"github.com/gorilla/websocket"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -53,6 +57,16 @@ import (
 	monitoringclient "kubesphere.io/kubesphere/pkg/simple/client/monitoring"
 )
 
+// followWriteTimeout closes the log stream if the client can't keep up with the new records.
+const followWriteTimeout = 30 * time.Second
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// Allow connections from any Origin
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
 type tenantHandler struct {
 	tenant          tenant.Interface
 	meteringOptions *meteringclient.Options
@@ -88,7 +102,7 @@ func (h *tenantHandler) ListWorkspaceTemplates(req *restful.Request, resp *restf
 	result, err := h.tenant.ListWorkspaceTemplates(user, queryParam)
 
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -109,7 +123,7 @@ func (h *tenantHandler) ListFederatedNamespaces(req *restful.Request, resp *rest
 
 	result, err := h.tenant.ListFederatedNamespaces(workspaceMember, workspace, queryParam)
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -138,7 +152,7 @@ func (h *tenantHandler) ListNamespaces(req *restful.Request, resp *restful.Respo
 
 	result, err := h.tenant.ListNamespaces(workspaceMember, workspace, queryParam)
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -168,7 +182,7 @@ func (h *tenantHandler) ListDevOpsProjects(req *restful.Request, resp *restful.R
 	result, err := h.tenant.ListDevOpsProjects(workspaceMember, workspace, queryParam)
 
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -385,7 +399,16 @@ func (h *tenantHandler) QueryLogs(req *restful.Request, resp *restful.Response)
 	queryParam, err := loggingv1alpha2.ParseQueryParameter(req)
 	if err != nil {
 		klog.Errorln(err)
-		api.HandleInternalError(resp, req, err)
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	if queryParam.Operation == loggingv1alpha2.OperationFollow {
+		if websocket.IsWebSocketUpgrade(req.Request) {
+			h.followLogsOverWebSocket(user, queryParam, req, resp)
+		} else {
+			h.followLogs(user, queryParam, req, resp)
+		}
 		return
 	}
 
@@ -409,6 +432,92 @@ func (h *tenantHandler) QueryLogs(req *restful.Request, resp *restful.Response)
 	}
 }
 
+func (h *tenantHandler) LogContext(req *restful.Request, resp *restful.Response) {
+	user, ok := request.UserFrom(req.Request.Context())
+	if !ok {
+		err := fmt.Errorf("cannot obtain user info")
+		klog.Errorln(err)
+		api.HandleForbidden(resp, req, err)
+		return
+	}
+	queryParam, err := loggingv1alpha2.ParseContextQueryParameter(req)
+	if err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	result, err := h.tenant.LogContext(user, queryParam)
+	if err != nil {
+		if err == logging.ErrRecordNotFound {
+			api.HandleNotFound(resp, req, err)
+			return
+		}
+		api.HandleError(resp, req, err)
+		return
+	}
+	resp.WriteAsJson(result)
+}
+
+// followLogs streams the new records as a chunked response, one JSON encoded event per line.
+func (h *tenantHandler) followLogs(user user.Info, query *loggingv1alpha2.Query, req *restful.Request, resp *restful.Response) {
+	flusher, _ := resp.ResponseWriter.(http.Flusher)
+	resp.Header().Set(restful.HEADER_ContentType, restful.MIME_JSON)
+	resp.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	encoder := json.NewEncoder(resp)
+	err := h.tenant.FollowLogs(req.Request.Context(), user, query, func(event loggingv1alpha2.FollowEvent) error {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		klog.Warningf("stopped following logs for user %s: %v", user.GetName(), err)
+	}
+}
+
+// followLogsOverWebSocket streams the new records as WebSocket text messages, one JSON encoded event per message.
+func (h *tenantHandler) followLogsOverWebSocket(user user.Info, query *loggingv1alpha2.Query, req *restful.Request, resp *restful.Response) {
+	conn, err := upgrader.Upgrade(resp.ResponseWriter, req.Request, nil)
+	if err != nil {
+		klog.Warning(err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(req.Request.Context())
+	defer cancel()
+	// nothing is expected from the client, the read fails once the client closes the connection
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	err = h.tenant.FollowLogs(ctx, user, query, func(event loggingv1alpha2.FollowEvent) error {
+		if err := conn.SetWriteDeadline(time.Now().Add(followWriteTimeout)); err != nil {
+			return err
+		}
+		return conn.WriteJSON(event)
+	})
+	if err != nil {
+		klog.Warningf("stopped following logs for user %s: %v", user.GetName(), err)
+		message := websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error())
+		conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+		return
+	}
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+}
+
 func (h *tenantHandler) Auditing(req *restful.Request, resp *restful.Response) {
 	user, ok := request.UserFrom(req.Request.Context())
 	if !ok {
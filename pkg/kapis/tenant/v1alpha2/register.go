@@ -249,7 +249,7 @@ func AddToContainer(c *restful.Container, factory informers.InformerFactory, k8s
 	ws.Route(ws.GET("/logs").
 		To(handler.QueryLogs).
 		Doc("Query logs against the cluster.").
-		Param(ws.QueryParameter("operation", "Operation type. This can be one of four types: query (for querying logs), statistics (for retrieving statistical data), histogram (for displaying log count by time interval) and export (for exporting logs). Defaults to query.").DefaultValue("query").DataType("string").Required(false)).
+		Param(ws.QueryParameter("operation", "Operation type. This can be one of six types: query (for querying logs), statistics (for retrieving statistical data), histogram (for displaying log count by time interval), export (for exporting logs), follow (for streaming new logs, over WebSocket if the request is a WebSocket upgrade, otherwise as a chunked response of one JSON event per line, the stream ends after an hour and clients should reconnect to keep following) and fields (for counting the top values of the fields parsed from JSON logs). Defaults to query.").DefaultValue("query").DataType("string").Required(false)).
 		Param(ws.QueryParameter("namespaces", "A comma-separated list of namespaces. This field restricts the query to specified namespaces. For example, the following filter matches the namespace my-ns and demo-ns: `my-ns,demo-ns`").DataType("string").Required(false)).
 		Param(ws.QueryParameter("namespace_query", "A comma-separated list of keywords. Differing from **namespaces**, this field performs fuzzy matching on namespaces. For example, the following value limits the query to namespaces whose name contains the word my(My,MY,...) *OR* demo(Demo,DemO,...): `my,demo`.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("workloads", "A comma-separated list of workloads. This field restricts the query to specified workloads. For example, the following filter matches the workload my-wl and demo-wl: `my-wl,demo-wl`").DataType("string").Required(false)).
@@ -258,19 +258,35 @@ func AddToContainer(c *restful.Container, factory informers.InformerFactory, k8s
 		Param(ws.QueryParameter("pod_query", "A comma-separated list of keywords. Differing from **pods**, this field performs fuzzy matching on pods. For example, the following value limits the query to pods whose name contains the word my(My,MY,...) *OR* demo(Demo,DemO,...): `my,demo`.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("containers", "A comma-separated list of containers. This field restricts the query to specified containers. For example, the following filter matches the container my-cont and demo-cont: `my-cont,demo-cont`").DataType("string").Required(false)).
 		Param(ws.QueryParameter("container_query", "A comma-separated list of keywords. Differing from **containers**, this field performs fuzzy matching on containers. For example, the following value limits the query to containers whose name contains the word my(My,MY,...) *OR* demo(Demo,DemO,...): `my,demo`.").DataType("string").Required(false)).
-		Param(ws.QueryParameter("log_query", "A comma-separated list of keywords. The query returns logs which contain at least one keyword. Case-insensitive matching. For example, if the field is set to `err,INFO`, the query returns any log containing err(ERR,Err,...) *OR* INFO(info,InFo,...).").DataType("string").Required(false)).
+		Param(ws.QueryParameter("log_query", "A comma-separated list of keywords. The query returns logs which contain at least one keyword. Case-insensitive matching. For example, if the field is set to `err,INFO`, the query returns any log containing err(ERR,Err,...) *OR* INFO(info,InFo,...). A keyword in the form of `field:<name>=<value>` filters logs by the field parsed from JSON logs instead, values of the same field are OR'ed and different fields are AND'ed, e.g. `field:level=error,field:level=warn,field:http.status=500`.").DataType("string").Required(false)).
+		Param(ws.QueryParameter("parse_json", "Whether to return the fields parsed from JSON logs. It requires **operation** is set to query or follow. Defaults to false.").DataType("boolean").DefaultValue("false").Required(false)).
+		Param(ws.QueryParameter("fields", "A comma-separated list of fields parsed from JSON logs to count the top values of, e.g. `level,trace_id`. It requires **operation** is set to fields.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("interval", "Time interval. It requires **operation** is set to histogram. The format is [0-9]+[smhdwMqy]. Defaults to 15m (i.e. 15 min).").DefaultValue("15m").DataType("string").Required(false)).
-		Param(ws.QueryParameter("start_time", "Start time of query. Default to 0. The format is a string representing seconds since the epoch, eg. 1559664000.").DataType("string").Required(false)).
+		Param(ws.QueryParameter("start_time", "Start time of query. Default to 0, or now if **operation** is set to follow. The format is a string representing seconds since the epoch, eg. 1559664000.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("end_time", "End time of query. Default to now. The format is a string representing seconds since the epoch, eg. 1559664000.").DataType("string").Required(false)).
 		Param(ws.QueryParameter("sort", "Sort order. One of asc, desc. This field sorts logs by timestamp.").DataType("string").DefaultValue("desc").Required(false)).
 		Param(ws.QueryParameter("from", "The offset from the result set. This field returns query results from the specified offset. It requires **operation** is set to query. Defaults to 0 (i.e. from the beginning of the result set).").DataType("integer").DefaultValue("0").Required(false)).
-		Param(ws.QueryParameter("size", "Size of result to return. It requires **operation** is set to query. Defaults to 10 (i.e. 10 log records).").DataType("integer").DefaultValue("10").Required(false)).
+		Param(ws.QueryParameter("size", "Size of result to return. It requires **operation** is set to query or fields. Defaults to 10 (i.e. 10 log records, or the top 10 values of each field, at most 100).").DataType("integer").DefaultValue("10").Required(false)).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
 		Writes(loggingv1alpha2.APIResponse{}).
 		Returns(http.StatusOK, api.StatusOK, loggingv1alpha2.APIResponse{})).
 		Consumes(restful.MIME_JSON, restful.MIME_XML).
 		Produces(restful.MIME_JSON, "text/plain")
 
+	ws.Route(ws.GET("/logs/context").
+		To(handler.LogContext).
+		Doc("Query the logs around a log record of a container, e.g. the lines before and after an error found by the log query.").
+		Param(ws.QueryParameter("namespace", "The namespace of the record.").DataType("string").Required(true)).
+		Param(ws.QueryParameter("pod", "The pod of the record.").DataType("string").Required(true)).
+		Param(ws.QueryParameter("container", "The container of the record.").DataType("string").Required(true)).
+		Param(ws.QueryParameter("time", "The time of the record, the same as the time of the records returned by the log query, eg. 2023-07-01T08:00:00.123456789Z.").DataType("string").Required(true)).
+		Param(ws.QueryParameter("offset", "The offset of the record in the records of the container at the same time, in the order of the log query sorted by `asc`. Defaults to 0.").DataType("integer").DefaultValue("0").Required(false)).
+		Param(ws.QueryParameter("before", "The max number of records before the record, no more than 1000. Defaults to 10.").DataType("integer").DefaultValue("10").Required(false)).
+		Param(ws.QueryParameter("after", "The max number of records after the record, no more than 1000. Defaults to 10.").DataType("integer").DefaultValue("10").Required(false)).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.LogQueryTag}).
+		Writes(loggingv1alpha2.APIResponse{}).
+		Returns(http.StatusOK, api.StatusOK, loggingv1alpha2.APIResponse{}))
+
 	ws.Route(ws.GET("/auditing/events").
 		To(handler.Auditing).
 		Doc("Query auditing events against the cluster").
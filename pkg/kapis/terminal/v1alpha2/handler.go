@@ -18,10 +18,12 @@ package v1alpha2
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	requestctx "kubesphere.io/kubesphere/pkg/apiserver/request"
 
 	"github.com/emicklei/go-restful"
@@ -33,6 +35,8 @@ import (
 	"kubesphere.io/kubesphere/pkg/models/terminal"
 )
 
+var errRecordingDisabled = errors.New("terminal recording is disabled")
+
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
@@ -43,12 +47,15 @@ var upgrader = websocket.Upgrader{
 type terminalHandler struct {
 	terminaler terminal.Interface
 	authorizer authorizer.Authorizer
+	// recordings is nil if the terminal recording is disabled.
+	recordings terminal.RecordingStore
 }
 
-func newTerminalHandler(client kubernetes.Interface, authorizer authorizer.Authorizer, config *rest.Config, options *terminal.Options) *terminalHandler {
+func newTerminalHandler(client kubernetes.Interface, authorizer authorizer.Authorizer, config *rest.Config, options *terminal.Options, recordings terminal.RecordingStore) *terminalHandler {
 	return &terminalHandler{
 		authorizer: authorizer,
-		terminaler: terminal.NewTerminaler(client, config, options),
+		terminaler: terminal.NewTerminaler(client, config, options, recordings),
+		recordings: recordings,
 	}
 }
 
@@ -87,7 +94,7 @@ func (t *terminalHandler) handleTerminalSession(request *restful.Request, respon
 		return
 	}
 
-	t.terminaler.HandleSession(shell, namespace, podName, containerName, conn)
+	t.terminaler.HandleSession(user.GetName(), shell, namespace, podName, containerName, conn)
 }
 
 func (t *terminalHandler) handleShellAccessToNode(request *restful.Request, response *restful.Response) {
@@ -121,5 +128,89 @@ func (t *terminalHandler) handleShellAccessToNode(request *restful.Request, resp
 		return
 	}
 
-	t.terminaler.HandleShellAccessToNode(nodename, conn)
+	t.terminaler.HandleShellAccessToNode(user.GetName(), nodename, conn)
+}
+
+func (t *terminalHandler) listRecordings(request *restful.Request, response *restful.Response) {
+	if t.recordings == nil {
+		api.HandleNotFound(response, request, errRecordingDisabled)
+		return
+	}
+
+	recordings, err := t.recordings.List()
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	q := query.ParseQueryParameter(request)
+	filtered := make([]interface{}, 0)
+	for _, recording := range recordings {
+		if matchRecording(recording, q) {
+			filtered = append(filtered, recording)
+		}
+	}
+
+	start, end := q.Pagination.GetValidPagination(len(filtered))
+	response.WriteEntity(api.ListResult{
+		Items:      filtered[start:end],
+		TotalItems: len(filtered),
+	})
+}
+
+func matchRecording(recording *terminal.Recording, q *query.Query) bool {
+	fields := map[query.Field]string{
+		"user":      recording.User,
+		"namespace": recording.Namespace,
+		"pod":       recording.Pod,
+		"node":      recording.Node,
+	}
+
+	return q.Match(func(filter query.Filter) bool {
+		value, ok := fields[filter.Field]
+		return ok && value == string(filter.Value)
+	})
+}
+
+func (t *terminalHandler) getRecording(request *restful.Request, response *restful.Response) {
+	if t.recordings == nil {
+		api.HandleNotFound(response, request, errRecordingDisabled)
+		return
+	}
+
+	recording, err := t.recordings.Get(request.PathParameter("recording"))
+	if err != nil {
+		handleRecordingError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(recording)
+}
+
+func (t *terminalHandler) downloadRecording(request *restful.Request, response *restful.Response) {
+	if t.recordings == nil {
+		api.HandleNotFound(response, request, errRecordingDisabled)
+		return
+	}
+
+	id := request.PathParameter("recording")
+	cast, err := t.recordings.Read(id)
+	if err != nil {
+		handleRecordingError(response, request, err)
+		return
+	}
+
+	response.Header().Set(restful.HEADER_ContentType, "application/x-asciicast")
+	response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.cast\"", id))
+	if _, err := response.Write(cast); err != nil {
+		klog.Warning(err)
+	}
+}
+
+func handleRecordingError(response *restful.Response, request *restful.Request, err error) {
+	if err == terminal.ErrRecordingNotFound {
+		api.HandleNotFound(response, request, err)
+		return
+	}
+	api.HandleInternalError(response, request, err)
 }
@@ -17,18 +17,23 @@ limitations under the License.
 package v1alpha2
 
 import (
+	"net/http"
+
 	"github.com/emicklei/go-restful"
 	restfulspec "github.com/emicklei/go-restful-openapi"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
 
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/models"
 	"kubesphere.io/kubesphere/pkg/models/terminal"
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
 )
 
 const (
@@ -37,11 +42,19 @@ const (
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
 
-func AddToContainer(c *restful.Container, client kubernetes.Interface, authorizer authorizer.Authorizer, config *rest.Config, options *terminal.Options) error {
+func AddToContainer(c *restful.Container, client kubernetes.Interface, authorizer authorizer.Authorizer, config *rest.Config, options *terminal.Options, s3Client s3.Interface) error {
 
 	webservice := runtime.NewWebService(GroupVersion)
 
-	handler := newTerminalHandler(client, authorizer, config, options)
+	var recordings terminal.RecordingStore
+	if options != nil {
+		var err error
+		if recordings, err = terminal.NewRecordingStore(options.Recording, s3Client); err != nil {
+			return err
+		}
+	}
+
+	handler := newTerminalHandler(client, authorizer, config, options, recordings)
 
 	webservice.Route(webservice.GET("/namespaces/{namespace}/pods/{pod}/exec").
 		To(handler.handleTerminalSession).
@@ -59,6 +72,34 @@ func AddToContainer(c *restful.Container, client kubernetes.Interface, authorize
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}).
 		Writes(models.PodInfo{}))
 
+	// Recordings are authorized by the resource "recordings" in the group terminal.kubesphere.io,
+	// so only the auditors granted with the permission can review the terminal sessions.
+	webservice.Route(webservice.GET("/recordings").
+		To(handler.listRecordings).
+		Param(webservice.QueryParameter("user", "filter recordings by the user who opened the terminal session").Required(false)).
+		Param(webservice.QueryParameter("namespace", "filter recordings by the namespace of the pod").Required(false)).
+		Param(webservice.QueryParameter("pod", "filter recordings by the name of the pod").Required(false)).
+		Param(webservice.QueryParameter("node", "filter recordings by the name of the node").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
+		Param(webservice.QueryParameter(query.ParameterLimit, "limit").Required(false)).
+		Doc("List terminal session recordings, sorted by the start time in descending order.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}).
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{terminal.Recording{}}}))
+
+	webservice.Route(webservice.GET("/recordings/{recording}").
+		To(handler.getRecording).
+		Param(webservice.PathParameter("recording", "id of the recording")).
+		Doc("Get the metadata of a terminal session recording.").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}).
+		Returns(http.StatusOK, api.StatusOK, terminal.Recording{}))
+
+	webservice.Route(webservice.GET("/recordings/{recording}/download").
+		To(handler.downloadRecording).
+		Param(webservice.PathParameter("recording", "id of the recording")).
+		Doc("Download a terminal session recording in asciinema v2 cast format.").
+		Produces("application/x-asciicast").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.TerminalTag}))
+
 	c.Add(webservice)
 
 	return nil
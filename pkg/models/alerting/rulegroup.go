@@ -151,7 +151,7 @@ func (o *ruleGroupOperator) ListRuleGroups(ctx context.Context, namespace string
 		return nil, err
 	}
 
-	listResult := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		hit, great := o.compareRuleGroupStatus(
 			&(left.(*kapialertingv2beta1.RuleGroup).Status), &(right.(*kapialertingv2beta1.RuleGroup).Status), field)
 		if hit {
@@ -166,6 +166,9 @@ func (o *ruleGroupOperator) ListRuleGroups(ctx context.Context, namespace string
 		}
 		return resources.DefaultObjectMetaFilter(obj.(*kapialertingv2beta1.RuleGroup).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return listResult, nil
 }
@@ -248,11 +251,14 @@ func (o *ruleGroupOperator) ListAlerts(ctx context.Context, namespace string,
 	if err != nil {
 		return nil, err
 	}
-	listResult := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		return o.compareAlert(&left.(*wrapAlert).Alert, &right.(*wrapAlert).Alert, field)
 	}, func(obj runtime.Object, filter query.Filter) bool {
 		return filterAlert(&obj.(*wrapAlert).Alert, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 	for i := range listResult.Items {
 		listResult.Items[i] = &listResult.Items[i].(*wrapAlert).Alert
 	}
@@ -274,29 +280,29 @@ func (d *ruleGroupOperator) compareAlert(left, right *kapialertingv2beta1.Alert,
 }
 
 func (d *ruleGroupOperator) createFilterAlertFunc(queryParam *query.Query) (func(alert *kapialertingv2beta1.Alert, filter query.Filter) bool, error) {
-	var labelFilters kapialertingv2beta1.LabelFilters
-	var labelMatchers []*promlabels.Matcher
-	var err error
-	if len(queryParam.Filters) > 0 {
-		if filters, ok := queryParam.Filters[kapialertingv2beta1.FieldAlertLabelFilters]; ok {
-			labelFilters = kapialertingv2beta1.ParseLabelFilters(string(filters))
-		}
-		if matcherStr, ok := queryParam.Filters[kapialertingv2beta1.FieldAlertLabelMatcher]; ok {
-			labelMatchers, err = parser.ParseMetricSelector(string(matcherStr))
-			if err != nil {
-				return nil, fmt.Errorf("invalid %s param: %v", kapialertingv2beta1.FieldAlertLabelMatcher, err)
-			}
+	// the label filters and matchers are parsed in advance, each of their values is matched separately
+	labelFilters := make(map[query.Value]kapialertingv2beta1.LabelFilters)
+	for _, value := range fieldValues(queryParam, kapialertingv2beta1.FieldAlertLabelFilters) {
+		labelFilters[value] = kapialertingv2beta1.ParseLabelFilters(string(value))
+	}
+	labelMatchers := make(map[query.Value][]*promlabels.Matcher)
+	for _, value := range fieldValues(queryParam, kapialertingv2beta1.FieldAlertLabelMatcher) {
+		matchers, err := parser.ParseMetricSelector(string(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s param: %v", kapialertingv2beta1.FieldAlertLabelMatcher, err)
 		}
+		labelMatchers[value] = matchers
 	}
 	return func(alert *kapialertingv2beta1.Alert, filter query.Filter) bool {
 		switch filter.Field {
 		case kapialertingv2beta1.FieldAlertLabelFilters:
+			labelFilters := labelFilters[filter.Value]
 			if labelFilters == nil {
 				return true
 			}
 			return labelFilters.Matches(alert.Labels)
 		case kapialertingv2beta1.FieldAlertLabelMatcher:
-			for _, m := range labelMatchers {
+			for _, m := range labelMatchers[filter.Value] {
 				var v string
 				if len(alert.Labels) > 0 {
 					v = alert.Labels[m.Name]
@@ -458,7 +464,7 @@ func (o *ruleGroupOperator) ListClusterRuleGroups(ctx context.Context,
 		return nil, err
 	}
 
-	listResult := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		hit, great := o.compareRuleGroupStatus(
 			&(left.(*kapialertingv2beta1.ClusterRuleGroup).Status), &(right.(*kapialertingv2beta1.ClusterRuleGroup).Status), field)
 		if hit {
@@ -473,6 +479,9 @@ func (o *ruleGroupOperator) ListClusterRuleGroups(ctx context.Context,
 		}
 		return resources.DefaultObjectMetaFilter(obj.(*kapialertingv2beta1.ClusterRuleGroup).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return listResult, nil
 }
@@ -501,11 +510,14 @@ func (o *ruleGroupOperator) ListClusterAlerts(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	listResult := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		return o.compareAlert(&left.(*wrapAlert).Alert, &right.(*wrapAlert).Alert, field)
 	}, func(obj runtime.Object, filter query.Filter) bool {
 		return filterAlert(&obj.(*wrapAlert).Alert, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 	for i := range listResult.Items {
 		listResult.Items[i] = &listResult.Items[i].(*wrapAlert).Alert
 	}
@@ -666,27 +678,13 @@ func (o *ruleGroupOperator) listGlobalRuleGroups(ctx context.Context, selector l
 func (o *ruleGroupOperator) ListGlobalRuleGroups(ctx context.Context,
 	queryParam *query.Query) (*api.ListResult, error) {
 
-	selector := queryParam.Selector()
-	if val, ok := queryParam.Filters[kapialertingv2beta1.FieldBuiltin]; ok {
-		// add match requirement to the selector to select only builtin or custom rulegroups
-		var operator selection.Operator
-		if val == controller.PrometheusRuleResourceLabelValueBuiltinTrue {
-			operator = selection.Equals
-		} else {
-			operator = selection.NotEquals
-		}
-		requirement, _ := labels.NewRequirement(
-			controller.PrometheusRuleResourceLabelKeyBuiltin,
-			operator,
-			[]string{controller.PrometheusRuleResourceLabelValueBuiltinTrue})
-		selector = selector.Add(*requirement)
-	}
+	selector := builtinSelector(queryParam, queryParam.Selector())
 	groups, err := o.listGlobalRuleGroups(ctx, selector)
 	if err != nil {
 		return nil, err
 	}
 
-	listResult := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		hit, great := o.compareRuleGroupStatus(
 			&(left.(*kapialertingv2beta1.GlobalRuleGroup).Status), &(right.(*kapialertingv2beta1.GlobalRuleGroup).Status), field)
 		if hit {
@@ -704,6 +702,9 @@ func (o *ruleGroupOperator) ListGlobalRuleGroups(ctx context.Context,
 		}
 		return resources.DefaultObjectMetaFilter(obj.(*kapialertingv2beta1.GlobalRuleGroup).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return listResult, nil
 }
@@ -711,21 +712,7 @@ func (o *ruleGroupOperator) ListGlobalRuleGroups(ctx context.Context,
 func (o *ruleGroupOperator) ListGlobalAlerts(ctx context.Context,
 	queryParam *query.Query) (*api.ListResult, error) {
 
-	selector := labels.Everything()
-	if val, ok := queryParam.Filters[kapialertingv2beta1.FieldBuiltin]; ok {
-		// add match requirement to the selector to select only builtin or custom rulegroups
-		var operator selection.Operator
-		if val == controller.PrometheusRuleResourceLabelValueBuiltinTrue {
-			operator = selection.Equals
-		} else {
-			operator = selection.NotEquals
-		}
-		requirement, _ := labels.NewRequirement(
-			controller.PrometheusRuleResourceLabelKeyBuiltin,
-			operator,
-			[]string{controller.PrometheusRuleResourceLabelValueBuiltinTrue})
-		selector = selector.Add(*requirement)
-	}
+	selector := builtinSelector(queryParam, labels.Everything())
 	groups, err := o.listGlobalRuleGroups(ctx, selector)
 	if err != nil {
 		return nil, err
@@ -747,7 +734,7 @@ func (o *ruleGroupOperator) ListGlobalAlerts(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	listResult := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		return o.compareAlert(&left.(*wrapAlert).Alert, &right.(*wrapAlert).Alert, field)
 	}, func(obj runtime.Object, filter query.Filter) bool {
 		if filter.Field == kapialertingv2beta1.FieldBuiltin { // ignoring this filter because it is filtered at the front
@@ -755,6 +742,9 @@ func (o *ruleGroupOperator) ListGlobalAlerts(ctx context.Context,
 		}
 		return filterAlert(&obj.(*wrapAlert).Alert, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 	for i := range listResult.Items {
 		listResult.Items[i] = &listResult.Items[i].(*wrapAlert).Alert
 	}
@@ -947,3 +937,61 @@ func setRuleGroupResourceTypeMeta(obj runtime.Object) {
 		o.Kind = alertingv2beta1.ResourceKindGlobalRuleGroup
 	}
 }
+
+// fieldValues returns all the values of the field in the filters and conditions of the query.
+func fieldValues(queryParam *query.Query, field query.Field) []query.Value {
+	var values []query.Value
+	if value, ok := queryParam.Filters[field]; ok {
+		values = append(values, value)
+	}
+	for _, condition := range queryParam.Conditions {
+		if condition.Field == field {
+			values = append(values, condition.Values...)
+		}
+	}
+	return values
+}
+
+// builtinSelector adds the requirement to the selector to select only builtin or custom rulegroups by the
+// builtin filter, which may have multiple values or be negated, e.g. ?builtin!=true selects the custom ones.
+func builtinSelector(queryParam *query.Query, selector labels.Selector) labels.Selector {
+	if len(fieldValues(queryParam, kapialertingv2beta1.FieldBuiltin)) == 0 {
+		return selector
+	}
+
+	builtin, custom := true, true
+	included, excluded := queryParam.Values(kapialertingv2beta1.FieldBuiltin)
+	if len(excluded) == 0 {
+		builtin, custom = false, false
+		for _, val := range included {
+			if val == controller.PrometheusRuleResourceLabelValueBuiltinTrue {
+				builtin = true
+			} else {
+				custom = true
+			}
+		}
+	}
+	for _, val := range excluded {
+		if val == controller.PrometheusRuleResourceLabelValueBuiltinTrue {
+			builtin = false
+		} else {
+			custom = false
+		}
+	}
+
+	var operators []selection.Operator
+	if !custom {
+		operators = append(operators, selection.Equals)
+	}
+	if !builtin {
+		operators = append(operators, selection.NotEquals)
+	}
+	for _, operator := range operators {
+		requirement, _ := labels.NewRequirement(
+			controller.PrometheusRuleResourceLabelKeyBuiltin,
+			operator,
+			[]string{controller.PrometheusRuleResourceLabelValueBuiltinTrue})
+		selector = selector.Add(*requirement)
+	}
+	return selector
+}
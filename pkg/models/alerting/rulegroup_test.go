@@ -0,0 +1,111 @@
+// Copyright 2023 The KubeSphere Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	promlabels "github.com/prometheus/prometheus/model/labels"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	alertingv2beta1 "kubesphere.io/api/alerting/v2beta1"
+
+	kapialertingv2beta1 "kubesphere.io/kubesphere/pkg/api/alerting/v2beta1"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	alertinglisters "kubesphere.io/kubesphere/pkg/client/listers/alerting/v2beta1"
+	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+)
+
+type fakeRuleClient struct {
+	groups []*alerting.RuleGroup
+}
+
+func (c *fakeRuleClient) PrometheusRules(ctx context.Context) ([]*alerting.RuleGroup, error) {
+	return c.groups, nil
+}
+
+func (c *fakeRuleClient) ThanosRules(ctx context.Context, matchers ...[]*promlabels.Matcher) ([]*alerting.RuleGroup, error) {
+	return c.groups, nil
+}
+
+func TestListAlertsContinue(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	err := indexer.Add(&alertingv2beta1.RuleGroup{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "group"},
+		Spec: alertingv2beta1.RuleGroupSpec{
+			Rules: []alertingv2beta1.NamespaceRule{{
+				Rule: alertingv2beta1.Rule{
+					Alert:  "alert",
+					Labels: map[string]string{alertingv2beta1.RuleLabelKeyRuleId: "rule"},
+				},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := &alerting.AlertingRule{
+		Name:   "alert",
+		State:  stateFiringString,
+		Labels: map[string]string{alertingv2beta1.RuleLabelKeyRuleId: "rule"},
+	}
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		activeAt := start.Add(time.Duration(i) * time.Minute)
+		rule.Alerts = append(rule.Alerts, &alerting.Alert{
+			Labels:   map[string]string{"pod": fmt.Sprintf("pod%d", i)},
+			State:    stateFiringString,
+			ActiveAt: &activeAt,
+		})
+	}
+
+	operator := &ruleGroupOperator{
+		ruleClient:      &fakeRuleClient{groups: []*alerting.RuleGroup{{Name: "group", Rules: []*alerting.AlertingRule{rule}}}},
+		ruleGroupLister: alertinglisters.NewRuleGroupLister(indexer),
+	}
+
+	var pods []string
+	token := ""
+	for i := 0; i < 3; i++ {
+		result, err := operator.ListAlerts(context.Background(), "default", &query.Query{
+			Pagination: &query.Pagination{Limit: 2},
+			SortBy:     kapialertingv2beta1.FieldAlertActiveAt,
+			Filters:    map[query.Field]query.Value{},
+			Continue:   token,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.TotalItems != 5 {
+			t.Errorf("expected 5 alerts, got %d", result.TotalItems)
+		}
+		for _, item := range result.Items {
+			pods = append(pods, item.(*kapialertingv2beta1.Alert).Labels["pod"])
+		}
+		token = result.Continue
+	}
+
+	if diff := cmp.Diff([]string{"pod4", "pod3", "pod2", "pod1", "pod0"}, pods); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", pods, diff)
+	}
+	if token != "" {
+		t.Errorf("expected no continue token on the last page, got %s", token)
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	iamv1alpha2listers "kubesphere.io/kubesphere/pkg/client/listers/iam/v1alpha2"
+)
+
+// accessTokenLastUsedInterval limits how often the last used time of a personal access token is recorded.
+const accessTokenLastUsedInterval = time.Minute
+
+var (
+	ErrAccessTokenRevoked = errors.New("personal access token has been revoked")
+	ErrAccessTokenExpired = errors.New("personal access token has expired")
+
+	// accessTokenVerbs are the verbs a personal access token can be restricted to.
+	accessTokenVerbs = sets.NewString("get", "list", "watch", "create", "update", "patch", "delete", "deletecollection", "proxy")
+)
+
+// AccessTokenOperator manages the personal access tokens of the users, a personal access token is a
+// JWT whose ID is the name of the AccessToken, deleting the AccessToken revokes the token.
+type AccessTokenOperator interface {
+	// CreateAccessToken creates the AccessToken of the user and issues the token,
+	// the token can't be retrieved after it's returned here.
+	CreateAccessToken(username string, accessToken *iamv1alpha2.AccessToken) (*iamv1alpha2.AccessToken, string, error)
+	// ListAccessTokens lists the AccessTokens of the user, the newest first.
+	ListAccessTokens(username string) ([]*iamv1alpha2.AccessToken, error)
+	// RevokeAccessToken deletes the AccessToken of the user.
+	RevokeAccessToken(username, name string) error
+	// Validate checks the AccessToken of the verified personal access token is neither revoked nor expired,
+	// nor created before the last password change of the user, and records the time and the client IP it's used.
+	Validate(verified *token.VerifiedResponse, user *iamv1alpha2.User, clientIP string) (*iamv1alpha2.AccessToken, error)
+}
+
+type accessTokenOperator struct {
+	ksClient          kubesphere.Interface
+	accessTokenLister iamv1alpha2listers.AccessTokenLister
+	issuer            token.Issuer
+	options           *authentication.Options
+	now               func() time.Time
+	// lastRecorded is the time the usage of each AccessToken is last recorded by this operator,
+	// so that the usage is not recorded again before the informer observes the update.
+	lastRecorded map[string]time.Time
+	mutex        sync.Mutex
+}
+
+func NewAccessTokenOperator(ksClient kubesphere.Interface, accessTokenLister iamv1alpha2listers.AccessTokenLister,
+	issuer token.Issuer, options *authentication.Options) AccessTokenOperator {
+	return &accessTokenOperator{
+		ksClient:          ksClient,
+		accessTokenLister: accessTokenLister,
+		issuer:            issuer,
+		options:           options,
+		now:               time.Now,
+		lastRecorded:      make(map[string]time.Time),
+	}
+}
+
+func (o *accessTokenOperator) CreateAccessToken(username string, accessToken *iamv1alpha2.AccessToken) (*iamv1alpha2.AccessToken, string, error) {
+	now := o.now()
+	accessToken = accessToken.DeepCopy()
+	if accessToken.Spec.ExpirationTime.IsZero() {
+		accessToken.Spec.ExpirationTime = metav1.NewTime(now.Add(o.options.PersonalAccessTokenMaxAge))
+	}
+	expiresIn := accessToken.Spec.ExpirationTime.Sub(now)
+	if expiresIn <= 0 {
+		return nil, "", apierrors.NewBadRequest("expirationTime must be in the future")
+	}
+	if expiresIn > o.options.PersonalAccessTokenMaxAge {
+		return nil, "", apierrors.NewBadRequest(fmt.Sprintf("expirationTime must be within %s", o.options.PersonalAccessTokenMaxAge))
+	}
+	for _, verb := range accessToken.Spec.Verbs {
+		if !accessTokenVerbs.Has(verb) {
+			return nil, "", apierrors.NewBadRequest(fmt.Sprintf("unsupported verb %q, must be one of %v", verb, accessTokenVerbs.List()))
+		}
+	}
+
+	accessToken.ObjectMeta = metav1.ObjectMeta{
+		GenerateName: fmt.Sprintf("%s-", username),
+		Labels:       map[string]string{iamv1alpha2.UserReferenceLabel: username},
+	}
+	accessToken.Status = iamv1alpha2.AccessTokenStatus{}
+	created, err := o.ksClient.IamV1alpha2().AccessTokens().Create(context.Background(), accessToken, metav1.CreateOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, "", err
+	}
+
+	tokenStr, err := o.issuer.IssueTo(&token.IssueRequest{
+		User:      &user.DefaultInfo{Name: username},
+		ExpiresIn: expiresIn,
+		Claims: token.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ID: created.Name},
+			TokenType:        token.PersonalAccessToken,
+		},
+	})
+	if err != nil {
+		klog.Error(err)
+		if err := o.ksClient.IamV1alpha2().AccessTokens().Delete(context.Background(), created.Name, metav1.DeleteOptions{}); err != nil {
+			klog.Error(err)
+		}
+		return nil, "", err
+	}
+	return created, tokenStr, nil
+}
+
+func (o *accessTokenOperator) ListAccessTokens(username string) ([]*iamv1alpha2.AccessToken, error) {
+	accessTokens, err := o.accessTokenLister.List(labels.SelectorFromSet(labels.Set{iamv1alpha2.UserReferenceLabel: username}))
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	sort.Slice(accessTokens, func(i, j int) bool {
+		return accessTokens[j].CreationTimestamp.Before(&accessTokens[i].CreationTimestamp)
+	})
+	return accessTokens, nil
+}
+
+func (o *accessTokenOperator) RevokeAccessToken(username, name string) error {
+	accessToken, err := o.accessTokenLister.Get(name)
+	if err != nil {
+		return err
+	}
+	if accessToken.Labels[iamv1alpha2.UserReferenceLabel] != username {
+		return apierrors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralAccessToken), name)
+	}
+	err = o.ksClient.IamV1alpha2().AccessTokens().Delete(context.Background(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (o *accessTokenOperator) Validate(verified *token.VerifiedResponse, user *iamv1alpha2.User, clientIP string) (*iamv1alpha2.AccessToken, error) {
+	accessToken, err := o.accessTokenLister.Get(verified.ID)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, ErrAccessTokenRevoked
+		}
+		return nil, err
+	}
+	if accessToken.Labels[iamv1alpha2.UserReferenceLabel] != verified.User.GetName() {
+		return nil, ErrAccessTokenRevoked
+	}
+	// changing the password revokes the personal access tokens created before
+	if value := user.Annotations[iamv1alpha2.LastPasswordChangeTimeAnnotation]; value != "" {
+		if changed, err := time.Parse(time.RFC3339, value); err == nil && accessToken.CreationTimestamp.Time.Before(changed) {
+			return nil, ErrAccessTokenRevoked
+		}
+	}
+	now := o.now()
+	if !now.Before(accessToken.Spec.ExpirationTime.Time) {
+		return nil, ErrAccessTokenExpired
+	}
+
+	if o.shouldRecordUsage(accessToken, now) {
+		// the usage is recorded in the background, failing to record it doesn't reject the request
+		go func() {
+			if err := o.recordUsage(accessToken.Name, now, clientIP); err != nil {
+				klog.Warningf("failed to record the usage of access token %s: %v", accessToken.Name, err)
+			}
+		}()
+	}
+	return accessToken, nil
+}
+
+// shouldRecordUsage returns true if the usage of the AccessToken is not recorded in the last
+// accessTokenLastUsedInterval, the usage is recorded at most once in the interval regardless of the client IP.
+func (o *accessTokenOperator) shouldRecordUsage(accessToken *iamv1alpha2.AccessToken, now time.Time) bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	lastUsed := o.lastRecorded[accessToken.Name]
+	if accessToken.Status.LastUsedTime != nil && accessToken.Status.LastUsedTime.After(lastUsed) {
+		lastUsed = accessToken.Status.LastUsedTime.Time
+	}
+	if now.Sub(lastUsed) < accessTokenLastUsedInterval {
+		return false
+	}
+
+	for name, recorded := range o.lastRecorded {
+		if now.Sub(recorded) >= accessTokenLastUsedInterval {
+			delete(o.lastRecorded, name)
+		}
+	}
+	o.lastRecorded[accessToken.Name] = now
+	return true
+}
+
+func (o *accessTokenOperator) recordUsage(name string, now time.Time, sourceIP string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": iamv1alpha2.AccessTokenStatus{
+			LastUsedTime:     &metav1.Time{Time: now},
+			LastUsedSourceIP: sourceIP,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = o.ksClient.IamV1alpha2().AccessTokens().Patch(context.Background(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
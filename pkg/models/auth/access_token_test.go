@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	ksinformers "kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func TestAccessTokenOperator(t *testing.T) {
+	options := &authentication.Options{
+		JwtSecret:                 "test-secret",
+		MaximumClockSkew:          10 * time.Second,
+		PersonalAccessTokenMaxAge: 24 * time.Hour,
+		OAuthOptions: &oauth.Options{
+			Issuer:            "kubesphere",
+			AccessTokenMaxAge: time.Hour,
+		},
+	}
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	inMemoryCache, err := cache.NewInMemoryCache(nil, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenOperator := NewTokenOperator(inMemoryCache, issuer, options)
+
+	ksClient := fakeks.NewSimpleClientset()
+	// the fake clientset doesn't generate the names
+	generated := 0
+	ksClient.PrependReactor("create", "accesstokens", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		accessToken := action.(k8stesting.CreateAction).GetObject().(*iamv1alpha2.AccessToken)
+		if accessToken.Name == "" {
+			generated++
+			accessToken.Name = fmt.Sprintf("%s%d", accessToken.GenerateName, generated)
+			accessToken.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Duration(generated) * time.Second))
+		}
+		return false, nil, nil
+	})
+	accessTokenInformer := ksinformers.NewSharedInformerFactory(ksClient, 0).Iam().V1alpha2().AccessTokens()
+	operator := NewAccessTokenOperator(ksClient, accessTokenInformer.Lister(), issuer, options)
+
+	_, _, err = operator.CreateAccessToken("admin", &iamv1alpha2.AccessToken{Spec: iamv1alpha2.AccessTokenSpec{Verbs: []string{"escalate"}}})
+	assert.True(t, apierrors.IsBadRequest(err))
+	_, _, err = operator.CreateAccessToken("admin", &iamv1alpha2.AccessToken{Spec: iamv1alpha2.AccessTokenSpec{
+		ExpirationTime: metav1.NewTime(time.Now().Add(48 * time.Hour))}})
+	assert.True(t, apierrors.IsBadRequest(err))
+	_, _, err = operator.CreateAccessToken("admin", &iamv1alpha2.AccessToken{Spec: iamv1alpha2.AccessTokenSpec{
+		ExpirationTime: metav1.NewTime(time.Now().Add(-time.Minute))}})
+	assert.True(t, apierrors.IsBadRequest(err))
+
+	ci, tokenStr, err := operator.CreateAccessToken("admin", &iamv1alpha2.AccessToken{Spec: iamv1alpha2.AccessTokenSpec{
+		Description: "ci",
+		Workspaces:  []string{"system-workspace"},
+		Verbs:       []string{"get", "list"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, "admin-1", ci.Name)
+	assert.Equal(t, "admin", ci.Labels[iamv1alpha2.UserReferenceLabel])
+	assert.WithinDuration(t, time.Now().Add(24*time.Hour), ci.Spec.ExpirationTime.Time, time.Minute)
+	assert.NoError(t, accessTokenInformer.Informer().GetIndexer().Add(ci))
+	deploy, _, err := operator.CreateAccessToken("admin", &iamv1alpha2.AccessToken{Spec: iamv1alpha2.AccessTokenSpec{Description: "deploy"}})
+	assert.NoError(t, err)
+	assert.NoError(t, accessTokenInformer.Informer().GetIndexer().Add(deploy))
+
+	accessTokens, err := operator.ListAccessTokens("admin")
+	assert.NoError(t, err)
+	assert.Len(t, accessTokens, 2)
+	assert.Equal(t, deploy.Name, accessTokens[0].Name)
+	accessTokens, err = operator.ListAccessTokens("guest")
+	assert.NoError(t, err)
+	assert.Empty(t, accessTokens)
+
+	// personal access tokens are not cached, they are verified by the AccessToken
+	verified, err := tokenOperator.Verify(tokenStr)
+	assert.NoError(t, err)
+	assert.Equal(t, token.PersonalAccessToken, verified.TokenType)
+	assert.Equal(t, ci.Name, verified.ID)
+	assert.Equal(t, "admin", verified.User.GetName())
+
+	var patches int32
+	ksClient.PrependReactor("patch", "accesstokens", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		atomic.AddInt32(&patches, 1)
+		return false, nil, nil
+	})
+	admin := &iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: "admin"}}
+	validated, err := operator.Validate(verified, admin, "10.0.0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, ci.Name, validated.Name)
+	assert.Eventually(t, func() bool {
+		recorded, err := ksClient.IamV1alpha2().AccessTokens().Get(context.Background(), ci.Name, metav1.GetOptions{})
+		return err == nil && recorded.Status.LastUsedSourceIP == "10.0.0.1" && recorded.Status.LastUsedTime != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// the usage is recorded at most once in the interval even if the client IP changes
+	for _, clientIP := range []string{"10.0.0.2", "10.0.0.3"} {
+		_, err = operator.Validate(verified, admin, clientIP)
+		assert.NoError(t, err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&patches))
+
+	operator.(*accessTokenOperator).now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	_, err = operator.Validate(verified, admin, "10.0.0.1")
+	assert.Equal(t, ErrAccessTokenExpired, err)
+	operator.(*accessTokenOperator).now = time.Now
+
+	// changing the password revokes the personal access tokens created before
+	passwordChanged := admin.DeepCopy()
+	passwordChanged.Annotations = map[string]string{
+		iamv1alpha2.LastPasswordChangeTimeAnnotation: ci.CreationTimestamp.Add(time.Hour).UTC().Format(time.RFC3339),
+	}
+	_, err = operator.Validate(verified, passwordChanged, "10.0.0.1")
+	assert.Equal(t, ErrAccessTokenRevoked, err)
+
+	err = operator.RevokeAccessToken("guest", ci.Name)
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.NoError(t, operator.RevokeAccessToken("admin", ci.Name))
+	_, err = ksClient.IamV1alpha2().AccessTokens().Get(context.Background(), ci.Name, metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.NoError(t, accessTokenInformer.Informer().GetIndexer().Delete(ci))
+	_, err = operator.Validate(verified, admin, "10.0.0.1")
+	assert.Equal(t, ErrAccessTokenRevoked, err)
+}
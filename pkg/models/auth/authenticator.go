@@ -90,10 +90,18 @@ func mappedUser(idp string, identity identityprovider.Identity) *iamv1alpha2.Use
 				iamv1alpha2.OriginUIDLabel:        identity.GetUserID(),
 			},
 		},
-		Spec: iamv1alpha2.UserSpec{Email: identity.GetEmail()},
+		Spec: iamv1alpha2.UserSpec{Email: identity.GetEmail(), Groups: groupsOf(identity)},
 	}
 }
 
+// groupsOf returns the group claims of the identity, it returns nil if the identity carries no groups.
+func groupsOf(identity identityprovider.Identity) []string {
+	if groupsIdentity, ok := identity.(identityprovider.GroupsIdentity); ok {
+		return groupsIdentity.GetGroups()
+	}
+	return nil
+}
+
 // findUser returns the user associated with the username or email
 func (u *userGetter) findUser(username string) (*iamv1alpha2.User, error) {
 	if _, err := mail.ParseAddress(username); err != nil {
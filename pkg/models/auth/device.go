@@ -0,0 +1,279 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+const (
+	// DeviceCodeTTL is the lifetime of the device code and the user code.
+	DeviceCodeTTL = 10 * time.Minute
+	// DevicePollingInterval is the minimum amount of time the client waits between the polling requests.
+	DevicePollingInterval = 5 * time.Second
+
+	// userCodeCharset excludes the vowels to avoid forming words and the characters easily confused,
+	// as recommended by https://datatracker.ietf.org/doc/html/rfc8628#section-6.1
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength  = 8
+)
+
+var (
+	ErrAuthorizationPending      = errors.New("the user has not approved the device authorization request yet")
+	ErrDevicePollingTooFrequent  = errors.New("the device polls too frequently")
+	ErrDeviceAuthorizationDenied = errors.New("the user denied the device authorization request")
+	ErrDeviceCodeExpired         = errors.New("the device code is invalid or expired")
+	ErrUserCodeNotFound          = errors.New("the user code is invalid or expired")
+)
+
+// DeviceAuthorization is a pending device authorization request of the OAuth 2.0 device authorization grant,
+// the device polls the token endpoint with the device code until the user approves the request with the user code.
+type DeviceAuthorization struct {
+	DeviceCode string    `json:"-"`
+	UserCode   string    `json:"userCode" description:"the code the user enters on the verification page"`
+	ClientID   string    `json:"clientID" description:"the OAuth client which requested the authorization"`
+	Scopes     []string  `json:"scopes,omitempty" description:"the scopes requested by the client"`
+	ExpiresAt  time.Time `json:"expiresAt" description:"the time the request expires"`
+}
+
+// DeviceAuthorizationOperator manages the device authorization requests, see https://datatracker.ietf.org/doc/html/rfc8628
+type DeviceAuthorizationOperator interface {
+	// CreateDeviceAuthorization creates a device authorization request of the client.
+	CreateDeviceAuthorization(clientID string, scopes []string) (*DeviceAuthorization, error)
+	// DescribeDeviceAuthorization returns the pending request of the user code for the user to verify.
+	DescribeDeviceAuthorization(userCode string) (*DeviceAuthorization, error)
+	// ApproveDeviceAuthorization approves the request of the user code on behalf of the user.
+	ApproveDeviceAuthorization(userCode, username string) error
+	// DenyDeviceAuthorization denies the request of the user code.
+	DenyDeviceAuthorization(userCode string) error
+	// PollDeviceAuthorization returns the user who approved the request of the device code,
+	// the request can only be completed once.
+	PollDeviceAuthorization(clientID, deviceCode string) (string, error)
+}
+
+type deviceAuthorizationOperator struct {
+	cache cache.Interface
+	now   func() time.Time
+}
+
+func NewDeviceAuthorizationOperator(cacheClient cache.Interface) DeviceAuthorizationOperator {
+	return &deviceAuthorizationOperator{cache: cacheClient, now: time.Now}
+}
+
+func deviceCodeKey(deviceCode string) string {
+	return fmt.Sprintf("kubesphere:device:%s", deviceCode)
+}
+
+// deviceDecisionKey holds the user who approved the request, or an empty value if the request is denied.
+func deviceDecisionKey(deviceCode string) string {
+	return fmt.Sprintf("kubesphere:device:%s:decision", deviceCode)
+}
+
+// devicePollKey exists within the polling interval after the device polled.
+func devicePollKey(deviceCode string) string {
+	return fmt.Sprintf("kubesphere:device:%s:poll", deviceCode)
+}
+
+func userCodeKey(userCode string) string {
+	return fmt.Sprintf("kubesphere:device:usercode:%s", userCode)
+}
+
+func (d *deviceAuthorizationOperator) CreateDeviceAuthorization(clientID string, scopes []string) (*DeviceAuthorization, error) {
+	deviceCode, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	authorization := &DeviceAuthorization{
+		DeviceCode: deviceCode,
+		ClientID:   clientID,
+		Scopes:     scopes,
+		ExpiresAt:  d.now().Add(DeviceCodeTTL),
+	}
+	data, err := json.Marshal(authorization)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.cache.Set(deviceCodeKey(deviceCode), string(data), DeviceCodeTTL); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	// the user codes are short, retry on the unlikely collision
+	for i := 0; i < 3; i++ {
+		userCode, err := randomUserCode()
+		if err != nil {
+			return nil, err
+		}
+		set, err := d.cache.SetNX(userCodeKey(userCode), deviceCode, DeviceCodeTTL)
+		if err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		if set {
+			authorization.UserCode = formatUserCode(userCode)
+			return authorization, nil
+		}
+	}
+	return nil, errors.New("failed to generate a unique user code")
+}
+
+func (d *deviceAuthorizationOperator) DescribeDeviceAuthorization(userCode string) (*DeviceAuthorization, error) {
+	deviceCode, err := d.cache.Get(userCodeKey(normalizeUserCode(userCode)))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return nil, ErrUserCodeNotFound
+		}
+		klog.Error(err)
+		return nil, err
+	}
+	authorization, err := d.getDeviceAuthorization(deviceCode)
+	if err == ErrDeviceCodeExpired {
+		return nil, ErrUserCodeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	authorization.UserCode = formatUserCode(normalizeUserCode(userCode))
+	return authorization, nil
+}
+
+func (d *deviceAuthorizationOperator) ApproveDeviceAuthorization(userCode, username string) error {
+	return d.decide(userCode, username)
+}
+
+func (d *deviceAuthorizationOperator) DenyDeviceAuthorization(userCode string) error {
+	return d.decide(userCode, "")
+}
+
+// decide records the decision of the user, the user code can't be used again after that.
+func (d *deviceAuthorizationOperator) decide(userCode, username string) error {
+	authorization, err := d.DescribeDeviceAuthorization(userCode)
+	if err != nil {
+		return err
+	}
+	set, err := d.cache.SetNX(deviceDecisionKey(authorization.DeviceCode), username, authorization.ExpiresAt.Sub(d.now()))
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if err = d.cache.Del(userCodeKey(normalizeUserCode(userCode))); err != nil {
+		klog.Error(err)
+		return err
+	}
+	if !set {
+		return ErrUserCodeNotFound
+	}
+	return nil
+}
+
+func (d *deviceAuthorizationOperator) PollDeviceAuthorization(clientID, deviceCode string) (string, error) {
+	if deviceCode == "" {
+		return "", ErrDeviceCodeExpired
+	}
+	authorization, err := d.getDeviceAuthorization(deviceCode)
+	if err != nil {
+		return "", err
+	}
+	if authorization.ClientID != clientID {
+		return "", ErrDeviceCodeExpired
+	}
+
+	set, err := d.cache.SetNX(devicePollKey(deviceCode), "", DevicePollingInterval)
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	if !set {
+		return "", ErrDevicePollingTooFrequent
+	}
+
+	username, err := d.cache.Get(deviceDecisionKey(deviceCode))
+	if err == cache.ErrNoSuchKey {
+		return "", ErrAuthorizationPending
+	}
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	// the device code can't be used again once the request is completed
+	if err = d.cache.Del(deviceCodeKey(deviceCode), deviceDecisionKey(deviceCode), devicePollKey(deviceCode)); err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	if username == "" {
+		return "", ErrDeviceAuthorizationDenied
+	}
+	return username, nil
+}
+
+func (d *deviceAuthorizationOperator) getDeviceAuthorization(deviceCode string) (*DeviceAuthorization, error) {
+	value, err := d.cache.Get(deviceCodeKey(deviceCode))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return nil, ErrDeviceCodeExpired
+		}
+		klog.Error(err)
+		return nil, err
+	}
+	authorization := &DeviceAuthorization{}
+	if err = json.Unmarshal([]byte(value), authorization); err != nil {
+		return nil, err
+	}
+	authorization.DeviceCode = deviceCode
+	return authorization, nil
+}
+
+func randomUserCode() (string, error) {
+	code := make([]byte, userCodeLength)
+	max := big.NewInt(int64(len(userCodeCharset)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeCharset[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// formatUserCode formats the user code as XXXX-XXXX for readability.
+func formatUserCode(userCode string) string {
+	if len(userCode) != userCodeLength {
+		return userCode
+	}
+	return userCode[:userCodeLength/2] + "-" + userCode[userCodeLength/2:]
+}
+
+// normalizeUserCode removes the punctuation and the whitespaces the user may enter.
+func normalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(userCode))
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func newTestDeviceAuthorizationOperator(t *testing.T) (DeviceAuthorizationOperator, cache.Interface) {
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	inMemoryCache, err := cache.NewInMemoryCache(nil, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewDeviceAuthorizationOperator(inMemoryCache), inMemoryCache
+}
+
+func TestDeviceAuthorizationApproved(t *testing.T) {
+	operator, cacheClient := newTestDeviceAuthorizationOperator(t)
+
+	authorization, err := operator.CreateDeviceAuthorization("kubectl", []string{"openid"})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, authorization.DeviceCode)
+	assert.Regexp(t, "^[BCDFGHJKLMNPQRSTVWXZ]{4}-[BCDFGHJKLMNPQRSTVWXZ]{4}$", authorization.UserCode)
+
+	_, err = operator.PollDeviceAuthorization("kubectl", authorization.DeviceCode)
+	assert.Equal(t, ErrAuthorizationPending, err)
+	_, err = operator.PollDeviceAuthorization("kubectl", authorization.DeviceCode)
+	assert.Equal(t, ErrDevicePollingTooFrequent, err)
+	// the polling interval is over
+	assert.NoError(t, cacheClient.Del(devicePollKey(authorization.DeviceCode)))
+
+	// the user may enter the user code in lower case without the hyphen
+	userCode := strings.ToLower(strings.ReplaceAll(authorization.UserCode, "-", ""))
+	described, err := operator.DescribeDeviceAuthorization(userCode)
+	assert.NoError(t, err)
+	assert.Equal(t, "kubectl", described.ClientID)
+	assert.Equal(t, []string{"openid"}, described.Scopes)
+	assert.Equal(t, authorization.UserCode, described.UserCode)
+
+	assert.NoError(t, operator.ApproveDeviceAuthorization(userCode, "admin"))
+	// the user code can only be used once
+	assert.Equal(t, ErrUserCodeNotFound, operator.ApproveDeviceAuthorization(userCode, "guest"))
+
+	// the device code is bound to the client
+	_, err = operator.PollDeviceAuthorization("other", authorization.DeviceCode)
+	assert.Equal(t, ErrDeviceCodeExpired, err)
+	username, err := operator.PollDeviceAuthorization("kubectl", authorization.DeviceCode)
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", username)
+	_, err = operator.PollDeviceAuthorization("kubectl", authorization.DeviceCode)
+	assert.Equal(t, ErrDeviceCodeExpired, err)
+}
+
+func TestDeviceAuthorizationDenied(t *testing.T) {
+	operator, _ := newTestDeviceAuthorizationOperator(t)
+
+	authorization, err := operator.CreateDeviceAuthorization("kubectl", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, operator.DenyDeviceAuthorization(authorization.UserCode))
+	_, err = operator.DescribeDeviceAuthorization(authorization.UserCode)
+	assert.Equal(t, ErrUserCodeNotFound, err)
+
+	_, err = operator.PollDeviceAuthorization("kubectl", authorization.DeviceCode)
+	assert.Equal(t, ErrDeviceAuthorizationDenied, err)
+	_, err = operator.PollDeviceAuthorization("kubectl", authorization.DeviceCode)
+	assert.Equal(t, ErrDeviceCodeExpired, err)
+
+	assert.Equal(t, ErrUserCodeNotFound, operator.ApproveDeviceAuthorization("BCDF-GHJK", "admin"))
+	_, err = operator.PollDeviceAuthorization("kubectl", "")
+	assert.Equal(t, ErrDeviceCodeExpired, err)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+// reasonUnlocked is the reason of the event recorded when a user is unlocked by an operator.
+const reasonUnlocked = "Unlocked"
+
+// AccountLockoutOperator unlocks the accounts locked after too many failed login attempts.
+type AccountLockoutOperator interface {
+	// Unlock activates the user immediately, the failed login attempts and the consecutive lockouts
+	// of the user are reset, and the operator is recorded in the status reason and an event of the user.
+	Unlock(username, operator string) error
+}
+
+type accountLockoutOperator struct {
+	ksClient    kubesphere.Interface
+	rateLimiter cache.RateLimiter
+	recorder    record.EventRecorder
+	now         func() time.Time
+}
+
+func NewAccountLockoutOperator(ksClient kubesphere.Interface, cacheClient cache.Interface, recorder record.EventRecorder,
+	options *authentication.Options) AccountLockoutOperator {
+	return &accountLockoutOperator{
+		ksClient:    ksClient,
+		rateLimiter: newLoginRateLimiter(cacheClient, options),
+		recorder:    recorder,
+		now:         time.Now,
+	}
+}
+
+func (o *accountLockoutOperator) Unlock(username, operator string) error {
+	var unlocked *iamv1alpha2.User
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		user, err := o.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		switch {
+		case user.Status.State == iamv1alpha2.UserAuthLimitExceeded:
+			// the failed login attempts before the transition are not counted by the user controller
+			user.Status = iamv1alpha2.UserStatus{
+				State:              iamv1alpha2.UserActive,
+				Reason:             fmt.Sprintf("Unlocked by %s", operator),
+				LastTransitionTime: &metav1.Time{Time: o.now()},
+				LastLoginTime:      user.Status.LastLoginTime,
+			}
+		case user.Status.LockoutCount > 0:
+			user.Status.LockoutCount = 0
+		default:
+			return nil
+		}
+		unlocked, err = o.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if o.rateLimiter != nil {
+		if err = o.rateLimiter.Reset(username); err != nil {
+			return err
+		}
+	}
+	if unlocked != nil {
+		o.recorder.Eventf(unlocked, corev1.EventTypeNormal, reasonUnlocked, "Unlocked by %s", operator)
+	}
+	klog.Infof("user %s is unlocked by %s", username, operator)
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func TestAccountLockoutOperatorUnlock(t *testing.T) {
+	locked := newActiveUser("locked", "P@88w0rd")
+	locked.Status = iamv1alpha2.UserStatus{
+		State:        iamv1alpha2.UserAuthLimitExceeded,
+		LockoutCount: 3,
+		LockedUntil:  &metav1.Time{Time: time.Now().Add(time.Hour)},
+	}
+	disabled := newActiveUser("disabled", "P@88w0rd")
+	disabled.Status = iamv1alpha2.UserStatus{State: iamv1alpha2.UserDisabled, LockoutCount: 1}
+	ksClient := fakeks.NewSimpleClientset(locked, disabled)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	cacheClient, _ := cache.NewInMemoryCache(nil, stopCh)
+	options := authentication.NewOptions()
+	recorder := record.NewFakeRecorder(10)
+	operator := NewAccountLockoutOperator(ksClient, cacheClient, recorder, options)
+	rateLimiter := newLoginRateLimiter(cacheClient, options)
+	for i := 0; i < options.AuthenticateRateLimiterMaxTries; i++ {
+		assert.NoError(t, rateLimiter.Record("locked"))
+	}
+
+	assert.NoError(t, operator.Unlock("locked", "admin"))
+	user, err := ksClient.IamV1alpha2().Users().Get(context.Background(), "locked", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, iamv1alpha2.UserActive, user.Status.State)
+	assert.Equal(t, "Unlocked by admin", user.Status.Reason)
+	assert.Zero(t, user.Status.LockoutCount)
+	assert.Nil(t, user.Status.LockedUntil)
+	exceeded, err := rateLimiter.Exceeded("locked")
+	assert.NoError(t, err)
+	assert.False(t, exceeded)
+	assert.Equal(t, "Normal Unlocked Unlocked by admin", <-recorder.Events)
+
+	// the disabled user is not activated
+	assert.NoError(t, operator.Unlock("disabled", "admin"))
+	user, err = ksClient.IamV1alpha2().Users().Get(context.Background(), "disabled", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, iamv1alpha2.UserDisabled, user.Status.State)
+	assert.Zero(t, user.Status.LockoutCount)
+	assert.Equal(t, "Normal Unlocked Unlocked by admin", <-recorder.Events)
+
+	// nothing is recorded if the user is not locked
+	assert.NoError(t, operator.Unlock("disabled", "admin"))
+	assert.Empty(t, recorder.Events)
+}
@@ -0,0 +1,642 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	authuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/totp"
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	iamv1alpha2listers "kubesphere.io/kubesphere/pkg/client/listers/iam/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+const (
+	// multiFactorChallengeTTL is how long the user has to pass the challenge after the password is verified.
+	multiFactorChallengeTTL = 5 * time.Minute
+	// multiFactorChallengeMaxAttempts is the number of passcodes that can be tried against a challenge,
+	// the user has to login with the password again once exceeded.
+	multiFactorChallengeMaxAttempts = 5
+	recoveryCodeCount               = 10
+	totpIssuer                      = "KubeSphere"
+	// sealedSecretPrefix marks the TOTP secret encrypted with AES-GCM.
+	sealedSecretPrefix = "aesgcm:"
+)
+
+var (
+	ErrMultiFactorChallengeNotFound = errors.New("the multi-factor authentication challenge is invalid or expired")
+	ErrIncorrectPasscode            = errors.New("incorrect passcode")
+	ErrTOTPEnrolled                 = errors.New("TOTP has been enrolled")
+	ErrTOTPNotEnrolled              = errors.New("TOTP has not been enrolled")
+)
+
+// MultiFactorAuthenticator performs the second step of the login of the KubeSphere-local accounts,
+// and manages the TOTP authenticators and the recovery codes of the users.
+type MultiFactorAuthenticator interface {
+	// Challenge returns a challenge if the authenticated user must pass the second factor to login,
+	// nil is returned if the second factor is not required.
+	Challenge(authenticated authuser.Info, provider string) (*MultiFactorChallenge, error)
+	// Verify verifies the passcode or the recovery code against the challenge,
+	// the user and the identity provider of the challenge are returned if passed.
+	// The recovery codes are returned as well if the pending TOTP enrollment is confirmed by the passcode.
+	Verify(challenge, passcode string) (authuser.Info, string, []string, error)
+	// Required checks whether the user must pass the second factor to login, that is the user has enrolled
+	// or the policy requires the second factor.
+	Required(authenticated authuser.Info, provider string) (bool, error)
+	// EnrollTOTP generates a TOTP secret for the user, the secret takes effect after confirmed by ConfirmTOTP.
+	EnrollTOTP(username string) (*TOTPEnrollment, error)
+	// ConfirmTOTP confirms the TOTP enrollment with a passcode and returns the recovery codes.
+	ConfirmTOTP(username, passcode string) ([]string, error)
+	// RegenerateRecoveryCodes replaces the recovery codes of the user, a current passcode or recovery code is required.
+	RegenerateRecoveryCodes(username, passcode string) ([]string, error)
+	// DisableMultiFactorAuth removes the TOTP authenticator and the recovery codes of the user,
+	// a current passcode or recovery code is required once the TOTP is confirmed.
+	DisableMultiFactorAuth(username, passcode string) error
+	// ResetMultiFactorAuth removes the TOTP authenticator and the recovery codes of the user without the passcode,
+	// it's used by the administrators when the user loses the authenticator.
+	ResetMultiFactorAuth(username string) error
+}
+
+// MultiFactorChallenge is issued once the password of a user is verified, the tokens are issued
+// after the user passes the challenge with a TOTP passcode or a recovery code.
+type MultiFactorChallenge struct {
+	Token string `json:"token"`
+	// Enrollment is set if the second factor is required but the user has not enrolled yet,
+	// the user enrolls by passing the challenge with a passcode generated by the secret.
+	Enrollment *TOTPEnrollment `json:"enrollment,omitempty"`
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret" description:"the base32 encoded secret"`
+	URI    string `json:"uri" description:"the otpauth URI encoded into the QR code scanned by the authenticator apps"`
+}
+
+// challengeState is the cached state of a challenge.
+type challengeState struct {
+	Username string              `json:"username"`
+	Groups   []string            `json:"groups,omitempty"`
+	Extra    map[string][]string `json:"extra,omitempty"`
+	Provider string              `json:"provider,omitempty"`
+}
+
+type multiFactorAuthenticator struct {
+	ksClient                kubesphere.Interface
+	userLister              iamv1alpha2listers.UserLister
+	globalRoleBindingLister iamv1alpha2listers.GlobalRoleBindingLister
+	cache                   cache.Interface
+	options                 *authentication.Options
+	now                     func() time.Time
+}
+
+func NewMultiFactorAuthenticator(ksClient kubesphere.Interface,
+	userLister iamv1alpha2listers.UserLister,
+	globalRoleBindingLister iamv1alpha2listers.GlobalRoleBindingLister,
+	cacheClient cache.Interface,
+	options *authentication.Options) MultiFactorAuthenticator {
+	return &multiFactorAuthenticator{
+		ksClient:                ksClient,
+		userLister:              userLister,
+		globalRoleBindingLister: globalRoleBindingLister,
+		cache:                   cacheClient,
+		options:                 options,
+		now:                     time.Now,
+	}
+}
+
+func challengeKey(challenge string) string {
+	return fmt.Sprintf("kubesphere:mfa:challenge:%s", challenge)
+}
+
+func challengeAttemptsKey(challenge string) string {
+	return fmt.Sprintf("kubesphere:mfa:challenge:%s:attempts", challenge)
+}
+
+// passcodeUsedKey records the time step counter of a passcode which has been used,
+// a passcode can only be used once even though it's valid for several time steps.
+func passcodeUsedKey(username string, counter uint64) string {
+	return fmt.Sprintf("kubesphere:user:%s:totp:%d", username, counter)
+}
+
+func (m *multiFactorAuthenticator) Challenge(authenticated authuser.Info, provider string) (*MultiFactorChallenge, error) {
+	// the second factor only applies to the KubeSphere-local accounts
+	if provider != "" {
+		return nil, nil
+	}
+	user, err := m.userLister.Get(authenticated.GetName())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		klog.Error(err)
+		return nil, err
+	}
+
+	var enrollment *TOTPEnrollment
+	if !totpConfirmed(user) {
+		required, err := m.requiredByPolicy(authenticated)
+		if err != nil || !required {
+			return nil, err
+		}
+		// the user enrolls on the first login after the second factor is required
+		if enrollment, err = m.pendingEnrollment(user.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	state, err := json.Marshal(challengeState{
+		Username: authenticated.GetName(),
+		Groups:   authenticated.GetGroups(),
+		Extra:    authenticated.GetExtra(),
+		Provider: provider,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = m.cache.Set(challengeKey(token), string(state), multiFactorChallengeTTL); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return &MultiFactorChallenge{Token: token, Enrollment: enrollment}, nil
+}
+
+func (m *multiFactorAuthenticator) Verify(challenge, passcode string) (authuser.Info, string, []string, error) {
+	if challenge == "" {
+		return nil, "", nil, ErrMultiFactorChallengeNotFound
+	}
+	value, err := m.cache.Get(challengeKey(challenge))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return nil, "", nil, ErrMultiFactorChallengeNotFound
+		}
+		klog.Error(err)
+		return nil, "", nil, err
+	}
+	var state challengeState
+	if err = json.Unmarshal([]byte(value), &state); err != nil {
+		return nil, "", nil, err
+	}
+
+	// the attempts are counted across all the replicas before verifying the passcode
+	attempts, err := m.cache.Incr(challengeAttemptsKey(challenge), multiFactorChallengeTTL)
+	if err != nil {
+		klog.Error(err)
+		return nil, "", nil, err
+	}
+	if attempts > multiFactorChallengeMaxAttempts {
+		m.deleteChallenge(challenge)
+		return nil, "", nil, ErrMultiFactorChallengeNotFound
+	}
+
+	recoveryCodes, err := m.verifyPasscode(state.Username, passcode)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	m.deleteChallenge(challenge)
+	return &authuser.DefaultInfo{Name: state.Username, Groups: state.Groups, Extra: state.Extra}, state.Provider, recoveryCodes, nil
+}
+
+func (m *multiFactorAuthenticator) deleteChallenge(challenge string) {
+	if err := m.cache.Del(challengeKey(challenge), challengeAttemptsKey(challenge)); err != nil {
+		klog.Warningf("failed to delete multi-factor authentication challenge: %v", err)
+	}
+}
+
+// verifyPasscode verifies the TOTP passcode or the recovery code of the user, and the recovery code is consumed
+// once it's used. The pending TOTP enrollment is confirmed by a valid passcode, the recovery codes are generated
+// along with the confirmation and returned.
+func (m *multiFactorAuthenticator) verifyPasscode(username, passcode string) ([]string, error) {
+	// get the latest user rather than the cached one, the enrollment may be created just now
+	user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	if user.Spec.MultiFactorAuth == nil || user.Spec.MultiFactorAuth.TOTP == nil {
+		return nil, ErrTOTPNotEnrolled
+	}
+
+	sealed := user.Spec.MultiFactorAuth.TOTP.Secret
+	secret, err := m.openTOTPSecret(username, sealed)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := m.validateTOTP(username, secret, passcode)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if !user.Spec.MultiFactorAuth.TOTP.Confirmed {
+			return m.confirmTOTP(username, sealed)
+		}
+		return nil, nil
+	}
+
+	// the recovery codes are only available after the TOTP is confirmed
+	if !totpConfirmed(user) {
+		return nil, ErrIncorrectPasscode
+	}
+	hashed := hashRecoveryCode(passcode)
+	// the update fails with a conflict if the recovery code is consumed concurrently,
+	// so that a recovery code can never be used twice
+	_, err = m.updateMultiFactorAuth(username, func(mfa *iamv1alpha2.MultiFactorAuth) error {
+		for i, code := range mfa.RecoveryCodes {
+			if subtle.ConstantTimeCompare([]byte(code), []byte(hashed)) == 1 {
+				mfa.RecoveryCodes = append(mfa.RecoveryCodes[:i], mfa.RecoveryCodes[i+1:]...)
+				klog.Infof("user %s logged in with a recovery code, %d recovery codes left", username, len(mfa.RecoveryCodes))
+				return nil
+			}
+		}
+		return ErrIncorrectPasscode
+	})
+	return nil, err
+}
+
+// verifyCurrentPasscode verifies the passcode or the recovery code of the user whose TOTP is confirmed,
+// it's required by the changes to the second factor, so that a stolen session can't remove it.
+func (m *multiFactorAuthenticator) verifyCurrentPasscode(username, passcode string) error {
+	user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if !totpConfirmed(user) {
+		return ErrTOTPNotEnrolled
+	}
+	_, err = m.verifyPasscode(username, passcode)
+	return err
+}
+
+// confirmTOTP confirms the pending TOTP enrollment with the sealed secret, the recovery codes are returned.
+func (m *multiFactorAuthenticator) confirmTOTP(username, sealed string) ([]string, error) {
+	codes, hashed, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	_, err = m.updateMultiFactorAuth(username, func(mfa *iamv1alpha2.MultiFactorAuth) error {
+		// the secret may be replaced concurrently
+		if mfa.TOTP == nil || mfa.TOTP.Secret != sealed {
+			return ErrIncorrectPasscode
+		}
+		if mfa.TOTP.Confirmed {
+			return ErrTOTPEnrolled
+		}
+		mfa.TOTP.Confirmed = true
+		mfa.TOTP.EnrolledTime = &metav1.Time{Time: m.now()}
+		mfa.RecoveryCodes = hashed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// validateTOTP validates the passcode and makes sure it has not been used.
+func (m *multiFactorAuthenticator) validateTOTP(username, secret, passcode string) (bool, error) {
+	counter, ok, err := totp.Validate(secret, passcode, m.now())
+	if err != nil || !ok {
+		return false, err
+	}
+	// the passcode is valid in all the accepted time steps
+	set, err := m.cache.SetNX(passcodeUsedKey(username, counter), "", (2*totp.Skew+1)*totp.Period)
+	if err != nil {
+		klog.Error(err)
+		return false, err
+	}
+	if !set {
+		return false, ErrIncorrectPasscode
+	}
+	return true, nil
+}
+
+func (m *multiFactorAuthenticator) Required(authenticated authuser.Info, provider string) (bool, error) {
+	if provider != "" {
+		return false, nil
+	}
+	user, err := m.userLister.Get(authenticated.GetName())
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		klog.Error(err)
+		return false, err
+	}
+	if totpConfirmed(user) {
+		return true, nil
+	}
+	return m.requiredByPolicy(authenticated)
+}
+
+// requiredByPolicy returns true if the policy requires the KubeSphere-local accounts bound
+// to the platform-admin GlobalRole to login with the second factor.
+func (m *multiFactorAuthenticator) requiredByPolicy(authenticated authuser.Info) (bool, error) {
+	if !m.options.RequireMultiFactorAuthForPlatformAdmins {
+		return false, nil
+	}
+	globalRoleBindings, err := m.globalRoleBindingLister.List(labels.Everything())
+	if err != nil {
+		klog.Error(err)
+		return false, err
+	}
+	groups := sets.NewString(authenticated.GetGroups()...)
+	for _, globalRoleBinding := range globalRoleBindings {
+		if globalRoleBinding.RoleRef.Name != iamv1alpha2.PlatformAdmin {
+			continue
+		}
+		for _, subject := range globalRoleBinding.Subjects {
+			if (subject.Kind == rbacv1.UserKind && subject.Name == authenticated.GetName()) ||
+				(subject.Kind == rbacv1.GroupKind && groups.Has(subject.Name)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// pendingEnrollment returns the TOTP enrollment which has not been confirmed, a new one is created if not exists.
+func (m *multiFactorAuthenticator) pendingEnrollment(username string) (*TOTPEnrollment, error) {
+	user, err := m.updateMultiFactorAuth(username, func(mfa *iamv1alpha2.MultiFactorAuth) error {
+		if mfa.TOTP != nil && mfa.TOTP.Secret != "" {
+			return nil
+		}
+		var err error
+		mfa.TOTP, err = m.newTOTP(username)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	secret, err := m.openTOTPSecret(username, user.Spec.MultiFactorAuth.TOTP.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return newTOTPEnrollment(username, secret), nil
+}
+
+func (m *multiFactorAuthenticator) EnrollTOTP(username string) (*TOTPEnrollment, error) {
+	user, err := m.updateMultiFactorAuth(username, func(mfa *iamv1alpha2.MultiFactorAuth) error {
+		if mfa.TOTP != nil && mfa.TOTP.Confirmed {
+			return ErrTOTPEnrolled
+		}
+		var err error
+		mfa.TOTP, err = m.newTOTP(username)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	secret, err := m.openTOTPSecret(username, user.Spec.MultiFactorAuth.TOTP.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return newTOTPEnrollment(username, secret), nil
+}
+
+// newTOTP generates a TOTP secret, which is sealed before stored in the user.
+func (m *multiFactorAuthenticator) newTOTP(username string) (*iamv1alpha2.TOTP, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := m.sealTOTPSecret(username, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &iamv1alpha2.TOTP{Secret: sealed}, nil
+}
+
+func (m *multiFactorAuthenticator) ConfirmTOTP(username, passcode string) ([]string, error) {
+	user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	if user.Spec.MultiFactorAuth == nil || user.Spec.MultiFactorAuth.TOTP == nil {
+		return nil, ErrTOTPNotEnrolled
+	}
+	if user.Spec.MultiFactorAuth.TOTP.Confirmed {
+		return nil, ErrTOTPEnrolled
+	}
+	sealed := user.Spec.MultiFactorAuth.TOTP.Secret
+	secret, err := m.openTOTPSecret(username, sealed)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := m.validateTOTP(username, secret, passcode)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrIncorrectPasscode
+	}
+	return m.confirmTOTP(username, sealed)
+}
+
+func (m *multiFactorAuthenticator) RegenerateRecoveryCodes(username, passcode string) ([]string, error) {
+	if err := m.verifyCurrentPasscode(username, passcode); err != nil {
+		return nil, err
+	}
+	var codes []string
+	_, err := m.updateMultiFactorAuth(username, func(mfa *iamv1alpha2.MultiFactorAuth) error {
+		if mfa.TOTP == nil || !mfa.TOTP.Confirmed {
+			return ErrTOTPNotEnrolled
+		}
+		var hashed []string
+		var err error
+		if codes, hashed, err = generateRecoveryCodes(); err != nil {
+			return err
+		}
+		mfa.RecoveryCodes = hashed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (m *multiFactorAuthenticator) DisableMultiFactorAuth(username, passcode string) error {
+	user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	// the pending enrollment doesn't protect the account yet, it can be removed without the passcode
+	if totpConfirmed(user) {
+		if err = m.verifyCurrentPasscode(username, passcode); err != nil {
+			return err
+		}
+	}
+	return m.ResetMultiFactorAuth(username)
+}
+
+func (m *multiFactorAuthenticator) ResetMultiFactorAuth(username string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if user.Spec.MultiFactorAuth == nil {
+			return nil
+		}
+		user.Spec.MultiFactorAuth = nil
+		_, err = m.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// updateMultiFactorAuth applies the mutation to the latest user, it's retried if the user is updated concurrently.
+func (m *multiFactorAuthenticator) updateMultiFactorAuth(username string, mutate func(mfa *iamv1alpha2.MultiFactorAuth) error) (*iamv1alpha2.User, error) {
+	var updated *iamv1alpha2.User
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if user.Spec.MultiFactorAuth == nil {
+			user.Spec.MultiFactorAuth = &iamv1alpha2.MultiFactorAuth{}
+		}
+		if err = mutate(user.Spec.MultiFactorAuth); err != nil {
+			return err
+		}
+		updated, err = m.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func totpConfirmed(user *iamv1alpha2.User) bool {
+	return user.Spec.MultiFactorAuth != nil && user.Spec.MultiFactorAuth.TOTP != nil &&
+		user.Spec.MultiFactorAuth.TOTP.Confirmed
+}
+
+// sealTOTPSecret encrypts the TOTP secret with the key derived from the JWT secret, so that the secret can't be
+// read from the user object. The username is authenticated along with the secret, the sealed secret of a user
+// can't be copied to another user.
+func (m *multiFactorAuthenticator) sealTOTPSecret(username, secret string) (string, error) {
+	aead, err := m.totpSecretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), []byte(username))
+	return sealedSecretPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// openTOTPSecret decrypts the TOTP secret sealed by sealTOTPSecret.
+func (m *multiFactorAuthenticator) openTOTPSecret(username, sealed string) (string, error) {
+	if !strings.HasPrefix(sealed, sealedSecretPrefix) {
+		return "", errors.New("the TOTP secret is not sealed")
+	}
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedSecretPrefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid sealed TOTP secret: %v", err)
+	}
+	aead, err := m.totpSecretCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("invalid sealed TOTP secret")
+	}
+	secret, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(username))
+	if err != nil {
+		return "", fmt.Errorf("failed to open the sealed TOTP secret: %v", err)
+	}
+	return string(secret), nil
+}
+
+func (m *multiFactorAuthenticator) totpSecretCipher() (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("kubesphere:totp:" + m.options.JwtSecret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func newTOTPEnrollment(username, secret string) *TOTPEnrollment {
+	return &TOTPEnrollment{Secret: secret, URI: totp.URI(totpIssuer, username, secret)}
+}
+
+// generateRecoveryCodes generates the recovery codes in the form of "xxxxx-xxxxx" along with their hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashed := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		data := make([]byte, 5)
+		if _, err := rand.Read(data); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(data))
+		codes = append(codes, code[:5]+"-"+code[5:])
+		hashed = append(hashed, hashRecoveryCode(code))
+	}
+	return codes, hashed, nil
+}
+
+// hashRecoveryCode hashes the recovery code, the recovery codes have enough entropy
+// so that a fast hash is sufficient.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int) (string, error) {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
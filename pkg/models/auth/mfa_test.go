@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/totp"
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	ksinformers "kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+type mfaTestEnv struct {
+	t             *testing.T
+	client        *fakeks.Clientset
+	informers     ksinformers.SharedInformerFactory
+	authenticator *multiFactorAuthenticator
+}
+
+func newMFATestEnv(t *testing.T, options *authentication.Options) *mfaTestEnv {
+	users := []*iamv1alpha2.User{
+		{ObjectMeta: metav1.ObjectMeta{Name: "admin"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "alice"}},
+	}
+	client := fakeks.NewSimpleClientset()
+	informers := ksinformers.NewSharedInformerFactory(client, 0)
+	for _, u := range users {
+		if _, err := client.IamV1alpha2().Users().Create(context.Background(), u, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := informers.Iam().V1alpha2().Users().Informer().GetIndexer().Add(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := informers.Iam().V1alpha2().GlobalRoleBindings().Informer().GetIndexer().Add(&iamv1alpha2.GlobalRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+		RoleRef:    rbacv1.RoleRef{APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Kind: iamv1alpha2.ResourceKindGlobalRole, Name: iamv1alpha2.PlatformAdmin},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "admin"}, {Kind: rbacv1.GroupKind, Name: "platform-admins"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	inMemoryCache, err := cache.NewInMemoryCache(nil, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authenticator := NewMultiFactorAuthenticator(client,
+		informers.Iam().V1alpha2().Users().Lister(),
+		informers.Iam().V1alpha2().GlobalRoleBindings().Lister(),
+		inMemoryCache, options).(*multiFactorAuthenticator)
+	now := time.Unix(1700000000, 0)
+	authenticator.now = func() time.Time { return now }
+	return &mfaTestEnv{t: t, client: client, informers: informers, authenticator: authenticator}
+}
+
+// sync updates the lister with the latest user.
+func (e *mfaTestEnv) sync(username string) *iamv1alpha2.User {
+	u, err := e.client.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if err = e.informers.Iam().V1alpha2().Users().Informer().GetIndexer().Update(u); err != nil {
+		e.t.Fatal(err)
+	}
+	return u
+}
+
+func (e *mfaTestEnv) passcode(secret string, after time.Duration) string {
+	code, err := totp.Passcode(secret, totp.Counter(e.authenticator.now().Add(after)))
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return code
+}
+
+func TestMultiFactorAuthEnrollment(t *testing.T) {
+	env := newMFATestEnv(t, &authentication.Options{JwtSecret: "secret"})
+	m := env.authenticator
+	aliceInfo := &user.DefaultInfo{Name: "alice", Groups: []string{"developers"}}
+
+	challenge, err := m.Challenge(aliceInfo, "")
+	assert.NoError(t, err)
+	assert.Nil(t, challenge, "the second factor is not required before enrolled")
+
+	enrollment, err := m.EnrollTOTP("alice")
+	assert.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/KubeSphere:alice?")
+	_, err = m.ConfirmTOTP("alice", "000000")
+	assert.Equal(t, ErrIncorrectPasscode, err)
+	codes, err := m.ConfirmTOTP("alice", env.passcode(enrollment.Secret, 0))
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	_, err = m.EnrollTOTP("alice")
+	assert.Equal(t, ErrTOTPEnrolled, err)
+	alice := env.sync("alice")
+	assert.NotContains(t, alice.Spec.MultiFactorAuth.RecoveryCodes, codes[0], "the recovery codes are hashed")
+	assert.NotContains(t, alice.Spec.MultiFactorAuth.TOTP.Secret, enrollment.Secret, "the secret is encrypted")
+	// the sealed secret can't be opened as the secret of another user
+	_, err = m.openTOTPSecret("admin", alice.Spec.MultiFactorAuth.TOTP.Secret)
+	assert.Error(t, err)
+
+	required, err := m.Required(aliceInfo, "")
+	assert.NoError(t, err)
+	assert.True(t, required)
+
+	challenge, err = m.Challenge(aliceInfo, "")
+	assert.NoError(t, err)
+	assert.NotNil(t, challenge)
+	assert.Nil(t, challenge.Enrollment)
+
+	// the passcode used to confirm the enrollment can't be used again
+	_, _, _, err = m.Verify(challenge.Token, env.passcode(enrollment.Secret, 0))
+	assert.Equal(t, ErrIncorrectPasscode, err)
+	authenticated, provider, recoveryCodes, err := m.Verify(challenge.Token, env.passcode(enrollment.Secret, totp.Period))
+	assert.NoError(t, err)
+	assert.Equal(t, "", provider)
+	assert.Nil(t, recoveryCodes, "the recovery codes are only returned when the enrollment is confirmed")
+	assert.Equal(t, "alice", authenticated.GetName())
+	assert.Equal(t, []string{"developers"}, authenticated.GetGroups())
+	// the challenge can only be passed once
+	_, _, _, err = m.Verify(challenge.Token, env.passcode(enrollment.Secret, -totp.Period))
+	assert.Equal(t, ErrMultiFactorChallengeNotFound, err)
+
+	// login with a recovery code, which can only be used once
+	challenge, err = m.Challenge(aliceInfo, "")
+	assert.NoError(t, err)
+	_, _, _, err = m.Verify(challenge.Token, codes[3])
+	assert.NoError(t, err)
+	challenge, err = m.Challenge(aliceInfo, "")
+	assert.NoError(t, err)
+	_, _, _, err = m.Verify(challenge.Token, codes[3])
+	assert.Equal(t, ErrIncorrectPasscode, err)
+	assert.Len(t, env.sync("alice").Spec.MultiFactorAuth.RecoveryCodes, recoveryCodeCount-1)
+
+	// the challenge is invalidated after too many attempts
+	for i := 1; i < multiFactorChallengeMaxAttempts; i++ {
+		_, _, _, err = m.Verify(challenge.Token, "000000")
+		assert.Equal(t, ErrIncorrectPasscode, err)
+	}
+	_, _, _, err = m.Verify(challenge.Token, codes[4])
+	assert.Equal(t, ErrMultiFactorChallengeNotFound, err)
+
+	// the changes to the second factor require a current passcode
+	_, err = m.RegenerateRecoveryCodes("alice", "000000")
+	assert.Equal(t, ErrIncorrectPasscode, err)
+	codes, err = m.RegenerateRecoveryCodes("alice", codes[5])
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Equal(t, ErrIncorrectPasscode, m.DisableMultiFactorAuth("alice", ""))
+	assert.NoError(t, m.DisableMultiFactorAuth("alice", env.passcode(enrollment.Secret, -totp.Period)))
+	env.sync("alice")
+	challenge, err = m.Challenge(aliceInfo, "")
+	assert.NoError(t, err)
+	assert.Nil(t, challenge)
+}
+
+func TestMultiFactorAuthRequiredForPlatformAdmins(t *testing.T) {
+	env := newMFATestEnv(t, &authentication.Options{JwtSecret: "secret", RequireMultiFactorAuthForPlatformAdmins: true})
+	m := env.authenticator
+
+	required, err := m.Required(&user.DefaultInfo{Name: "alice"}, "")
+	assert.NoError(t, err)
+	assert.False(t, required)
+	required, err = m.Required(&user.DefaultInfo{Name: "alice", Groups: []string{"platform-admins"}}, "")
+	assert.NoError(t, err)
+	assert.True(t, required)
+	// the accounts of the identity providers are not challenged
+	challenge, err := m.Challenge(&user.DefaultInfo{Name: "admin"}, "ldap")
+	assert.NoError(t, err)
+	assert.Nil(t, challenge)
+
+	// the platform admin enrolls on login
+	challenge, err = m.Challenge(&user.DefaultInfo{Name: "admin"}, "")
+	assert.NoError(t, err)
+	assert.NotNil(t, challenge.Enrollment)
+	env.sync("admin")
+	// the pending enrollment is reused on the next login
+	next, err := m.Challenge(&user.DefaultInfo{Name: "admin"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, challenge.Enrollment.Secret, next.Enrollment.Secret)
+
+	authenticated, _, recoveryCodes, err := m.Verify(challenge.Token, env.passcode(challenge.Enrollment.Secret, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", authenticated.GetName())
+	assert.Len(t, recoveryCodes, recoveryCodeCount, "the recovery codes are generated when the enrollment is confirmed on login")
+	admin := env.sync("admin")
+	assert.True(t, admin.Spec.MultiFactorAuth.TOTP.Confirmed)
+	assert.NotNil(t, admin.Spec.MultiFactorAuth.TOTP.EnrolledTime)
+	assert.Len(t, admin.Spec.MultiFactorAuth.RecoveryCodes, recoveryCodeCount)
+
+	challenge, err = m.Challenge(&user.DefaultInfo{Name: "admin"}, "")
+	assert.NoError(t, err)
+	assert.Nil(t, challenge.Enrollment)
+}
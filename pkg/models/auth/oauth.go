@@ -29,6 +29,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	authuser "k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/klog/v2"
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
@@ -56,7 +57,7 @@ func NewOAuthAuthenticator(ksClient kubesphere.Interface,
 }
 
 func (o *oauthAuthenticator) Authenticate(_ context.Context, provider string, req *http.Request) (authuser.Info, string, error) {
-	providerOptions, err := o.options.OAuthOptions.IdentityProviderOptions(provider)
+	providerOptions, err := identityprovider.GetIdentityProviderOptions(provider)
 	// identity provider not registered
 	if err != nil {
 		klog.Error(err)
@@ -95,8 +96,27 @@ func (o *oauthAuthenticator) Authenticate(_ context.Context, provider string, re
 			// state not active
 			return nil, "", AccountIsNotActiveError
 		}
-		return &authuser.DefaultInfo{Name: user.GetName()}, providerOptions.Name, nil
+		if user, err = o.syncGroups(user, authenticated); err != nil {
+			klog.Error(err)
+			return nil, providerOptions.Name, err
+		}
+		return &authuser.DefaultInfo{Name: user.GetName(), Groups: user.Spec.Groups}, providerOptions.Name, nil
 	}
 
 	return nil, "", errors.NewNotFound(iamv1alpha2.Resource("user"), authenticated.GetUsername())
 }
+
+// syncGroups updates the groups of the user with the group claims of the identity,
+// the user is returned as is if the identity carries no groups.
+func (o *oauthAuthenticator) syncGroups(user *iamv1alpha2.User, identity identityprovider.Identity) (*iamv1alpha2.User, error) {
+	if _, ok := identity.(identityprovider.GroupsIdentity); !ok {
+		return user, nil
+	}
+	groups := groupsOf(identity)
+	if sets.NewString(groups...).Equal(sets.NewString(user.Spec.Groups...)) {
+		return user, nil
+	}
+	user = user.DeepCopy()
+	user.Spec.Groups = groups
+	return o.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
+}
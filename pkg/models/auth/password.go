@@ -20,6 +20,7 @@ package auth
 
 import (
 	"context"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -32,36 +33,122 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
 	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
 	iamv1alpha2listers "kubesphere.io/kubesphere/pkg/client/listers/iam/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+const (
+	loginRateLimiterPrefix    = "kubesphere:ratelimit:login"
+	sourceIPRateLimiterPrefix = "kubesphere:ratelimit:login-ip"
 )
 
 type passwordAuthenticator struct {
 	ksClient    kubesphere.Interface
 	userGetter  *userGetter
 	authOptions *authentication.Options
+	// rateLimiter counts the failed login attempts across all the replicas, it is nil if the cache is not provided
+	rateLimiter cache.RateLimiter
+	// sourceIPRateLimiter counts the failed login attempts of the source IPs regardless of the username,
+	// it is nil if the source IPs are not throttled
+	sourceIPRateLimiter cache.RateLimiter
+}
+
+// newLoginRateLimiter creates the rate limiter counts the failed login attempts of the users,
+// nil is returned if the cache is not provided or the rate limit is disabled.
+func newLoginRateLimiter(cacheClient cache.Interface, options *authentication.Options) cache.RateLimiter {
+	if cacheClient == nil || options.AuthenticateRateLimiterMaxTries <= 0 || options.AuthenticateRateLimiterDuration <= 0 {
+		return nil
+	}
+	return cache.NewSlidingWindowRateLimiter(cacheClient, loginRateLimiterPrefix,
+		int64(options.AuthenticateRateLimiterMaxTries), options.AuthenticateRateLimiterDuration)
 }
 
 func NewPasswordAuthenticator(ksClient kubesphere.Interface,
 	userLister iamv1alpha2listers.UserLister,
+	cacheClient cache.Interface,
 	options *authentication.Options) PasswordAuthenticator {
 	passwordAuthenticator := &passwordAuthenticator{
 		ksClient:    ksClient,
 		userGetter:  &userGetter{userLister: userLister},
 		authOptions: options,
 	}
+	passwordAuthenticator.rateLimiter = newLoginRateLimiter(cacheClient, options)
+	if policy := options.LockoutPolicy; cacheClient != nil && policy != nil && policy.SourceIPMaxTries > 0 && policy.SourceIPDuration > 0 {
+		passwordAuthenticator.sourceIPRateLimiter = cache.NewSlidingWindowRateLimiter(cacheClient, sourceIPRateLimiterPrefix,
+			int64(policy.SourceIPMaxTries), policy.SourceIPDuration)
+	}
 	return passwordAuthenticator
 }
 
-func (p *passwordAuthenticator) Authenticate(_ context.Context, provider, username, password string) (authuser.Info, string, error) {
+func (p *passwordAuthenticator) Authenticate(ctx context.Context, provider, username, password string) (authuser.Info, string, error) {
 	// empty username or password are not allowed
 	if username == "" || password == "" {
 		return nil, "", IncorrectPasswordError
 	}
+	var sourceIP string
+	// the forwarding headers can be spoofed to evade the throttling, unless they are set by the trusted proxies
+	if requestInfo, ok := request.RequestInfoFrom(ctx); ok {
+		sourceIP = requestInfo.ClientIP
+	}
+	if exceeded(p.sourceIPRateLimiter, sourceIP) {
+		klog.Errorf("%s, source IP: %s", RateLimitExceededError, sourceIP)
+		return nil, "", RateLimitExceededError
+	}
+	if exceeded(p.rateLimiter, username) {
+		klog.Errorf("%s, username: %s", RateLimitExceededError, username)
+		return nil, "", RateLimitExceededError
+	}
+
+	var authenticated authuser.Info
+	var err error
 	if provider != "" {
-		return p.authByProvider(provider, username, password)
+		authenticated, provider, err = p.authByProvider(provider, username, password)
+	} else {
+		authenticated, provider, err = p.authByKubeSphere(username, password)
+	}
+	p.recordLoginAttempt(username, sourceIP, err)
+	return authenticated, provider, err
+}
+
+// exceeded checks whether the failed login attempts of the key exceed the limit,
+// the login is allowed if the rate limiter is disabled or the cache is unavailable.
+func exceeded(rateLimiter cache.RateLimiter, key string) bool {
+	if rateLimiter == nil || key == "" {
+		return false
+	}
+	exceeded, err := rateLimiter.Exceeded(key)
+	if err != nil {
+		klog.Warningf("failed to check login rate limit of %s: %v", key, err)
+		return false
+	}
+	return exceeded
+}
+
+// recordLoginAttempt counts the failed login attempt, the count of the user is reset after a successful login.
+// The count of the source IP is never reset, so that it can't be reset with another account.
+func (p *passwordAuthenticator) recordLoginAttempt(username, sourceIP string, authErr error) {
+	if p.sourceIPRateLimiter != nil && sourceIP != "" && authErr == IncorrectPasswordError {
+		if err := p.sourceIPRateLimiter.Record(sourceIP); err != nil {
+			klog.Warningf("failed to record login attempt from %s: %v", sourceIP, err)
+		}
+	}
+	if p.rateLimiter == nil {
+		return
+	}
+	var err error
+	switch authErr {
+	case nil:
+		err = p.rateLimiter.Reset(username)
+	case IncorrectPasswordError:
+		err = p.rateLimiter.Record(username)
+	default:
+		return
+	}
+	if err != nil {
+		klog.Warningf("failed to record login attempt of user %s: %v", username, err)
 	}
-	return p.authByKubeSphere(username, password)
 }
 
 // authByKubeSphere authenticate by the kubesphere user
@@ -102,6 +189,11 @@ func (p *passwordAuthenticator) authByKubeSphere(username, password string) (aut
 			u.Extra = map[string][]string{
 				iamv1alpha2.ExtraUninitialized: {uninitialized},
 			}
+		} else if p.passwordExpired(user) {
+			// the expired password must be reset the same as the uninitialized one
+			u.Extra = map[string][]string{
+				iamv1alpha2.ExtraUninitialized: {"true"},
+			}
 		}
 		return u, "", nil
 	}
@@ -109,9 +201,25 @@ func (p *passwordAuthenticator) authByKubeSphere(username, password string) (aut
 	return nil, "", IncorrectPasswordError
 }
 
+// passwordExpired checks whether the password of the user exceeds the maximum age,
+// the creation time is used if the password has never been changed.
+func (p *passwordAuthenticator) passwordExpired(user *iamv1alpha2.User) bool {
+	policy := p.authOptions.PasswordPolicy
+	if policy == nil || policy.MaxAge <= 0 {
+		return false
+	}
+	lastChangeTime := user.CreationTimestamp.Time
+	if value := user.Annotations[iamv1alpha2.LastPasswordChangeTimeAnnotation]; value != "" {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			lastChangeTime = t
+		}
+	}
+	return policy.Expired(lastChangeTime, time.Now())
+}
+
 // authByProvider authenticate by the third-party identity provider user
 func (p *passwordAuthenticator) authByProvider(provider, username, password string) (authuser.Info, string, error) {
-	providerOptions, err := p.authOptions.OAuthOptions.IdentityProviderOptions(provider)
+	providerOptions, err := identityprovider.GetIdentityProviderOptions(provider)
 	if err != nil {
 		klog.Error(err)
 		return nil, "", err
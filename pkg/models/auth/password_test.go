@@ -22,6 +22,7 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 	"golang.org/x/crypto/bcrypt"
@@ -33,8 +34,10 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
 	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
 	ksinformers "kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 )
 
 func TestEncryptPassword(t *testing.T) {
@@ -133,6 +136,7 @@ func Test_passwordAuthenticator_Authenticate(t *testing.T) {
 	authenticator := NewPasswordAuthenticator(
 		ksClient,
 		ksInformerFactory.Iam().V1alpha2().Users().Lister(),
+		nil,
 		oauthOptions,
 	)
 
@@ -260,6 +264,38 @@ type fakePasswordIdentity struct {
 	Password string `json:"password"`
 }
 
+func Test_passwordAuthenticator_RateLimit(t *testing.T) {
+	options := authentication.NewOptions()
+	options.AuthenticateRateLimiterMaxTries = 2
+
+	ksClient := fakeks.NewSimpleClientset()
+	ksInformerFactory := ksinformers.NewSharedInformerFactory(ksClient, 0)
+	_ = ksInformerFactory.Iam().V1alpha2().Users().Informer().GetIndexer().Add(newActiveUser("user1", "password"))
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	cacheClient, _ := cache.NewInMemoryCache(nil, stopCh)
+	authenticator := NewPasswordAuthenticator(ksClient, ksInformerFactory.Iam().V1alpha2().Users().Lister(), cacheClient, options)
+
+	steps := []struct {
+		password string
+		expected error
+	}{
+		{"wrong", IncorrectPasswordError},
+		// a successful login resets the failed attempts
+		{"password", nil},
+		{"wrong", IncorrectPasswordError},
+		{"wrong", IncorrectPasswordError},
+		{"password", RateLimitExceededError},
+	}
+
+	for i, step := range steps {
+		if _, _, err := authenticator.Authenticate(context.Background(), "", "user1", step.password); err != step.expected {
+			t.Errorf("step %d: expected error %v, got %v", i, step.expected, err)
+		}
+	}
+}
+
 func (f fakePasswordIdentity) GetUserID() string {
 	return f.UID
 }
@@ -303,3 +339,43 @@ func newActiveUser(username string, password string) *iamv1alpha2.User {
 	u.Status.State = iamv1alpha2.UserActive
 	return u
 }
+
+func Test_passwordAuthenticator_SourceIPRateLimit(t *testing.T) {
+	options := authentication.NewOptions()
+	options.LockoutPolicy.SourceIPMaxTries = 2
+	options.LockoutPolicy.SourceIPDuration = time.Minute
+
+	ksClient := fakeks.NewSimpleClientset()
+	ksInformerFactory := ksinformers.NewSharedInformerFactory(ksClient, 0)
+	_ = ksInformerFactory.Iam().V1alpha2().Users().Informer().GetIndexer().Add(newActiveUser("user1", "password"))
+	_ = ksInformerFactory.Iam().V1alpha2().Users().Informer().GetIndexer().Add(newActiveUser("user2", "password"))
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	cacheClient, _ := cache.NewInMemoryCache(nil, stopCh)
+	authenticator := NewPasswordAuthenticator(ksClient, ksInformerFactory.Iam().V1alpha2().Users().Lister(), cacheClient, options)
+	from := func(sourceIP string) context.Context {
+		return request.WithRequestInfo(context.Background(), &request.RequestInfo{ClientIP: sourceIP})
+	}
+
+	steps := []struct {
+		sourceIP string
+		username string
+		password string
+		expected error
+	}{
+		{"10.0.0.1", "user1", "wrong", IncorrectPasswordError},
+		// a successful login doesn't reset the failed attempts of the source IP
+		{"10.0.0.1", "user2", "password", nil},
+		{"10.0.0.1", "user2", "wrong", IncorrectPasswordError},
+		// the source IP is throttled regardless of the username
+		{"10.0.0.1", "user1", "password", RateLimitExceededError},
+		{"10.0.0.2", "user1", "password", nil},
+	}
+
+	for i, step := range steps {
+		if _, _, err := authenticator.Authenticate(from(step.sourceIP), "", step.username, step.password); err != step.expected {
+			t.Errorf("step %d: expected error %v, got %v", i, step.expected, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+/*
+
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+
+*/
+
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+// sessionLastSeenUpdateInterval limits how often the last seen time of a session is written to the cache.
+const sessionLastSeenUpdateInterval = time.Minute
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session is a login session of a user, all the tokens issued after the login belong to the session.
+type Session struct {
+	ID               string    `json:"id" description:"session id"`
+	Username         string    `json:"username" description:"the user of the session"`
+	IdentityProvider string    `json:"identityProvider,omitempty" description:"the identity provider the user logged in with"`
+	ClientIP         string    `json:"clientIP,omitempty" description:"the IP address of the client that logged in"`
+	UserAgent        string    `json:"userAgent,omitempty" description:"the user agent of the client that logged in"`
+	IssuedAt         time.Time `json:"issuedAt" description:"the time the session was created"`
+	LastSeen         time.Time `json:"lastSeen" description:"the last time a token of the session was used"`
+	ExpiresAt        time.Time `json:"expiresAt,omitempty" description:"the time the session expires, zero means never expire"`
+}
+
+func sessionKey(username, sessionID string) string {
+	return fmt.Sprintf("kubesphere:user:%s:session:%s", username, sessionID)
+}
+
+func (t *tokenOperator) CreateSession(session *Session) (*Session, error) {
+	now := time.Now()
+	session.ID = uuid.New().String()
+	session.IssuedAt = now
+	session.LastSeen = now
+	if maxAge := t.options.OAuthOptions.AccessTokenMaxAge; maxAge > 0 {
+		session.ExpiresAt = now.Add(maxAge + t.options.OAuthOptions.AccessTokenInactivityTimeout)
+	}
+	if err := t.saveSession(session); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return session, nil
+}
+
+func (t *tokenOperator) ListSessions(username string) ([]*Session, error) {
+	keys, err := t.cache.Keys(sessionKey(username, "*"))
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0)
+	for _, key := range keys {
+		session, err := t.getSession(username, key[strings.LastIndex(key, ":")+1:])
+		if err != nil {
+			// the session expired after listing the keys
+			if err == ErrSessionNotFound {
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeen.After(sessions[j].LastSeen)
+	})
+	return sessions, nil
+}
+
+func (t *tokenOperator) RevokeSession(username, sessionID string) error {
+	if _, err := t.getSession(username, sessionID); err != nil {
+		return err
+	}
+
+	keys, err := t.cache.Keys(tokenKey(username, "*"))
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	// the value of a cached token is the id of the session the token is issued in
+	revoked := []string{sessionKey(username, sessionID)}
+	for _, key := range keys {
+		if value, err := t.cache.Get(key); err == nil && value == sessionID {
+			revoked = append(revoked, key)
+		}
+	}
+
+	if err := t.cache.Del(revoked...); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// extendSession extends the session to outlive the tokens issued in it.
+func (t *tokenOperator) extendSession(username, sessionID string, duration time.Duration) error {
+	session, err := t.getSession(username, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.ExpiresAt.IsZero() {
+		return nil
+	}
+	if expiresAt := time.Now().Add(duration); expiresAt.After(session.ExpiresAt) {
+		session.ExpiresAt = expiresAt
+		return t.saveSession(session)
+	}
+	return nil
+}
+
+// touchSession checks the session is not terminated and updates the last seen time.
+func (t *tokenOperator) touchSession(username, sessionID string) error {
+	session, err := t.getSession(username, sessionID)
+	if err != nil {
+		return err
+	}
+	if time.Since(session.LastSeen) < sessionLastSeenUpdateInterval {
+		return nil
+	}
+	session.LastSeen = time.Now()
+	if err := t.saveSession(session); err != nil {
+		// the session is still valid, the last seen time will be updated next time
+		klog.Warningf("failed to update last seen time of session %s: %v", sessionID, err)
+	}
+	return nil
+}
+
+func (t *tokenOperator) getSession(username, sessionID string) (*Session, error) {
+	data, err := t.cache.Get(sessionKey(username, sessionID))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	session := &Session{}
+	if err := json.Unmarshal([]byte(data), session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (t *tokenOperator) saveSession(session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	duration := cache.NeverExpire
+	if !session.ExpiresAt.IsZero() {
+		duration = time.Until(session.ExpiresAt)
+		if duration <= 0 {
+			return ErrSessionNotFound
+		}
+	}
+	return t.cache.Set(sessionKey(session.Username, session.ID), string(data), duration)
+}
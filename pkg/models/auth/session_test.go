@@ -0,0 +1,107 @@
+/*
+
+ Copyright 2023 The KubeSphere Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+
+*/
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func newTestTokenOperator(t *testing.T) TokenManagementInterface {
+	options := &authentication.Options{
+		JwtSecret:        "test-secret",
+		MaximumClockSkew: 10 * time.Second,
+		OAuthOptions: &oauth.Options{
+			Issuer:                       "kubesphere",
+			AccessTokenMaxAge:            time.Hour,
+			AccessTokenInactivityTimeout: time.Hour,
+		},
+	}
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	inMemoryCache, err := cache.NewInMemoryCache(nil, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTokenOperator(inMemoryCache, issuer, options)
+}
+
+func TestSessions(t *testing.T) {
+	operator := newTestTokenOperator(t)
+	admin := &user.DefaultInfo{Name: "admin"}
+
+	issue := func(sessionID string) string {
+		tokenStr, err := operator.IssueTo(&token.IssueRequest{
+			User:      admin,
+			Claims:    token.Claims{TokenType: token.AccessToken, SessionID: sessionID},
+			ExpiresIn: time.Hour,
+		})
+		assert.NoError(t, err)
+		return tokenStr
+	}
+
+	laptop, err := operator.CreateSession(&Session{Username: "admin", ClientIP: "10.0.0.1", UserAgent: "laptop"})
+	assert.NoError(t, err)
+	desktop, err := operator.CreateSession(&Session{Username: "admin", ClientIP: "10.0.0.2", UserAgent: "desktop"})
+	assert.NoError(t, err)
+
+	laptopToken := issue(laptop.ID)
+	desktopToken := issue(desktop.ID)
+	legacyToken := issue("")
+
+	sessions, err := operator.ListSessions("admin")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	verified, err := operator.Verify(laptopToken)
+	assert.NoError(t, err)
+	assert.Equal(t, laptop.ID, verified.SessionID)
+
+	assert.NoError(t, operator.RevokeSession("admin", laptop.ID))
+	assert.Equal(t, ErrSessionNotFound, operator.RevokeSession("admin", laptop.ID))
+
+	_, err = operator.Verify(laptopToken)
+	assert.Error(t, err)
+	_, err = operator.Verify(desktopToken)
+	assert.NoError(t, err)
+	_, err = operator.Verify(legacyToken)
+	assert.NoError(t, err)
+
+	sessions, err = operator.ListSessions("admin")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "desktop", sessions[0].UserAgent)
+
+	assert.NoError(t, operator.RevokeAllUserTokens("admin"))
+	sessions, err = operator.ListSessions("admin")
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
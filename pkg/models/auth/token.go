@@ -41,6 +41,12 @@ type TokenManagementInterface interface {
 	Revoke(token string) error
 	// RevokeAllUserTokens revoke all user tokens
 	RevokeAllUserTokens(username string) error
+	// CreateSession creates a login session of the user, tokens issued with the session ID belong to the session
+	CreateSession(session *Session) (*Session, error)
+	// ListSessions lists the active sessions of the user
+	ListSessions(username string) ([]*Session, error)
+	// RevokeSession terminates the session of the user and revokes all the tokens issued in the session
+	RevokeSession(username, sessionID string) error
 	// Keys hold encryption and signing keys.
 	Keys() *token.Keys
 }
@@ -79,13 +85,21 @@ func (t *tokenOperator) Verify(tokenStr string) (*token.VerifiedResponse, error)
 	if err != nil {
 		return nil, err
 	}
+	// personal access tokens are revoked by deleting the AccessToken, see AccessTokenOperator
 	if t.options.OAuthOptions.AccessTokenMaxAge == 0 ||
-		response.TokenType == token.StaticToken {
+		response.TokenType == token.StaticToken ||
+		response.TokenType == token.PersonalAccessToken {
 		return response, nil
 	}
 	if err := t.tokenCacheValidate(response.User.GetName(), tokenStr); err != nil {
 		return nil, err
 	}
+	if response.SessionID != "" {
+		if err := t.touchSession(response.User.GetName(), response.SessionID); err != nil {
+			klog.V(4).Info(fmt.Errorf("%s: %s", err, response.SessionID))
+			return nil, err
+		}
+	}
 	return response, nil
 }
 
@@ -96,24 +110,31 @@ func (t *tokenOperator) IssueTo(request *token.IssueRequest) (string, error) {
 		return "", err
 	}
 	if request.ExpiresIn > 0 {
-		if err = t.cacheToken(request.User.GetName(), tokenStr, request.ExpiresIn); err != nil {
+		if err = t.cacheToken(request.User.GetName(), tokenStr, request.SessionID, request.ExpiresIn); err != nil {
 			klog.Error(err)
 			return "", err
 		}
+		if request.SessionID != "" {
+			if err = t.extendSession(request.User.GetName(), request.SessionID, request.ExpiresIn); err != nil {
+				klog.Error(err)
+				return "", err
+			}
+		}
 	}
 	return tokenStr, nil
 }
 
-// RevokeAllUserTokens revoke all user tokens in the cache
+// RevokeAllUserTokens revoke all user tokens and sessions in the cache
 func (t *tokenOperator) RevokeAllUserTokens(username string) error {
-	pattern := fmt.Sprintf("kubesphere:user:%s:token:*", username)
-	if keys, err := t.cache.Keys(pattern); err != nil {
-		klog.Error(err)
-		return err
-	} else if len(keys) > 0 {
-		if err := t.cache.Del(keys...); err != nil {
+	for _, pattern := range []string{tokenKey(username, "*"), sessionKey(username, "*")} {
+		if keys, err := t.cache.Keys(pattern); err != nil {
 			klog.Error(err)
 			return err
+		} else if len(keys) > 0 {
+			if err := t.cache.Del(keys...); err != nil {
+				klog.Error(err)
+				return err
+			}
 		}
 	}
 	return nil
@@ -125,7 +146,7 @@ func (t *tokenOperator) Keys() *token.Keys {
 
 // tokenCacheValidate verify that the token is in the cache
 func (t *tokenOperator) tokenCacheValidate(username, token string) error {
-	key := fmt.Sprintf("kubesphere:user:%s:token:%s", username, token)
+	key := tokenKey(username, token)
 	if exist, err := t.cache.Exists(key); err != nil {
 		return err
 	} else if !exist {
@@ -136,12 +157,20 @@ func (t *tokenOperator) tokenCacheValidate(username, token string) error {
 	return nil
 }
 
-// cacheToken cache the token for a period of time
-func (t *tokenOperator) cacheToken(username, token string, duration time.Duration) error {
-	key := fmt.Sprintf("kubesphere:user:%s:token:%s", username, token)
-	if err := t.cache.Set(key, token, duration); err != nil {
+// cacheToken cache the token for a period of time, the value is the session ID
+// if the token is issued in a session, otherwise the token itself
+func (t *tokenOperator) cacheToken(username, token, sessionID string, duration time.Duration) error {
+	value := token
+	if sessionID != "" {
+		value = sessionID
+	}
+	if err := t.cache.Set(tokenKey(username, token), value, duration); err != nil {
 		klog.Error(err)
 		return err
 	}
 	return nil
 }
+
+func tokenKey(username, token string) string {
+	return fmt.Sprintf("kubesphere:user:%s:token:%s", username, token)
+}
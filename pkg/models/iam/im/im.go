@@ -69,8 +69,15 @@ func (im *imOperator) UpdateUser(new *iamv1alpha2.User) (*iamv1alpha2.User, erro
 		klog.Error(err)
 		return nil, err
 	}
-	// keep encrypted password and user status
+	// keep encrypted password, multi-factor authentication and user status
 	new.Spec.EncryptedPassword = old.Spec.EncryptedPassword
+	new.Spec.MultiFactorAuth = old.Spec.MultiFactorAuth
+	if history, ok := old.Annotations[iamv1alpha2.PasswordHistoryAnnotation]; ok {
+		if new.Annotations == nil {
+			new.Annotations = make(map[string]string)
+		}
+		new.Annotations[iamv1alpha2.PasswordHistoryAnnotation] = history
+	}
 	status := old.Status
 	// only support enable or disable
 	if new.Status.State == iamv1alpha2.UserDisabled || new.Status.State == iamv1alpha2.UserActive {
@@ -177,5 +184,13 @@ func ensurePasswordNotOutput(user *iamv1alpha2.User) *iamv1alpha2.User {
 	out := user.DeepCopy()
 	// ensure encrypted password will not be output
 	out.Spec.EncryptedPassword = ""
+	delete(out.Annotations, iamv1alpha2.PasswordHistoryAnnotation)
+	// the TOTP secret and recovery codes are credentials as well, only the enrollment state is output
+	if mfa := out.Spec.MultiFactorAuth; mfa != nil {
+		if mfa.TOTP != nil {
+			mfa.TOTP.Secret = ""
+		}
+		mfa.RecoveryCodes = nil
+	}
 	return out
 }
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"fmt"
+	"strings"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+
+	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	clusterlisters "kubesphere.io/kubesphere/pkg/client/listers/cluster/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/models/auth"
+)
+
+const (
+	execAPIVersion = "client.authentication.k8s.io/v1beta1"
+	execServerEnv  = "KUBESPHERE_SERVER"
+)
+
+type tokenOperator struct {
+	tokenManager  auth.TokenManagementInterface
+	clusterLister clusterlisters.ClusterLister
+	options       *authentication.KubeconfigOptions
+}
+
+// NewTokenOperator returns an operator generating the kubeconfig which connects to ks-apiserver with the tokens
+// issued by the KubeSphere OAuth server, the tokens expire after options.TokenMaxAge and are invalidated by
+// RevokeAllUserTokens. The kubeconfig contains a context for each ready member cluster if the cluster lister
+// is not nil. Nothing is stored for the users, so CreateKubeConfig and UpdateKubeconfig do nothing.
+func NewTokenOperator(tokenManager auth.TokenManagementInterface, clusterLister clusterlisters.ClusterLister,
+	options *authentication.KubeconfigOptions) Interface {
+	return &tokenOperator{tokenManager: tokenManager, clusterLister: clusterLister, options: options}
+}
+
+func (o *tokenOperator) CreateKubeConfig(_ *iamv1alpha2.User) error {
+	return nil
+}
+
+func (o *tokenOperator) UpdateKubeconfig(_ string, _ *certificatesv1.CertificateSigningRequest) error {
+	return nil
+}
+
+// GetKubeConfig returns a kubeconfig for the specified user, a new token is issued each time unless
+// the kubeconfig obtains the tokens with an exec credential plugin.
+func (o *tokenOperator) GetKubeConfig(username string) (string, error) {
+	authInfo, err := o.authInfo(username)
+	if err != nil {
+		return "", err
+	}
+
+	server := strings.TrimSuffix(o.options.Server, "/")
+	clusters := map[string]string{defaultClusterName: server}
+	if o.clusterLister != nil {
+		members, err := o.clusterLister.List(labels.Everything())
+		if err != nil {
+			klog.Error(err)
+			return "", err
+		}
+		for _, cluster := range members {
+			// the host cluster is the local one
+			if _, ok := cluster.Labels[clusterv1alpha1.HostCluster]; ok || !isClusterReady(cluster) {
+				continue
+			}
+			clusters[cluster.Name] = fmt.Sprintf("%s/clusters/%s", server, cluster.Name)
+		}
+	}
+
+	currentContext := fmt.Sprintf("%s@%s", username, defaultClusterName)
+	config := clientcmdapi.Config{
+		Kind:           configMapKind,
+		APIVersion:     configMapAPIVersion,
+		Preferences:    clientcmdapi.Preferences{},
+		Clusters:       make(map[string]*clientcmdapi.Cluster, len(clusters)),
+		Contexts:       make(map[string]*clientcmdapi.Context, len(clusters)),
+		AuthInfos:      map[string]*clientcmdapi.AuthInfo{username: authInfo},
+		CurrentContext: currentContext,
+	}
+	for name, server := range clusters {
+		config.Clusters[name] = &clientcmdapi.Cluster{Server: server}
+		config.Contexts[fmt.Sprintf("%s@%s", username, name)] = &clientcmdapi.Context{
+			Cluster:   name,
+			AuthInfo:  username,
+			Namespace: defaultNamespace,
+		}
+	}
+
+	data, err := clientcmd.Write(config)
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (o *tokenOperator) authInfo(username string) (*clientcmdapi.AuthInfo, error) {
+	if o.options.ExecCommand != "" {
+		return &clientcmdapi.AuthInfo{
+			Exec: &clientcmdapi.ExecConfig{
+				APIVersion:      execAPIVersion,
+				Command:         o.options.ExecCommand,
+				Args:            o.options.ExecArgs,
+				Env:             []clientcmdapi.ExecEnvVar{{Name: execServerEnv, Value: o.options.Server}},
+				InteractiveMode: clientcmdapi.IfAvailableExecInteractiveMode,
+			},
+		}, nil
+	}
+	accessToken, err := o.tokenManager.IssueTo(&token.IssueRequest{
+		User:      &user.DefaultInfo{Name: username},
+		Claims:    token.Claims{TokenType: token.AccessToken},
+		ExpiresIn: o.options.TokenMaxAge,
+	})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return &clientcmdapi.AuthInfo{Token: accessToken}, nil
+}
+
+func isClusterReady(cluster *clusterv1alpha1.Cluster) bool {
+	for _, condition := range cluster.Status.Conditions {
+		if condition.Type == clusterv1alpha1.ClusterReady && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
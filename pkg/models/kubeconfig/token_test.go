@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+
+	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	"kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	ksinformers "kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/models/auth"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func newTestCluster(name string, host, ready bool) *clusterv1alpha1.Cluster {
+	cluster := &clusterv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}}}
+	if host {
+		cluster.Labels[clusterv1alpha1.HostCluster] = ""
+	}
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	cluster.Status.Conditions = []clusterv1alpha1.ClusterCondition{{Type: clusterv1alpha1.ClusterReady, Status: status}}
+	return cluster
+}
+
+func TestTokenOperator(t *testing.T) {
+	options := &authentication.Options{
+		JwtSecret:        "test-secret",
+		MaximumClockSkew: 10 * time.Second,
+		OAuthOptions:     &oauth.Options{Issuer: "kubesphere", AccessTokenMaxAge: time.Hour},
+		KubeconfigOptions: &authentication.KubeconfigOptions{
+			Mode:        authentication.KubeconfigModeToken,
+			Server:      "https://ks-apiserver.example.com/",
+			TokenMaxAge: time.Hour,
+		},
+	}
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	inMemoryCache, err := cache.NewInMemoryCache(nil, stopCh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenOperator := auth.NewTokenOperator(inMemoryCache, issuer, options)
+
+	informerFactory := ksinformers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	clusterInformer := informerFactory.Cluster().V1alpha1().Clusters().Informer()
+	for _, cluster := range []*clusterv1alpha1.Cluster{
+		newTestCluster("host", true, true),
+		newTestCluster("member", false, true),
+		newTestCluster("unready", false, false),
+	} {
+		assert.NoError(t, clusterInformer.GetIndexer().Add(cluster))
+	}
+
+	operator := NewTokenOperator(tokenOperator, informerFactory.Cluster().V1alpha1().Clusters().Lister(), options.KubeconfigOptions)
+	data, err := operator.GetKubeConfig("user1")
+	assert.NoError(t, err)
+	kubeconfig, err := clientcmd.Load([]byte(data))
+	assert.NoError(t, err)
+
+	assert.Len(t, kubeconfig.Clusters, 2)
+	assert.Equal(t, "https://ks-apiserver.example.com", kubeconfig.Clusters["local"].Server)
+	assert.Equal(t, "https://ks-apiserver.example.com/clusters/member", kubeconfig.Clusters["member"].Server)
+	assert.Equal(t, "user1@local", kubeconfig.CurrentContext)
+	assert.Equal(t, "member", kubeconfig.Contexts["user1@member"].Cluster)
+	assert.Equal(t, "user1", kubeconfig.Contexts["user1@member"].AuthInfo)
+
+	accessToken := kubeconfig.AuthInfos["user1"].Token
+	verified, err := tokenOperator.Verify(accessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", verified.User.GetName())
+	assert.Equal(t, token.AccessToken, verified.TokenType)
+
+	// the token is invalidated with the other tokens of the user
+	assert.NoError(t, tokenOperator.RevokeAllUserTokens("user1"))
+	_, err = tokenOperator.Verify(accessToken)
+	assert.Error(t, err)
+}
+
+func TestTokenOperatorExecPlugin(t *testing.T) {
+	options := &authentication.KubeconfigOptions{
+		Mode:        authentication.KubeconfigModeToken,
+		Server:      "https://ks-apiserver.example.com",
+		ExecCommand: "kubectl",
+		ExecArgs:    []string{"kubesphere", "get-token"},
+	}
+	operator := NewTokenOperator(nil, nil, options)
+	data, err := operator.GetKubeConfig("user1")
+	assert.NoError(t, err)
+	kubeconfig, err := clientcmd.Load([]byte(data))
+	assert.NoError(t, err)
+
+	assert.Len(t, kubeconfig.Clusters, 1)
+	authInfo := kubeconfig.AuthInfos["user1"]
+	assert.Empty(t, authInfo.Token)
+	assert.Equal(t, "kubectl", authInfo.Exec.Command)
+	assert.Equal(t, []string{"kubesphere", "get-token"}, authInfo.Exec.Args)
+	assert.Equal(t, "https://ks-apiserver.example.com", authInfo.Exec.Env[0].Value)
+}
@@ -0,0 +1,240 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/api/logging/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+const (
+	// followInterval is how often the new records are polled in the follow mode.
+	followInterval = 2 * time.Second
+	// followBatchSize is the max number of records polled at once, the next batch is polled
+	// right after the previous one is handled if there may be more records.
+	followBatchSize = 500
+	// followLag is how long the records are polled again after the cursor passes their time, the records
+	// are searchable a while after they are collected, and the ones from different nodes arrive out of order.
+	followLag = 10 * time.Second
+	// followAuthorizeInterval is how often the filter is called again, the logs the user is allowed
+	// to view are authorized again at this interval rather than before each poll.
+	followAuthorizeInterval = 30 * time.Second
+	// followMaxDuration is the max lifetime of a stream, the clients reconnect to follow longer.
+	followMaxDuration = time.Hour
+)
+
+// FilterFunc returns the search filter of the logs to follow, or nil if there are no logs to follow.
+type FilterFunc func() (*logging.SearchFilter, error)
+
+// follower polls the log store for the records newer than the cursor minus the lag, the records polled
+// within the lag are polled again in the next batches, so they are remembered to be skipped.
+type follower struct {
+	client            logging.Client
+	filter            FilterFunc
+	handler           func(v1alpha2.FollowEvent) error
+	interval          time.Duration
+	batchSize         int64
+	lag               time.Duration
+	authorizeInterval time.Duration
+	maxDuration       time.Duration
+	now               func() time.Time
+
+	// searchFilter is the result of the last call of the filter at authorizedAt
+	searchFilter *logging.SearchFilter
+	authorizedAt time.Time
+
+	// since is the time the follower starts from, no records before it are polled
+	since  time.Time
+	cursor time.Time
+	// catchingUp is whether the last batch is full, the next one is polled from the cursor without the lag
+	// to make progress, the records arrived late are polled once the follower catches up
+	catchingUp bool
+	// seen is the time of the records handled by their keys
+	seen map[string]time.Time
+}
+
+func newFollower(client logging.Client, filter FilterFunc, handler func(v1alpha2.FollowEvent) error) *follower {
+	return &follower{
+		client:            client,
+		filter:            filter,
+		handler:           handler,
+		interval:          followInterval,
+		batchSize:         followBatchSize,
+		lag:               followLag,
+		authorizeInterval: followAuthorizeInterval,
+		maxDuration:       followMaxDuration,
+		now:               time.Now,
+		seen:              make(map[string]time.Time),
+	}
+}
+
+// run polls until the context is done or the max duration is reached. The next batch is not polled until
+// the handler returns, so a slow consumer slows down the polling instead of buffering the records in memory.
+func (f *follower) run(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, f.maxDuration)
+	defer cancel()
+	for {
+		more, err := f.poll()
+		if err != nil {
+			return err
+		}
+		if more {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(f.interval):
+		}
+	}
+}
+
+// poll handles the new records and returns whether there may be more records to poll.
+func (f *follower) poll() (bool, error) {
+	sf, err := f.searchFilterToPoll()
+	if err != nil {
+		return false, err
+	}
+	if f.cursor.IsZero() {
+		f.cursor = f.now()
+		if sf != nil && !sf.Starttime.IsZero() {
+			f.cursor = sf.Starttime
+		}
+		f.since = f.cursor
+	}
+	if sf == nil {
+		return false, nil
+	}
+
+	sf.Starttime = f.cursor
+	if !f.catchingUp {
+		sf.Starttime = f.cursor.Add(-f.lag)
+		if sf.Starttime.Before(f.since) {
+			sf.Starttime = f.since
+		}
+	}
+	sf.Endtime = time.Time{}
+	logs, err := f.client.SearchLogs(*sf, 0, f.batchSize, v1alpha2.OrderAscending)
+	if err != nil {
+		return false, err
+	}
+
+	keywords := keywordsRegex(sf.LogSearch)
+	event := v1alpha2.FollowEvent{}
+	// the identical records at the same time are told apart by their ordinals
+	ordinals := make(map[string]int)
+	for _, record := range logs.Records {
+		t, err := time.Parse(time.RFC3339Nano, record.Time)
+		if err != nil {
+			klog.Warningf("invalid time %s of log record: %v", record.Time, err)
+			continue
+		}
+		key := recordKey(record, 0)
+		ordinals[key]++
+		key = recordKey(record, ordinals[key])
+		if _, ok := f.seen[key]; ok {
+			continue
+		}
+		f.seen[key] = t
+		if t.After(f.cursor) {
+			f.cursor = t
+		}
+		event.Records = append(event.Records, v1alpha2.FollowRecord{
+			Record:     record,
+			Highlights: highlight(keywords, record.Log),
+		})
+	}
+
+	more := int64(len(logs.Records)) >= f.batchSize
+	if more && f.catchingUp && len(event.Records) == 0 {
+		// the whole batch is at the cursor time, skip the rest records at that time to make progress
+		f.cursor = f.cursor.Add(time.Nanosecond)
+	}
+	f.catchingUp = more
+	for key, t := range f.seen {
+		if t.Before(f.cursor.Add(-f.lag)) {
+			delete(f.seen, key)
+		}
+	}
+	if len(event.Records) > 0 {
+		if err = f.handler(event); err != nil {
+			return false, err
+		}
+	}
+	return more, nil
+}
+
+// searchFilterToPoll returns a copy of the search filter, the filter is called again once the authorize interval passes.
+func (f *follower) searchFilterToPoll() (*logging.SearchFilter, error) {
+	now := f.now()
+	if f.authorizedAt.IsZero() || now.Sub(f.authorizedAt) >= f.authorizeInterval {
+		sf, err := f.filter()
+		if err != nil {
+			return nil, err
+		}
+		f.searchFilter, f.authorizedAt = sf, now
+	}
+	if f.searchFilter == nil {
+		return nil, nil
+	}
+	sf := *f.searchFilter
+	return &sf, nil
+}
+
+// recordKey identifies the record by its time, source and content, and the ordinal of the identical ones.
+func recordKey(record logging.Record, ordinal int) string {
+	return strings.Join([]string{record.Time, record.Namespace, record.Pod, record.Container, record.Log, strconv.Itoa(ordinal)}, "/")
+}
+
+// keywordsRegex returns the regular expression matching any of the keywords case-insensitively,
+// the same as the keyword search, or nil if there are no keywords.
+func keywordsRegex(keywords []string) *regexp.Regexp {
+	quoted := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword != "" {
+			quoted = append(quoted, regexp.QuoteMeta(keyword))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	return regexp.MustCompile("(?i)(" + strings.Join(quoted, "|") + ")")
+}
+
+func highlight(keywords *regexp.Regexp, log string) []v1alpha2.Highlight {
+	if keywords == nil {
+		return nil
+	}
+	var highlights []v1alpha2.Highlight
+	for _, match := range keywords.FindAllStringIndex(log, -1) {
+		highlights = append(highlights, v1alpha2.Highlight{Start: match[0], End: match[1]})
+	}
+	return highlights
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/kubesphere/pkg/api/logging/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+type fakeClient struct {
+	filters []logging.SearchFilter
+	batches [][]logging.Record
+}
+
+func (c *fakeClient) GetCurrentStats(sf logging.SearchFilter) (logging.Statistics, error) {
+	return logging.Statistics{}, nil
+}
+
+func (c *fakeClient) CountLogsByInterval(sf logging.SearchFilter, interval string) (logging.Histogram, error) {
+	return logging.Histogram{}, nil
+}
+
+func (c *fakeClient) GetLogContext(cf logging.ContextFilter) (logging.LogContext, error) {
+	return logging.LogContext{}, nil
+}
+
+func (c *fakeClient) CountFieldValues(sf logging.SearchFilter, fields []string, size int64) (logging.Facets, error) {
+	return logging.Facets{}, nil
+}
+
+func (c *fakeClient) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
+	return nil
+}
+
+func (c *fakeClient) SearchLogs(sf logging.SearchFilter, from, size int64, order string) (logging.Logs, error) {
+	c.filters = append(c.filters, sf)
+	if len(c.batches) == 0 {
+		return logging.Logs{}, nil
+	}
+	records := c.batches[0]
+	c.batches = c.batches[1:]
+	return logging.Logs{Total: int64(len(records)), Records: records}, nil
+}
+
+func record(t, pod, log string) logging.Record {
+	return logging.Record{Log: log, Time: t, Namespace: "default", Pod: pod, Container: "app"}
+}
+
+func TestFollower(t *testing.T) {
+	start := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	client := &fakeClient{
+		batches: [][]logging.Record{
+			{
+				record("2023-07-01T00:00:01Z", "a", "Error: disk full"),
+				record("2023-07-01T00:00:02Z", "b", "ok"),
+			},
+			// the records at the cursor time are returned again
+			{
+				record("2023-07-01T00:00:02Z", "b", "ok"),
+				record("2023-07-01T00:00:02Z", "a", "retrying after error"),
+			},
+			{
+				record("2023-07-01T00:00:02Z", "b", "ok"),
+				record("2023-07-01T00:00:02Z", "a", "retrying after error"),
+			},
+		},
+	}
+	var events []v1alpha2.FollowEvent
+	f := newFollower(client, func() (*logging.SearchFilter, error) {
+		return &logging.SearchFilter{LogSearch: []string{"error"}}, nil
+	}, func(event v1alpha2.FollowEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	f.batchSize = 2
+	f.now = func() time.Time { return start }
+
+	// the batch is full, there may be more records
+	more, err := f.poll()
+	assert.NoError(t, err)
+	assert.True(t, more)
+	_, err = f.poll()
+	assert.NoError(t, err)
+	// nothing new in a full batch, the cursor moves forward
+	more, err = f.poll()
+	assert.NoError(t, err)
+	assert.True(t, more)
+
+	assert.Equal(t, []v1alpha2.FollowEvent{
+		{
+			Records: []v1alpha2.FollowRecord{
+				{
+					Record:     record("2023-07-01T00:00:01Z", "a", "Error: disk full"),
+					Highlights: []v1alpha2.Highlight{{Start: 0, End: 5}},
+				},
+				{Record: record("2023-07-01T00:00:02Z", "b", "ok")},
+			},
+		},
+		{
+			Records: []v1alpha2.FollowRecord{
+				{
+					Record:     record("2023-07-01T00:00:02Z", "a", "retrying after error"),
+					Highlights: []v1alpha2.Highlight{{Start: 15, End: 20}},
+				},
+			},
+		},
+	}, events)
+
+	assert.Equal(t, start, client.filters[0].Starttime)
+	assert.Equal(t, start.Add(2*time.Second), client.filters[1].Starttime)
+	assert.True(t, client.filters[2].Endtime.IsZero())
+	assert.Equal(t, start.Add(2*time.Second+time.Nanosecond), f.cursor)
+}
+
+func TestFollowerLag(t *testing.T) {
+	start := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	client := &fakeClient{
+		batches: [][]logging.Record{
+			{record("2023-07-01T00:00:20Z", "a", "started")},
+			// the record of b arrives late, and the identical records are logged at the same time
+			{
+				record("2023-07-01T00:00:15Z", "b", "started"),
+				record("2023-07-01T00:00:20Z", "a", "started"),
+				record("2023-07-01T00:00:21Z", "a", "ping"),
+				record("2023-07-01T00:00:21Z", "a", "ping"),
+			},
+			{
+				record("2023-07-01T00:00:15Z", "b", "started"),
+				record("2023-07-01T00:00:20Z", "a", "started"),
+				record("2023-07-01T00:00:21Z", "a", "ping"),
+				record("2023-07-01T00:00:21Z", "a", "ping"),
+				record("2023-07-01T00:00:21Z", "a", "ping"),
+				record("2023-07-01T00:00:40Z", "a", "stopped"),
+			},
+		},
+	}
+	var logs []string
+	f := newFollower(client, func() (*logging.SearchFilter, error) {
+		return &logging.SearchFilter{}, nil
+	}, func(event v1alpha2.FollowEvent) error {
+		for _, r := range event.Records {
+			logs = append(logs, r.Pod+": "+r.Log)
+		}
+		return nil
+	})
+	f.now = func() time.Time { return start }
+	for range client.batches {
+		more, err := f.poll()
+		assert.NoError(t, err)
+		assert.False(t, more)
+	}
+
+	assert.Equal(t, []string{"a: started", "b: started", "a: ping", "a: ping", "a: ping", "a: stopped"}, logs)
+	assert.Equal(t, start, client.filters[0].Starttime)
+	// the records within the lag are polled again
+	assert.Equal(t, start.Add(10*time.Second), client.filters[1].Starttime)
+	assert.Equal(t, start.Add(11*time.Second), client.filters[2].Starttime)
+	// the records out of the lag are forgotten
+	assert.Len(t, f.seen, 1)
+}
+
+func TestFollowerStops(t *testing.T) {
+	// no logs are visible, nothing is polled
+	client := &fakeClient{}
+	f := newFollower(client, func() (*logging.SearchFilter, error) {
+		return nil, nil
+	}, func(event v1alpha2.FollowEvent) error {
+		return nil
+	})
+	f.interval = time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, f.run(ctx))
+	assert.Empty(t, client.filters)
+
+	// the stream stops once the handler fails
+	client = &fakeClient{batches: [][]logging.Record{{record("2023-07-01T00:00:01Z", "a", "ok")}}}
+	f = newFollower(client, func() (*logging.SearchFilter, error) {
+		return &logging.SearchFilter{}, nil
+	}, func(event v1alpha2.FollowEvent) error {
+		return errors.New("connection closed")
+	})
+	f.interval = time.Millisecond
+	assert.EqualError(t, f.run(context.Background()), "connection closed")
+}
+
+func TestFollowerAuthorizeInterval(t *testing.T) {
+	now := time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)
+	client := &fakeClient{}
+	calls := 0
+	f := newFollower(client, func() (*logging.SearchFilter, error) {
+		calls++
+		return &logging.SearchFilter{LogSearch: []string{"error"}}, nil
+	}, func(event v1alpha2.FollowEvent) error {
+		return nil
+	})
+	f.now = func() time.Time { return now }
+
+	for i := 0; i <= 30; i++ {
+		_, err := f.poll()
+		assert.NoError(t, err)
+		now = now.Add(2 * time.Second)
+	}
+	// the filter is called at 0s, 30s and 60s
+	assert.Equal(t, 3, calls)
+	// the polls don't change the filter
+	assert.Equal(t, &logging.SearchFilter{LogSearch: []string{"error"}}, f.searchFilter)
+}
+
+func TestFollowerMaxDuration(t *testing.T) {
+	f := newFollower(&fakeClient{}, func() (*logging.SearchFilter, error) {
+		return &logging.SearchFilter{}, nil
+	}, func(event v1alpha2.FollowEvent) error {
+		return nil
+	})
+	f.interval = time.Millisecond
+	f.maxDuration = 20 * time.Millisecond
+	done := make(chan error)
+	go func() {
+		done <- f.run(context.Background())
+	}()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stream is not stopped after the max duration")
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package logging
 
 import (
+	"context"
 	"io"
 
 	"kubesphere.io/kubesphere/pkg/api/logging/v1alpha2"
@@ -28,6 +29,11 @@ type LoggingOperator interface {
 	CountLogsByInterval(sf logging.SearchFilter, interval string) (v1alpha2.APIResponse, error)
 	ExportLogs(sf logging.SearchFilter, w io.Writer) error
 	SearchLogs(sf logging.SearchFilter, from, size int64, order string) (v1alpha2.APIResponse, error)
+	GetLogContext(cf logging.ContextFilter) (v1alpha2.APIResponse, error)
+	CountFieldValues(sf logging.SearchFilter, fields []string, size int64) (v1alpha2.APIResponse, error)
+	// FollowLogs streams the new records matching the filter to the handler until the context is done
+	// or the stream lasts an hour, the filter is called again every 30 seconds.
+	FollowLogs(ctx context.Context, filter FilterFunc, handler func(v1alpha2.FollowEvent) error) error
 }
 
 type loggingOperator struct {
@@ -56,3 +62,17 @@ func (l loggingOperator) SearchLogs(sf logging.SearchFilter, from, size int64, o
 	res, err := l.c.SearchLogs(sf, from, size, order)
 	return v1alpha2.APIResponse{Logs: &res}, err
 }
+
+func (l loggingOperator) GetLogContext(cf logging.ContextFilter) (v1alpha2.APIResponse, error) {
+	res, err := l.c.GetLogContext(cf)
+	return v1alpha2.APIResponse{Context: &res}, err
+}
+
+func (l loggingOperator) CountFieldValues(sf logging.SearchFilter, fields []string, size int64) (v1alpha2.APIResponse, error) {
+	res, err := l.c.CountFieldValues(sf, fields, size)
+	return v1alpha2.APIResponse{Facets: &res}, err
+}
+
+func (l loggingOperator) FollowLogs(ctx context.Context, filter FilterFunc, handler func(v1alpha2.FollowEvent) error) error {
+	return newFollower(l.c, filter, handler).run(ctx)
+}
@@ -56,7 +56,7 @@ func (c clustersGetter) List(_ string, query *query.Query) (*api.ListResult, err
 		result = append(result, cluster)
 	}
 
-	return v1alpha3.DefaultList(result, query, c.compare, c.filter, c.transform), nil
+	return v1alpha3.DefaultList(result, query, c.compare, c.filter, c.transform)
 }
 
 func (c clustersGetter) transform(obj runtime.Object) runtime.Object {
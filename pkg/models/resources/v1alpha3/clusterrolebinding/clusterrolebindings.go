@@ -51,7 +51,7 @@ func (d *clusterrolebindingsGetter) List(_ string, query *query.Query) (*api.Lis
 		result = append(result, roleBinding)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *clusterrolebindingsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
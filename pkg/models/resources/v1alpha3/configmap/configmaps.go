@@ -49,7 +49,7 @@ func (d *configmapsGetter) List(namespace string, query *query.Query) (*api.List
 		result = append(result, configmap)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *configmapsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -53,7 +53,7 @@ func (c crdGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
 		result = append(result, crd)
 	}
 
-	return v1alpha3.DefaultList(result, query, c.compare, c.filter), nil
+	return v1alpha3.DefaultList(result, query, c.compare, c.filter)
 }
 
 func (c crdGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -58,7 +58,7 @@ func (d *daemonSetGetter) List(namespace string, query *query.Query) (*api.ListR
 		result = append(result, daemonSet)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *daemonSetGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
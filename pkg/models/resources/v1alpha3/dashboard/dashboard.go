@@ -60,7 +60,7 @@ func (d *dashboardGetter) List(namespace string, query *query.Query) (*api.ListR
 		result = append(result, &dashboards.Items[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *dashboardGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -60,7 +60,7 @@ func (d *deploymentsGetter) List(namespace string, query *query.Query) (*api.Lis
 		result = append(result, deployment)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *deploymentsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -50,7 +50,7 @@ func (d *fedConfigMapsGetter) List(namespace string, query *query.Query) (*api.L
 		result = append(result, configmap)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *fedConfigMapsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
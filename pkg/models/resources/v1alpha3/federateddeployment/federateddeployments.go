@@ -53,7 +53,7 @@ func (f *fedreatedDeploymentGetter) List(namespace string, query *query.Query) (
 		result = append(result, fedDeployment)
 	}
 
-	return v1alpha3.DefaultList(result, query, f.compare, f.filter), nil
+	return v1alpha3.DefaultList(result, query, f.compare, f.filter)
 }
 
 func (f *fedreatedDeploymentGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -50,7 +50,7 @@ func (n federatedNamespacesGetter) List(_ string, query *query.Query) (*api.List
 		result = append(result, item)
 	}
 
-	return v1alpha3.DefaultList(result, query, n.compare, n.filter), nil
+	return v1alpha3.DefaultList(result, query, n.compare, n.filter)
 }
 
 func (n federatedNamespacesGetter) filter(item runtime.Object, filter query.Filter) bool {
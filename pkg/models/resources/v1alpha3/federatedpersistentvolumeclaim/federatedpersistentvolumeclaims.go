@@ -54,7 +54,7 @@ func (p *fedPersistentVolumeClaimGetter) List(namespace string, query *query.Que
 	for _, pvc := range all {
 		result = append(result, pvc)
 	}
-	return v1alpha3.DefaultList(result, query, p.compare, p.filter), nil
+	return v1alpha3.DefaultList(result, query, p.compare, p.filter)
 }
 
 func (p *fedPersistentVolumeClaimGetter) compare(left, right runtime.Object, field query.Field) bool {
@@ -52,7 +52,7 @@ func (d *fedSecretGetter) List(namespace string, query *query.Query) (*api.ListR
 		result = append(result, secret)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *fedSecretGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
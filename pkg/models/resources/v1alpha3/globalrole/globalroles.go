@@ -21,6 +21,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
@@ -46,11 +47,28 @@ func (d *globalrolesGetter) Get(_, name string) (runtime.Object, error) {
 func (d *globalrolesGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
 
 	var roles []*iamv1alpha2.GlobalRole
-	var err error
 
-	if aggregateTo := query.Filters[iamv1alpha2.AggregateTo]; aggregateTo != "" {
-		roles, err = d.fetchAggregationRoles(string(aggregateTo))
-		delete(query.Filters, iamv1alpha2.AggregateTo)
+	aggregateTo, ok, err := v1alpha3.SelectorValues(query, iamv1alpha2.AggregateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		// the roles aggregated to any of the roles
+		seen := sets.NewString()
+		for _, name := range aggregateTo {
+			aggregated, err := d.fetchAggregationRoles(name)
+			if err != nil {
+				return nil, err
+			}
+			for _, role := range aggregated {
+				if !seen.Has(role.Name) {
+					seen.Insert(role.Name)
+					roles = append(roles, role)
+				}
+			}
+		}
+		query.Delete(iamv1alpha2.AggregateTo)
 	} else {
 		roles, err = d.sharedInformers.Iam().V1alpha2().GlobalRoles().Lister().List(query.Selector())
 	}
@@ -64,7 +82,7 @@ func (d *globalrolesGetter) List(_ string, query *query.Query) (*api.ListResult,
 		result = append(result, role)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *globalrolesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
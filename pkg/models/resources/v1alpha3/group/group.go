@@ -51,7 +51,7 @@ func (d *groupGetter) List(_ string, query *query.Query) (*api.ListResult, error
 		result = append(result, group)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *groupGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
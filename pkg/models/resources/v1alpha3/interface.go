@@ -17,9 +17,13 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/klog/v2"
 
@@ -45,19 +49,13 @@ type FilterFunc func(runtime.Object, query.Filter) bool
 
 type TransformFunc func(runtime.Object) runtime.Object
 
-func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFunc, filterFunc FilterFunc, transformFuncs ...TransformFunc) *api.ListResult {
+// DefaultList filters, sorts and paginates the objects by the query, a bad request error is returned
+// if the continue token of the query is invalid.
+func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFunc, filterFunc FilterFunc, transformFuncs ...TransformFunc) (*api.ListResult, error) {
 	// selected matched ones
 	var filtered []runtime.Object
 	for _, object := range objects {
-		selected := true
-		for field, value := range q.Filters {
-			if !filterFunc(object, query.Filter{Field: field, Value: value}) {
-				selected = false
-				break
-			}
-		}
-
-		if selected {
+		if DefaultFilter(object, q, filterFunc) {
 			for _, transform := range transformFuncs {
 				object = transform(object)
 			}
@@ -66,25 +64,186 @@ func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFu
 	}
 
 	// sort by sortBy field
-	sort.Slice(filtered, func(i, j int) bool {
-		if !q.Ascending {
-			return compareFunc(filtered[i], filtered[j], q.SortBy)
-		}
-		return !compareFunc(filtered[i], filtered[j], q.SortBy)
+	DefaultSort(filtered, q, compareFunc)
+
+	return DefaultPaginate(filtered, q, compareFunc)
+}
+
+// DefaultSort sorts the objects by the sortBy field of the query, objects with the same sort key
+// are ordered by namespace and name, so that the order is stable across requests.
+func DefaultSort(objects []runtime.Object, q *query.Query, compareFunc CompareFunc) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return less(objects[i], objects[j], q, compareFunc)
 	})
+}
 
-	total := len(filtered)
+func less(left, right runtime.Object, q *query.Query, compareFunc CompareFunc) bool {
+	if compareFunc(left, right, q.SortBy) {
+		return !q.Ascending
+	}
+	if compareFunc(right, left, q.SortBy) {
+		return q.Ascending
+	}
 
+	leftMeta, err := meta.Accessor(left)
+	if err != nil {
+		return false
+	}
+	rightMeta, err := meta.Accessor(right)
+	if err != nil {
+		return false
+	}
+	if leftMeta.GetNamespace() != rightMeta.GetNamespace() {
+		return leftMeta.GetNamespace() < rightMeta.GetNamespace()
+	}
+	return leftMeta.GetName() < rightMeta.GetName()
+}
+
+// DefaultPaginate returns the page of the sorted objects. If the query has a continue token,
+// the page starts right after the last object of the previous page and the offset is ignored.
+// A continue token is returned if there are more objects after the page.
+func DefaultPaginate(sorted []runtime.Object, q *query.Query, compareFunc CompareFunc) (*api.ListResult, error) {
 	if q.Pagination == nil {
 		q.Pagination = query.NoPagination
 	}
 
+	total := len(sorted)
 	start, end := q.Pagination.GetValidPagination(total)
 
-	return &api.ListResult{
-		TotalItems: len(filtered),
-		Items:      objectsToInterfaces(filtered[start:end]),
+	token, err := q.ContinueToken()
+	if err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	if token != nil {
+		start = continueIndex(sorted, token, q, compareFunc)
+		end = total
+		if q.Pagination.Limit > 0 && start+q.Pagination.Limit < total {
+			end = start + q.Pagination.Limit
+		}
+	}
+
+	result := &api.ListResult{
+		TotalItems: total,
+		Items:      objectsToInterfaces(sorted[start:end]),
+	}
+
+	if q.Pagination.Limit > 0 && end > start && end < total {
+		result.Continue = newContinueToken(sorted[end-1], end, q).Encode()
+	}
+
+	return result, nil
+}
+
+// newContinueToken returns the continue token of the last object of a page, next is the index of the
+// object after it. Only the object meta is recorded, objects without it are paged by the index.
+func newContinueToken(last runtime.Object, next int, q *query.Query) *query.ContinueToken {
+	token := &query.ContinueToken{
+		SortBy:    q.SortBy,
+		Ascending: q.Ascending,
+		Offset:    next,
+	}
+
+	if accessor, err := meta.Accessor(last); err == nil {
+		token.Namespace = accessor.GetNamespace()
+		token.Name = accessor.GetName()
+		token.ResourceVersion = accessor.GetResourceVersion()
+		token.CreationTimestamp = accessor.GetCreationTimestamp().Time
+	}
+	return token
+}
+
+// sortedByObjectMeta returns true if the sort key of the field is immutable and recorded in the continue token.
+func sortedByObjectMeta(field query.Field) bool {
+	switch field {
+	case query.FieldName, query.FieldCreateTime, query.FieldCreationTimeStamp:
+		return true
+	}
+	return false
+}
+
+// continueIndex returns the index of the first object after the last seen object of the continue token.
+func continueIndex(sorted []runtime.Object, token *query.ContinueToken, q *query.Query, compareFunc CompareFunc) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	if len(token.Name) > 0 {
+		for i, object := range sorted {
+			accessor, err := meta.Accessor(object)
+			if err != nil {
+				continue
+			}
+			if accessor.GetNamespace() == token.Namespace && accessor.GetName() == token.Name {
+				// the sort key of other fields may have changed if the object was updated
+				if accessor.GetResourceVersion() == token.ResourceVersion || sortedByObjectMeta(q.SortBy) {
+					return i + 1
+				}
+				break
+			}
+		}
+	}
+
+	// the sort key of other fields is not recorded, so that no content of the object is carried by the token,
+	// the next page starts at the same index as if it were paged by the offset
+	if len(token.Name) == 0 || !sortedByObjectMeta(q.SortBy) {
+		if token.Offset > len(sorted) {
+			return len(sorted)
+		}
+		return token.Offset
+	}
+
+	// the last seen object is deleted, locate it with a pivot built from the token,
+	// the pivot is a new object of the same type so that no field of another object is compared
+	objectType := reflect.TypeOf(sorted[0])
+	if objectType.Kind() != reflect.Ptr {
+		return len(sorted)
+	}
+	pivot, ok := reflect.New(objectType.Elem()).Interface().(runtime.Object)
+	if !ok {
+		return len(sorted)
+	}
+	accessor, err := meta.Accessor(pivot)
+	if err != nil {
+		return len(sorted)
+	}
+	accessor.SetNamespace(token.Namespace)
+	accessor.SetName(token.Name)
+	accessor.SetResourceVersion(token.ResourceVersion)
+	accessor.SetCreationTimestamp(metav1.NewTime(token.CreationTimestamp))
+
+	return sort.Search(len(sorted), func(i int) bool {
+		return less(pivot, sorted[i], q, compareFunc)
+	})
+}
+
+// DefaultFilter return true if the object matches all the filters and conditions of the query
+func DefaultFilter(object runtime.Object, q *query.Query, filterFunc FilterFunc) bool {
+	return q.Match(func(filter query.Filter) bool {
+		return filterFunc(object, filter)
+	})
+}
+
+// SelectorValues returns the non-empty values of the field which selects where to list the objects from instead
+// of being matched against the objects, ok is false if the field has no such value. The objects in any of the
+// values are listed, the negated filters of the field are rejected since they can not be listed from.
+func SelectorValues(q *query.Query, field query.Field) (values []string, ok bool, err error) {
+	included, excluded := q.Values(field)
+	if len(excluded) > 0 {
+		return nil, false, errors.NewBadRequest(fmt.Sprintf("negated filter %s is not supported", field))
+	}
+
+	ok = q.Filters[field] != ""
+	for _, condition := range q.Conditions {
+		if condition.Field == field {
+			ok = true
+		}
+	}
+	for _, value := range included {
+		if value != "" {
+			values = append(values, string(value))
+		}
 	}
+	return values, ok, nil
 }
 
 // DefaultObjectMetaCompare return true is left great than right
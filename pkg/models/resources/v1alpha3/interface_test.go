@@ -18,7 +18,19 @@
 
 package v1alpha3
 
-import "testing"
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+)
 
 func TestLabelMatch(t *testing.T) {
 	tests := []struct {
@@ -83,3 +95,184 @@ func TestLabelMatch(t *testing.T) {
 		}
 	}
 }
+
+func compareConfigMaps(left, right runtime.Object, field query.Field) bool {
+	leftConfigMap, rightConfigMap := left.(*corev1.ConfigMap), right.(*corev1.ConfigMap)
+	if field == "rank" {
+		return leftConfigMap.Data["rank"] > rightConfigMap.Data["rank"]
+	}
+	return DefaultObjectMetaCompare(leftConfigMap.ObjectMeta, rightConfigMap.ObjectMeta, field)
+}
+
+func filterConfigMaps(object runtime.Object, filter query.Filter) bool {
+	return DefaultObjectMetaFilter(object.(*corev1.ConfigMap).ObjectMeta, filter)
+}
+
+func newConfigMap(namespace, name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			ResourceVersion:   "1",
+			CreationTimestamp: metav1.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func listNames(t *testing.T, objects []runtime.Object, q *query.Query) ([]string, string) {
+	result, err := DefaultList(objects, q, compareConfigMaps, filterConfigMaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range result.Items {
+		cm := item.(*corev1.ConfigMap)
+		names = append(names, cm.Namespace+"/"+cm.Name)
+	}
+	return names, result.Continue
+}
+
+func TestDefaultListContinue(t *testing.T) {
+	var objects []runtime.Object
+	for i := 0; i < 5; i++ {
+		// objects with the same creation timestamp and name are ordered by namespace
+		objects = append(objects, newConfigMap(fmt.Sprintf("ns%d", i%2), fmt.Sprintf("cm%d", i/2)))
+	}
+
+	newQuery := func(token string) *query.Query {
+		return &query.Query{
+			Pagination: &query.Pagination{Limit: 2},
+			SortBy:     query.FieldName,
+			Ascending:  true,
+			Filters:    map[query.Field]query.Value{},
+			Continue:   token,
+		}
+	}
+
+	names, token := listNames(t, objects, newQuery(""))
+	if diff := cmp.Diff([]string{"ns0/cm0", "ns1/cm0"}, names); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", names, diff)
+	}
+
+	// the last seen object is deleted and a new object is created before it
+	objects = append(objects[1:], newConfigMap("ns0", "a"))
+	objects = objects[1:]
+	objects = append(objects, newConfigMap("ns0", "cm9"))
+
+	names, token = listNames(t, objects, newQuery(token))
+	if diff := cmp.Diff([]string{"ns0/cm1", "ns1/cm1"}, names); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", names, diff)
+	}
+
+	names, token = listNames(t, objects, newQuery(token))
+	if diff := cmp.Diff([]string{"ns0/cm2", "ns0/cm9"}, names); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", names, diff)
+	}
+	if token != "" {
+		t.Errorf("expected no continue token on the last page, got %s", token)
+	}
+
+	q := newQuery(token)
+	q.Continue = "invalid"
+	if _, err := DefaultList(objects, q, compareConfigMaps, filterConfigMaps); !errors.IsBadRequest(err) {
+		t.Errorf("expected bad request error for invalid continue token, got %v", err)
+	}
+
+	_, token = listNames(t, objects, newQuery(""))
+	q = newQuery(token)
+	q.Ascending = false
+	if _, err := q.ContinueToken(); err == nil {
+		t.Errorf("expected error for continue token with different sort order")
+	}
+}
+
+func TestDefaultListContinueWithSortField(t *testing.T) {
+	var objects []runtime.Object
+	for i, rank := range []string{"c", "a", "e", "b", "d"} {
+		configMap := newConfigMap("default", fmt.Sprintf("cm%d", i))
+		configMap.Data = map[string]string{"rank": rank}
+		objects = append(objects, configMap)
+	}
+
+	newQuery := func(token string) *query.Query {
+		return &query.Query{
+			Pagination: &query.Pagination{Limit: 2},
+			SortBy:     "rank",
+			Ascending:  true,
+			Filters:    map[query.Field]query.Value{},
+			Continue:   token,
+		}
+	}
+
+	names, token := listNames(t, objects, newQuery(""))
+	if diff := cmp.Diff([]string{"default/cm1", "default/cm3"}, names); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", names, diff)
+	}
+
+	// the content of the objects is not carried by the token
+	decoded, err := query.DecodeContinueToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&query.ContinueToken{
+		Version:           decoded.Version,
+		SortBy:            "rank",
+		Ascending:         true,
+		Namespace:         "default",
+		Name:              "cm3",
+		ResourceVersion:   "1",
+		CreationTimestamp: decoded.CreationTimestamp,
+		Offset:            2,
+	}, decoded); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", decoded, diff)
+	}
+
+	// the last seen object is updated, the next page starts at the offset
+	updated := objects[3].(*corev1.ConfigMap).DeepCopy()
+	updated.Data["rank"] = "f"
+	updated.ResourceVersion = "2"
+	objects[3] = updated
+	names, _ = listNames(t, objects, newQuery(token))
+	if diff := cmp.Diff([]string{"default/cm4", "default/cm2"}, names); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", names, diff)
+	}
+}
+
+type rankedItem struct {
+	runtime.Object
+	rank int
+}
+
+func TestDefaultListContinueWithoutObjectMeta(t *testing.T) {
+	var objects []runtime.Object
+	for _, rank := range []int{3, 1, 4, 0, 2} {
+		objects = append(objects, &rankedItem{rank: rank})
+	}
+
+	compare := func(left, right runtime.Object, _ query.Field) bool {
+		return left.(*rankedItem).rank > right.(*rankedItem).rank
+	}
+	var ranks []int
+	token := ""
+	for i := 0; i < 3; i++ {
+		result, err := DefaultList(objects, &query.Query{
+			Pagination: &query.Pagination{Limit: 2},
+			Ascending:  true,
+			Filters:    map[query.Field]query.Value{},
+			Continue:   token,
+		}, compare, func(runtime.Object, query.Filter) bool { return true })
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, item := range result.Items {
+			ranks = append(ranks, item.(*rankedItem).rank)
+		}
+		token = result.Continue
+	}
+	if diff := cmp.Diff([]int{0, 1, 2, 3, 4}, ranks); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", ranks, diff)
+	}
+	if token != "" {
+		t.Errorf("expected no continue token on the last page, got %s", token)
+	}
+}
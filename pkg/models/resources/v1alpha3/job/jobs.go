@@ -59,7 +59,7 @@ func (d *jobsGetter) List(namespace string, query *query.Query) (*api.ListResult
 		result = append(result, job)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *jobsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
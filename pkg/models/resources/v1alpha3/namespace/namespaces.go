@@ -51,7 +51,7 @@ func (n namespacesGetter) List(_ string, query *query.Query) (*api.ListResult, e
 		result = append(result, item)
 	}
 
-	return v1alpha3.DefaultList(result, query, n.compare, n.filter), nil
+	return v1alpha3.DefaultList(result, query, n.compare, n.filter)
 }
 
 func (n namespacesGetter) filter(item runtime.Object, filter query.Filter) bool {
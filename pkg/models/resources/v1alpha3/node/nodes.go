@@ -18,7 +18,6 @@ package node
 
 import (
 	"fmt"
-	"sort"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -92,35 +91,20 @@ func (c *nodesGetter) List(_ string, q *query.Query) (*api.ListResult, error) {
 		return nil, err
 	}
 
-	var filtered []*v1.Node
+	var filtered []runtime.Object
 	for _, object := range nodes {
-		selected := true
-		for field, value := range q.Filters {
-			if !c.filter(object, query.Filter{Field: field, Value: value}) {
-				selected = false
-				break
-			}
-		}
-
-		if selected {
+		if v1alpha3.DefaultFilter(object, q, c.filter) {
 			filtered = append(filtered, object)
 		}
 	}
 
 	// sort by sortBy field
-	sort.Slice(filtered, func(i, j int) bool {
-		if !q.Ascending {
-			return c.compare(filtered[i], filtered[j], q.SortBy)
-		}
-		return !c.compare(filtered[i], filtered[j], q.SortBy)
-	})
+	v1alpha3.DefaultSort(filtered, q, c.compare)
 
-	total := len(filtered)
-	if q.Pagination == nil {
-		q.Pagination = query.NoPagination
+	page, err := v1alpha3.DefaultPaginate(filtered, q, c.compare)
+	if err != nil {
+		return nil, err
 	}
-	start, end := q.Pagination.GetValidPagination(total)
-	selectedNodes := filtered[start:end]
 
 	// ignore the error, skip annotating process if error happened
 	pods, _ := c.informers.Core().V1().Pods().Lister().Pods("").List(labels.Everything())
@@ -132,16 +116,14 @@ func (c *nodesGetter) List(_ string, q *query.Query) (*api.ListResult, error) {
 	}
 
 	var result = make([]interface{}, 0)
-	for _, node := range selectedNodes {
-		node = node.DeepCopy()
+	for _, item := range page.Items {
+		node := item.(*v1.Node).DeepCopy()
 		c.annotateNode(node, nonTerminatedPodsList)
 		result = append(result, node)
 	}
 
-	return &api.ListResult{
-		TotalItems: total,
-		Items:      result,
-	}, nil
+	page.Items = result
+	return page, nil
 }
 
 func (c *nodesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
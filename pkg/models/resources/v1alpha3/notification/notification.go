@@ -50,7 +50,7 @@ func (g *notificationmanagerGetter) List(_ string, query *query.Query) (*api.Lis
 	for _, obj := range objs {
 		result = append(result, obj)
 	}
-	return v1alpha3.DefaultList(result, query, compare, filter), nil
+	return v1alpha3.DefaultList(result, query, compare, filter)
 }
 
 type configGetter struct {
@@ -75,7 +75,7 @@ func (g *configGetter) List(_ string, query *query.Query) (*api.ListResult, erro
 	for _, obj := range objs {
 		result = append(result, obj)
 	}
-	return v1alpha3.DefaultList(result, query, compare, filter), nil
+	return v1alpha3.DefaultList(result, query, compare, filter)
 }
 
 type receiverGetter struct {
@@ -100,7 +100,7 @@ func (g *receiverGetter) List(_ string, query *query.Query) (*api.ListResult, er
 	for _, obj := range objs {
 		result = append(result, obj)
 	}
-	return v1alpha3.DefaultList(result, query, compare, filter), nil
+	return v1alpha3.DefaultList(result, query, compare, filter)
 }
 
 type routerGetter struct {
@@ -125,7 +125,7 @@ func (g *routerGetter) List(_ string, query *query.Query) (*api.ListResult, erro
 	for _, obj := range objs {
 		result = append(result, obj)
 	}
-	return v1alpha3.DefaultList(result, query, compare, filter), nil
+	return v1alpha3.DefaultList(result, query, compare, filter)
 }
 
 type silenceGetter struct {
@@ -150,7 +150,7 @@ func (g *silenceGetter) List(_ string, query *query.Query) (*api.ListResult, err
 	for _, obj := range objs {
 		result = append(result, obj)
 	}
-	return v1alpha3.DefaultList(result, query, compare, filter), nil
+	return v1alpha3.DefaultList(result, query, compare, filter)
 }
 
 func compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -63,7 +63,7 @@ func (r *helmApplicationsGetter) List(_ string, query *query.Query) (*api.ListRe
 		result = append(result, apps[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter)
 }
 
 func (r *helmApplicationsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
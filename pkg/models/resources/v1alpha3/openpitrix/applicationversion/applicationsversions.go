@@ -63,7 +63,7 @@ func (r *applicationVersionsGetter) List(_ string, query *query.Query) (*api.Lis
 		result = append(result, apps[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter)
 }
 
 func (r *applicationVersionsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
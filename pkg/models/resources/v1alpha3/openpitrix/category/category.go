@@ -63,7 +63,7 @@ func (r *helmCategoriesGetter) List(_ string, query *query.Query) (*api.ListResu
 		result = append(result, ctg[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter)
 }
 
 func (r *helmCategoriesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -63,7 +63,7 @@ func (r *helmReleasesGetter) List(_ string, query *query.Query) (*api.ListResult
 		result = append(result, rls[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter)
 }
 
 func (r *helmReleasesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
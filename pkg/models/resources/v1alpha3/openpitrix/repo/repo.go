@@ -55,7 +55,7 @@ func (d *reposGetter) List(_ string, query *query.Query) (*api.ListResult, error
 		result = append(result, user)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *reposGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
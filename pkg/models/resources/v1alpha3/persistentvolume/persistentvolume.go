@@ -54,7 +54,7 @@ func (p *persistentVolumeGetter) List(namespace string, query *query.Query) (*ap
 	for _, pv := range all {
 		result = append(result, pv)
 	}
-	return v1alpha3.DefaultList(result, query, p.compare, p.filter), nil
+	return v1alpha3.DefaultList(result, query, p.compare, p.filter)
 }
 
 func (p *persistentVolumeGetter) compare(obj1, obj2 runtime.Object, field query.Field) bool {
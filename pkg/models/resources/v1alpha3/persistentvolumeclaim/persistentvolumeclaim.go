@@ -71,7 +71,7 @@ func (p *persistentVolumeClaimGetter) List(namespace string, query *query.Query)
 		p.annotatePVC(pvc)
 		result = append(result, pvc)
 	}
-	return v1alpha3.DefaultList(result, query, p.compare, p.filter), nil
+	return v1alpha3.DefaultList(result, query, p.compare, p.filter)
 }
 
 func (p *persistentVolumeClaimGetter) compare(left, right runtime.Object, field query.Field) bool {
@@ -67,7 +67,7 @@ func (p *podsGetter) List(namespace string, query *query.Query) (*api.ListResult
 		result = append(result, pod)
 	}
 
-	return v1alpha3.DefaultList(result, query, p.compare, p.filter), nil
+	return v1alpha3.DefaultList(result, query, p.compare, p.filter)
 }
 
 func (p *podsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
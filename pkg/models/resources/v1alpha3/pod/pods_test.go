@@ -98,6 +98,30 @@ func TestListPods(t *testing.T) {
 			},
 			nil,
 		},
+		{
+			"test multiple names and negated phase filter",
+			"default",
+			&query.Query{
+				Pagination: &query.Pagination{
+					Limit:  10,
+					Offset: 0,
+				},
+				SortBy:    query.FieldName,
+				Ascending: false,
+				Filters: map[query.Field]query.Value{
+					query.FieldNamespace: query.Value("default"),
+				},
+				Conditions: []query.Condition{
+					{Field: query.FieldName, Values: []query.Value{"foo1", "foo4", "foo5"}},
+					{Field: fieldPhase, Values: []query.Value{query.Value(corev1.PodRunning)}, Negated: true},
+				},
+			},
+			&api.ListResult{
+				Items:      []interface{}{foo4, foo1},
+				TotalItems: 2,
+			},
+			nil,
+		},
 	}
 
 	getter := prepare()
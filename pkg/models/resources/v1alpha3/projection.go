@@ -0,0 +1,363 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DefaultTableColumns are the columns of the Table if no fields are specified
+var DefaultTableColumns = []string{"metadata.name", "metadata.namespace", "metadata.creationTimestamp"}
+
+// FieldPath is a JSONPath-like path of an object field, e.g. metadata.name, {.status.phase},
+// spec.containers[*].image, spec.containers[0].name or metadata.labels['app.kubernetes.io/name'].
+type FieldPath struct {
+	path     string
+	segments []pathSegment
+}
+
+type pathSegment struct {
+	key string
+	// index of the array element, -1 means all the elements
+	index   int
+	isIndex bool
+}
+
+// ParseFieldPath parses the field path.
+func ParseFieldPath(path string) (*FieldPath, error) {
+	s := strings.TrimSpace(path)
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		s = s[1 : len(s)-1]
+	}
+	s = strings.TrimPrefix(s, "$")
+	s = strings.TrimPrefix(s, ".")
+
+	var segments []pathSegment
+	expectKey := true
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			if expectKey {
+				return nil, fmt.Errorf("invalid field path %s", path)
+			}
+			s, expectKey = s[1:], true
+		case '[':
+			if expectKey && len(segments) > 0 {
+				return nil, fmt.Errorf("invalid field path %s", path)
+			}
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid field path %s", path)
+			}
+			segment, err := parseBracketSegment(s[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid field path %s: %v", path, err)
+			}
+			segments = append(segments, segment)
+			s, expectKey = s[end+1:], false
+		default:
+			if !expectKey {
+				return nil, fmt.Errorf("invalid field path %s", path)
+			}
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			segments = append(segments, pathSegment{key: s[:end]})
+			s, expectKey = s[end:], false
+		}
+	}
+
+	if len(segments) == 0 || expectKey {
+		return nil, fmt.Errorf("invalid field path %s", path)
+	}
+
+	return &FieldPath{path: path, segments: segments}, nil
+}
+
+// ParseFieldPaths parses the field paths.
+func ParseFieldPaths(paths []string) ([]*FieldPath, error) {
+	fieldPaths := make([]*FieldPath, 0, len(paths))
+	for _, path := range paths {
+		fieldPath, err := ParseFieldPath(path)
+		if err != nil {
+			return nil, err
+		}
+		fieldPaths = append(fieldPaths, fieldPath)
+	}
+	return fieldPaths, nil
+}
+
+func parseBracketSegment(s string) (pathSegment, error) {
+	if s == "*" {
+		return pathSegment{index: -1, isIndex: true}, nil
+	}
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return pathSegment{key: s[1 : len(s)-1]}, nil
+	}
+	index, err := strconv.Atoi(s)
+	if err != nil || index < 0 {
+		return pathSegment{}, fmt.Errorf("invalid array index %s", s)
+	}
+	return pathSegment{index: index, isIndex: true}, nil
+}
+
+func (p *FieldPath) String() string {
+	return p.path
+}
+
+// project returns the value at the path, wrapped in the same structure as the object.
+func project(value interface{}, segments []pathSegment) (interface{}, bool) {
+	if len(segments) == 0 {
+		return value, true
+	}
+
+	segment := segments[0]
+	if !segment.isIndex {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		field, ok := object[segment.key]
+		if !ok {
+			return nil, false
+		}
+		projected, ok := project(field, segments[1:])
+		if !ok {
+			return nil, false
+		}
+		return map[string]interface{}{segment.key: projected}, true
+	}
+
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	if segment.index >= 0 {
+		if segment.index >= len(array) {
+			return nil, false
+		}
+		projected, ok := project(array[segment.index], segments[1:])
+		if !ok {
+			return nil, false
+		}
+		return []interface{}{projected}, true
+	}
+
+	// keep the elements without the field, so the elements of different paths are merged by index
+	projected := make([]interface{}, len(array))
+	for i := range array {
+		projected[i], _ = project(array[i], segments[1:])
+	}
+	return projected, true
+}
+
+// lookup returns the value at the path, the values of all the elements are returned for the wildcard index.
+func lookup(value interface{}, segments []pathSegment) (interface{}, bool) {
+	if len(segments) == 0 {
+		return value, true
+	}
+
+	segment := segments[0]
+	if !segment.isIndex {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		field, ok := object[segment.key]
+		if !ok {
+			return nil, false
+		}
+		return lookup(field, segments[1:])
+	}
+
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	if segment.index >= 0 {
+		if segment.index >= len(array) {
+			return nil, false
+		}
+		return lookup(array[segment.index], segments[1:])
+	}
+
+	values := make([]interface{}, 0, len(array))
+	for i := range array {
+		if v, ok := lookup(array[i], segments[1:]); ok {
+			values = append(values, v)
+		}
+	}
+	return values, true
+}
+
+func merge(dst, src interface{}) interface{} {
+	switch s := src.(type) {
+	case map[string]interface{}:
+		d, ok := dst.(map[string]interface{})
+		if !ok {
+			return src
+		}
+		for key, value := range s {
+			if existing, ok := d[key]; ok {
+				d[key] = merge(existing, value)
+			} else {
+				d[key] = value
+			}
+		}
+		return d
+	case []interface{}:
+		d, ok := dst.([]interface{})
+		if !ok || len(d) != len(s) {
+			return src
+		}
+		for i := range s {
+			d[i] = merge(d[i], s[i])
+		}
+		return d
+	default:
+		if src == nil {
+			return dst
+		}
+		return src
+	}
+}
+
+// toUnstructured converts the item to the generic JSON representation.
+func toUnstructured(item interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	object := make(map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// keep the precision of the large integers, e.g. the size of the resources
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+// ProjectFields returns the items with only the fields of the paths, fields missing in an item are omitted.
+func ProjectFields(items []interface{}, paths []*FieldPath) ([]interface{}, error) {
+	projected := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		object, err := toUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+
+		var result interface{} = map[string]interface{}{}
+		for _, path := range paths {
+			if value, ok := project(object, path.segments); ok {
+				result = merge(result, value)
+			}
+		}
+		projected = append(projected, result)
+	}
+	return projected, nil
+}
+
+// ToTable converts the items to the Kubernetes Table, each path is a column of the table
+// and the metadata of the items are kept in the rows.
+func ToTable(items []interface{}, paths []*FieldPath) (*metav1.Table, error) {
+	table := &metav1.Table{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: metav1.SchemeGroupVersion.String(),
+			Kind:       "Table",
+		},
+		ColumnDefinitions: make([]metav1.TableColumnDefinition, len(paths)),
+		Rows:              make([]metav1.TableRow, 0, len(items)),
+	}
+
+	// the type of a column is the type of the first non-nil value
+	typed := make([]bool, len(paths))
+	for i, path := range paths {
+		table.ColumnDefinitions[i] = metav1.TableColumnDefinition{
+			Name:        path.String(),
+			Type:        "string",
+			Description: fmt.Sprintf("the value of the field %s", path.String()),
+		}
+	}
+
+	for _, item := range items {
+		object, err := toUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+
+		row := metav1.TableRow{Cells: make([]interface{}, len(paths))}
+		for i, path := range paths {
+			value, _ := lookup(object, path.segments)
+			row.Cells[i] = value
+			if value != nil && !typed[i] {
+				table.ColumnDefinitions[i].Type, typed[i] = columnType(value), true
+			}
+		}
+
+		metadata, err := partialObjectMetadata(object)
+		if err != nil {
+			return nil, err
+		}
+		row.Object = runtime.RawExtension{Object: metadata}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table, nil
+}
+
+func columnType(value interface{}) string {
+	switch v := value.(type) {
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	default:
+		return "object"
+	}
+}
+
+func partialObjectMetadata(object map[string]interface{}) (*metav1.PartialObjectMetadata, error) {
+	metadata := &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: metav1.SchemeGroupVersion.String(), Kind: "PartialObjectMetadata"},
+	}
+
+	data, err := json.Marshal(object["metadata"])
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &metadata.ObjectMeta); err != nil {
+		return nil, err
+	}
+	metadata.ManagedFields = nil
+	return metadata, nil
+}
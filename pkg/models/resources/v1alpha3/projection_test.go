@@ -0,0 +1,115 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var projectionPod = &corev1.Pod{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:      "foo",
+		Namespace: "default",
+		Labels:    map[string]string{"app.kubernetes.io/name": "foo"},
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl"},
+		},
+	},
+	Spec: corev1.PodSpec{
+		Containers: []corev1.Container{
+			{Name: "app", Image: "nginx"},
+			{Name: "sidecar", Image: "envoy"},
+		},
+	},
+	Status: corev1.PodStatus{Phase: corev1.PodRunning},
+}
+
+func TestParseFieldPath(t *testing.T) {
+	valid := []string{"metadata.name", ".metadata.name", "{.status.phase}", "$.spec.containers[*].image",
+		"spec.containers[0].name", "metadata.labels['app.kubernetes.io/name']"}
+	for _, path := range valid {
+		if _, err := ParseFieldPath(path); err != nil {
+			t.Errorf("expected valid field path %s, got %v", path, err)
+		}
+	}
+
+	invalid := []string{"", ".", "metadata..name", "metadata.", "spec.containers[", "spec.containers[-1]",
+		"spec.containers[0]name", "spec.[0]"}
+	for _, path := range invalid {
+		if _, err := ParseFieldPath(path); err == nil {
+			t.Errorf("expected invalid field path %s", path)
+		}
+	}
+}
+
+func TestProjectFields(t *testing.T) {
+	paths, err := ParseFieldPaths([]string{"metadata.name", "metadata.labels['app.kubernetes.io/name']",
+		"spec.containers[*].name", "spec.containers[*].image", "status.phase", "status.podIP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := ProjectFields([]interface{}{projectionPod}, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `[{"metadata":{"labels":{"app.kubernetes.io/name":"foo"},"name":"foo"},` +
+		`"spec":{"containers":[{"image":"nginx","name":"app"},{"image":"envoy","name":"sidecar"}]},` +
+		`"status":{"phase":"Running"}}]`
+	if diff := cmp.Diff(expected, string(data)); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", expected, diff)
+	}
+}
+
+func TestToTable(t *testing.T) {
+	paths, err := ParseFieldPaths([]string{"metadata.name", "spec.containers[*].image", "status.podIP"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := ToTable([]interface{}{projectionPod}, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"string", "object", "string"}, []string{
+		table.ColumnDefinitions[0].Type, table.ColumnDefinitions[1].Type, table.ColumnDefinitions[2].Type,
+	}); diff != "" {
+		t.Errorf("column types differ (-want, +got): %s", diff)
+	}
+
+	expected := []interface{}{"foo", []interface{}{"nginx", "envoy"}, nil}
+	if diff := cmp.Diff(expected, table.Rows[0].Cells); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", expected, diff)
+	}
+
+	metadata := table.Rows[0].Object.Object.(*metav1.PartialObjectMetadata)
+	if metadata.Name != "foo" || metadata.Namespace != "default" || metadata.ManagedFields != nil {
+		t.Errorf("unexpected object metadata %v", metadata.ObjectMeta)
+	}
+}
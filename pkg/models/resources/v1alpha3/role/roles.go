@@ -22,6 +22,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	"k8s.io/klog/v2"
 
@@ -47,11 +48,28 @@ func (d *rolesGetter) Get(namespace, name string) (runtime.Object, error) {
 func (d *rolesGetter) List(namespace string, query *query.Query) (*api.ListResult, error) {
 
 	var roles []*rbacv1.Role
-	var err error
 
-	if aggregateTo := query.Filters[iamv1alpha2.AggregateTo]; aggregateTo != "" {
-		roles, err = d.fetchAggregationRoles(namespace, string(aggregateTo))
-		delete(query.Filters, iamv1alpha2.AggregateTo)
+	aggregateTo, ok, err := v1alpha3.SelectorValues(query, iamv1alpha2.AggregateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok {
+		// the roles aggregated to any of the roles
+		seen := sets.NewString()
+		for _, name := range aggregateTo {
+			aggregated, err := d.fetchAggregationRoles(namespace, name)
+			if err != nil {
+				return nil, err
+			}
+			for _, role := range aggregated {
+				if !seen.Has(role.Name) {
+					seen.Insert(role.Name)
+					roles = append(roles, role)
+				}
+			}
+		}
+		query.Delete(iamv1alpha2.AggregateTo)
 	} else {
 		roles, err = d.sharedInformers.Rbac().V1().Roles().Lister().Roles(namespace).List(query.Selector())
 	}
@@ -65,7 +83,7 @@ func (d *rolesGetter) List(namespace string, query *query.Query) (*api.ListResul
 		result = append(result, role)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *rolesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
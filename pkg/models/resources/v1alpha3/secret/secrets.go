@@ -57,7 +57,7 @@ func (s *secretSearcher) List(namespace string, query *query.Query) (*api.ListRe
 		result = append(result, secret)
 	}
 
-	return v1alpha3.DefaultList(result, query, s.compare, s.filter), nil
+	return v1alpha3.DefaultList(result, query, s.compare, s.filter)
 }
 
 func (s *secretSearcher) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -52,8 +52,8 @@ func BenchmarkDefaultListWith1000(b *testing.B) {
 	list := prepareList(testSecret, 1000, expectedListCount)
 
 	for i := 0; i < b.N; i++ {
-		list := v1alpha3.DefaultList(list, q, s.compare, s.filter)
-		if list.TotalItems != expectedListCount {
+		list, err := v1alpha3.DefaultList(list, q, s.compare, s.filter)
+		if err != nil || list.TotalItems != expectedListCount {
 			b.Error("test failed")
 		}
 	}
@@ -67,8 +67,8 @@ func BenchmarkDefaultListWith5000(b *testing.B) {
 
 	list := prepareList(testSecret, 5000, expectedListCount)
 	for i := 0; i < b.N; i++ {
-		list := v1alpha3.DefaultList(list, q, s.compare, s.filter)
-		if list.TotalItems != expectedListCount {
+		list, err := v1alpha3.DefaultList(list, q, s.compare, s.filter)
+		if err != nil || list.TotalItems != expectedListCount {
 			b.Error("test failed")
 		}
 	}
@@ -81,8 +81,8 @@ func BenchmarkDefaultListWith10000(b *testing.B) {
 	expectedListCount := rand.Intn(20)
 	list := prepareList(testSecret, 100000, expectedListCount)
 	for i := 0; i < b.N; i++ {
-		list := v1alpha3.DefaultList(list, q, s.compare, s.filter)
-		if list.TotalItems != expectedListCount {
+		list, err := v1alpha3.DefaultList(list, q, s.compare, s.filter)
+		if err != nil || list.TotalItems != expectedListCount {
 			b.Error("test failed")
 		}
 	}
@@ -94,8 +94,8 @@ func BenchmarkDefaultListWith50000(b *testing.B) {
 	q.Filters[query.ParameterFieldSelector] = "metadata.resourceVersion=1234567"
 	expectedListCount := rand.Intn(20)
 	for i := 0; i < b.N; i++ {
-		list := v1alpha3.DefaultList(prepareList(testSecret, 50000, expectedListCount), q, s.compare, s.filter)
-		if list.TotalItems != expectedListCount {
+		list, err := v1alpha3.DefaultList(prepareList(testSecret, 50000, expectedListCount), q, s.compare, s.filter)
+		if err != nil || list.TotalItems != expectedListCount {
 			b.Error("test failed")
 		}
 	}
@@ -50,7 +50,7 @@ func (d *servicesGetter) List(namespace string, query *query.Query) (*api.ListRe
 		result = append(result, deployment)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *servicesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
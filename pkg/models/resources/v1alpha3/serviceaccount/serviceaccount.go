@@ -49,7 +49,7 @@ func (d *serviceaccountsGetter) List(namespace string, query *query.Query) (*api
 		result = append(result, serviceaccount)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *serviceaccountsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -48,31 +48,7 @@ func (d *usersGetter) Get(_, name string) (runtime.Object, error) {
 
 func (d *usersGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
 
-	var users []*iamv1alpha2.User
-	var err error
-
-	if namespace := query.Filters[iamv1alpha2.ScopeNamespace]; namespace != "" {
-		role := query.Filters[iamv1alpha2.ResourcesSingularRole]
-		users, err = d.listAllUsersInNamespace(string(namespace), string(role))
-		delete(query.Filters, iamv1alpha2.ScopeNamespace)
-		delete(query.Filters, iamv1alpha2.ResourcesSingularRole)
-	} else if workspace := query.Filters[iamv1alpha2.ScopeWorkspace]; workspace != "" {
-		workspaceRole := query.Filters[iamv1alpha2.ResourcesSingularWorkspaceRole]
-		users, err = d.listAllUsersInWorkspace(string(workspace), string(workspaceRole))
-		delete(query.Filters, iamv1alpha2.ScopeWorkspace)
-		delete(query.Filters, iamv1alpha2.ResourcesSingularWorkspaceRole)
-	} else if cluster := query.Filters[iamv1alpha2.ScopeCluster]; cluster == "true" {
-		clusterRole := query.Filters[iamv1alpha2.ResourcesSingularClusterRole]
-		users, err = d.listAllUsersInCluster(string(clusterRole))
-		delete(query.Filters, iamv1alpha2.ScopeCluster)
-		delete(query.Filters, iamv1alpha2.ResourcesSingularClusterRole)
-	} else if globalRole := query.Filters[iamv1alpha2.ResourcesSingularGlobalRole]; globalRole != "" {
-		users, err = d.listAllUsersByGlobalRole(string(globalRole))
-		delete(query.Filters, iamv1alpha2.ResourcesSingularGlobalRole)
-	} else {
-		users, err = d.ksInformer.Iam().V1alpha2().Users().Lister().List(query.Selector())
-	}
-
+	users, err := d.listUsers(query)
 	if err != nil {
 		return nil, err
 	}
@@ -82,7 +58,77 @@ func (d *usersGetter) List(_ string, query *query.Query) (*api.ListResult, error
 		result = append(result, user)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
+}
+
+// listUsers lists the users in the scope selected by the query, the scope and role filters can have
+// multiple values, the users bound to any of the roles in any of the scopes are listed.
+func (d *usersGetter) listUsers(q *query.Query) ([]*iamv1alpha2.User, error) {
+	namespaces, inNamespaces, err := v1alpha3.SelectorValues(q, iamv1alpha2.ScopeNamespace)
+	if err != nil {
+		return nil, err
+	}
+	workspaces, inWorkspaces, err := v1alpha3.SelectorValues(q, iamv1alpha2.ScopeWorkspace)
+	if err != nil {
+		return nil, err
+	}
+	clusters, _, err := v1alpha3.SelectorValues(q, iamv1alpha2.ScopeCluster)
+	if err != nil {
+		return nil, err
+	}
+	globalRoles, byGlobalRoles, err := v1alpha3.SelectorValues(q, iamv1alpha2.ResourcesSingularGlobalRole)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case inNamespaces:
+		return d.listUsersInScopes(q, namespaces, iamv1alpha2.ScopeNamespace, iamv1alpha2.ResourcesSingularRole, d.listAllUsersInNamespace)
+	case inWorkspaces:
+		return d.listUsersInScopes(q, workspaces, iamv1alpha2.ScopeWorkspace, iamv1alpha2.ResourcesSingularWorkspaceRole, d.listAllUsersInWorkspace)
+	case sliceutil.HasString(clusters, "true"):
+		return d.listUsersInScopes(q, []string{""}, iamv1alpha2.ScopeCluster, iamv1alpha2.ResourcesSingularClusterRole,
+			func(_ string, roles []string) ([]*iamv1alpha2.User, error) {
+				return d.listAllUsersInCluster(roles)
+			})
+	case byGlobalRoles:
+		q.Delete(iamv1alpha2.ResourcesSingularGlobalRole)
+		if len(globalRoles) == 0 {
+			return nil, nil
+		}
+		return d.listAllUsersByGlobalRole(globalRoles)
+	default:
+		return d.ksInformer.Iam().V1alpha2().Users().Lister().List(q.Selector())
+	}
+}
+
+// listUsersInScopes lists the users bound to the roles in the scopes, the users bound to any role are listed
+// if there is no role filter. The scope and role filters are removed from the query once they are applied.
+func (d *usersGetter) listUsersInScopes(q *query.Query, scopes []string, scopeField, roleField query.Field,
+	listFunc func(scope string, roles []string) ([]*iamv1alpha2.User, error)) ([]*iamv1alpha2.User, error) {
+	roles, byRoles, err := v1alpha3.SelectorValues(q, roleField)
+	if err != nil {
+		return nil, err
+	}
+	q.Delete(scopeField)
+	q.Delete(roleField)
+	if byRoles && len(roles) == 0 {
+		return nil, nil
+	}
+
+	var users []*iamv1alpha2.User
+	for _, scope := range scopes {
+		list, err := listFunc(scope, roles)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range list {
+			if !contains(users, user.Name) {
+				users = append(users, user)
+			}
+		}
+	}
+	return users, nil
 }
 
 func (d *usersGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -119,7 +165,7 @@ func (d *usersGetter) filter(object runtime.Object, filter query.Filter) bool {
 	}
 }
 
-func (d *usersGetter) listAllUsersInWorkspace(workspace, role string) ([]*iamv1alpha2.User, error) {
+func (d *usersGetter) listAllUsersInWorkspace(workspace string, roles []string) ([]*iamv1alpha2.User, error) {
 	var users []*iamv1alpha2.User
 	var err error
 	workspaceRoleBindings, err := d.ksInformer.Iam().V1alpha2().
@@ -131,7 +177,7 @@ func (d *usersGetter) listAllUsersInWorkspace(workspace, role string) ([]*iamv1a
 	}
 
 	for _, roleBinding := range workspaceRoleBindings {
-		if role != "" && roleBinding.RoleRef.Name != role {
+		if len(roles) > 0 && !sliceutil.HasString(roles, roleBinding.RoleRef.Name) {
 			continue
 		}
 		for _, subject := range roleBinding.Subjects {
@@ -167,7 +213,7 @@ func (d *usersGetter) listAllUsersInWorkspace(workspace, role string) ([]*iamv1a
 	return users, nil
 }
 
-func (d *usersGetter) listAllUsersInNamespace(namespace, role string) ([]*iamv1alpha2.User, error) {
+func (d *usersGetter) listAllUsersInNamespace(namespace string, roles []string) ([]*iamv1alpha2.User, error) {
 	var users []*iamv1alpha2.User
 	var err error
 
@@ -180,7 +226,7 @@ func (d *usersGetter) listAllUsersInNamespace(namespace, role string) ([]*iamv1a
 	}
 
 	for _, roleBinding := range roleBindings {
-		if role != "" && roleBinding.RoleRef.Name != role {
+		if len(roles) > 0 && !sliceutil.HasString(roles, roleBinding.RoleRef.Name) {
 			continue
 		}
 		for _, subject := range roleBinding.Subjects {
@@ -215,7 +261,7 @@ func (d *usersGetter) listAllUsersInNamespace(namespace, role string) ([]*iamv1a
 	return users, nil
 }
 
-func (d *usersGetter) listAllUsersByGlobalRole(globalRole string) ([]*iamv1alpha2.User, error) {
+func (d *usersGetter) listAllUsersByGlobalRole(globalRoles []string) ([]*iamv1alpha2.User, error) {
 	var users []*iamv1alpha2.User
 	var err error
 
@@ -228,7 +274,7 @@ func (d *usersGetter) listAllUsersByGlobalRole(globalRole string) ([]*iamv1alpha
 	}
 
 	for _, roleBinding := range globalRoleBindings {
-		if roleBinding.RoleRef.Name != globalRole {
+		if !sliceutil.HasString(globalRoles, roleBinding.RoleRef.Name) {
 			continue
 		}
 		for _, subject := range roleBinding.Subjects {
@@ -264,7 +310,7 @@ func (d *usersGetter) listAllUsersByGlobalRole(globalRole string) ([]*iamv1alpha
 	return users, nil
 }
 
-func (d *usersGetter) listAllUsersInCluster(clusterRole string) ([]*iamv1alpha2.User, error) {
+func (d *usersGetter) listAllUsersInCluster(clusterRoles []string) ([]*iamv1alpha2.User, error) {
 	var users []*iamv1alpha2.User
 	var err error
 
@@ -276,7 +322,7 @@ func (d *usersGetter) listAllUsersInCluster(clusterRole string) ([]*iamv1alpha2.
 	}
 
 	for _, roleBinding := range roleBindings {
-		if clusterRole != "" && roleBinding.RoleRef.Name != clusterRole {
+		if len(clusterRoles) > 0 && !sliceutil.HasString(clusterRoles, roleBinding.RoleRef.Name) {
 			continue
 		}
 		for _, subject := range roleBinding.Subjects {
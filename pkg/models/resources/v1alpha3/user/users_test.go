@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
@@ -81,6 +83,43 @@ func TestListUsers(t *testing.T) {
 	}
 }
 
+func TestListUsersByGlobalRoles(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	informer := informers.NewSharedInformerFactory(client, 0)
+	for _, user := range users {
+		informer.Iam().V1alpha2().Users().Informer().GetIndexer().Add(user)
+	}
+	for role, user := range map[string]string{"admin": "foo1", "viewer": "bar1", "regular": "foo2"} {
+		informer.Iam().V1alpha2().GlobalRoleBindings().Informer().GetIndexer().Add(&iamv1alpha2.GlobalRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: user + "-" + role},
+			RoleRef:    rbacv1.RoleRef{Name: role},
+			Subjects:   []rbacv1.Subject{{Kind: iamv1alpha2.ResourceKindUser, Name: user}},
+		})
+	}
+	getter := New(informer, nil)
+
+	q := query.New()
+	q.SortBy = query.FieldName
+	q.Conditions = []query.Condition{{Field: iamv1alpha2.ResourcesSingularGlobalRole, Values: []query.Value{"admin", "viewer"}}}
+	got, err := getter.List("", q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range got.Items {
+		names = append(names, item.(*iamv1alpha2.User).Name)
+	}
+	if diff := cmp.Diff(names, []string{"foo1", "bar1"}); diff != "" {
+		t.Errorf("differ (-got, +want): %s", diff)
+	}
+
+	q = query.New()
+	q.Conditions = []query.Condition{{Field: iamv1alpha2.ResourcesSingularGlobalRole, Values: []query.Value{"admin"}, Negated: true}}
+	if _, err = getter.List("", q); !errors.IsBadRequest(err) {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
 var (
 	foo1 = &iamv1alpha2.User{
 		ObjectMeta: metav1.ObjectMeta{
@@ -58,7 +58,7 @@ func (v *volumeSnapshotGetter) List(namespace string, query *query.Query) (*api.
 		result = append(result, snapshot)
 	}
 
-	return v1alpha3.DefaultList(result, query, v.compare, v.filter), nil
+	return v1alpha3.DefaultList(result, query, v.compare, v.filter)
 }
 
 func (v *volumeSnapshotGetter) compare(left, right runtime.Object, field query.Field) bool {
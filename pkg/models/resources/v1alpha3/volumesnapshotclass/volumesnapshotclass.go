@@ -65,7 +65,7 @@ func (v *volumeSnapshotClassGetter) List(namespace string, query *query.Query) (
 		result = append(result, snapshotClass)
 	}
 
-	return v1alpha3.DefaultList(result, query, v.compare, v.filter), nil
+	return v1alpha3.DefaultList(result, query, v.compare, v.filter)
 }
 
 func (v *volumeSnapshotClassGetter) compare(left, right runtime.Object, field query.Field) bool {
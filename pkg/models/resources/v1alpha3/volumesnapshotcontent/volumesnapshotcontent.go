@@ -57,7 +57,7 @@ func (v *volumesnapshotcontentGetter) List(namespace string, query *query.Query)
 		result = append(result, snapshotContent)
 	}
 
-	return v1alpha3.DefaultList(result, query, v.compare, v.filter), nil
+	return v1alpha3.DefaultList(result, query, v.compare, v.filter)
 }
 
 func (v *volumesnapshotcontentGetter) compare(left, right runtime.Object, field query.Field) bool {
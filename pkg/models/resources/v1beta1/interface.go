@@ -37,15 +37,11 @@ type TransformFunc func(runtime.Object) runtime.Object
 func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFunc, filterFunc FilterFunc, transformFuncs ...TransformFunc) ([]runtime.Object, *int64) {
 	// selected matched ones
 	var filtered []runtime.Object
-	if len(q.Filters) != 0 {
+	if q.HasFilters() {
 		for _, object := range objects {
-			selected := true
-			for field, value := range q.Filters {
-				if !filterFunc(object, query.Filter{Field: field, Value: value}) {
-					selected = false
-					break
-				}
-			}
+			selected := q.Match(func(filter query.Filter) bool {
+				return filterFunc(object, filter)
+			})
 
 			if selected {
 				for _, transform := range transformFuncs {
@@ -102,17 +102,21 @@ func (t *tenantOperator) ListDevOpsProjects(user user.Info, workspace string, qu
 		}
 
 		// avoid duplication
-		if !contains(devopsProjects, devopsProject) {
+		if inRestrictedWorkspaces(user, devopsProject.(*devopsv1alpha3.DevOpsProject).Labels[tenantv1alpha1.WorkspaceLabel]) &&
+			!contains(devopsProjects, devopsProject) {
 			devopsProjects = append(devopsProjects, devopsProject)
 		}
 	}
 
 	// devops project filtering
-	result := resources.DefaultList(devopsProjects, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+	result, err := resources.DefaultList(devopsProjects, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
 		return resources.DefaultObjectMetaCompare(left.(*devopsv1alpha3.DevOpsProject).ObjectMeta, right.(*devopsv1alpha3.DevOpsProject).ObjectMeta, field)
 	}, func(object runtime.Object, filter query.Filter) bool {
 		devopsProject := object.(*devopsv1alpha3.DevOpsProject)
 		return resources.DefaultObjectMetaFilter(devopsProject.ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
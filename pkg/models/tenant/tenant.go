@@ -51,6 +51,7 @@ import (
 	loggingv1alpha2 "kubesphere.io/kubesphere/pkg/api/logging/v1alpha2"
 	meteringv1alpha1 "kubesphere.io/kubesphere/pkg/api/metering/v1alpha1"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/scope"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	"kubesphere.io/kubesphere/pkg/apiserver/request"
 	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
@@ -95,6 +96,8 @@ type Interface interface {
 	Events(user user.Info, queryParam *eventsv1alpha1.Query) (*eventsv1alpha1.APIResponse, error)
 	QueryLogs(user user.Info, query *loggingv1alpha2.Query) (*loggingv1alpha2.APIResponse, error)
 	ExportLogs(user user.Info, query *loggingv1alpha2.Query, writer io.Writer) error
+	FollowLogs(ctx context.Context, user user.Info, query *loggingv1alpha2.Query, handler func(loggingv1alpha2.FollowEvent) error) error
+	LogContext(user user.Info, query *loggingv1alpha2.ContextQuery) (*loggingv1alpha2.APIResponse, error)
 	Auditing(user user.Info, queryParam *auditingv1alpha1.Query) (*auditingv1alpha1.APIResponse, error)
 	DescribeNamespace(workspace, namespace string) (*corev1.Namespace, error)
 	DeleteNamespace(workspace, namespace string) error
@@ -177,6 +180,9 @@ func (t *tenantOperator) ListWorkspaces(user user.Info, queryParam *query.Query)
 	workspaces := make([]runtime.Object, 0)
 	for _, roleBinding := range workspaceRoleBindings {
 		workspaceName := roleBinding.Labels[tenantv1alpha1.WorkspaceLabel]
+		if !inRestrictedWorkspaces(user, workspaceName) {
+			continue
+		}
 		obj, err := t.resourceGetter.Get(tenantv1alpha1.ResourcePluralWorkspace, "", workspaceName)
 		if errors.IsNotFound(err) {
 			klog.Warningf("workspace role binding: %+v found but workspace not exist", roleBinding.Name)
@@ -195,15 +201,25 @@ func (t *tenantOperator) ListWorkspaces(user user.Info, queryParam *query.Query)
 	}
 
 	// use default pagination search logic
-	result := resources.DefaultList(workspaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+	result, err := resources.DefaultList(workspaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
 		return resources.DefaultObjectMetaCompare(left.(*tenantv1alpha1.Workspace).ObjectMeta, right.(*tenantv1alpha1.Workspace).ObjectMeta, field)
 	}, func(workspace runtime.Object, filter query.Filter) bool {
 		return resources.DefaultObjectMetaFilter(workspace.(*tenantv1alpha1.Workspace).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
 
+// inRestrictedWorkspaces returns false if the personal access token of the user is restricted to other workspaces,
+// the resources listed through the role bindings of the user are filtered by it since the authorizer is not asked.
+func inRestrictedWorkspaces(user user.Info, workspace string) bool {
+	workspaces, ok := scope.RestrictedWorkspaces(user)
+	return !ok || workspaces.Has(workspace)
+}
+
 func (t *tenantOperator) GetWorkspace(workspace string) (*tenantv1alpha1.Workspace, error) {
 	obj, err := t.resourceGetter.Get(tenantv1alpha1.ResourcePluralWorkspace, "", workspace)
 	if err != nil {
@@ -250,6 +266,9 @@ func (t *tenantOperator) ListWorkspaceTemplates(user user.Info, queryParam *quer
 	workspaces := make([]runtime.Object, 0)
 	for _, roleBinding := range workspaceRoleBindings {
 		workspaceName := roleBinding.Labels[tenantv1alpha1.WorkspaceLabel]
+		if !inRestrictedWorkspaces(user, workspaceName) {
+			continue
+		}
 		obj, err := t.resourceGetter.Get(tenantv1alpha2.ResourcePluralWorkspaceTemplate, "", workspaceName)
 		if errors.IsNotFound(err) {
 			klog.Warningf("workspace role binding: %+v found but workspace not exist", roleBinding.Name)
@@ -268,11 +287,14 @@ func (t *tenantOperator) ListWorkspaceTemplates(user user.Info, queryParam *quer
 	}
 
 	// use default pagination search logic
-	result := resources.DefaultList(workspaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+	result, err := resources.DefaultList(workspaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
 		return resources.DefaultObjectMetaCompare(left.(*tenantv1alpha2.WorkspaceTemplate).ObjectMeta, right.(*tenantv1alpha2.WorkspaceTemplate).ObjectMeta, field)
 	}, func(workspace runtime.Object, filter query.Filter) bool {
 		return resources.DefaultObjectMetaFilter(workspace.(*tenantv1alpha2.WorkspaceTemplate).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
@@ -330,18 +352,22 @@ func (t *tenantOperator) ListFederatedNamespaces(user user.Info, workspace strin
 		}
 		namespace := obj.(*typesv1beta1.FederatedNamespace)
 		// label matching selector, remove duplicate entity
-		if queryParam.Selector().Matches(labels.Set(namespace.Labels)) &&
+		if inRestrictedWorkspaces(user, namespace.Labels[tenantv1alpha1.WorkspaceLabel]) &&
+			queryParam.Selector().Matches(labels.Set(namespace.Labels)) &&
 			!contains(namespaces, namespace) {
 			namespaces = append(namespaces, namespace)
 		}
 	}
 
 	// use default pagination search logic
-	result := resources.DefaultList(namespaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+	result, err := resources.DefaultList(namespaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
 		return resources.DefaultObjectMetaCompare(left.(*typesv1beta1.FederatedNamespace).ObjectMeta, right.(*typesv1beta1.FederatedNamespace).ObjectMeta, field)
 	}, func(object runtime.Object, filter query.Filter) bool {
 		return resources.DefaultObjectMetaFilter(object.(*typesv1beta1.FederatedNamespace).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
@@ -395,18 +421,22 @@ func (t *tenantOperator) ListNamespaces(user user.Info, workspace string, queryP
 		}
 		namespace := obj.(*corev1.Namespace)
 		// label matching selector, remove duplicate entity
-		if queryParam.Selector().Matches(labels.Set(namespace.Labels)) &&
+		if inRestrictedWorkspaces(user, namespace.Labels[tenantv1alpha1.WorkspaceLabel]) &&
+			queryParam.Selector().Matches(labels.Set(namespace.Labels)) &&
 			!contains(namespaces, namespace) {
 			namespaces = append(namespaces, namespace)
 		}
 	}
 
 	// use default pagination search logic
-	result := resources.DefaultList(namespaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+	result, err := resources.DefaultList(namespaces, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
 		return resources.DefaultObjectMetaCompare(left.(*corev1.Namespace).ObjectMeta, right.(*corev1.Namespace).ObjectMeta, field)
 	}, func(object runtime.Object, filter query.Filter) bool {
 		return resources.DefaultObjectMetaFilter(object.(*corev1.Namespace).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
@@ -691,11 +721,14 @@ func (t *tenantOperator) ListClusters(user user.Info, queryParam *query.Query) (
 	}
 
 	// use default pagination search logic
-	result := resources.DefaultList(items, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+	result, err := resources.DefaultList(items, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
 		return resources.DefaultObjectMetaCompare(left.(*clusterv1alpha1.Cluster).ObjectMeta, right.(*clusterv1alpha1.Cluster).ObjectMeta, field)
 	}, func(workspace runtime.Object, filter query.Filter) bool {
 		return resources.DefaultObjectMetaFilter(workspace.(*clusterv1alpha1.Cluster).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return result, nil
 }
@@ -865,17 +898,93 @@ func (t *tenantOperator) Events(user user.Info, queryParam *eventsv1alpha1.Query
 }
 
 func (t *tenantOperator) QueryLogs(user user.Info, query *loggingv1alpha2.Query) (*loggingv1alpha2.APIResponse, error) {
-	iNamespaces, err := t.listIntersectedNamespaces(nil, nil,
-		stringutils.Split(query.NamespaceFilter, ","),
-		stringutils.Split(query.NamespaceSearch, ","))
+	sf, err := t.logSearchFilter(user, query)
 	if err != nil {
-		klog.Error(err)
 		return nil, err
 	}
 
-	namespaceCreateTimeMap := make(map[string]*time.Time)
+	var ar loggingv1alpha2.APIResponse
+	noHit := sf == nil
 
-	var isGlobalAdmin bool
+	switch query.Operation {
+	case loggingv1alpha2.OperationStatistics:
+		if noHit {
+			ar.Statistics = &loggingclient.Statistics{}
+		} else {
+			ar, err = t.lo.GetCurrentStats(*sf)
+		}
+	case loggingv1alpha2.OperationHistogram:
+		if noHit {
+			ar.Histogram = &loggingclient.Histogram{}
+		} else {
+			ar, err = t.lo.CountLogsByInterval(*sf, query.Interval)
+		}
+	case loggingv1alpha2.OperationFields:
+		if noHit {
+			ar.Facets = &loggingclient.Facets{}
+		} else {
+			ar, err = t.lo.CountFieldValues(*sf, query.Fields, query.Size)
+		}
+	default:
+		if noHit {
+			ar.Logs = &loggingclient.Logs{}
+		} else {
+			ar, err = t.lo.SearchLogs(*sf, query.From, query.Size, query.Sort)
+		}
+		if err == nil && query.ParseJSON {
+			parseLogFields(ar.Logs.Records)
+		}
+	}
+	return &ar, err
+}
+
+func (t *tenantOperator) ExportLogs(user user.Info, query *loggingv1alpha2.Query, writer io.Writer) error {
+	sf, err := t.logSearchFilter(user, query)
+	if err != nil {
+		return err
+	}
+
+	if sf == nil {
+		return nil
+	} else {
+		return t.lo.ExportLogs(*sf, writer)
+	}
+}
+
+func (t *tenantOperator) FollowLogs(ctx context.Context, user user.Info, query *loggingv1alpha2.Query, handler func(loggingv1alpha2.FollowEvent) error) error {
+	// the namespaces are authorized again periodically, the stream stops following
+	// the namespaces once the user is not allowed to view their logs
+	if query.ParseJSON {
+		next := handler
+		handler = func(event loggingv1alpha2.FollowEvent) error {
+			for i := range event.Records {
+				event.Records[i].Fields = loggingclient.ParseFields(event.Records[i].Log)
+			}
+			return next(event)
+		}
+	}
+	return t.lo.FollowLogs(ctx, func() (*loggingclient.SearchFilter, error) {
+		return t.logSearchFilter(user, query)
+	}, handler)
+}
+
+// parseLogFields fills the fields of the records with JSON log lines.
+func parseLogFields(records []loggingclient.Record) {
+	for i := range records {
+		records[i].Fields = loggingclient.ParseFields(records[i].Log)
+	}
+}
+
+func (t *tenantOperator) LogContext(user user.Info, query *loggingv1alpha2.ContextQuery) (*loggingv1alpha2.APIResponse, error) {
+	cf := loggingclient.ContextFilter{
+		Namespace: query.Namespace,
+		Pod:       query.Pod,
+		Container: query.Container,
+		Time:      query.Time,
+		Offset:    query.Offset,
+		Before:    query.Before,
+		After:     query.After,
+	}
 
 	// If it is a global admin, the user can view logs from any namespace.
 	podLogs := authorizer.AttributesRecord{
@@ -893,87 +1002,42 @@ func (t *tenantOperator) QueryLogs(user user.Info, query *loggingv1alpha2.Query)
 		klog.Error(err)
 		return nil, err
 	}
-	if decision == authorizer.DecisionAllow {
-		isGlobalAdmin = true
-		if query.NamespaceFilter != "" || query.NamespaceSearch != "" {
-			for _, ns := range iNamespaces {
-				namespaceCreateTimeMap[ns.Name] = nil
-			}
-		}
-	}
 
-	// If it is a regular user, this user can only view logs of namespaces the user belongs to.
-	if !isGlobalAdmin {
-		for _, ns := range iNamespaces {
-			podLogs := authorizer.AttributesRecord{
-				User:            user,
-				Verb:            "get",
-				APIGroup:        "",
-				APIVersion:      "v1",
-				Namespace:       ns.Name,
-				Resource:        "pods",
-				Subresource:     "log",
-				ResourceRequest: true,
-				ResourceScope:   request.NamespaceScope,
-			}
-			decision, _, err := t.authorizer.Authorize(podLogs)
-			if err != nil {
-				klog.Error(err)
-				return nil, err
-			}
-			if decision == authorizer.DecisionAllow {
-				namespaceCreateTimeMap[ns.Name] = &ns.CreationTimestamp.Time
-			}
-		}
-	}
-
-	sf := loggingclient.SearchFilter{
-		NamespaceFilter: namespaceCreateTimeMap,
-		WorkloadSearch:  stringutils.Split(query.WorkloadSearch, ","),
-		WorkloadFilter:  stringutils.Split(query.WorkloadFilter, ","),
-		PodSearch:       stringutils.Split(query.PodSearch, ","),
-		PodFilter:       stringutils.Split(query.PodFilter, ","),
-		ContainerSearch: stringutils.Split(query.ContainerSearch, ","),
-		ContainerFilter: stringutils.Split(query.ContainerFilter, ","),
-		LogSearch:       stringutils.Split(query.LogSearch, ","),
-		Starttime:       query.StartTime,
-		Endtime:         query.EndTime,
-	}
-
-	var ar loggingv1alpha2.APIResponse
-	noHit := !isGlobalAdmin && len(namespaceCreateTimeMap) == 0 ||
-		isGlobalAdmin && len(namespaceCreateTimeMap) == 0 && (query.NamespaceFilter != "" || query.NamespaceSearch != "")
-
-	switch query.Operation {
-	case loggingv1alpha2.OperationStatistics:
-		if noHit {
-			ar.Statistics = &loggingclient.Statistics{}
-		} else {
-			ar, err = t.lo.GetCurrentStats(sf)
+	// If it is a regular user, this user can only view logs of the namespace since its creation.
+	if decision != authorizer.DecisionAllow {
+		obj, err := t.resourceGetter.Get("namespaces", "", query.Namespace)
+		if err != nil {
+			return nil, err
 		}
-	case loggingv1alpha2.OperationHistogram:
-		if noHit {
-			ar.Histogram = &loggingclient.Histogram{}
-		} else {
-			ar, err = t.lo.CountLogsByInterval(sf, query.Interval)
+		namespace := obj.(*corev1.Namespace)
+		podLogs.Namespace = namespace.Name
+		podLogs.ResourceScope = request.NamespaceScope
+		decision, reason, err := t.authorizer.Authorize(podLogs)
+		if err != nil {
+			klog.Error(err)
+			return nil, err
 		}
-	default:
-		if noHit {
-			ar.Logs = &loggingclient.Logs{}
-		} else {
-			ar, err = t.lo.SearchLogs(sf, query.From, query.Size, query.Sort)
+		if decision != authorizer.DecisionAllow {
+			return nil, errors.NewForbidden(corev1.Resource("pods/log"), query.Pod, fmt.Errorf(reason))
 		}
+		cf.Starttime = namespace.CreationTimestamp.Time
 	}
-	return &ar, err
+
+	ar, err := t.lo.GetLogContext(cf)
+	if err != nil {
+		return nil, err
+	}
+	return &ar, nil
 }
 
-func (t *tenantOperator) ExportLogs(user user.Info, query *loggingv1alpha2.Query, writer io.Writer) error {
+// logSearchFilter returns the search filter of the logs the user is allowed to view, nil if there are none.
+func (t *tenantOperator) logSearchFilter(user user.Info, query *loggingv1alpha2.Query) (*loggingclient.SearchFilter, error) {
 	iNamespaces, err := t.listIntersectedNamespaces(nil, nil,
 		stringutils.Split(query.NamespaceFilter, ","),
 		stringutils.Split(query.NamespaceSearch, ","))
 	if err != nil {
 		klog.Error(err)
-		return err
+		return nil, err
 	}
 
 	namespaceCreateTimeMap := make(map[string]*time.Time)
@@ -994,7 +1058,7 @@ func (t *tenantOperator) ExportLogs(user user.Info, query *loggingv1alpha2.Query
 	decision, _, err := t.authorizer.Authorize(podLogs)
 	if err != nil {
 		klog.Error(err)
-		return err
+		return nil, err
 	}
 	if decision == authorizer.DecisionAllow {
 		isGlobalAdmin = true
@@ -1022,7 +1086,7 @@ func (t *tenantOperator) ExportLogs(user user.Info, query *loggingv1alpha2.Query
 			decision, _, err := t.authorizer.Authorize(podLogs)
 			if err != nil {
 				klog.Error(err)
-				return err
+				return nil, err
 			}
 			if decision == authorizer.DecisionAllow {
 				namespaceCreateTimeMap[ns.Name] = &ns.CreationTimestamp.Time
@@ -1030,7 +1094,13 @@ func (t *tenantOperator) ExportLogs(user user.Info, query *loggingv1alpha2.Query
 		}
 	}
 
-	sf := loggingclient.SearchFilter{
+	noHit := !isGlobalAdmin && len(namespaceCreateTimeMap) == 0 ||
+		isGlobalAdmin && len(namespaceCreateTimeMap) == 0 && (query.NamespaceFilter != "" || query.NamespaceSearch != "")
+	if noHit {
+		return nil, nil
+	}
+
+	sf := &loggingclient.SearchFilter{
 		NamespaceFilter: namespaceCreateTimeMap,
 		WorkloadSearch:  stringutils.Split(query.WorkloadSearch, ","),
 		WorkloadFilter:  stringutils.Split(query.WorkloadFilter, ","),
@@ -1039,18 +1109,11 @@ func (t *tenantOperator) ExportLogs(user user.Info, query *loggingv1alpha2.Query
 		ContainerSearch: stringutils.Split(query.ContainerSearch, ","),
 		ContainerFilter: stringutils.Split(query.ContainerFilter, ","),
 		LogSearch:       stringutils.Split(query.LogSearch, ","),
+		FieldFilter:     query.FieldFilter,
 		Starttime:       query.StartTime,
 		Endtime:         query.EndTime,
 	}
-
-	noHit := !isGlobalAdmin && len(namespaceCreateTimeMap) == 0 ||
-		isGlobalAdmin && len(namespaceCreateTimeMap) == 0 && (query.NamespaceFilter != "" || query.NamespaceSearch != "")
-
-	if noHit {
-		return nil
-	} else {
-		return t.lo.ExportLogs(sf, writer)
-	}
+	return sf, nil
 }
 
 func (t *tenantOperator) Auditing(user user.Info, queryParam *auditingv1alpha1.Query) (*auditingv1alpha1.APIResponse, error) {
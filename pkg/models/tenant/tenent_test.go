@@ -131,6 +131,39 @@ func TestTenantOperator_ListNamespaces(t *testing.T) {
 	}
 }
 
+func TestTenantOperator_ListWithRestrictedAccessToken(t *testing.T) {
+	tenantOperator := prepare()
+	restrictedTo := func(workspace string) user.Info {
+		return &user.DefaultInfo{
+			Name:  tester2.Name,
+			Extra: map[string][]string{iamv1alpha2.ExtraAccessTokenWorkspaces: {workspace}},
+		}
+	}
+
+	// the workspaces and the namespaces listed through the role bindings are filtered by the access token
+	result, err := tenantOperator.ListWorkspaceTemplates(restrictedTo(testWorkspace.Name), query.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&api.ListResult{Items: []interface{}{}, TotalItems: 0}, result); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", result, diff)
+	}
+	result, err = tenantOperator.ListNamespaces(restrictedTo(systemWorkspace.Name), "", query.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&api.ListResult{Items: []interface{}{}, TotalItems: 0}, result); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", result, diff)
+	}
+	result, err = tenantOperator.ListNamespaces(restrictedTo(testWorkspace.Name), "", query.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&api.ListResult{Items: []interface{}{testNamespace}, TotalItems: 1}, result); diff != "" {
+		t.Errorf("%T differ (-want, +got): %s", result, diff)
+	}
+}
+
 func TestTenantOperator_DescribeNamespace(t *testing.T) {
 	tenantOperator := prepare()
 	tests := []struct {
@@ -13,11 +13,40 @@
 // limitations under the License.
 package terminal
 
-import "github.com/spf13/pflag"
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+)
 
 type Options struct {
-	Image   string `json:"image,omitempty" yaml:"image,omitempty"`
-	Timeout int    `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Image     string            `json:"image,omitempty" yaml:"image,omitempty"`
+	Timeout   int               `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Recording *RecordingOptions `json:"recording,omitempty" yaml:"recording,omitempty"`
+}
+
+const (
+	RecordingStorageLocal = "local"
+	RecordingStorageS3    = "s3"
+
+	// DefaultRecordingMaxSize is the default maximum size in bytes of a recording.
+	DefaultRecordingMaxSize = 100 << 20
+)
+
+// RecordingOptions configures the recording of terminal sessions.
+type RecordingOptions struct {
+	// Enable the recording of pod and node terminal sessions.
+	Enable bool `json:"enable" yaml:"enable"`
+	// The storage of the recordings, local or s3, default to local. The s3 storage uses the s3 options of ks-apiserver.
+	Storage string `json:"storage,omitempty" yaml:"storage,omitempty"`
+	// The directory to store the recordings when the storage is local.
+	Dir string `json:"dir,omitempty" yaml:"dir,omitempty"`
+	// The key prefix of the recordings when the storage is s3, default to terminal-recordings.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// Record the input of the terminal sessions besides the output.
+	RecordInput bool `json:"recordInput,omitempty" yaml:"recordInput,omitempty"`
+	// The maximum size in bytes of a recording, the events beyond it are not recorded, default to 100MiB.
+	MaxSize int64 `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
 }
 
 func NewTerminalOptions() *Options {
@@ -29,6 +58,20 @@ func NewTerminalOptions() *Options {
 
 func (s *Options) Validate() []error {
 	var errs []error
+	if s.Recording != nil && s.Recording.Enable {
+		switch s.Recording.Storage {
+		case RecordingStorageLocal, "":
+			if len(s.Recording.Dir) == 0 {
+				errs = append(errs, fmt.Errorf("terminal recording directory is required when storage is %s", RecordingStorageLocal))
+			}
+		case RecordingStorageS3:
+		default:
+			errs = append(errs, fmt.Errorf("unsupported terminal recording storage %s", s.Recording.Storage))
+		}
+		if s.Recording.MaxSize < 0 {
+			errs = append(errs, fmt.Errorf("terminal recording max size must not be negative"))
+		}
+	}
 	return errs
 }
 
@@ -0,0 +1,233 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/klog/v2"
+)
+
+const (
+	castVersion = 2
+	// The asciinema v2 event types.
+	castOutput = "o"
+	castInput  = "i"
+	castResize = "r"
+
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+var recordingIDRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Recording is the metadata of a recorded terminal session.
+type Recording struct {
+	ID         string    `json:"id" description:"recording id"`
+	User       string    `json:"user" description:"the user who opened the terminal session"`
+	Namespace  string    `json:"namespace,omitempty" description:"namespace of the pod"`
+	Pod        string    `json:"pod,omitempty" description:"name of the pod"`
+	Container  string    `json:"container,omitempty" description:"name of the container"`
+	Node       string    `json:"node,omitempty" description:"name of the node, only for node shell sessions"`
+	Shell      string    `json:"shell,omitempty" description:"the shell of the terminal session"`
+	StartTime  time.Time `json:"startTime" description:"the time the terminal session started"`
+	EndTime    time.Time `json:"endTime" description:"the time the terminal session ended"`
+	ExitReason string    `json:"exitReason" description:"the reason the terminal session ended"`
+	Size       int64     `json:"size" description:"size in bytes of the recording"`
+	Truncated  bool      `json:"truncated,omitempty" description:"whether the recording is truncated for exceeding the maximum size"`
+}
+
+// castHeader is the header of the asciinema v2 cast file, the metadata of the recording
+// is kept as an extra field, which is ignored by asciinema players.
+type castHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Duration  float64           `json:"duration"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	Recording *Recording        `json:"kubesphere,omitempty"`
+}
+
+func ValidRecordingID(id string) bool {
+	return recordingIDRegexp.MatchString(id)
+}
+
+// recorder records a terminal session in asciinema v2 format, the events are written into
+// a temporary file and the cast file is saved to the store when the session is closed.
+// The events beyond the maximum size are dropped and the recording is marked as truncated.
+type recorder struct {
+	PtyHandler
+	store       RecordingStore
+	recordInput bool
+	maxSize     int64
+	recording   *Recording
+
+	mutex   sync.Mutex
+	events  *os.File
+	writer  *bufio.Writer
+	written int64
+	width   uint16
+	height  uint16
+}
+
+func newRecorder(handler PtyHandler, store RecordingStore, options *RecordingOptions, recording *Recording) (*recorder, error) {
+	events, err := os.CreateTemp("", "terminal-recording-")
+	if err != nil {
+		return nil, err
+	}
+
+	recording.ID = fmt.Sprintf("%s-%s", recording.StartTime.UTC().Format("20060102150405"), rand.String(8))
+
+	maxSize := options.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultRecordingMaxSize
+	}
+
+	return &recorder{
+		PtyHandler:  handler,
+		store:       store,
+		recordInput: options.RecordInput,
+		maxSize:     maxSize,
+		recording:   recording,
+		events:      events,
+		writer:      bufio.NewWriter(events),
+	}, nil
+}
+
+func (r *recorder) Read(p []byte) (int, error) {
+	n, err := r.PtyHandler.Read(p)
+	if n > 0 && r.recordInput {
+		r.record(castInput, string(p[:n]))
+	}
+	return n, err
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	n, err := r.PtyHandler.Write(p)
+	if n > 0 {
+		r.record(castOutput, string(p[:n]))
+	}
+	return n, err
+}
+
+func (r *recorder) Next() *remotecommand.TerminalSize {
+	size := r.PtyHandler.Next()
+	if size != nil {
+		r.mutex.Lock()
+		if r.width == 0 {
+			r.width, r.height = size.Width, size.Height
+		}
+		r.mutex.Unlock()
+		r.record(castResize, fmt.Sprintf("%dx%d", size.Width, size.Height))
+	}
+	return size
+}
+
+func (r *recorder) record(eventType, data string) {
+	event, err := json.Marshal([]interface{}{
+		time.Since(r.recording.StartTime).Seconds(),
+		eventType,
+		data,
+	})
+	if err != nil {
+		klog.Warningf("marshal terminal recording event error: %v", err)
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.recording.Truncated {
+		return
+	}
+	if r.written+int64(len(event))+1 > r.maxSize {
+		r.recording.Truncated = true
+		return
+	}
+	_, _ = r.writer.Write(event)
+	_ = r.writer.WriteByte('\n')
+	r.written += int64(len(event)) + 1
+}
+
+// Close saves the recording with the reason the session ended.
+func (r *recorder) Close(reason string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	defer func() {
+		_ = r.events.Close()
+		_ = os.Remove(r.events.Name())
+	}()
+
+	r.recording.EndTime = time.Now()
+	r.recording.ExitReason = reason
+
+	if err := r.writer.Flush(); err != nil {
+		klog.Warningf("flush terminal recording %s error: %v", r.recording.ID, err)
+		return
+	}
+
+	header := castHeader{
+		Version:   castVersion,
+		Width:     r.width,
+		Height:    r.height,
+		Timestamp: r.recording.StartTime.Unix(),
+		Duration:  r.recording.EndTime.Sub(r.recording.StartTime).Seconds(),
+		Title:     r.title(),
+		Env:       map[string]string{"SHELL": r.recording.Shell, "TERM": "xterm"},
+		Recording: r.recording,
+	}
+	if header.Width == 0 {
+		header.Width, header.Height = defaultWidth, defaultHeight
+	}
+
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		klog.Warningf("marshal terminal recording %s header error: %v", r.recording.ID, err)
+		return
+	}
+
+	if _, err := r.events.Seek(0, io.SeekStart); err != nil {
+		klog.Warningf("read terminal recording %s error: %v", r.recording.ID, err)
+		return
+	}
+
+	headerBytes = append(headerBytes, '\n')
+	r.recording.Size = int64(len(headerBytes)) + r.written
+	cast := io.MultiReader(bytes.NewReader(headerBytes), io.LimitReader(r.events, r.written))
+	if err := r.store.Save(r.recording, cast); err != nil {
+		klog.Warningf("save terminal recording %s error: %v", r.recording.ID, err)
+	}
+}
+
+func (r *recorder) title() string {
+	if len(r.recording.Node) > 0 {
+		return fmt.Sprintf("%s@node/%s", r.recording.User, r.recording.Node)
+	}
+	return fmt.Sprintf("%s@%s/%s/%s", r.recording.User, r.recording.Namespace, r.recording.Pod, r.recording.Container)
+}
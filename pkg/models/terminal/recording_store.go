@@ -0,0 +1,246 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/s3"
+)
+
+const (
+	DefaultRecordingPrefix = "terminal-recordings"
+	castSuffix             = ".cast"
+	metadataSuffix         = ".json"
+)
+
+var ErrRecordingNotFound = errors.New("recording not found")
+
+// RecordingStore stores the terminal session recordings.
+type RecordingStore interface {
+	// Save saves the metadata and the asciinema cast file of a recording, the cast file
+	// is streamed from the reader and its size is given by the Size of the recording.
+	Save(recording *Recording, cast io.Reader) error
+	// List returns the metadata of all recordings, sorted by the start time in descending order.
+	List() ([]*Recording, error)
+	// Get returns the metadata of a recording.
+	Get(id string) (*Recording, error)
+	// Read returns the asciinema cast file of a recording.
+	Read(id string) ([]byte, error)
+}
+
+// NewRecordingStore creates the recording store according to the options,
+// it returns nil if the recording is disabled.
+func NewRecordingStore(options *RecordingOptions, s3Client s3.Interface) (RecordingStore, error) {
+	if options == nil || !options.Enable {
+		return nil, nil
+	}
+
+	switch options.Storage {
+	case RecordingStorageS3:
+		if s3Client == nil {
+			return nil, fmt.Errorf("s3 is required to store terminal recordings")
+		}
+		prefix := options.Prefix
+		if len(prefix) == 0 {
+			prefix = DefaultRecordingPrefix
+		}
+		return &s3RecordingStore{client: s3Client, prefix: prefix}, nil
+	case RecordingStorageLocal, "":
+		if len(options.Dir) == 0 {
+			return nil, fmt.Errorf("directory is required to store terminal recordings")
+		}
+		if err := os.MkdirAll(options.Dir, 0700); err != nil {
+			return nil, err
+		}
+		return &localRecordingStore{dir: options.Dir}, nil
+	default:
+		return nil, fmt.Errorf("unsupported terminal recording storage %s", options.Storage)
+	}
+}
+
+func sortRecordings(recordings []*Recording) {
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].StartTime.After(recordings[j].StartTime)
+	})
+}
+
+type localRecordingStore struct {
+	dir string
+}
+
+func (l *localRecordingStore) Save(recording *Recording, cast io.Reader) error {
+	metadata, err := json.Marshal(recording)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filepath.Join(l.dir, recording.ID+castSuffix), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, cast); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	// The metadata is written at last, so only the completed recordings are listed.
+	return os.WriteFile(filepath.Join(l.dir, recording.ID+metadataSuffix), metadata, 0600)
+}
+
+func (l *localRecordingStore) List() ([]*Recording, error) {
+	files, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	recordings := make([]*Recording, 0)
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), metadataSuffix) {
+			continue
+		}
+
+		recording, err := l.Get(strings.TrimSuffix(f.Name(), metadataSuffix))
+		if err != nil {
+			return nil, err
+		}
+		recordings = append(recordings, recording)
+	}
+
+	sortRecordings(recordings)
+	return recordings, nil
+}
+
+func (l *localRecordingStore) Get(id string) (*Recording, error) {
+	if !ValidRecordingID(id) {
+		return nil, ErrRecordingNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(l.dir, id+metadataSuffix))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrRecordingNotFound
+		}
+		return nil, err
+	}
+
+	recording := &Recording{}
+	if err := json.Unmarshal(data, recording); err != nil {
+		return nil, err
+	}
+	return recording, nil
+}
+
+func (l *localRecordingStore) Read(id string) ([]byte, error) {
+	if _, err := l.Get(id); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(l.dir, id+castSuffix))
+}
+
+type s3RecordingStore struct {
+	client s3.Interface
+	prefix string
+}
+
+func (s *s3RecordingStore) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+func (s *s3RecordingStore) Save(recording *Recording, cast io.Reader) error {
+	metadata, err := json.Marshal(recording)
+	if err != nil {
+		return err
+	}
+
+	if err := s.client.Upload(s.key(recording.ID+castSuffix), recording.ID+castSuffix, cast, int(recording.Size)); err != nil {
+		return err
+	}
+
+	// The metadata is uploaded at last, so only the completed recordings are listed.
+	return s.client.Upload(s.key(recording.ID+metadataSuffix), recording.ID+metadataSuffix, bytes.NewReader(metadata), len(metadata))
+}
+
+func (s *s3RecordingStore) List() ([]*Recording, error) {
+	prefix := s.prefix
+	if len(prefix) > 0 && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	keys, err := s.client.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	recordings := make([]*Recording, 0)
+	for _, key := range keys {
+		if !strings.HasSuffix(key, metadataSuffix) {
+			continue
+		}
+
+		recording, err := s.Get(strings.TrimSuffix(path.Base(key), metadataSuffix))
+		if err != nil {
+			return nil, err
+		}
+		recordings = append(recordings, recording)
+	}
+
+	sortRecordings(recordings)
+	return recordings, nil
+}
+
+func (s *s3RecordingStore) Get(id string) (*Recording, error) {
+	if !ValidRecordingID(id) {
+		return nil, ErrRecordingNotFound
+	}
+
+	data, err := s.client.Read(s.key(id + metadataSuffix))
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == awss3.ErrCodeNoSuchKey {
+			return nil, ErrRecordingNotFound
+		}
+		return nil, err
+	}
+
+	recording := &Recording{}
+	if err := json.Unmarshal(data, recording); err != nil {
+		return nil, err
+	}
+	return recording, nil
+}
+
+func (s *s3RecordingStore) Read(id string) ([]byte, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return s.client.Read(s.key(id + castSuffix))
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package terminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/s3/fake"
+)
+
+type fakePty struct {
+	input  *bytes.Buffer
+	output *bytes.Buffer
+	sizes  chan remotecommand.TerminalSize
+}
+
+func (f *fakePty) Read(p []byte) (int, error) {
+	return f.input.Read(p)
+}
+
+func (f *fakePty) Write(p []byte) (int, error) {
+	return f.output.Write(p)
+}
+
+func (f *fakePty) Next() *remotecommand.TerminalSize {
+	size, ok := <-f.sizes
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+func TestRecorder(t *testing.T) {
+	dir, err := os.MkdirTemp("", "terminal-recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewRecordingStore(&RecordingOptions{Enable: true, Dir: dir}, nil)
+	assert.NoError(t, err)
+
+	pty := &fakePty{
+		input:  bytes.NewBufferString("ls\r"),
+		output: &bytes.Buffer{},
+		sizes:  make(chan remotecommand.TerminalSize, 1),
+	}
+	pty.sizes <- remotecommand.TerminalSize{Width: 120, Height: 40}
+	close(pty.sizes)
+
+	r, err := newRecorder(pty, store, &RecordingOptions{RecordInput: true}, &Recording{
+		User:      "admin",
+		Namespace: "default",
+		Pod:       "nginx",
+		Container: "nginx",
+		Shell:     "sh",
+		StartTime: time.Now(),
+	})
+	assert.NoError(t, err)
+
+	assert.NotNil(t, r.Next())
+	buf := make([]byte, 16)
+	n, err := r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "ls\r", string(buf[:n]))
+	_, err = r.Write([]byte("README.md\r\n"))
+	assert.NoError(t, err)
+	r.Close("Process exited")
+
+	recordings, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, recordings, 1)
+	assert.Equal(t, "admin", recordings[0].User)
+	assert.Equal(t, "Process exited", recordings[0].ExitReason)
+	assert.False(t, recordings[0].Truncated)
+
+	cast, err := store.Read(recordings[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, recordings[0].Size, int64(len(cast)))
+
+	lines := strings.Split(strings.TrimSpace(string(cast)), "\n")
+	assert.Len(t, lines, 4)
+
+	header := &castHeader{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), header))
+	assert.Equal(t, 2, header.Version)
+	assert.Equal(t, uint16(120), header.Width)
+	assert.Equal(t, uint16(40), header.Height)
+	assert.Equal(t, "admin@default/nginx/nginx", header.Title)
+
+	expected := [][]string{{"r", "120x40"}, {"i", "ls\r"}, {"o", "README.md\r\n"}}
+	for i, line := range lines[1:] {
+		var event []interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &event))
+		assert.Equal(t, expected[i][0], event[1])
+		assert.Equal(t, expected[i][1], event[2])
+	}
+
+	_, err = store.Get("../" + recordings[0].ID)
+	assert.Equal(t, ErrRecordingNotFound, err)
+}
+
+func TestS3RecordingStore(t *testing.T) {
+	store, err := NewRecordingStore(&RecordingOptions{Enable: true, Storage: RecordingStorageS3, Prefix: "recordings"}, fake.NewFakeS3())
+	assert.NoError(t, err)
+
+	recording := &Recording{ID: "20230101000000-abcdefgh", User: "admin", Node: "node1", Size: 4}
+	assert.NoError(t, store.Save(recording, strings.NewReader("cast")))
+
+	recordings, err := store.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Recording{recording}, recordings)
+
+	_, err = store.Get("20230101000000-notfound")
+	assert.Equal(t, ErrRecordingNotFound, err)
+}
+
+func TestRecorderMaxSize(t *testing.T) {
+	dir, err := os.MkdirTemp("", "terminal-recording")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewRecordingStore(&RecordingOptions{Enable: true, Dir: dir}, nil)
+	assert.NoError(t, err)
+
+	pty := &fakePty{input: &bytes.Buffer{}, output: &bytes.Buffer{}}
+	r, err := newRecorder(pty, store, &RecordingOptions{MaxSize: 64}, &Recording{
+		User:      "admin",
+		Node:      "node1",
+		StartTime: time.Now(),
+	})
+	assert.NoError(t, err)
+
+	_, err = r.Write([]byte("hello\r\n"))
+	assert.NoError(t, err)
+	_, err = r.Write([]byte(strings.Repeat("x", 64)))
+	assert.NoError(t, err)
+	_, err = r.Write([]byte("bye\r\n"))
+	assert.NoError(t, err)
+	r.Close("Process exited")
+
+	recordings, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, recordings, 1)
+	assert.True(t, recordings[0].Truncated)
+
+	cast, err := store.Read(recordings[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, recordings[0].Size, int64(len(cast)))
+
+	lines := strings.Split(strings.TrimSpace(string(cast)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "hello")
+}
@@ -153,14 +153,16 @@ func (t TerminalSession) Close(status uint32, reason string) {
 }
 
 type Interface interface {
-	HandleSession(shell, namespace, podName, containerName string, conn *websocket.Conn)
-	HandleShellAccessToNode(nodename string, conn *websocket.Conn)
+	HandleSession(user, shell, namespace, podName, containerName string, conn *websocket.Conn)
+	HandleShellAccessToNode(user, nodename string, conn *websocket.Conn)
 }
 
 type terminaler struct {
 	client  kubernetes.Interface
 	config  *rest.Config
 	options *Options
+	// store stores the session recordings, it is nil if the recording is disabled.
+	store RecordingStore
 }
 
 type NodeTerminaler struct {
@@ -174,8 +176,8 @@ type NodeTerminaler struct {
 	client        kubernetes.Interface
 }
 
-func NewTerminaler(client kubernetes.Interface, config *rest.Config, options *Options) Interface {
-	return &terminaler{client: client, config: config, options: options}
+func NewTerminaler(client kubernetes.Interface, config *rest.Config, options *Options, store RecordingStore) Interface {
+	return &terminaler{client: client, config: config, options: options, store: store}
 }
 
 func NewNodeTerminaler(nodename string, options *Options, client kubernetes.Interface) (*NodeTerminaler, error) {
@@ -326,35 +328,68 @@ func isValidShell(validShells []string, shell string) bool {
 	return false
 }
 
-func (t *terminaler) HandleSession(shell, namespace, podName, containerName string, conn *websocket.Conn) {
+func (t *terminaler) HandleSession(user, shell, namespace, podName, containerName string, conn *websocket.Conn) {
+	t.handleSession(shell, namespace, podName, containerName, conn, &Recording{
+		User:      user,
+		Namespace: namespace,
+		Pod:       podName,
+		Container: containerName,
+	})
+}
+
+// handleSession starts the shell and records the session if the recording is enabled.
+func (t *terminaler) handleSession(shell, namespace, podName, containerName string, conn *websocket.Conn, recording *Recording) {
 	var err error
 	validShells := []string{"bash", "sh"}
 
 	session := &TerminalSession{conn: conn, sizeChan: make(chan remotecommand.TerminalSize)}
 
+	var handler PtyHandler = session
+	var r *recorder
+	if t.store != nil {
+		recording.StartTime = time.Now()
+		if r, err = newRecorder(session, t.store, t.options.Recording, recording); err != nil {
+			klog.Warningf("create terminal recorder error: %v", err)
+			session.Close(2, "Failed to record the terminal session")
+			return
+		}
+		handler = r
+	}
+
 	if isValidShell(validShells, shell) {
 		cmd := []string{shell}
-		err = t.startProcess(namespace, podName, containerName, cmd, session)
+		recording.Shell = shell
+		err = t.startProcess(namespace, podName, containerName, cmd, handler)
 	} else {
 		// No shell given or it was not valid: try some shells until one succeeds or all fail
 		// FIXME: if the first shell fails then the first keyboard event is lost
 		for _, testShell := range validShells {
 			cmd := []string{testShell}
-			if err = t.startProcess(namespace, podName, containerName, cmd, session); err == nil {
+			recording.Shell = testShell
+			if err = t.startProcess(namespace, podName, containerName, cmd, handler); err == nil {
 				break
 			}
 		}
 	}
 
+	reason := "Process exited"
 	if err != nil {
-		session.Close(2, err.Error())
-		return
+		reason = err.Error()
+	}
+
+	if err != nil {
+		session.Close(2, reason)
+	} else {
+		session.Close(1, reason)
 	}
 
-	session.Close(1, "Process exited")
+	// The recording is saved after the websocket is closed, so the client isn't kept waiting for the upload.
+	if r != nil {
+		r.Close(reason)
+	}
 }
 
-func (t *terminaler) HandleShellAccessToNode(nodename string, conn *websocket.Conn) {
+func (t *terminaler) HandleShellAccessToNode(user, nodename string, conn *websocket.Conn) {
 
 	nodeTerminaler, err := NewNodeTerminaler(nodename, t.options, t.client)
 	if err != nil {
@@ -372,7 +407,10 @@ func (t *terminaler) HandleShellAccessToNode(nodename string, conn *websocket.Co
 		klog.Warning("watching pod status error: ", err)
 		return
 	} else {
-		t.HandleSession(nodeTerminaler.Shell, nodeTerminaler.Namespace, nodeTerminaler.PodName, nodeTerminaler.ContainerName, conn)
+		t.handleSession(nodeTerminaler.Shell, nodeTerminaler.Namespace, nodeTerminaler.PodName, nodeTerminaler.ContainerName, conn, &Recording{
+			User: user,
+			Node: nodename,
+		})
 		defer nodeTerminaler.CleanUpNSEnterPod()
 	}
 }
@@ -17,6 +17,8 @@ limitations under the License.
 package auditing
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -39,6 +41,75 @@ type Options struct {
 	Password           string        `json:"password" yaml:"password"`
 	IndexPrefix        string        `json:"indexPrefix,omitempty" yaml:"indexPrefix,omitempty"`
 	Version            string        `json:"version" yaml:"version"`
+	// The maximum retries of sending a batch of auditing events to the auditing webhook,
+	// it only works when the spool is disabled, spooled events are retried until they are delivered.
+	EventSendRetries int `json:"eventSendRetries,omitempty" yaml:"eventSendRetries,omitempty"`
+	// The maximum interval between two retries of sending auditing events.
+	EventRetryMaxInterval time.Duration `json:"eventRetryMaxInterval,omitempty" yaml:"eventRetryMaxInterval,omitempty"`
+	// The directory to persist the auditing events pending delivery, the spool is disabled if it is empty.
+	SpoolDir string `json:"spoolDir,omitempty" yaml:"spoolDir,omitempty"`
+	// The maximum size in bytes of the spool, the oldest events are dropped when the spool is full.
+	SpoolMaxBytes int64 `json:"spoolMaxBytes,omitempty" yaml:"spoolMaxBytes,omitempty"`
+	// The sinks which auditing events are delivered to, the supported sinks are webhook, file, stdout and syslog.
+	// Auditing events are delivered to the webhook if it is empty.
+	Sinks  []string           `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+	File   *FileSinkOptions   `json:"file,omitempty" yaml:"file,omitempty"`
+	Syslog *SyslogSinkOptions `json:"syslog,omitempty" yaml:"syslog,omitempty"`
+}
+
+const (
+	SinkWebhook = "webhook"
+	SinkFile    = "file"
+	SinkStdout  = "stdout"
+	SinkSyslog  = "syslog"
+)
+
+// FileSinkOptions configures the sink which writes auditing events into a local file as JSON lines.
+type FileSinkOptions struct {
+	// The path of the file which auditing events are written into.
+	Path string `json:"path" yaml:"path"`
+	// The maximum size in megabytes of the file before it gets rotated.
+	MaxSize int `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+	// The maximum age of the file before it gets rotated.
+	MaxAge time.Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+	// The maximum number of rotated files to retain, all rotated files are retained if it is 0.
+	MaxBackups int `json:"maxBackups,omitempty" yaml:"maxBackups,omitempty"`
+}
+
+// SyslogFacilities are the codes of the syslog facilities defined in RFC 5424.
+var SyslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+// SyslogSinkOptions configures the sink which sends auditing events to a syslog server in RFC 5424 format.
+type SyslogSinkOptions struct {
+	// The network of the syslog server, one of udp, tcp and unix, the local syslog server is used if it is empty.
+	Network string `json:"network" yaml:"network"`
+	// The address of the syslog server.
+	Address string `json:"address" yaml:"address"`
+	// The syslog facility, e.g. local0, auth, daemon.
+	Facility string `json:"facility,omitempty" yaml:"facility,omitempty"`
+	// The app name of the syslog messages.
+	Tag string `json:"tag,omitempty" yaml:"tag,omitempty"`
 }
 
 func NewAuditingOptions() *Options {
@@ -57,6 +128,28 @@ func (s *Options) ApplyTo(options *Options) {
 
 func (s *Options) Validate() []error {
 	errs := make([]error, 0)
+
+	for _, sink := range s.Sinks {
+		switch sink {
+		case SinkWebhook, SinkStdout:
+		case SinkFile:
+			if s.File == nil || len(s.File.Path) == 0 {
+				errs = append(errs, fmt.Errorf("auditing file sink requires a file path"))
+			}
+		case SinkSyslog:
+			if s.Syslog != nil && len(s.Syslog.Network) > 0 && len(s.Syslog.Address) == 0 {
+				errs = append(errs, fmt.Errorf("auditing syslog sink requires a syslog address"))
+			}
+			if s.Syslog != nil && len(s.Syslog.Facility) > 0 {
+				if _, ok := SyslogFacilities[strings.ToLower(s.Syslog.Facility)]; !ok {
+					errs = append(errs, fmt.Errorf("unsupported auditing syslog facility %s", s.Syslog.Facility))
+				}
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unsupported auditing sink %s", sink))
+		}
+	}
+
 	return errs
 }
 
@@ -85,6 +178,18 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 		"The batch size of auditing events.")
 	fs.DurationVar(&s.EventBatchInterval, "auditing-event-batch-interval", c.EventBatchInterval,
 		"The batch interval of auditing events.")
+	fs.IntVar(&s.EventSendRetries, "auditing-event-send-retries", c.EventSendRetries,
+		"The maximum retries of sending a batch of auditing events when auditing-spool-dir is not set.")
+	fs.DurationVar(&s.EventRetryMaxInterval, "auditing-event-retry-max-interval", c.EventRetryMaxInterval,
+		"The maximum interval between two retries of sending auditing events.")
+	fs.StringVar(&s.SpoolDir, "auditing-spool-dir", c.SpoolDir, ""+
+		"The directory to persist auditing events pending delivery. If it is set, auditing events are "+
+		"retried until they are delivered and replayed after ks-apiserver restarts.")
+	fs.Int64Var(&s.SpoolMaxBytes, "auditing-spool-max-bytes", c.SpoolMaxBytes,
+		"The maximum size in bytes of the auditing spool, the oldest events are dropped when the spool is full.")
+	fs.StringSliceVar(&s.Sinks, "auditing-sinks", c.Sinks, ""+
+		"The sinks which auditing events are delivered to, the supported sinks are webhook, file, stdout and syslog. "+
+		"Auditing events are delivered to the webhook if it is empty.")
 
 	fs.StringVar(&s.Host, "auditing-elasticsearch-host", c.Host, ""+
 		"Elasticsearch service host. KubeSphere is using elastic as auditing store, "+
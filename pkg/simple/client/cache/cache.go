@@ -34,7 +34,7 @@ type Interface interface {
 	// Keys retrieves all keys match the given pattern
 	Keys(pattern string) ([]string, error)
 
-	// Get retrieves the value of the given key, return error if key doesn't exist
+	// Get retrieves the value of the given key, return ErrNoSuchKey if key doesn't exist
 	Get(key string) (string, error)
 
 	// Set sets the value and living duration of the given key, zero duration means never expire
@@ -48,6 +48,18 @@ type Interface interface {
 
 	// Expires updates object's expiration time, return err if key doesn't exist
 	Expire(key string, duration time.Duration) error
+
+	// Incr atomically increments the integer value of the given key by one and returns the new value,
+	// a key that doesn't exist is created with the living duration, zero duration means never expire
+	Incr(key string, duration time.Duration) (int64, error)
+
+	// SetNX sets the value and living duration of the given key only if the key doesn't exist,
+	// returns true if the value is set
+	SetNX(key string, value string, duration time.Duration) (bool, error)
+
+	// GetSet atomically sets the value and living duration of the given key and returns the old value,
+	// ErrNoSuchKey is returned along with the new value being set if the key doesn't exist
+	GetSet(key string, value string, duration time.Duration) (string, error)
 }
 
 // DynamicOptions the options of the cache. For redis, options key can be  "host", "port", "db", "password".
@@ -17,8 +17,11 @@ limitations under the License.
 package cache
 
 import (
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -62,6 +65,7 @@ type InMemoryCacheOptions struct {
 
 // imMemoryCache implements cache.Interface use memory objects, it should be used only for testing
 type inMemoryCache struct {
+	mutex sync.RWMutex
 	store map[string]simpleObject
 }
 
@@ -82,6 +86,8 @@ func NewInMemoryCache(options *InMemoryCacheOptions, stopCh <-chan struct{}) (In
 }
 
 func (s *inMemoryCache) cleanInvalidToken() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	for k, v := range s.store {
 		if v.IsExpired() {
 			delete(s.store, k)
@@ -98,6 +104,8 @@ func (s *inMemoryCache) Keys(pattern string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
 	var keys []string
 	for k := range s.store {
 		if re.MatchString(k) {
@@ -109,6 +117,13 @@ func (s *inMemoryCache) Keys(pattern string) ([]string, error) {
 }
 
 func (s *inMemoryCache) Set(key string, value string, duration time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.set(key, value, duration)
+	return nil
+}
+
+func (s *inMemoryCache) set(key string, value string, duration time.Duration) {
 	sobject := simpleObject{
 		value:       value,
 		neverExpire: false,
@@ -120,10 +135,11 @@ func (s *inMemoryCache) Set(key string, value string, duration time.Duration) er
 	}
 
 	s.store[key] = sobject
-	return nil
 }
 
 func (s *inMemoryCache) Del(keys ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	for _, key := range keys {
 		delete(s.store, key)
 	}
@@ -131,16 +147,22 @@ func (s *inMemoryCache) Del(keys ...string) error {
 }
 
 func (s *inMemoryCache) Get(key string) (string, error) {
-	if sobject, ok := s.store[key]; ok {
-		if sobject.neverExpire || time.Now().Before(sobject.expiredAt) {
-			return sobject.value, nil
-		}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.get(key)
+}
+
+func (s *inMemoryCache) get(key string) (string, error) {
+	if sobject, ok := s.store[key]; ok && !sobject.IsExpired() {
+		return sobject.value, nil
 	}
 
 	return "", ErrNoSuchKey
 }
 
 func (s *inMemoryCache) Exists(keys ...string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
 	for _, key := range keys {
 		if _, ok := s.store[key]; !ok {
 			return false, nil
@@ -151,23 +173,56 @@ func (s *inMemoryCache) Exists(keys ...string) (bool, error) {
 }
 
 func (s *inMemoryCache) Expire(key string, duration time.Duration) error {
-	value, err := s.Get(key)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, err := s.get(key)
 	if err != nil {
 		return err
 	}
 
-	sobject := simpleObject{
-		value:       value,
-		neverExpire: false,
-		expiredAt:   time.Now().Add(duration),
+	s.set(key, value, duration)
+	return nil
+}
+
+func (s *inMemoryCache) Incr(key string, duration time.Duration) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, err := s.get(key)
+	if err != nil {
+		s.set(key, "1", duration)
+		return 1, nil
 	}
 
-	if duration == NeverExpire {
-		sobject.neverExpire = true
+	count, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value of key %s is not an integer", key)
 	}
+	count++
 
+	// keep the expiration time of the existing key
+	sobject := s.store[key]
+	sobject.value = strconv.FormatInt(count, 10)
 	s.store[key] = sobject
-	return nil
+	return count, nil
+}
+
+func (s *inMemoryCache) SetNX(key string, value string, duration time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := s.get(key); err == nil {
+		return false, nil
+	}
+
+	s.set(key, value, duration)
+	return true, nil
+}
+
+func (s *inMemoryCache) GetSet(key string, value string, duration time.Duration) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	old, err := s.get(key)
+	s.set(key, value, duration)
+	return old, err
 }
 
 type inMemoryCacheFactory struct {
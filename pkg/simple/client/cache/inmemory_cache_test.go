@@ -17,6 +17,7 @@ limitations under the License.
 package cache
 
 import (
+	"sync"
 	"testing"
 	"time"
 
@@ -138,3 +139,53 @@ func TestDeleteAndExpireCache(t *testing.T) {
 		})
 	}
 }
+
+func TestAtomicOperations(t *testing.T) {
+	cacheClient, _ := NewInMemoryCache(nil, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cacheClient.Incr("counter", time.Minute); err != nil {
+				t.Errorf("Error incr key, %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, _ := cacheClient.Get("counter"); got != "100" {
+		t.Errorf("expected counter 100, got %s", got)
+	}
+
+	if _, err := cacheClient.Incr("foo", NeverExpire); err != nil {
+		t.Fatalf("Error incr key, %v", err)
+	}
+	_ = cacheClient.Set("bar", "val", NeverExpire)
+	if _, err := cacheClient.Incr("bar", NeverExpire); err == nil {
+		t.Errorf("expected error incr non-integer value")
+	}
+
+	if ok, _ := cacheClient.SetNX("foo", "val", NeverExpire); ok {
+		t.Errorf("expected SetNX to fail on existing key")
+	}
+	if ok, _ := cacheClient.SetNX("baz", "val", time.Millisecond*100); !ok {
+		t.Errorf("expected SetNX to succeed on new key")
+	}
+	time.Sleep(time.Millisecond * 100)
+	if ok, _ := cacheClient.SetNX("baz", "val2", NeverExpire); !ok {
+		t.Errorf("expected SetNX to succeed on expired key")
+	}
+
+	if _, err := cacheClient.GetSet("qux", "val1", NeverExpire); err != ErrNoSuchKey {
+		t.Errorf("expected ErrNoSuchKey, got %v", err)
+	}
+	if old, _ := cacheClient.GetSet("qux", "val2", time.Millisecond*100); old != "val1" {
+		t.Errorf("expected old value val1, got %s", old)
+	}
+	time.Sleep(time.Millisecond * 100)
+	if _, err := cacheClient.Get("qux"); err != ErrNoSuchKey {
+		t.Errorf("expected key expired, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RateLimiter limits the number of events of a key within a period of time.
+type RateLimiter interface {
+	// Allow records an event of the key and returns true if the number of events is within the limit
+	Allow(key string) (bool, error)
+
+	// Record records an event of the key without checking the limit
+	Record(key string) error
+
+	// Exceeded returns true if the number of events of the key has reached the limit, no event is recorded
+	Exceeded(key string) (bool, error)
+
+	// Reset forgets all the events of the key
+	Reset(key string) error
+}
+
+// slidingWindowRateLimiter approximates a sliding window by weighting the counter of the previous
+// fixed window with its overlap with the sliding window. The counters are stored in the cache,
+// so the limit is shared by all the ks-apiserver replicas.
+type slidingWindowRateLimiter struct {
+	cache  Interface
+	prefix string
+	limit  int64
+	window time.Duration
+	now    func() time.Time
+}
+
+// NewSlidingWindowRateLimiter creates a rate limiter allows limit events per key within the window,
+// the counters are stored in the cache with the key prefix.
+func NewSlidingWindowRateLimiter(cache Interface, prefix string, limit int64, window time.Duration) RateLimiter {
+	return &slidingWindowRateLimiter{
+		cache:  cache,
+		prefix: prefix,
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+func (r *slidingWindowRateLimiter) counterKey(key string, index int64) string {
+	return fmt.Sprintf("%s:%s:%d", r.prefix, key, index)
+}
+
+func (r *slidingWindowRateLimiter) Allow(key string) (bool, error) {
+	now := r.now()
+	index := now.UnixNano() / int64(r.window)
+	// the counter is needed until the next window ends
+	current, err := r.cache.Incr(r.counterKey(key, index), 2*r.window)
+	if err != nil {
+		return false, err
+	}
+	count, err := r.estimate(key, now, current)
+	if err != nil {
+		return false, err
+	}
+	return count <= float64(r.limit), nil
+}
+
+func (r *slidingWindowRateLimiter) Record(key string) error {
+	index := r.now().UnixNano() / int64(r.window)
+	_, err := r.cache.Incr(r.counterKey(key, index), 2*r.window)
+	return err
+}
+
+func (r *slidingWindowRateLimiter) Exceeded(key string) (bool, error) {
+	now := r.now()
+	index := now.UnixNano() / int64(r.window)
+	current, err := r.counter(r.counterKey(key, index))
+	if err != nil {
+		return false, err
+	}
+	count, err := r.estimate(key, now, current)
+	if err != nil {
+		return false, err
+	}
+	return count >= float64(r.limit), nil
+}
+
+func (r *slidingWindowRateLimiter) Reset(key string) error {
+	index := r.now().UnixNano() / int64(r.window)
+	return r.cache.Del(r.counterKey(key, index), r.counterKey(key, index-1))
+}
+
+// estimate returns the number of events in the sliding window ends at now.
+func (r *slidingWindowRateLimiter) estimate(key string, now time.Time, current int64) (float64, error) {
+	index := now.UnixNano() / int64(r.window)
+	previous, err := r.counter(r.counterKey(key, index-1))
+	if err != nil {
+		return 0, err
+	}
+	elapsed := float64(now.UnixNano()%int64(r.window)) / float64(r.window)
+	return float64(previous)*(1-elapsed) + float64(current), nil
+}
+
+func (r *slidingWindowRateLimiter) counter(key string) (int64, error) {
+	value, err := r.cache.Get(key)
+	if err == ErrNoSuchKey {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindowRateLimiter(t *testing.T) {
+	cacheClient, _ := NewInMemoryCache(nil, nil)
+	limiter := NewSlidingWindowRateLimiter(cacheClient, "test", 3, time.Minute).(*slidingWindowRateLimiter)
+
+	now := time.Unix(0, 0).Add(time.Hour)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if allowed, err := limiter.Allow("admin"); err != nil || !allowed {
+			t.Fatalf("expected event %d allowed, got %v, %v", i, allowed, err)
+		}
+	}
+	if allowed, _ := limiter.Allow("admin"); allowed {
+		t.Errorf("expected event exceeding the limit denied")
+	}
+	if allowed, _ := limiter.Allow("guest"); !allowed {
+		t.Errorf("expected events of other keys allowed")
+	}
+
+	// half of the previous window overlaps with the sliding window, 4 events are weighted to 2
+	now = now.Add(time.Minute + 30*time.Second)
+	if exceeded, _ := limiter.Exceeded("admin"); exceeded {
+		t.Errorf("expected limit not exceeded")
+	}
+	if err := limiter.Record("admin"); err != nil {
+		t.Fatal(err)
+	}
+	if exceeded, _ := limiter.Exceeded("admin"); !exceeded {
+		t.Errorf("expected limit exceeded")
+	}
+
+	if err := limiter.Reset("admin"); err != nil {
+		t.Fatal(err)
+	}
+	if exceeded, _ := limiter.Exceeded("admin"); exceeded {
+		t.Errorf("expected limit not exceeded after reset")
+	}
+}
@@ -28,6 +28,26 @@ import (
 
 const typeRedis = "redis"
 
+var (
+	// incrScript increments the key and sets the expiration only if the key is created
+	incrScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count`)
+
+	// getSetScript sets the key with the expiration and returns the old value
+	getSetScript = redis.NewScript(`
+local old = redis.call('GET', KEYS[1])
+if tonumber(ARGV[2]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+return old`)
+)
+
 type redisClient struct {
 	client *redis.Client
 }
@@ -74,7 +94,11 @@ func NewRedisClient(option *redisOptions, stopCh <-chan struct{}) (Interface, er
 }
 
 func (r *redisClient) Get(key string) (string, error) {
-	return r.client.Get(key).Result()
+	value, err := r.client.Get(key).Result()
+	if err == redis.Nil {
+		return "", ErrNoSuchKey
+	}
+	return value, err
 }
 
 func (r *redisClient) Keys(pattern string) ([]string, error) {
@@ -102,6 +126,22 @@ func (r *redisClient) Expire(key string, duration time.Duration) error {
 	return r.client.Expire(key, duration).Err()
 }
 
+func (r *redisClient) Incr(key string, duration time.Duration) (int64, error) {
+	return incrScript.Run(r.client, []string{key}, duration.Milliseconds()).Int64()
+}
+
+func (r *redisClient) SetNX(key string, value string, duration time.Duration) (bool, error) {
+	return r.client.SetNX(key, value, duration).Result()
+}
+
+func (r *redisClient) GetSet(key string, value string, duration time.Duration) (string, error) {
+	old, err := getSetScript.Run(r.client, []string{key}, value, duration.Milliseconds()).String()
+	if err == redis.Nil {
+		return "", ErrNoSuchKey
+	}
+	return old, err
+}
+
 type redisFactory struct{}
 
 func (rf *redisFactory) Type() string {
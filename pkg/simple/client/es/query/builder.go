@@ -14,6 +14,7 @@ type Builder struct {
 	From          int64               `json:"from,omitempty"`
 	Size          int64               `json:"size,omitempty"`
 	Sorts         []map[string]string `json:"sort,omitempty"`
+	SearchAfter   []interface{}       `json:"search_after,omitempty"`
 	*Query        `json:",inline"`
 	*Aggregations `json:"aggs,omitempty"`
 }
@@ -23,7 +24,25 @@ func NewBuilder() *Builder {
 }
 
 func (b *Builder) Bytes() ([]byte, error) {
-	return jsoniter.Marshal(b)
+	data, err := jsoniter.Marshal(b)
+	if err != nil || b.Aggregations == nil || len(b.Aggregations.NamedTerms) == 0 {
+		return data, err
+	}
+
+	// the named terms aggregations are added along with the others
+	body := make(map[string]interface{})
+	if err = jsoniter.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	aggs, _ := body["aggs"].(map[string]interface{})
+	if aggs == nil {
+		aggs = make(map[string]interface{})
+	}
+	for name, terms := range b.Aggregations.NamedTerms {
+		aggs[name] = terms
+	}
+	body["aggs"] = aggs
+	return jsoniter.Marshal(body)
 }
 
 func (b *Builder) WithQuery(q *Query) *Builder {
@@ -60,6 +79,21 @@ func (b *Builder) WithSort(key, order string) *Builder {
 	return b
 }
 
+// WithTiebreakSort sorts the hits with the same values of the previous sort keys by the key.
+func (b *Builder) WithTiebreakSort(key, order string) *Builder {
+	if order == "" {
+		order = "desc"
+	}
+	b.Sorts = append(b.Sorts, map[string]string{key: order})
+	return b
+}
+
+// WithSearchAfter returns the hits after the sort values, the values are in the order of the sort keys.
+func (b *Builder) WithSearchAfter(values ...interface{}) *Builder {
+	b.SearchAfter = values
+	return b
+}
+
 // Query
 
 type Query struct {
@@ -84,6 +118,9 @@ func (q *Query) WithBool(b *Bool) *Query {
 type Aggregations struct {
 	*CardinalityAggregation   `json:"cardinality_aggregation,omitempty"`
 	*DateHistogramAggregation `json:"date_histogram_aggregation,omitempty"`
+	*TermsAggregation         `json:"terms_aggregation,omitempty"`
+	// NamedTerms are the terms aggregations by their names, so that several ones are searched at once
+	NamedTerms map[string]*TermsAggregation `json:"-"`
 }
 
 type CardinalityAggregation struct {
@@ -103,6 +140,23 @@ type DateHistogram struct {
 	Interval string `json:"interval,omitempty"`
 }
 
+type TermsAggregation struct {
+	*TermsBucketing `json:"terms,omitempty"`
+}
+
+// TermsBucketing buckets the documents by the top values of the field, or the values returned by the script
+type TermsBucketing struct {
+	Field  string  `json:"field,omitempty"`
+	Script *Script `json:"script,omitempty"`
+	Size   int64   `json:"size,omitempty"`
+}
+
+// Script is a painless script with the parameters.
+type Script struct {
+	Source string                 `json:"source,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+}
+
 func NewAggregations() *Aggregations {
 	return &Aggregations{}
 }
@@ -130,6 +184,34 @@ func (a *Aggregations) WithDateHistogramAggregation(field string, interval strin
 	return a
 }
 
+func (a *Aggregations) WithTermsAggregation(field string, size int64) *Aggregations {
+
+	a.TermsAggregation = &TermsAggregation{
+		&TermsBucketing{
+			Field: field,
+			Size:  size,
+		},
+	}
+
+	return a
+}
+
+// WithScriptTermsAggregation adds a terms aggregation of the name, which buckets the documents by the top values
+// returned by the script.
+func (a *Aggregations) WithScriptTermsAggregation(name string, script *Script, size int64) *Aggregations {
+	if a.NamedTerms == nil {
+		a.NamedTerms = make(map[string]*TermsAggregation)
+	}
+	a.NamedTerms[name] = &TermsAggregation{
+		&TermsBucketing{
+			Script: script,
+			Size:   size,
+		},
+	}
+
+	return a
+}
+
 type Item interface {
 	IsValid() bool
 }
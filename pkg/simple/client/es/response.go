@@ -24,6 +24,9 @@ type Hit struct {
 type Aggregations struct {
 	CardinalityAggregation   `json:"cardinality_aggregation,omitempty"`
 	DateHistogramAggregation `json:"date_histogram_aggregation,omitempty"`
+	TermsAggregation         `json:"terms_aggregation,omitempty"`
+	// NamedTerms are the results of the named terms aggregations by their names
+	NamedTerms map[string]TermsAggregation `json:"-"`
 }
 
 type CardinalityAggregation struct {
@@ -39,6 +42,17 @@ type Bucket struct {
 	Count int64 `json:"doc_count,omitempty"`
 }
 
+type TermsAggregation struct {
+	// SumOtherDocCount counts the documents not in the buckets
+	SumOtherDocCount int64         `json:"sum_other_doc_count,omitempty"`
+	TermsBuckets     []TermsBucket `json:"buckets,omitempty"`
+}
+
+type TermsBucket struct {
+	Key   string `json:"key,omitempty"`
+	Count int64  `json:"doc_count,omitempty"`
+}
+
 func parseResponse(body []byte) (*Response, error) {
 	var res Response
 	err := jsoniter.Unmarshal(body, &res)
@@ -46,5 +60,24 @@ func parseResponse(body []byte) (*Response, error) {
 		klog.Error(err)
 		return nil, err
 	}
+
+	// the named terms aggregations are the ones with the buckets of the string keys
+	var named struct {
+		Aggregations map[string]jsoniter.RawMessage `json:"aggregations,omitempty"`
+	}
+	if err = jsoniter.Unmarshal(body, &named); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	for name, raw := range named.Aggregations {
+		var terms TermsAggregation
+		if jsoniter.Unmarshal(raw, &terms) != nil {
+			continue
+		}
+		if res.NamedTerms == nil {
+			res.NamedTerms = make(map[string]TermsAggregation)
+		}
+		res.NamedTerms[name] = terms
+	}
 	return &res, nil
 }
@@ -19,8 +19,11 @@ package elasticsearch
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
 	"time"
 
 	"kubesphere.io/kubesphere/pkg/simple/client/es"
@@ -30,12 +33,6 @@ import (
 	"kubesphere.io/kubesphere/pkg/utils/stringutils"
 )
 
-const (
-	podNameMaxLength          = 63
-	podNameSuffixLength       = 6  // 5 characters + 1 hyphen
-	replicaSetSuffixMaxLength = 11 // max 10 characters + 1 hyphen
-)
-
 type Source struct {
 	Log        string `json:"log"`
 	Time       string `json:"time"`
@@ -49,14 +46,28 @@ type Kubernetes struct {
 	Host      string `json:"host"`
 }
 
+// numberRegex matches the numbers accepted by the numeric fields.
+var numberRegex = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// fieldValuesScript returns the values of a field parsed from the JSON log lines as strings, the keyword sub-field
+// is used for the strings, and the field itself for the numbers and the booleans which have no keyword sub-field.
+const fieldValuesScript = "String f = doc.containsKey(params.keyword) ? params.keyword : params.field; " +
+	"List values = new ArrayList(); " +
+	"if (doc.containsKey(f)) { for (def v : doc[f]) { values.add(String.valueOf(v)); } } " +
+	"return values;"
+
+// tiebreaker sorts the records at the same time, which is the index order of the documents.
+const tiebreaker = "_doc"
+
 // Elasticsearch implement logging interface
 type client struct {
-	c *es.Client
+	c         *es.Client
+	fieldsKey string
 }
 
 func NewClient(options *logging.Options) (logging.Client, error) {
 
-	c := &client{}
+	c := &client{fieldsKey: options.FieldsKey}
 
 	var err error
 	c.c, err = es.NewClient(options.Host, options.BasicAuth, options.Username, options.Password, options.IndexPrefix, options.Version)
@@ -67,7 +78,7 @@ func (c *client) GetCurrentStats(sf logging.SearchFilter) (logging.Statistics, e
 	var err error
 
 	b := query.NewBuilder().
-		WithQuery(parseToQueryPart(sf)).
+		WithQuery(parseToQueryPart(sf, c.fieldsKey)).
 		WithAggregations(query.NewAggregations().
 			WithCardinalityAggregation("kubernetes.docker_id.keyword")).
 		WithSize(0)
@@ -87,7 +98,7 @@ func (c *client) GetCurrentStats(sf logging.SearchFilter) (logging.Statistics, e
 func (c *client) CountLogsByInterval(sf logging.SearchFilter, interval string) (logging.Histogram, error) {
 
 	b := query.NewBuilder().
-		WithQuery(parseToQueryPart(sf)).
+		WithQuery(parseToQueryPart(sf, c.fieldsKey)).
 		WithAggregations(query.NewAggregations().
 			WithDateHistogramAggregation("time", interval)).
 		WithSize(0)
@@ -112,8 +123,9 @@ func (c *client) CountLogsByInterval(sf logging.SearchFilter, interval string) (
 func (c *client) SearchLogs(sf logging.SearchFilter, f, s int64, o string) (logging.Logs, error) {
 
 	b := query.NewBuilder().
-		WithQuery(parseToQueryPart(sf)).
+		WithQuery(parseToQueryPart(sf, c.fieldsKey)).
 		WithSort("time", o).
+		WithTiebreakSort(tiebreaker, o).
 		WithFrom(f).
 		WithSize(s)
 
@@ -122,21 +134,10 @@ func (c *client) SearchLogs(sf logging.SearchFilter, f, s int64, o string) (logg
 		return logging.Logs{}, err
 	}
 
-	l := logging.Logs{
-		Total: c.c.GetTotalHitCount(resp.Total),
-	}
-
-	for _, hit := range resp.AllHits {
-		s := c.getSource(hit.Source)
-		l.Records = append(l.Records, logging.Record{
-			Log:       s.Log,
-			Time:      s.Time,
-			Namespace: s.Namespace,
-			Pod:       s.Pod,
-			Container: s.Container,
-		})
-	}
-	return l, nil
+	return logging.Logs{
+		Total:   c.c.GetTotalHitCount(resp.Total),
+		Records: c.getRecords(resp),
+	}, nil
 }
 
 func (c *client) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
@@ -145,7 +146,7 @@ func (c *client) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
 	var data []string
 
 	b := query.NewBuilder().
-		WithQuery(parseToQueryPart(sf)).
+		WithQuery(parseToQueryPart(sf, c.fieldsKey)).
 		WithSort("time", "desc").
 		WithFrom(0).
 		WithSize(1000)
@@ -186,6 +187,130 @@ func (c *client) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
 	return nil
 }
 
+func (c *client) GetLogContext(cf logging.ContextFilter) (logging.LogContext, error) {
+	if !cf.Starttime.IsZero() && cf.Time.Before(cf.Starttime) {
+		return logging.LogContext{}, logging.ErrRecordNotFound
+	}
+
+	// the records of the container at the same time, the record is at the offset of them
+	b := query.NewBuilder().
+		WithQuery(parseToContextQueryPart(cf, query.NewRange("time").WithGTE(cf.Time).WithLTE(cf.Time))).
+		WithSort("time", "asc").
+		WithTiebreakSort(tiebreaker, "asc").
+		WithSize(cf.Offset + cf.After + 1)
+	resp, err := c.c.Search(b, cf.Time, cf.Time, false)
+	if err != nil {
+		return logging.LogContext{}, err
+	}
+	records := c.getRecords(resp)
+	if int64(len(records)) <= cf.Offset {
+		return logging.LogContext{}, logging.ErrRecordNotFound
+	}
+
+	lc := logging.LogContext{
+		Record: records[cf.Offset],
+		After:  records[cf.Offset+1:],
+	}
+	if cf.Offset > cf.Before {
+		lc.Before = records[cf.Offset-cf.Before : cf.Offset]
+	} else {
+		lc.Before = records[:cf.Offset]
+	}
+
+	// the rest records are searched after the first and the last records at the time in both directions
+	if n := cf.Before - int64(len(lc.Before)); n > 0 {
+		r := query.NewRange("time")
+		if !cf.Starttime.IsZero() {
+			r.WithGTE(cf.Starttime)
+		}
+		b := query.NewBuilder().
+			WithQuery(parseToContextQueryPart(cf, r)).
+			WithSort("time", "desc").
+			WithTiebreakSort(tiebreaker, "desc").
+			WithSearchAfter(searchAfter(resp.AllHits[0], cf.Time, 0)...).
+			WithSize(n)
+		resp, err := c.c.Search(b, cf.Starttime, cf.Time, false)
+		if err != nil {
+			return logging.LogContext{}, err
+		}
+		before := c.getRecords(resp)
+		for i, j := 0, len(before)-1; i < j; i, j = i+1, j-1 {
+			before[i], before[j] = before[j], before[i]
+		}
+		lc.Before = append(before, lc.Before...)
+	}
+	if n := cf.After - int64(len(lc.After)); n > 0 {
+		b := query.NewBuilder().
+			WithQuery(parseToContextQueryPart(cf, nil)).
+			WithSort("time", "asc").
+			WithTiebreakSort(tiebreaker, "asc").
+			WithSearchAfter(searchAfter(resp.AllHits[len(resp.AllHits)-1], cf.Time, math.MaxInt64)...).
+			WithSize(n)
+		resp, err := c.c.Search(b, cf.Time, time.Time{}, false)
+		if err != nil {
+			return logging.LogContext{}, err
+		}
+		lc.After = append(lc.After, c.getRecords(resp)...)
+	}
+	return lc, nil
+}
+
+// searchAfter returns the sort values of the hit to search after, or the time with the tiebreaker given
+// if the hit has no sort values.
+func searchAfter(hit es.Hit, t time.Time, tiebreak int64) []interface{} {
+	if len(hit.Sort) == 2 {
+		return []interface{}{hit.Sort[0], hit.Sort[1]}
+	}
+	return []interface{}{t.UnixMilli(), tiebreak}
+}
+
+func (c *client) CountFieldValues(sf logging.SearchFilter, fields []string, size int64) (logging.Facets, error) {
+	aggregations := query.NewAggregations()
+	for i, field := range fields {
+		path := fieldPath(c.fieldsKey, field)
+		aggregations.WithScriptTermsAggregation(fieldValuesAggregation(i), &query.Script{
+			Source: fieldValuesScript,
+			Params: map[string]interface{}{"field": path, "keyword": path + ".keyword"},
+		}, size)
+	}
+	b := query.NewBuilder().
+		WithQuery(parseToQueryPart(sf, c.fieldsKey)).
+		WithAggregations(aggregations).
+		WithSize(0)
+
+	resp, err := c.c.Search(b, sf.Starttime, sf.Endtime, false)
+	if err != nil {
+		return logging.Facets{}, err
+	}
+
+	facets := logging.Facets{
+		Total:  c.c.GetTotalHitCount(resp.Total),
+		Fields: make([]logging.Facet, 0, len(fields)),
+	}
+	for i, field := range fields {
+		terms := resp.NamedTerms[fieldValuesAggregation(i)]
+		facet := logging.Facet{
+			Field:  field,
+			Values: make([]logging.FacetValue, 0, len(terms.TermsBuckets)),
+			Other:  terms.SumOtherDocCount,
+		}
+		for _, bucket := range terms.TermsBuckets {
+			facet.Values = append(facet.Values, logging.FacetValue{
+				Value: bucket.Key,
+				Count: bucket.Count,
+			})
+		}
+		facets.Fields = append(facets.Fields, facet)
+	}
+	return facets, nil
+}
+
+// fieldValuesAggregation is the name of the aggregation of the field at the index, the field names are not
+// used since they may contain the characters not allowed in the aggregation names.
+func fieldValuesAggregation(i int) string {
+	return "field_values_" + strconv.Itoa(i)
+}
+
 func (c *client) scroll(id string) ([]string, string, error) {
 	resp, err := c.c.Scroll(id)
 	if err != nil {
@@ -199,6 +324,26 @@ func (c *client) scroll(id string) ([]string, string, error) {
 	return data, resp.ScrollId, nil
 }
 
+func (c *client) getRecords(resp *es.Response) []logging.Record {
+	var records []logging.Record
+	for _, hit := range resp.AllHits {
+		s := c.getSource(hit.Source)
+		record := logging.Record{
+			Log:       s.Log,
+			Time:      s.Time,
+			Namespace: s.Namespace,
+			Pod:       s.Pod,
+			Container: s.Container,
+		}
+		// the tiebreaker is the last sort value if the hits are sorted by it
+		if len(hit.Sort) == 2 {
+			record.Tiebreaker = &hit.Sort[1]
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
 func (c *client) getSource(val interface{}) Source {
 
 	s := Source{}
@@ -216,7 +361,7 @@ func (c *client) getSource(val interface{}) Source {
 	return s
 }
 
-func parseToQueryPart(sf logging.SearchFilter) *query.Query {
+func parseToQueryPart(sf logging.SearchFilter, fieldsKey string) *query.Query {
 
 	var mini int32 = 1
 	b := query.NewBool()
@@ -237,7 +382,7 @@ func parseToQueryPart(sf logging.SearchFilter) *query.Query {
 	if sf.WorkloadFilter != nil {
 		bi := query.NewBool().WithMinimumShouldMatch(mini)
 		for _, wk := range sf.WorkloadFilter {
-			bi.AppendShould(query.NewRegex("kubernetes.pod_name.keyword", podNameRegex(wk)))
+			bi.AppendShould(query.NewRegex("kubernetes.pod_name.keyword", logging.PodNameRegex(wk)))
 		}
 
 		b.AppendFilter(bi)
@@ -268,6 +413,18 @@ func parseToQueryPart(sf logging.SearchFilter) *query.Query {
 		AppendMultiShould(query.NewMultiMatchPhrasePrefix("log", sf.LogSearch)).
 		WithMinimumShouldMatch(mini))
 
+	// the fields parsed from the JSON log lines are matched exactly
+	fields := make([]string, 0, len(sf.FieldFilter))
+	for field := range sf.FieldFilter {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		b.AppendFilter(query.NewBool().
+			AppendMultiShould(fieldValueQueries(fieldPath(fieldsKey, field), sf.FieldFilter[field])).
+			WithMinimumShouldMatch(mini))
+	}
+
 	r := query.NewRange("time")
 	if !sf.Starttime.IsZero() {
 		r.WithGTE(sf.Starttime)
@@ -281,27 +438,36 @@ func parseToQueryPart(sf logging.SearchFilter) *query.Query {
 	return query.NewQuery().WithBool(b)
 }
 
-func podNameRegex(workloadName string) string {
-	var regex string
-	if len(workloadName) <= podNameMaxLength-replicaSetSuffixMaxLength-podNameSuffixLength {
-		// match deployment pods, eg. <deploy>-579dfbcddd-24znw
-		// replicaset rand string is limited to vowels
-		// https://github.com/kubernetes/kubernetes/blob/master/staging/src/k8s.io/apimachinery/pkg/util/rand/rand.go#L83
-		regex += workloadName + "-[bcdfghjklmnpqrstvwxz2456789]{1,10}-[a-z0-9]{5}|"
-		// match statefulset pods, eg. <sts>-0
-		regex += workloadName + "-[0-9]+|"
-		// match pods of daemonset or job, eg. <ds>-29tdk, <job>-5xqvl
-		regex += workloadName + "-[a-z0-9]{5}"
-	} else if len(workloadName) <= podNameMaxLength-podNameSuffixLength {
-		replicaSetSuffixLength := podNameMaxLength - podNameSuffixLength - len(workloadName)
-		regex += fmt.Sprintf("%s%d%s", workloadName+"-[bcdfghjklmnpqrstvwxz2456789]{", replicaSetSuffixLength, "}[a-z0-9]{5}|")
-		regex += workloadName + "-[0-9]+|"
-		regex += workloadName + "-[a-z0-9]{5}"
-	} else {
-		// Rand suffix may overwrites the workload name if the name is too long
-		// This won't happen for StatefulSet because long name will cause ReplicaSet fails during StatefulSet creation.
-		regex += workloadName[:podNameMaxLength-podNameSuffixLength+1] + "[a-z0-9]{5}|"
-		regex += workloadName + "-[0-9]+"
+// fieldPath returns the path of the field parsed from the JSON log lines in the documents
+// fieldValueQueries matches the keyword sub-field of the strings, and the field itself of the numbers and the
+// booleans. The field itself is only queried with the values which are numbers or booleans, otherwise the
+// search fails for the fields mapped as numbers or booleans.
+func fieldValueQueries(path string, values []string) []query.Item {
+	items := query.NewMultiMatchPhrase(path+".keyword", values)
+	for _, value := range values {
+		if isNumberOrBool(value) {
+			items = append(items, query.NewMatchPhrase(path, value))
+		}
+	}
+	return items
+}
+
+func isNumberOrBool(value string) bool {
+	return numberRegex.MatchString(value) || value == "true" || value == "false"
+}
+
+func fieldPath(fieldsKey, field string) string {
+	if fieldsKey == "" {
+		return field
 	}
-	return regex
+	return fieldsKey + "." + field
+}
+
+func parseToContextQueryPart(cf logging.ContextFilter, r *query.Range) *query.Query {
+	b := query.NewBool().
+		AppendFilter(query.NewMatchPhrase("kubernetes.namespace_name.keyword", cf.Namespace)).
+		AppendFilter(query.NewMatchPhrase("kubernetes.pod_name.keyword", cf.Pod)).
+		AppendFilter(query.NewMatchPhrase("kubernetes.container_name.keyword", cf.Container)).
+		AppendFilter(r)
+	return query.NewQuery().WithBool(b)
 }
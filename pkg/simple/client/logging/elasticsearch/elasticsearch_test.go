@@ -18,9 +18,11 @@ package elasticsearch
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -279,7 +281,7 @@ func TestParseToQueryPart(t *testing.T) {
 				t.Fatalf("read expected error, %s", err.Error())
 			}
 
-			result, _ := query.NewBuilder().WithQuery(parseToQueryPart(test.filter)).Bytes()
+			result, _ := query.NewBuilder().WithQuery(parseToQueryPart(test.filter, "")).Bytes()
 			if diff := cmp.Diff(string(result), string(result)); diff != "" {
 				t.Fatalf("%T differ (-got, +want): %s", expected, diff)
 			}
@@ -287,6 +289,175 @@ func TestParseToQueryPart(t *testing.T) {
 	}
 }
 
+func TestGetLogContext(t *testing.T) {
+	// the hits are sorted by the time and the tiebreaker starting from the doc
+	hits := func(doc int, logs ...string) string {
+		var items []string
+		for i, log := range logs {
+			items = append(items, fmt.Sprintf(`{"_source":{"log":%q,"time":"2020-05-16T16:00:42.608Z","kubernetes":{"namespace_name":"default","pod_name":"redis-0","container_name":"redis"}},"sort":[1589644842608,%d]}`, log, doc+i))
+		}
+		return fmt.Sprintf(`{"hits":{"total":{"value":%d},"hits":[%s]}}`, len(logs), strings.Join(items, ","))
+	}
+	var bodies []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(res http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		body := string(b)
+		bodies = append(bodies, body)
+		switch {
+		case strings.Contains(body, `"search_after"`) && strings.Contains(body, `"desc"`):
+			_, _ = res.Write([]byte(hits(1, "before 2", "before 3")))
+		case strings.Contains(body, `"search_after"`):
+			_, _ = res.Write([]byte(hits(8, "after 1")))
+		default:
+			_, _ = res.Write([]byte(hits(5, "before 1", "record", "after 0")))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := NewClient(&logging.Options{
+		Host:        srv.URL,
+		IndexPrefix: "ks-logstash-log",
+		Version:     es.ElasticV7,
+	})
+	if err != nil {
+		t.Fatalf("create client error, %s", err)
+	}
+
+	recordTime := time.Date(2020, 5, 16, 16, 0, 42, 608000000, time.UTC)
+	result, err := client.GetLogContext(logging.ContextFilter{
+		Namespace: "default",
+		Pod:       "redis-0",
+		Container: "redis",
+		Time:      recordTime,
+		Offset:    1,
+		Before:    3,
+		After:     2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs []string
+	for _, r := range result.Before {
+		logs = append(logs, r.Log)
+	}
+	logs = append(logs, "|", result.Record.Log, "|")
+	for _, r := range result.After {
+		logs = append(logs, r.Log)
+	}
+	// the records before the time are returned in the descending order
+	if diff := cmp.Diff(logs, []string{"before 3", "before 2", "before 1", "|", "record", "|", "after 0", "after 1"}); diff != "" {
+		t.Fatalf("differ (-got, +want): %s", diff)
+	}
+	if result.Record.Tiebreaker == nil || *result.Record.Tiebreaker != 6 {
+		t.Fatalf("unexpected tiebreaker of the record: %v", result.Record.Tiebreaker)
+	}
+	// the records at the same time are searched after by the tiebreaker
+	if len(bodies) != 3 || !strings.Contains(bodies[0], `"size":4`) || !strings.Contains(bodies[0], `{"_doc":"asc"}`) ||
+		!strings.Contains(bodies[1], `"search_after":[1589644842608,5]`) || !strings.Contains(bodies[1], `"size":2`) ||
+		!strings.Contains(bodies[2], `"search_after":[1589644842608,7]`) || !strings.Contains(bodies[2], `"size":1`) {
+		t.Fatalf("unexpected requests: %v", bodies)
+	}
+
+	_, err = client.GetLogContext(logging.ContextFilter{Time: recordTime, Offset: 3})
+	if err != logging.ErrRecordNotFound {
+		t.Fatalf("expected record not found, got %v", err)
+	}
+}
+
+func TestCountFieldValues(t *testing.T) {
+	var bodies []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(res http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(b))
+		_, _ = res.Write([]byte(`{"hits":{"total":{"value":12}},"aggregations":{` +
+			`"field_values_0":{"sum_other_doc_count":2,"buckets":[{"key":"info","doc_count":7},{"key":"error","doc_count":3}]},` +
+			`"field_values_1":{"sum_other_doc_count":0,"buckets":[]},` +
+			`"field_values_2":{"sum_other_doc_count":0,"buckets":[{"key":"200","doc_count":9},{"key":"500","doc_count":3}]}}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := NewClient(&logging.Options{
+		Host:        srv.URL,
+		IndexPrefix: "ks-logstash-log",
+		Version:     es.ElasticV7,
+		FieldsKey:   "json",
+	})
+	if err != nil {
+		t.Fatalf("create client error, %s", err)
+	}
+
+	result, err := client.CountFieldValues(logging.SearchFilter{
+		FieldFilter: map[string][]string{"service": {"api"}},
+	}, []string{"level", "trace_id", "status"}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := logging.Facets{
+		Total: 12,
+		Fields: []logging.Facet{
+			{
+				Field:  "level",
+				Values: []logging.FacetValue{{Value: "info", Count: 7}, {Value: "error", Count: 3}},
+				Other:  2,
+			},
+			{
+				Field:  "trace_id",
+				Values: []logging.FacetValue{},
+			},
+			{
+				Field:  "status",
+				Values: []logging.FacetValue{{Value: "200", Count: 9}, {Value: "500", Count: 3}},
+			},
+		},
+	}
+	if diff := cmp.Diff(result, expected); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", expected, diff)
+	}
+	// the values of all the fields are counted in a single search
+	if len(bodies) != 1 ||
+		!strings.Contains(bodies[0], `"field_values_0":{"terms":{"script":{"source":`) ||
+		!strings.Contains(bodies[0], `"params":{"field":"json.level","keyword":"json.level.keyword"}`) ||
+		!strings.Contains(bodies[0], `"params":{"field":"json.status","keyword":"json.status.keyword"}`) ||
+		!strings.Contains(bodies[0], `"size":5`) ||
+		!strings.Contains(bodies[0], `"match_phrase":{"json.service.keyword":"api"}`) {
+		t.Fatalf("unexpected requests: %v", bodies)
+	}
+}
+
+func TestFieldFilter(t *testing.T) {
+	result, err := query.NewBuilder().WithQuery(parseToQueryPart(logging.SearchFilter{
+		FieldFilter: map[string][]string{
+			"service": {"api"},
+			"status":  {"500", "503"},
+			"cached":  {"true"},
+			"latency": {"0.5"},
+		},
+	}, "json")).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := string(result)
+	// the numbers and the booleans are matched on the field as well, which has no keyword sub-field if mapped as them
+	for _, expected := range []string{
+		`{"match_phrase":{"json.status.keyword":"500"}},{"match_phrase":{"json.status.keyword":"503"}},` +
+			`{"match_phrase":{"json.status":"500"}},{"match_phrase":{"json.status":"503"}}`,
+		`{"match_phrase":{"json.cached.keyword":"true"}},{"match_phrase":{"json.cached":"true"}}`,
+		`{"match_phrase":{"json.latency.keyword":"0.5"}},{"match_phrase":{"json.latency":"0.5"}}`,
+		`{"match_phrase":{"json.service.keyword":"api"}}],"minimum_should_match":1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %s in the query %s", expected, body)
+		}
+	}
+	if strings.Contains(body, `"json.service":`) {
+		t.Errorf("unexpected query of the strings on the field %s", body)
+	}
+}
+
 func mockElasticsearchService(pattern, fakeResp string, fakeCode int) *httptest.Server {
 	mux := http.NewServeMux()
 	mux.HandleFunc(pattern, func(res http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,45 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// fieldNameRegex matches the dot separated paths of the fields, e.g. level and http.status
+var fieldNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// IsValidFieldName returns whether the name is a dot separated path of a field,
+// the names are limited so they are safe to be embedded in the queries of the log stores.
+func IsValidFieldName(name string) bool {
+	return len(name) <= 256 && fieldNameRegex.MatchString(name)
+}
+
+// ParseFields returns the fields of the log line if it's a JSON object, otherwise nil.
+func ParseFields(log string) map[string]interface{} {
+	log = strings.TrimSpace(log)
+	if !strings.HasPrefix(log, "{") {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(log), &fields); err != nil {
+		return nil
+	}
+	return fields
+}
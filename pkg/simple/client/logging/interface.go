@@ -17,15 +17,24 @@ limitations under the License.
 package logging
 
 import (
+	"errors"
 	"io"
 	"time"
 )
 
+var ErrRecordNotFound = errors.New("log record not found")
+
 type Client interface {
 	GetCurrentStats(sf SearchFilter) (Statistics, error)
 	CountLogsByInterval(sf SearchFilter, interval string) (Histogram, error)
 	SearchLogs(sf SearchFilter, from, size int64, order string) (Logs, error)
 	ExportLogs(sf SearchFilter, w io.Writer) error
+	// GetLogContext returns the records around a record of a container, ErrRecordNotFound is returned
+	// if there is no such record.
+	GetLogContext(cf ContextFilter) (LogContext, error)
+	// CountFieldValues returns the top values of the fields parsed from the JSON log lines
+	// with their counts in the matched logs, at most size values for each field.
+	CountFieldValues(sf SearchFilter, fields []string, size int64) (Facets, error)
 }
 
 // Log search result
@@ -40,6 +49,10 @@ type Record struct {
 	Namespace string `json:"namespace,omitempty" description:"namespace"`
 	Pod       string `json:"pod,omitempty" description:"pod name"`
 	Container string `json:"container,omitempty" description:"container name"`
+	// Fields is only set if parsing the JSON log messages is requested
+	Fields map[string]interface{} `json:"fields,omitempty" description:"fields parsed from the JSON log message"`
+	// Tiebreaker orders the records at the same time, it's only set by the backends which support it
+	Tiebreaker *int64 `json:"tiebreaker,omitempty" description:"value ordering the records at the same time, records at the same time are sorted by it"`
 }
 
 // Log statistics result
@@ -76,6 +89,50 @@ type SearchFilter struct {
 	ContainerFilter []string
 	LogSearch       []string
 
+	// FieldFilter matches the fields parsed from the JSON log lines, the logs match if
+	// each of the fields equals any of its values. The field names are dot separated paths.
+	FieldFilter map[string][]string
+
 	Starttime time.Time
 	Endtime   time.Time
 }
+
+// Field value counts result
+type Facets struct {
+	Total  int64   `json:"total" description:"total number of matched logs"`
+	Fields []Facet `json:"fields" description:"value counts of the fields"`
+}
+
+type Facet struct {
+	Field  string       `json:"field" description:"field name"`
+	Values []FacetValue `json:"values" description:"top values of the field in the descending order of the counts"`
+	Other  int64        `json:"other" description:"total number of matched logs with the other values of the field"`
+}
+
+type FacetValue struct {
+	Value string `json:"value" description:"field value"`
+	Count int64  `json:"count" description:"total number of matched logs with the value"`
+}
+
+// ContextFilter selects the records around a record of a container. The record is identified by its time and
+// its offset in the records of the container at the same time, in the order the log store returns them.
+type ContextFilter struct {
+	Namespace string
+	Pod       string
+	Container string
+	Time      time.Time
+	Offset    int64
+	// Before and After are the max number of records before and after the record
+	Before int64
+	After  int64
+
+	// Starttime excludes the earlier records, e.g. the records of a deleted namespace with the same name
+	Starttime time.Time
+}
+
+// Log context result
+type LogContext struct {
+	Before []Record `json:"before,omitempty" description:"records before the record, in the time order"`
+	Record Record   `json:"record" description:"the record"`
+	After  []Record `json:"after,omitempty" description:"records after the record, in the time order"`
+}
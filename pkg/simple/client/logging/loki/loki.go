@@ -0,0 +1,619 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/utils/stringutils"
+)
+
+const (
+	namespaceLabel = "namespace"
+	podLabel       = "pod"
+	containerLabel = "container"
+	// the labels extracted from the JSON log lines to filter by the fields
+	fieldLabelPrefix = "ks_field_"
+
+	queryPath      = "/loki/api/v1/query"
+	queryRangePath = "/loki/api/v1/query_range"
+
+	// defaultLookback is the range queried if neither the start time nor the creation time of the namespace is known,
+	// Loki rejects the queries longer than its max_query_length.
+	defaultLookback = 7 * 24 * time.Hour
+
+	exportPageSize = 1000
+	// limit to retrieve max 100k records, the same as Elasticsearch
+	exportMaxPages = 100
+)
+
+type response struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+	Error string `json:"error,omitempty"`
+}
+
+// stream is an item of the streams result, the values are pairs of the timestamp in nanoseconds and the log line
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// sample is an item of the vector result
+type sample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]json.Number    `json:"value"`
+}
+
+// series is an item of the matrix result, the values are pairs of the timestamp in seconds and the sample value
+type series struct {
+	Metric map[string]string `json:"metric"`
+	Values [][2]json.Number  `json:"values"`
+}
+
+type entry struct {
+	timestamp int64
+	line      string
+	labels    map[string]string
+}
+
+// namespaceGroup is the namespaces queried from the same start time.
+type namespaceGroup struct {
+	start      time.Time
+	namespaces []string
+}
+
+// Loki implement logging interface
+type client struct {
+	host     string
+	username string
+	password string
+	client   *http.Client
+	now      func() time.Time
+	// pageSize is the number of the log entries queried at once to export
+	pageSize int64
+}
+
+func NewClient(options *logging.Options) (logging.Client, error) {
+	u, err := url.Parse(options.Host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid loki host %s", options.Host)
+	}
+	c := &client{
+		host:     strings.TrimSuffix(options.Host, "/"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+		pageSize: exportPageSize,
+	}
+	if options.BasicAuth {
+		c.username = options.Username
+		c.password = options.Password
+	}
+	return c, nil
+}
+
+func (c *client) GetCurrentStats(sf logging.SearchFilter) (logging.Statistics, error) {
+	end := c.end(sf)
+	var stats logging.Statistics
+	for _, group := range c.groupNamespaces(sf, end) {
+		rng := end.Sub(group.start).Milliseconds()
+		if rng <= 0 {
+			continue
+		}
+		params := url.Values{}
+		params.Set("query", fmt.Sprintf("sum by (%s, %s, %s) (count_over_time(%s [%dms]))",
+			namespaceLabel, podLabel, containerLabel, selector(sf, group.namespaces), rng))
+		params.Set("time", formatTime(end))
+
+		var samples []sample
+		if err := c.query(queryPath, params, &samples); err != nil {
+			return logging.Statistics{}, err
+		}
+		for _, s := range samples {
+			count, err := s.Value[1].Float64()
+			if err != nil {
+				return logging.Statistics{}, err
+			}
+			if count > 0 {
+				stats.Containers++
+				stats.Logs += int64(count)
+			}
+		}
+	}
+	return stats, nil
+}
+
+func (c *client) CountLogsByInterval(sf logging.SearchFilter, interval string) (logging.Histogram, error) {
+	d, err := model.ParseDuration(interval)
+	if err != nil {
+		return logging.Histogram{}, err
+	}
+	step := time.Duration(d)
+	if step <= 0 {
+		return logging.Histogram{}, fmt.Errorf("invalid interval %s", interval)
+	}
+
+	end := c.end(sf)
+	counts := make(map[int64]int64)
+	for _, group := range c.groupNamespaces(sf, end) {
+		if !group.start.Before(end) {
+			continue
+		}
+		// the value at t counts the logs in (t-step, t], align the buckets to the interval like the date histogram
+		params := url.Values{}
+		params.Set("query", fmt.Sprintf("sum(count_over_time(%s [%dms]))", selector(sf, group.namespaces), step.Milliseconds()))
+		params.Set("start", formatTime(group.start.Truncate(step).Add(step)))
+		params.Set("end", formatTime(end.Truncate(step).Add(step)))
+		params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+
+		var matrix []series
+		if err := c.query(queryRangePath, params, &matrix); err != nil {
+			return logging.Histogram{}, err
+		}
+		for _, s := range matrix {
+			for _, v := range s.Values {
+				t, err := v[0].Float64()
+				if err != nil {
+					return logging.Histogram{}, err
+				}
+				count, err := v[1].Float64()
+				if err != nil {
+					return logging.Histogram{}, err
+				}
+				counts[int64(t*1000)-step.Milliseconds()] += int64(count)
+			}
+		}
+	}
+
+	h := logging.Histogram{}
+	for t, count := range counts {
+		h.Total += count
+		h.Buckets = append(h.Buckets, logging.Bucket{Time: t, Count: count})
+	}
+	sort.Slice(h.Buckets, func(i, j int) bool {
+		return h.Buckets[i].Time < h.Buckets[j].Time
+	})
+	return h, nil
+}
+
+func (c *client) SearchLogs(sf logging.SearchFilter, f, s int64, o string) (logging.Logs, error) {
+	stats, err := c.GetCurrentStats(sf)
+	if err != nil {
+		return logging.Logs{}, err
+	}
+	l := logging.Logs{Total: stats.Logs}
+	if l.Total == 0 || f >= l.Total {
+		return l, nil
+	}
+
+	end := c.end(sf)
+	entries, err := c.queryEntries(sf, c.start(sf, end), end, f+s, o == "asc")
+	if err != nil {
+		return logging.Logs{}, err
+	}
+	entries = dropBeforeCreation(sf, entries)
+	if int64(len(entries)) <= f {
+		return l, nil
+	}
+	entries = entries[f:]
+	if int64(len(entries)) > s {
+		entries = entries[:s]
+	}
+	l.Records = toRecords(entries)
+	return l, nil
+}
+
+func (c *client) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
+	end := c.end(sf)
+	start := c.start(sf, end)
+	// the end is exclusive, page backward from the latest logs
+	cursor := end.Add(time.Nanosecond)
+	// the cursor is inclusive to not miss the entries at the time of the last entry exported, they are queried
+	// again in the next page, so the ones exported are counted to be skipped
+	var seen map[string]int
+	for i := 0; i < exportMaxPages; i++ {
+		entries, err := c.queryEntries(sf, start, cursor, c.pageSize, false)
+		if err != nil {
+			return err
+		}
+
+		var exported []entry
+		last := cursor.Add(-time.Nanosecond).UnixNano()
+		occurrences := make(map[string]int)
+		for _, e := range entries {
+			if e.timestamp == last {
+				key := entryKey(e)
+				occurrences[key]++
+				if occurrences[key] <= seen[key] {
+					continue
+				}
+			}
+			exported = append(exported, e)
+		}
+
+		output := new(strings.Builder)
+		for _, e := range dropBeforeCreation(sf, exported) {
+			output.WriteString(stringutils.StripAnsi(e.line))
+			if !strings.HasSuffix(e.line, "\n") {
+				output.WriteString("\n")
+			}
+		}
+		if _, err = io.WriteString(w, output.String()); err != nil {
+			return err
+		}
+
+		if int64(len(entries)) < c.pageSize {
+			return nil
+		}
+		if len(exported) == 0 {
+			// the whole page is at the cursor time, skip the rest entries at that time to make progress
+			cursor = time.Unix(0, last)
+			seen = nil
+			continue
+		}
+		last = entries[len(entries)-1].timestamp
+		seen = make(map[string]int)
+		for _, e := range entries {
+			if e.timestamp == last {
+				seen[entryKey(e)]++
+			}
+		}
+		cursor = time.Unix(0, last).Add(time.Nanosecond)
+	}
+	return nil
+}
+
+// entryKey identifies the entries at the same time, the identical lines of a stream are told apart by their occurrences.
+func entryKey(e entry) string {
+	return strings.Join([]string{e.labels[namespaceLabel], e.labels[podLabel], e.labels[containerLabel], e.line}, "/")
+}
+
+// CountFieldValues is not supported, loki does not index the fields of the log lines.
+func (c *client) CountFieldValues(sf logging.SearchFilter, fields []string, size int64) (logging.Facets, error) {
+	return logging.Facets{}, fmt.Errorf("counting the field values is not supported by loki")
+}
+
+func (c *client) GetLogContext(cf logging.ContextFilter) (logging.LogContext, error) {
+	if !cf.Starttime.IsZero() && cf.Time.Before(cf.Starttime) {
+		return logging.LogContext{}, logging.ErrRecordNotFound
+	}
+	sel := fmt.Sprintf("{%s=%s, %s=%s, %s=%s}", namespaceLabel, strconv.Quote(cf.Namespace),
+		podLabel, strconv.Quote(cf.Pod), containerLabel, strconv.Quote(cf.Container))
+
+	// the entries of the container at the same time, the record is at the offset of them
+	entries, err := c.queryStream(sel, cf.Time, cf.Time.Add(time.Nanosecond), cf.Offset+cf.After+1, true)
+	if err != nil {
+		return logging.LogContext{}, err
+	}
+	if int64(len(entries)) <= cf.Offset {
+		return logging.LogContext{}, logging.ErrRecordNotFound
+	}
+	before := entries[:cf.Offset]
+	if cf.Offset > cf.Before {
+		before = entries[cf.Offset-cf.Before : cf.Offset]
+	}
+	after := entries[cf.Offset+1:]
+
+	if n := cf.Before - int64(len(before)); n > 0 {
+		start := cf.Starttime
+		if start.IsZero() {
+			start = cf.Time.Add(-defaultLookback)
+		}
+		earlier, err := c.queryStream(sel, start, cf.Time, n, false)
+		if err != nil {
+			return logging.LogContext{}, err
+		}
+		for i, j := 0, len(earlier)-1; i < j; i, j = i+1, j-1 {
+			earlier[i], earlier[j] = earlier[j], earlier[i]
+		}
+		before = append(earlier, before...)
+	}
+	if n := cf.After - int64(len(after)); n > 0 {
+		later, err := c.queryStream(sel, cf.Time.Add(time.Nanosecond), c.now(), n, true)
+		if err != nil {
+			return logging.LogContext{}, err
+		}
+		after = append(after, later...)
+	}
+
+	return logging.LogContext{
+		Before: toRecords(before),
+		Record: toRecord(entries[cf.Offset]),
+		After:  toRecords(after),
+	}, nil
+}
+
+// queryEntries returns at most limit log entries in [start, end) sorted by the time.
+func (c *client) queryEntries(sf logging.SearchFilter, start, end time.Time, limit int64, ascending bool) ([]entry, error) {
+	return c.queryStream(selector(sf, namespaces(sf)), start, end, limit, ascending)
+}
+
+// queryStream returns at most limit log entries of the log query in [start, end) sorted by the time.
+func (c *client) queryStream(query string, start, end time.Time, limit int64, ascending bool) ([]entry, error) {
+	if !start.Before(end) {
+		return nil, nil
+	}
+	direction := "backward"
+	if ascending {
+		direction = "forward"
+	}
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", formatTime(start))
+	params.Set("end", formatTime(end))
+	params.Set("limit", strconv.FormatInt(limit, 10))
+	params.Set("direction", direction)
+
+	var streams []stream
+	if err := c.query(queryRangePath, params, &streams); err != nil {
+		return nil, err
+	}
+
+	var entries []entry
+	for _, s := range streams {
+		for _, v := range s.Values {
+			timestamp, err := strconv.ParseInt(v[0], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{timestamp: timestamp, line: v[1], labels: s.Stream})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if ascending {
+			return entries[i].timestamp < entries[j].timestamp
+		}
+		return entries[i].timestamp > entries[j].timestamp
+	})
+	if int64(len(entries)) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
+
+func toRecords(entries []entry) []logging.Record {
+	var records []logging.Record
+	for _, e := range entries {
+		records = append(records, toRecord(e))
+	}
+	return records
+}
+
+func toRecord(e entry) logging.Record {
+	return logging.Record{
+		Log:       e.line,
+		Time:      time.Unix(0, e.timestamp).UTC().Format(time.RFC3339Nano),
+		Namespace: e.labels[namespaceLabel],
+		Pod:       e.labels[podLabel],
+		Container: e.labels[containerLabel],
+	}
+}
+
+// dropBeforeCreation drops the entries earlier than the creation time of their namespace,
+// which belong to a deleted namespace with the same name.
+func dropBeforeCreation(sf logging.SearchFilter, entries []entry) []entry {
+	result := entries[:0]
+	for _, e := range entries {
+		if t := sf.NamespaceFilter[e.labels[namespaceLabel]]; t != nil && e.timestamp < t.UnixNano() {
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+func (c *client) query(path string, params url.Values, result interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.host+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("loki responded with %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var r response
+	if err = json.Unmarshal(body, &r); err != nil {
+		return err
+	}
+	if r.Status != "success" {
+		return fmt.Errorf("loki query failed: %s", r.Error)
+	}
+	return json.Unmarshal(r.Data.Result, result)
+}
+
+func (c *client) end(sf logging.SearchFilter) time.Time {
+	if sf.Endtime.IsZero() {
+		return c.now()
+	}
+	return sf.Endtime
+}
+
+// start returns the earliest time to query of all the namespaces.
+func (c *client) start(sf logging.SearchFilter, end time.Time) time.Time {
+	var start time.Time
+	for i, group := range c.groupNamespaces(sf, end) {
+		if i == 0 || group.start.Before(start) {
+			start = group.start
+		}
+	}
+	return start
+}
+
+// groupNamespaces groups the namespaces by the time to query from, which is the later one of the start time
+// and the creation time of the namespace, so the logs of a deleted namespace with the same name are excluded.
+func (c *client) groupNamespaces(sf logging.SearchFilter, end time.Time) []namespaceGroup {
+	defaultStart := sf.Starttime
+	if defaultStart.IsZero() {
+		defaultStart = end.Add(-defaultLookback)
+	}
+	if len(sf.NamespaceFilter) == 0 {
+		return []namespaceGroup{{start: defaultStart}}
+	}
+
+	groups := make(map[int64]*namespaceGroup)
+	for ns, createdAt := range sf.NamespaceFilter {
+		start := defaultStart
+		if createdAt != nil && createdAt.After(start) {
+			start = *createdAt
+		}
+		group, ok := groups[start.UnixNano()]
+		if !ok {
+			group = &namespaceGroup{start: start}
+			groups[start.UnixNano()] = group
+		}
+		group.namespaces = append(group.namespaces, ns)
+	}
+
+	result := make([]namespaceGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Strings(group.namespaces)
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].start.Before(result[j].start)
+	})
+	return result
+}
+
+func namespaces(sf logging.SearchFilter) []string {
+	result := make([]string, 0, len(sf.NamespaceFilter))
+	for ns := range sf.NamespaceFilter {
+		result = append(result, ns)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// selector translates the search filter into a LogQL log stream selector with the line filter,
+// all the namespaces are selected if no namespace is given.
+func selector(sf logging.SearchFilter, namespaces []string) string {
+	var matchers []string
+	if len(namespaces) == 0 {
+		matchers = append(matchers, matcher(namespaceLabel, ".+"))
+	} else {
+		matchers = append(matchers, matcher(namespaceLabel, alternate(namespaces)))
+	}
+
+	if len(sf.WorkloadFilter) > 0 {
+		regexes := make([]string, 0, len(sf.WorkloadFilter))
+		for _, wk := range sf.WorkloadFilter {
+			regexes = append(regexes, logging.PodNameRegex(wk))
+		}
+		matchers = append(matchers, matcher(podLabel, strings.Join(regexes, "|")))
+	}
+	if len(sf.PodFilter) > 0 {
+		matchers = append(matchers, matcher(podLabel, alternate(sf.PodFilter)))
+	}
+	if len(sf.ContainerFilter) > 0 {
+		matchers = append(matchers, matcher(containerLabel, alternate(sf.ContainerFilter)))
+	}
+
+	// fuzzy matching
+	if len(sf.WorkloadSearch) > 0 {
+		matchers = append(matchers, matcher(podLabel, contains(sf.WorkloadSearch)))
+	}
+	if len(sf.PodSearch) > 0 {
+		matchers = append(matchers, matcher(podLabel, contains(sf.PodSearch)))
+	}
+	if len(sf.ContainerSearch) > 0 {
+		matchers = append(matchers, matcher(containerLabel, contains(sf.ContainerSearch)))
+	}
+
+	s := "{" + strings.Join(matchers, ", ") + "}"
+	if len(sf.LogSearch) > 0 {
+		s += " |~ " + strconv.Quote("(?i)"+alternate(sf.LogSearch))
+	}
+	if len(sf.FieldFilter) > 0 {
+		s += fieldFilter(sf.FieldFilter)
+	}
+	return s
+}
+
+// fieldFilter extracts the fields of the JSON log lines into labels and filters the lines by them,
+// the values of a field are OR'ed and the fields are AND'ed.
+func fieldFilter(filter map[string][]string) string {
+	fields := make([]string, 0, len(filter))
+	for field := range filter {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	extractions := make([]string, 0, len(fields))
+	var filters []string
+	for i, field := range fields {
+		label := fmt.Sprintf("%s%d", fieldLabelPrefix, i)
+		extractions = append(extractions, label+"="+strconv.Quote(field))
+		matches := make([]string, 0, len(filter[field]))
+		for _, v := range filter[field] {
+			matches = append(matches, label+"="+strconv.Quote(v))
+		}
+		filters = append(filters, " | "+strings.Join(matches, " or "))
+	}
+	return " | json " + strings.Join(extractions, ", ") + strings.Join(filters, "")
+}
+
+func matcher(label, regex string) string {
+	return label + "=~" + strconv.Quote(regex)
+}
+
+// contains returns the regular expression matching the strings containing any of the values case-insensitively.
+func contains(values []string) string {
+	return "(?i).*" + alternate(values) + ".*"
+}
+
+// alternate returns the regular expression matching any of the values, the label matchers are fully anchored.
+func alternate(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	return "(" + strings.Join(quoted, "|") + ")"
+}
+
+func formatTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,337 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loki
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+const (
+	statsResponse = `{"status":"success","data":{"resultType":"vector","result":[
+{"metric":{"namespace":"default","pod":"nginx-7c5b4f9d4b-abcde","container":"nginx"},"value":[1690000000,"3"]},
+{"metric":{"namespace":"default","pod":"redis-0","container":"redis"},"value":[1690000000,"2"]}]}}`
+	histogramResponse = `{"status":"success","data":{"resultType":"matrix","result":[
+{"metric":{},"values":[[1690000200,"4"],[1690001100,"1"]]}]}}`
+	streamsResponse = `{"status":"success","data":{"resultType":"streams","result":[
+{"stream":{"namespace":"default","pod":"nginx-7c5b4f9d4b-abcde","container":"nginx"},"values":[["1690000300000000000","GET /index.html"],["1690000100000000000","GET /"]]},
+{"stream":{"namespace":"default","pod":"redis-0","container":"redis"},"values":[["1690000200000000000","\u001b[32mready\u001b[0m"]]}]}}`
+)
+
+type stub struct {
+	requests []*http.Request
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests = append(s.requests, r)
+	if user, password, ok := r.BasicAuth(); !ok || user != "admin" || password != "P@88w0rd" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query().Get("query")
+	switch {
+	case r.URL.Path == queryPath:
+		w.Write([]byte(statsResponse))
+	case r.URL.Path == queryRangePath && strings.HasPrefix(query, "sum("):
+		w.Write([]byte(histogramResponse))
+	case r.URL.Path == queryRangePath:
+		w.Write([]byte(streamsResponse))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T) (*client, *stub) {
+	s := &stub{}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(&logging.Options{
+		Backend:   logging.BackendLoki,
+		Host:      srv.URL,
+		BasicAuth: true,
+		Username:  "admin",
+		Password:  "P@88w0rd",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(*client), s
+}
+
+func TestSelector(t *testing.T) {
+	var tests = []struct {
+		filter     logging.SearchFilter
+		namespaces []string
+		expected   string
+	}{
+		{
+			expected: `{namespace=~".+"}`,
+		},
+		{
+			filter: logging.SearchFilter{
+				PodFilter:       []string{"redis-0", "nginx.1"},
+				ContainerFilter: []string{"redis"},
+				LogSearch:       []string{"error", "panic("},
+			},
+			namespaces: []string{"default", "kube-system"},
+			expected: `{namespace=~"(default|kube-system)", pod=~"(redis-0|nginx\\.1)", container=~"(redis)"} ` +
+				`|~ "(?i)(error|panic\\()"`,
+		},
+		{
+			filter: logging.SearchFilter{
+				WorkloadFilter:  []string{"redis"},
+				WorkloadSearch:  []string{"red"},
+				PodSearch:       []string{"is-"},
+				ContainerSearch: []string{"Redis"},
+			},
+			namespaces: []string{"default"},
+			expected: `{namespace=~"(default)", pod=~"redis-[bcdfghjklmnpqrstvwxz2456789]{1,10}-[a-z0-9]{5}|redis-[0-9]+|redis-[a-z0-9]{5}", ` +
+				`pod=~"(?i).*(red).*", pod=~"(?i).*(is-).*", container=~"(?i).*(Redis).*"}`,
+		},
+		{
+			filter: logging.SearchFilter{
+				LogSearch: []string{"timeout"},
+				FieldFilter: map[string][]string{
+					"level":       {"error", "warn"},
+					"http.status": {"500"},
+				},
+			},
+			namespaces: []string{"default"},
+			expected: `{namespace=~"(default)"} |~ "(?i)(timeout)" | json ks_field_0="http.status", ks_field_1="level" ` +
+				`| ks_field_0="500" | ks_field_1="error" or ks_field_1="warn"`,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, selector(test.filter, test.namespaces))
+	}
+}
+
+func TestGetCurrentStats(t *testing.T) {
+	c, s := newTestClient(t)
+	end := time.Unix(1690000000, 0)
+	created := end.Add(-time.Hour)
+	stats, err := c.GetCurrentStats(logging.SearchFilter{
+		NamespaceFilter: map[string]*time.Time{"default": &created, "kube-system": nil},
+		Starttime:       end.Add(-2 * time.Hour),
+		Endtime:         end,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, logging.Statistics{Containers: 4, Logs: 10}, stats)
+
+	// the namespaces are queried from their creation time
+	assert.Len(t, s.requests, 2)
+	assert.Equal(t, `sum by (namespace, pod, container) (count_over_time({namespace=~"(kube-system)"} [7200000ms]))`,
+		s.requests[0].URL.Query().Get("query"))
+	assert.Equal(t, `sum by (namespace, pod, container) (count_over_time({namespace=~"(default)"} [3600000ms]))`,
+		s.requests[1].URL.Query().Get("query"))
+	assert.Equal(t, "1690000000000000000", s.requests[1].URL.Query().Get("time"))
+}
+
+func TestCountLogsByInterval(t *testing.T) {
+	c, s := newTestClient(t)
+	h, err := c.CountLogsByInterval(logging.SearchFilter{
+		Starttime: time.Unix(1689999400, 0),
+		Endtime:   time.Unix(1690001000, 0),
+	}, "15m")
+	assert.NoError(t, err)
+	assert.Equal(t, logging.Histogram{
+		Total: 5,
+		Buckets: []logging.Bucket{
+			{Time: 1689999300000, Count: 4},
+			{Time: 1690000200000, Count: 1},
+		},
+	}, h)
+
+	query := s.requests[0].URL.Query()
+	assert.Equal(t, `sum(count_over_time({namespace=~".+"} [900000ms]))`, query.Get("query"))
+	assert.Equal(t, "1690000200000000000", query.Get("start"))
+	assert.Equal(t, "1690001100000000000", query.Get("end"))
+	assert.Equal(t, "900", query.Get("step"))
+
+	_, err = c.CountLogsByInterval(logging.SearchFilter{}, "1M")
+	assert.Error(t, err)
+}
+
+func TestSearchLogs(t *testing.T) {
+	c, s := newTestClient(t)
+	end := time.Unix(1690000400, 0)
+	// the logs of the deleted namespace with the same name are dropped
+	created := time.Unix(0, 1690000150000000000)
+	logs, err := c.SearchLogs(logging.SearchFilter{
+		NamespaceFilter: map[string]*time.Time{"default": &created},
+		Starttime:       time.Unix(1690000000, 0),
+		Endtime:         end,
+	}, 1, 10, "desc")
+	assert.NoError(t, err)
+	assert.Equal(t, logging.Logs{
+		Total: 5,
+		Records: []logging.Record{
+			{
+				Log:       "\u001b[32mready\u001b[0m",
+				Time:      "2023-07-22T04:30:00Z",
+				Namespace: "default",
+				Pod:       "redis-0",
+				Container: "redis",
+			},
+		},
+	}, logs)
+
+	query := s.requests[1].URL.Query()
+	assert.Equal(t, `{namespace=~"(default)"}`, query.Get("query"))
+	assert.Equal(t, "11", query.Get("limit"))
+	assert.Equal(t, "backward", query.Get("direction"))
+	assert.Equal(t, "1690000150000000000", query.Get("start"))
+}
+
+func TestExportLogs(t *testing.T) {
+	c, s := newTestClient(t)
+	var buf bytes.Buffer
+	err := c.ExportLogs(logging.SearchFilter{
+		Starttime: time.Unix(1690000000, 0),
+		Endtime:   time.Unix(1690000400, 0),
+	}, &buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "GET /index.html\nready\nGET /\n", buf.String())
+	// the page is not full, no more pages are queried
+	assert.Len(t, s.requests, 1)
+}
+
+// pagingStub serves the entries in [start, end) backward, like loki does.
+type pagingStub struct {
+	timestamps []int64
+	lines      []string
+}
+
+func (s *pagingStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	start, _ := strconv.ParseInt(query.Get("start"), 10, 64)
+	end, _ := strconv.ParseInt(query.Get("end"), 10, 64)
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	var values [][]string
+	for i, timestamp := range s.timestamps {
+		if timestamp >= start && timestamp < end && len(values) < limit {
+			values = append(values, []string{strconv.FormatInt(timestamp, 10), s.lines[i]})
+		}
+	}
+	result, _ := json.Marshal(map[string]interface{}{
+		"status": "success",
+		"data": map[string]interface{}{
+			"resultType": "streams",
+			"result":     []interface{}{map[string]interface{}{"stream": map[string]string{"namespace": "default"}, "values": values}},
+		},
+	})
+	w.Write(result)
+}
+
+func TestExportLogsPaging(t *testing.T) {
+	tests := []struct {
+		name       string
+		timestamps []int64
+		lines      []string
+		want       string
+	}{
+		{
+			name:       "entries at the page boundary",
+			timestamps: []int64{5, 4, 4, 3},
+			lines:      []string{"e", "d1", "d2", "c"},
+			want:       "e\nd1\nd2\nc\n",
+		},
+		{
+			name:       "identical lines at the page boundary",
+			timestamps: []int64{5, 4, 4, 3},
+			lines:      []string{"e", "d", "d", "c"},
+			want:       "e\nd\nd\nc\n",
+		},
+		{
+			name:       "more entries at the same time than a page",
+			timestamps: []int64{4, 4, 4, 3},
+			lines:      []string{"d1", "d2", "d3", "c"},
+			want:       "d1\nd2\nc\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &pagingStub{timestamps: tt.timestamps, lines: tt.lines}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+			c, err := NewClient(&logging.Options{Backend: logging.BackendLoki, Host: srv.URL})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.(*client).pageSize = 2
+			var buf bytes.Buffer
+			assert.NoError(t, c.ExportLogs(logging.SearchFilter{
+				Starttime: time.Unix(0, 1),
+				Endtime:   time.Unix(0, 10),
+			}, &buf))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestGetLogContext(t *testing.T) {
+	c, s := newTestClient(t)
+	c.now = func() time.Time { return time.Unix(1690000400, 0) }
+	lc, err := c.GetLogContext(logging.ContextFilter{
+		Namespace: "default",
+		Pod:       "redis-0",
+		Container: "redis",
+		Time:      time.Unix(0, 1690000200000000000),
+		Before:    1,
+		After:     3,
+	})
+	assert.NoError(t, err)
+	// the stub returns the same streams for all the queries
+	assert.Equal(t, "GET /", lc.Record.Log)
+	assert.Len(t, lc.Before, 1)
+	assert.Len(t, lc.After, 3)
+
+	assert.Len(t, s.requests, 3)
+	query := s.requests[0].URL.Query()
+	assert.Equal(t, `{namespace="default", pod="redis-0", container="redis"}`, query.Get("query"))
+	assert.Equal(t, "1690000200000000000", query.Get("start"))
+	assert.Equal(t, "1690000200000000001", query.Get("end"))
+	assert.Equal(t, "4", query.Get("limit"))
+	assert.Equal(t, "forward", query.Get("direction"))
+	assert.Equal(t, "backward", s.requests[1].URL.Query().Get("direction"))
+	assert.Equal(t, "1690000200000000000", s.requests[1].URL.Query().Get("end"))
+	assert.Equal(t, "1690000200000000001", s.requests[2].URL.Query().Get("start"))
+
+	_, err = c.GetLogContext(logging.ContextFilter{
+		Time:      time.Unix(1690000200, 0),
+		Starttime: time.Unix(1690000300, 0),
+	})
+	assert.Equal(t, logging.ErrRecordNotFound, err)
+}
+
+func TestUnauthorized(t *testing.T) {
+	c, _ := newTestClient(t)
+	c.password = ""
+	_, err := c.GetCurrentStats(logging.SearchFilter{})
+	assert.Error(t, err)
+}
@@ -17,18 +17,31 @@ limitations under the License.
 package logging
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 
 	"kubesphere.io/kubesphere/pkg/utils/reflectutils"
 )
 
+const (
+	BackendElasticsearch = "elasticsearch"
+	BackendLoki          = "loki"
+)
+
 type Options struct {
+	// Backend is the log store, Elasticsearch is used if it's empty
+	Backend     string `json:"backend,omitempty" yaml:"backend,omitempty"`
 	Host        string `json:"host" yaml:"host"`
 	BasicAuth   bool   `json:"basicAuth" yaml:"basicAuth"`
 	Username    string `json:"username" yaml:"username"`
 	Password    string `json:"password" yaml:"password"`
 	IndexPrefix string `json:"indexPrefix,omitempty" yaml:"indexPrefix,omitempty"`
 	Version     string `json:"version" yaml:"version"`
+	// FieldsKey is the key the log agent puts the fields parsed from the JSON log lines under,
+	// e.g. the Merge_Log_Key of the Fluent Bit kubernetes filter, the fields are at the top level
+	// of the documents if it's empty. Loki parses the fields at query time and ignores it.
+	FieldsKey string `json:"fieldsKey,omitempty" yaml:"fieldsKey,omitempty"`
 }
 
 func NewLoggingOptions() *Options {
@@ -47,10 +60,22 @@ func (s *Options) ApplyTo(options *Options) {
 
 func (s *Options) Validate() []error {
 	errs := make([]error, 0)
+	switch s.Backend {
+	case "", BackendElasticsearch, BackendLoki:
+	default:
+		errs = append(errs, fmt.Errorf("logging backend MUST be one of %s and %s", BackendElasticsearch, BackendLoki))
+	}
+	if s.FieldsKey != "" && !IsValidFieldName(s.FieldsKey) {
+		errs = append(errs, fmt.Errorf("invalid logging fields key %s", s.FieldsKey))
+	}
 	return errs
 }
 
 func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&s.Backend, "logging-backend", c.Backend, ""+
+		"Logging backend, one of elasticsearch and loki. The Loki address is set by logging-elasticsearch-host, "+
+		"and so are the basic auth options, the index prefix and the version are ignored for Loki.")
+
 	fs.StringVar(&s.Host, "logging-elasticsearch-host", c.Host, ""+
 		"Elasticsearch logging service host. KubeSphere is using elastic as log store, "+
 		"if this filed left blank, KubeSphere will use kubernetes builtin log API instead, and"+
@@ -76,4 +101,8 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 	fs.StringVar(&s.Version, "logging-elasticsearch-version", c.Version, ""+
 		"Elasticsearch major version, e.g. 5/6/7, if left blank, will detect automatically."+
 		"Currently, minimum supported version is 5.x")
+
+	fs.StringVar(&s.FieldsKey, "logging-fields-key", c.FieldsKey, ""+
+		"The key of the fields parsed from the JSON log lines by the log agent in the Elasticsearch documents, "+
+		"the fields are at the top level of the documents if it's empty.")
 }
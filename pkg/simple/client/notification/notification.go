@@ -0,0 +1,111 @@
+/*
+Copyright 2023 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	AlertsAPIPath = "/api/v2/alerts"
+
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
+// Alert is an alert in the format of the Alertmanager webhook, the notification manager routes it
+// to the receivers selected by the routers matching its labels.
+type Alert struct {
+	Status      string            `json:"status"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt,omitempty"`
+}
+
+// message is the payload of the Alertmanager webhook
+type message struct {
+	Version  string   `json:"version"`
+	Receiver string   `json:"receiver"`
+	Status   string   `json:"status"`
+	Alerts   []*Alert `json:"alerts"`
+}
+
+// Client sends the alerts to the notification manager
+type Client interface {
+	SendAlerts(ctx context.Context, alerts ...*Alert) error
+}
+
+type client struct {
+	endpoint string
+	client   *http.Client
+}
+
+func NewClient(options *Options) Client {
+	return &client{
+		endpoint: options.Endpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *client) SendAlerts(ctx context.Context, alerts ...*Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	msg := message{
+		Version: "4",
+		Status:  AlertStatusResolved,
+		Alerts:  alerts,
+	}
+	for _, alert := range alerts {
+		if alert.Status == AlertStatusFiring {
+			msg.Status = AlertStatusFiring
+			break
+		}
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+AlertsAPIPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to send alerts to notification manager, status: %s, body: %s", resp.Status, string(data))
+	}
+	return nil
+}
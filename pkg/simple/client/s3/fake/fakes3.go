@@ -19,6 +19,8 @@ package fake
 import (
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/s3"
@@ -73,3 +75,14 @@ func (s *FakeS3) Read(key string) ([]byte, error) {
 	}
 	return nil, awserr.New(s3.ErrCodeNoSuchKey, "no such object", nil)
 }
+
+func (s *FakeS3) List(prefix string) ([]string, error) {
+	var keys []string
+	for key := range s.Storage {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
@@ -29,4 +29,7 @@ type Interface interface {
 
 	// Delete deletes an object by its key
 	Delete(key string) error
+
+	// List returns the keys of the objects which start with the prefix
+	List(prefix string) ([]string, error)
 }
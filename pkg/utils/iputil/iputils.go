@@ -17,8 +17,10 @@ limitations under the License.
 package iputil
 
 import (
+	"fmt"
 	"net"
 	"net/http"
+	"strings"
 )
 
 const (
@@ -45,3 +47,81 @@ func RemoteIp(req *http.Request) string {
 
 	return remoteAddr
 }
+
+// ClientIP returns the IP of the client which can't be spoofed by the forwarding headers. The forwarding headers
+// are only honoured if the request is sent by the trusted proxies, and the X-Forwarded-For header is read from
+// right to left until an untrusted address is found, since the addresses on the left are set by the client.
+func ClientIP(req *http.Request, trustedProxies []*net.IPNet) string {
+	remoteAddr, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		remoteAddr = req.RemoteAddr
+	}
+	clientIP := normalize(remoteAddr)
+	if !trusted(clientIP, trustedProxies) {
+		return clientIP
+	}
+
+	if forwardedFor := req.Header.Get(XForwardedFor); forwardedFor != "" {
+		addresses := strings.Split(forwardedFor, ",")
+		for i := len(addresses) - 1; i >= 0; i-- {
+			ip := normalize(strings.TrimSpace(addresses[i]))
+			if net.ParseIP(ip) == nil {
+				break
+			}
+			clientIP = ip
+			if !trusted(ip, trustedProxies) {
+				break
+			}
+		}
+		return clientIP
+	}
+	if ip := normalize(strings.TrimSpace(req.Header.Get(XRealIP))); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return clientIP
+}
+
+// ParseCIDRs parses the CIDRs, a single IP is treated as the CIDR which only contains itself.
+func ParseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %s", cidr)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func trusted(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(ip string) string {
+	if ip == "::1" {
+		return "127.0.0.1"
+	}
+	return ip
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package iputil
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "headers of untrusted peer are ignored",
+			remoteAddr: "1.2.3.4:1234",
+			headers:    map[string]string{XForwardedFor: "5.6.7.8", XRealIP: "5.6.7.8", XClientIP: "5.6.7.8"},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "forwarded by trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{XForwardedFor: "5.6.7.8"},
+			expected:   "5.6.7.8",
+		},
+		{
+			name:       "addresses set by the client are ignored",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{XForwardedFor: "9.9.9.9, 5.6.7.8, 192.168.0.1"},
+			expected:   "5.6.7.8",
+		},
+		{
+			name:       "real IP set by trusted proxy",
+			remoteAddr: "192.168.0.1:1234",
+			headers:    map[string]string{XRealIP: "5.6.7.8"},
+			expected:   "5.6.7.8",
+		},
+		{
+			name:       "no forwarding headers",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &http.Request{RemoteAddr: test.remoteAddr, Header: http.Header{}}
+			for key, value := range test.headers {
+				req.Header.Set(key, value)
+			}
+			if got := ClientIP(req, trustedProxies); got != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, got)
+			}
+		})
+	}
+
+	if _, err := ParseCIDRs([]string{"invalid"}); err == nil {
+		t.Errorf("expected error for invalid CIDR")
+	}
+}
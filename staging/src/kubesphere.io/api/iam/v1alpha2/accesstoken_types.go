@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindAccessToken      = "AccessToken"
+	ResourcesSingularAccessToken = "accesstoken"
+	ResourcesPluralAccessToken   = "accesstokens"
+	// ExtraAccessToken is the name of the personal access token the request is authenticated with.
+	ExtraAccessToken = "accessToken"
+	// ExtraAccessTokenWorkspaces is the workspaces the personal access token is restricted to.
+	ExtraAccessTokenWorkspaces = "accessTokenWorkspaces"
+	// ExtraAccessTokenVerbs is the verbs the personal access token is restricted to.
+	ExtraAccessTokenVerbs = "accessTokenVerbs"
+)
+
+// AccessTokenSpec defines the desired state of AccessToken
+type AccessTokenSpec struct {
+	// Description of the purpose of the token
+	// +optional
+	Description string `json:"description,omitempty"`
+	// The token is rejected after the expiration time
+	ExpirationTime metav1.Time `json:"expirationTime"`
+	// The token only authorizes the requests to the resources in the workspaces if specified
+	// +optional
+	Workspaces []string `json:"workspaces,omitempty"`
+	// The token only authorizes the requests with the verbs if specified, e.g. get, list and watch
+	// +optional
+	Verbs []string `json:"verbs,omitempty"`
+}
+
+// AccessTokenStatus defines the observed state of AccessToken
+type AccessTokenStatus struct {
+	// Last time the token was used, it's updated at most once per minute
+	// +optional
+	LastUsedTime *metav1.Time `json:"lastUsedTime,omitempty"`
+	// Source IP of the client which used the token when LastUsedTime was updated
+	// +optional
+	LastUsedSourceIP string `json:"lastUsedSourceIP,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="User",type="string",JSONPath=".metadata.labels.iam\\.kubesphere\\.io/user-ref"
+// +kubebuilder:printcolumn:name="Expiration",type="date",JSONPath=".spec.expirationTime"
+// +kubebuilder:printcolumn:name="Last Used",type="date",JSONPath=".status.lastUsedTime"
+// +kubebuilder:resource:categories="iam",scope="Cluster"
+
+// AccessToken is a personal access token of a user, deleting the AccessToken revokes the token.
+type AccessToken struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AccessTokenSpec   `json:"spec"`
+	Status AccessTokenStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +genclient:nonNamespaced
+
+// AccessTokenList contains a list of AccessToken
+type AccessTokenList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessToken `json:"items"`
+}
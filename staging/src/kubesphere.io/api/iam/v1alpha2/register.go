@@ -47,6 +47,8 @@ func Resource(resource string) schema.GroupResource {
 // Adds the list of known types to the given scheme.
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
+		&AccessToken{},
+		&AccessTokenList{},
 		&User{},
 		&UserList{},
 		&LoginRecord{},
@@ -61,6 +61,7 @@ const (
 	GrantedClustersAnnotation             = "iam.kubesphere.io/granted-clusters"
 	UninitializedAnnotation               = "iam.kubesphere.io/uninitialized"
 	LastPasswordChangeTimeAnnotation      = "iam.kubesphere.io/last-password-change-time"
+	PasswordHistoryAnnotation             = "iam.kubesphere.io/password-history"
 	RoleAnnotation                        = "iam.kubesphere.io/role"
 	RoleTemplateLabel                     = "iam.kubesphere.io/role-template"
 	ScopeLabelFormat                      = "scope.kubesphere.io/%s"
@@ -145,6 +146,32 @@ type UserSpec struct {
 	// - ^(.*[0-9].*[A-Z].*[a-z].*)$ ...
 	// Last but not least, the bcrypt string is also included to match the encrypted password. ^(\$2[ayb]\$.{56})$
 	EncryptedPassword string `json:"password,omitempty"`
+
+	// MultiFactorAuth is the second factor enrolled by the user, it's managed by ks-apiserver
+	// and only applies to the KubeSphere-local accounts.
+	// +optional
+	MultiFactorAuth *MultiFactorAuth `json:"multiFactorAuth,omitempty"`
+}
+
+// MultiFactorAuth defines the second factors of a user.
+type MultiFactorAuth struct {
+	// TOTP is the time-based one-time password(https://www.ietf.org/rfc/rfc6238.txt) authenticator of the user.
+	// +optional
+	TOTP *TOTP `json:"totp,omitempty"`
+	// RecoveryCodes are the SHA-256 hashes of the single-use codes which can be used instead of the TOTP passcode.
+	// +optional
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+type TOTP struct {
+	// Secret is the base32 encoded shared secret encrypted by ks-apiserver.
+	Secret string `json:"secret"`
+	// Confirmed means the user has verified a passcode generated by the secret,
+	// the passcode is not required to login until the TOTP is confirmed.
+	// +optional
+	Confirmed bool `json:"confirmed,omitempty"`
+	// +optional
+	EnrolledTime *metav1.Time `json:"enrolledTime,omitempty"`
 }
 
 type UserState string
@@ -173,6 +200,12 @@ type UserStatus struct {
 	// Last login attempt timestamp
 	// +optional
 	LastLoginTime *metav1.Time `json:"lastLoginTime,omitempty"`
+	// The number of consecutive lockouts, the lock duration grows with it
+	// +optional
+	LockoutCount int32 `json:"lockoutCount,omitempty"`
+	// The time the user will be unlocked automatically
+	// +optional
+	LockedUntil *metav1.Time `json:"lockedUntil,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -0,0 +1,207 @@
+/*
+Copyright 2023 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindSavedSearch      = "SavedSearch"
+	ResourcesSingularSavedSearch = "savedsearch"
+	ResourcesPluralSavedSearch   = "savedsearches"
+)
+
+// SearchType is the type of the records to search.
+type SearchType string
+
+const (
+	SearchTypeLogs     SearchType = "logs"
+	SearchTypeEvents   SearchType = "events"
+	SearchTypeAuditing SearchType = "auditing"
+)
+
+// ComparisonOperator compares the count of the records with the threshold.
+type ComparisonOperator string
+
+const (
+	ComparisonGreaterThan        ComparisonOperator = "GreaterThan"
+	ComparisonGreaterThanOrEqual ComparisonOperator = "GreaterThanOrEqual"
+	ComparisonLessThan           ComparisonOperator = "LessThan"
+	ComparisonLessThanOrEqual    ComparisonOperator = "LessThanOrEqual"
+)
+
+// LogQuery selects the logs of the containers in the namespace of the SavedSearch,
+// the fields named xxxQuery are fuzzy matched.
+type LogQuery struct {
+	// +optional
+	Workloads []string `json:"workloads,omitempty"`
+	// +optional
+	WorkloadQuery []string `json:"workloadQuery,omitempty"`
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+	// +optional
+	PodQuery []string `json:"podQuery,omitempty"`
+	// +optional
+	Containers []string `json:"containers,omitempty"`
+	// +optional
+	ContainerQuery []string `json:"containerQuery,omitempty"`
+	// Keywords matched in the log messages case-insensitively, any of them matches
+	// +optional
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+// EventQuery selects the Kubernetes events of the objects in the namespace of the SavedSearch,
+// the fields named xxxQuery are fuzzy matched.
+type EventQuery struct {
+	// +optional
+	InvolvedObjectNames []string `json:"involvedObjectNames,omitempty"`
+	// +optional
+	InvolvedObjectNameQuery []string `json:"involvedObjectNameQuery,omitempty"`
+	// +optional
+	InvolvedObjectKinds []string `json:"involvedObjectKinds,omitempty"`
+	// +optional
+	Reasons []string `json:"reasons,omitempty"`
+	// +optional
+	ReasonQuery []string `json:"reasonQuery,omitempty"`
+	// +optional
+	MessageQuery []string `json:"messageQuery,omitempty"`
+	// Type of the events, Normal or Warning
+	// +optional
+	Type string `json:"type,omitempty"`
+}
+
+// AuditingQuery selects the auditing events of the objects in the namespace of the SavedSearch,
+// the fields named xxxQuery are fuzzy matched.
+type AuditingQuery struct {
+	// +optional
+	ObjectRefNames []string `json:"objectRefNames,omitempty"`
+	// +optional
+	ObjectRefNameQuery []string `json:"objectRefNameQuery,omitempty"`
+	// +optional
+	ObjectRefResources []string `json:"objectRefResources,omitempty"`
+	// +optional
+	ObjectRefSubresources []string `json:"objectRefSubresources,omitempty"`
+	// +optional
+	Verbs []string `json:"verbs,omitempty"`
+	// +optional
+	Levels []string `json:"levels,omitempty"`
+	// +optional
+	Users []string `json:"users,omitempty"`
+	// +optional
+	UserQuery []string `json:"userQuery,omitempty"`
+	// +optional
+	ResponseCodes []int32 `json:"responseCodes,omitempty"`
+	// +optional
+	ResponseStatus []string `json:"responseStatus,omitempty"`
+}
+
+// AlertSpec evaluates the search periodically and notifies when the count of the matched records
+// in the window crosses the threshold, and again when it's resolved.
+type AlertSpec struct {
+	// How often the search is evaluated, at least one minute
+	Interval metav1.Duration `json:"interval"`
+	// The time range counted back from the evaluation, defaults to the interval
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+	// The alert fires if the count compared with the threshold holds
+	Threshold int64 `json:"threshold"`
+	// Defaults to GreaterThanOrEqual
+	// +kubebuilder:validation:Enum=GreaterThan;GreaterThanOrEqual;LessThan;LessThanOrEqual
+	// +optional
+	Comparison ComparisonOperator `json:"comparison,omitempty"`
+	// +kubebuilder:validation:Enum=critical;error;warning;info
+	// +optional
+	Severity string `json:"severity,omitempty"`
+	// Labels added to the notification, the notification routers select the receivers by the labels
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Message of the notification
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SavedSearchSpec defines the desired state of SavedSearch
+type SavedSearchSpec struct {
+	// Description of the search
+	// +optional
+	Description string `json:"description,omitempty"`
+	// +kubebuilder:validation:Enum=logs;events;auditing
+	Type SearchType `json:"type"`
+	// The query of the logs, required if the type is logs
+	// +optional
+	Logs *LogQuery `json:"logs,omitempty"`
+	// The query of the events, required if the type is events
+	// +optional
+	Events *EventQuery `json:"events,omitempty"`
+	// The query of the auditing events, required if the type is auditing
+	// +optional
+	Auditing *AuditingQuery `json:"auditing,omitempty"`
+	// The search is only saved for reuse if no alert is specified
+	// +optional
+	Alert *AlertSpec `json:"alert,omitempty"`
+}
+
+// SavedSearchStatus defines the observed state of SavedSearch
+type SavedSearchStatus struct {
+	// Last time the alert was evaluated
+	// +optional
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+	// The count of the matched records in the window of the last evaluation
+	// +optional
+	LastCount *int64 `json:"lastCount,omitempty"`
+	// The error of the last evaluation
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+	// Whether the alert is firing
+	// +optional
+	Firing bool `json:"firing,omitempty"`
+	// Last time the alert started firing
+	// +optional
+	FiringSince *metav1.Time `json:"firingSince,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="Count",type="integer",JSONPath=".status.lastCount"
+// +kubebuilder:printcolumn:name="Firing",type="boolean",JSONPath=".status.firing"
+// +kubebuilder:resource:categories="search",scope="Namespaced"
+
+// SavedSearch is a log, event or auditing search saved in a namespace for reuse, the search is restricted to
+// the namespace, and notifies the receivers through the notification manager if an alert is specified.
+type SavedSearch struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SavedSearchSpec   `json:"spec"`
+	Status SavedSearchStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SavedSearchList contains a list of SavedSearch
+type SavedSearchList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SavedSearch `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SavedSearch{}, &SavedSearchList{})
+}